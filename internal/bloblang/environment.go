@@ -1,6 +1,8 @@
 package bloblang
 
 import (
+	"time"
+
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/parser"
@@ -127,6 +129,39 @@ func (e *Environment) WithDisabledImports() *Environment {
 	}
 }
 
+// WithCoverage returns a version of the environment where the execution of
+// branches within the if and match expressions of parsed mappings are recorded
+// by a coverage recorder.
+func (e *Environment) WithCoverage(c *parser.Coverage) *Environment {
+	return &Environment{
+		pCtx: e.pCtx.WithCoverage(c),
+	}
+}
+
+// WithClock returns a copy of the environment where functions that read the
+// current time, such as now and timestamp_unix, instead obtain it from a
+// provided clock.
+func (e *Environment) WithClock(now func() time.Time) *Environment {
+	nextCtx := e.pCtx
+	nextCtx.Functions = e.pCtx.Functions.WithClock(now)
+	return &Environment{
+		pCtx: nextCtx,
+	}
+}
+
+// WithRandomSeed returns a copy of the environment where functions that
+// generate random values, such as uuid_v4, nanoid and random_int, are
+// deterministic for a given seed. The values generated by uuid_v4 and nanoid
+// depend on the order in which they're executed across all mappings parsed
+// from the environment.
+func (e *Environment) WithRandomSeed(seed int64) *Environment {
+	nextCtx := e.pCtx
+	nextCtx.Functions = e.pCtx.Functions.WithRandSource(query.NewRandSource(seed))
+	return &Environment{
+		pCtx: nextCtx,
+	}
+}
+
 // WithoutMethods returns a copy of the environment but with a variadic list of
 // method names removed. Instantiation of these removed methods within a mapping
 // will cause errors at parse time.
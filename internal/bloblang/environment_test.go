@@ -0,0 +1,78 @@
+package bloblang
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironmentWithClock(t *testing.T) {
+	fixed := time.Date(2021, 10, 20, 15, 4, 5, 123, time.FixedZone("", 3600))
+	env := GlobalEnvironment().WithClock(func() time.Time {
+		return fixed
+	})
+
+	exec, err := env.NewMapping(`
+root.now = now()
+root.unix = timestamp_unix()
+root.unix_nano = timestamp_unix_nano()
+root.timestamp = timestamp("2006-01-02 15:04")
+root.timestamp_utc = timestamp_utc("2006-01-02 15:04")
+`)
+	require.NoError(t, err)
+
+	res, err := exec.MapPart(0, message.New([][]byte{[]byte(`{}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"now":"2021-10-20T15:04:05.000000123+01:00","timestamp":"2021-10-20 15:04","timestamp_utc":"2021-10-20 14:04","unix":1634738645,"unix_nano":1634738645000000123}`, string(res.Get()))
+
+	field, err := env.NewField(`${! timestamp_unix() }`)
+	require.NoError(t, err)
+	assert.Equal(t, "1634738645", field.String(0, message.New([][]byte{[]byte(`{}`)})))
+
+	// The clock doesn't leak into the source environment.
+	exec, err = GlobalEnvironment().NewMapping(`root = timestamp_unix()`)
+	require.NoError(t, err)
+	res, err = exec.MapPart(0, message.New([][]byte{[]byte(`{}`)}))
+	require.NoError(t, err)
+	assert.NotEqual(t, "1634738645", string(res.Get()))
+}
+
+func TestEnvironmentWithRandomSeed(t *testing.T) {
+	mapping := `
+root.uuid_a = uuid_v4()
+root.uuid_b = uuid_v4()
+root.nanoid = nanoid()
+root.nanoid_custom = nanoid(8, "abc")
+root.random = random_int()
+root.random_seeded = random_int(5)
+`
+
+	run := func(env *Environment) map[string]interface{} {
+		t.Helper()
+		exec, err := env.NewMapping(mapping)
+		require.NoError(t, err)
+		res, err := exec.MapPart(0, message.New([][]byte{[]byte(`{}`)}))
+		require.NoError(t, err)
+		v, err := res.JSON()
+		require.NoError(t, err)
+		return v.(map[string]interface{})
+	}
+
+	first := run(GlobalEnvironment().WithRandomSeed(10))
+	second := run(GlobalEnvironment().WithRandomSeed(10))
+	other := run(GlobalEnvironment().WithRandomSeed(11))
+
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first["uuid_a"], other["uuid_a"])
+	assert.NotEqual(t, first["random"], other["random"])
+	assert.NotEqual(t, first["random_seeded"], other["random_seeded"])
+
+	assert.NotEqual(t, first["uuid_a"], first["uuid_b"])
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), first["uuid_a"])
+	assert.Regexp(t, regexp.MustCompile(`^[_\-0-9a-zA-Z]{21}$`), first["nanoid"])
+	assert.Regexp(t, regexp.MustCompile(`^[abc]{8}$`), first["nanoid_custom"])
+}
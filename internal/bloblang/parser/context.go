@@ -16,6 +16,7 @@ type Context struct {
 	Methods      *query.MethodSet
 	namedContext *namedContext
 	importer     Importer
+	coverage     *coverageSource
 }
 
 // EmptyContext returns a parser context with no functions, methods or import
@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+)
+
+// CoverageBranch describes a branch of an if or match expression within a
+// parsed mapping, and the number of times it was executed.
+type CoverageBranch struct {
+	// Source is the full mapping or interpolated field the branch belongs to.
+	Source string
+
+	// Line and Column locate the expression the branch belongs to within the
+	// source, starting at 1.
+	Line   int
+	Column int
+
+	// Expression is either "if" or "match".
+	Expression string
+
+	// Branch identifies the branch within the expression, e.g. "else if 1" or
+	// "case 2".
+	Branch string
+
+	Hits int64
+
+	// index orders branches within an expression.
+	index int
+}
+
+// Coverage records which branches of the if and match expressions within
+// mappings are executed. Branches parsed from the same source and position are
+// recorded as one, and therefore a mapping can be parsed any number of times.
+type Coverage struct {
+	mut      sync.Mutex
+	branches map[coverageKey]*CoverageBranch
+}
+
+type coverageKey struct {
+	source       string
+	offset       int
+	expr, branch string
+}
+
+// NewCoverage returns an empty coverage recorder.
+func NewCoverage() *Coverage {
+	return &Coverage{
+		branches: map[coverageKey]*CoverageBranch{},
+	}
+}
+
+// Branches returns a snapshot of all recorded branches, sorted by source and
+// position.
+func (c *Coverage) Branches() []CoverageBranch {
+	c.mut.Lock()
+	branches := make([]CoverageBranch, 0, len(c.branches))
+	for _, b := range c.branches {
+		bCopy := *b
+		bCopy.Hits = atomic.LoadInt64(&b.Hits)
+		branches = append(branches, bCopy)
+	}
+	c.mut.Unlock()
+
+	sort.SliceStable(branches, func(i, j int) bool {
+		if branches[i].Source != branches[j].Source {
+			return branches[i].Source < branches[j].Source
+		}
+		if branches[i].Line != branches[j].Line {
+			return branches[i].Line < branches[j].Line
+		}
+		if branches[i].Column != branches[j].Column {
+			return branches[i].Column < branches[j].Column
+		}
+		return branches[i].index < branches[j].index
+	})
+	return branches
+}
+
+func (c *Coverage) register(source []rune, offset int, expr, branch string, index int) *CoverageBranch {
+	key := coverageKey{
+		source: string(source),
+		offset: offset,
+		expr:   expr,
+		branch: branch,
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if b, exists := c.branches[key]; exists {
+		return b
+	}
+
+	line, col := LineAndColOf(source, source[offset:])
+	b := &CoverageBranch{
+		Source:     key.source,
+		Line:       line,
+		Column:     col,
+		Expression: expr,
+		Branch:     branch,
+		index:      index,
+	}
+	c.branches[key] = b
+	return b
+}
+
+//------------------------------------------------------------------------------
+
+// coverageSource ties a coverage recorder to the source currently being parsed
+// in order to locate expressions.
+type coverageSource struct {
+	c      *Coverage
+	source []rune
+}
+
+// WithCoverage returns a Context where the branches of if and match
+// expressions within parsed mappings are recorded by a coverage recorder.
+func (pCtx Context) WithCoverage(c *Coverage) Context {
+	pCtx.coverage = &coverageSource{c: c}
+	return pCtx
+}
+
+// withCoverageSource returns a Context where branches are located within a
+// given source.
+func (pCtx Context) withCoverageSource(source []rune) Context {
+	if pCtx.coverage != nil {
+		pCtx.coverage = &coverageSource{c: pCtx.coverage.c, source: source}
+	}
+	return pCtx
+}
+
+// coverBranch wraps the nth branch function of an expression that begins at
+// the provided input so that its executions are recorded.
+func (pCtx Context) coverBranch(input []rune, expr, branch string, index int, fn query.Function) query.Function {
+	cs := pCtx.coverage
+	if cs == nil || fn == nil {
+		return fn
+	}
+	offset := len(cs.source) - len(input)
+	if offset < 0 || offset > len(cs.source) {
+		return fn
+	}
+	return &coveredFunction{
+		Function: fn,
+		branch:   cs.c.register(cs.source, offset, expr, branch, index),
+	}
+}
+
+type coveredFunction struct {
+	query.Function
+	branch *CoverageBranch
+}
+
+func (c *coveredFunction) Exec(ctx query.FunctionContext) (interface{}, error) {
+	atomic.AddInt64(&c.branch.Hits, 1)
+	return c.Function.Exec(ctx)
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoverageBranches(t *testing.T) {
+	mapping := `root.a = if this.a > 10 {
+  "big"
+} else if this.a > 5 {
+  "medium"
+} else {
+  "small"
+}
+root.b = match this.b {
+  "foo" => 1
+  _ => 2
+}`
+
+	cov := NewCoverage()
+	pCtx := GlobalContext().WithCoverage(cov)
+
+	for i := 0; i < 2; i++ {
+		exec, err := ParseMapping(pCtx, mapping)
+		require.Nil(t, err)
+
+		_, mErr := exec.MapPart(0, message.New([][]byte{[]byte(`{"a":20,"b":"foo"}`)}))
+		require.NoError(t, mErr)
+	}
+
+	type branch struct {
+		line, col    int
+		expr, branch string
+		hits         int64
+	}
+	var branches []branch
+	for _, b := range cov.Branches() {
+		assert.Equal(t, mapping, b.Source)
+		branches = append(branches, branch{b.Line, b.Column, b.Expression, b.Branch, b.Hits})
+	}
+	assert.Equal(t, []branch{
+		{1, 10, "if", "if", 2},
+		{1, 10, "if", "else if 1", 0},
+		{1, 10, "if", "else", 0},
+		{8, 10, "match", "case 0", 2},
+		{8, 10, "match", "case 1", 0},
+	}, branches)
+}
+
+func TestCoverageDisabled(t *testing.T) {
+	exec, err := ParseMapping(GlobalContext(), `root = if true { "foo" }`)
+	require.Nil(t, err)
+
+	res, mErr := exec.MapPart(0, message.New([][]byte{[]byte(`{}`)}))
+	require.NoError(t, mErr)
+	assert.Equal(t, "foo", string(res.Get()))
+}
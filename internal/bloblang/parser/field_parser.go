@@ -2,6 +2,9 @@ package parser
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
@@ -90,7 +93,7 @@ func parseFieldResolvers(pCtx Context, expr string) ([]field.Resolver, *Error) {
 
 // ParseField attempts to parse a field expression.
 func ParseField(pCtx Context, expr string) (*field.Expression, *Error) {
-	resolvers, err := parseFieldResolvers(pCtx, expr)
+	resolvers, err := parseFieldResolvers(pCtx.withCoverageSource([]rune(expr)), expr)
 	if err != nil {
 		return nil, err
 	}
@@ -98,3 +101,62 @@ func ParseField(pCtx Context, expr string) (*field.Expression, *Error) {
 }
 
 //------------------------------------------------------------------------------
+
+// FieldToQuery attempts to convert an interpolated string expression into an
+// equivalent Bloblang query that returns the resolved string. Interpolations
+// that use deprecated function syntax cannot be converted.
+func FieldToQuery(pCtx Context, expr string) (string, error) {
+	var segments []string
+	var literal strings.Builder
+	flushLiteral := func() {
+		if literal.Len() > 0 {
+			segments = append(segments, strconv.Quote(literal.String()))
+			literal.Reset()
+		}
+	}
+
+	input := []rune(expr)
+	for len(input) > 0 {
+		if res := escapedBlock(input); res.Err == nil {
+			literal.WriteString(string(res.Payload.(field.StaticResolver)))
+			input = res.Remaining
+			continue
+		}
+		if len(input) < 3 || input[0] != '$' || input[1] != '{' || input[2] != '!' {
+			literal.WriteRune(input[0])
+			input = input[1:]
+			continue
+		}
+
+		end := -1
+		for i := 3; i < len(input); i++ {
+			if input[i] == '}' {
+				end = i
+				break
+			}
+		}
+		if end == -1 {
+			literal.WriteString(string(input))
+			break
+		}
+
+		queryStr := strings.TrimSpace(string(input[3:end]))
+		res := queryParser(pCtx)([]rune(queryStr))
+		if res.Err == nil && len(strings.TrimSpace(string(res.Remaining))) > 0 {
+			res.Err = NewError(res.Remaining, "end of expression")
+		}
+		if res.Err != nil {
+			return "", fmt.Errorf("failed to convert interpolation %q: %v", "${!"+string(input[3:end])+"}", res.Err.ErrorAtPosition([]rune(queryStr)))
+		}
+
+		flushLiteral()
+		segments = append(segments, "("+queryStr+").string()")
+		input = input[end+1:]
+	}
+	flushLiteral()
+
+	if len(segments) == 0 {
+		return `""`, nil
+	}
+	return strings.Join(segments, " + "), nil
+}
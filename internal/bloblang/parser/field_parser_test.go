@@ -247,3 +247,67 @@ func TestFieldExpressions(t *testing.T) {
 		})
 	}
 }
+
+func TestFieldToQuery(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		query  string
+		output string
+		err    string
+	}{
+		"empty": {
+			input:  ``,
+			query:  `""`,
+			output: ``,
+		},
+		"static string": {
+			input:  `foo bar`,
+			query:  `"foo bar"`,
+			output: `foo bar`,
+		},
+		"escaped interpolation": {
+			input:  `foo ${{! bar }}`,
+			query:  `"foo ${! bar }"`,
+			output: `foo ${! bar }`,
+		},
+		"single interpolation": {
+			input:  `${! this.id }`,
+			query:  `(this.id).string()`,
+			output: `123`,
+		},
+		"mixed interpolations": {
+			input:  `id: "${!this.id}" topic: ${! meta("topic") }!`,
+			query:  `"id: \"" + (this.id).string() + "\" topic: " + (meta("topic")).string() + "!"`,
+			output: `id: "123" topic: foo!`,
+		},
+		"bad interpolation": {
+			input: `foo ${! this.id.not a thing }`,
+			err:   `failed to convert interpolation "${! this.id.not a thing }": line 1 char 12: expected end of expression`,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			query, err := FieldToQuery(GlobalContext(), test.input)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.query, query)
+
+			exec, perr := ParseMapping(GlobalContext(), "root = "+query)
+			require.Nil(t, perr)
+
+			part := message.NewPart([]byte(`{"id":123}`))
+			part.Metadata().Set("topic", "foo")
+			msg := message.New(nil)
+			msg.Append(part)
+
+			res, qerr := exec.MapPart(0, msg)
+			require.NoError(t, qerr)
+			assert.Equal(t, test.output, string(res.Get()))
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 // messages.
 func ParseMapping(pCtx Context, expr string) (*mapping.Executor, *Error) {
 	in := []rune(expr)
+	pCtx = pCtx.withCoverageSource(in)
 
 	resDirectImport := singleRootImport(pCtx)(in)
 	if resDirectImport.Err != nil && resDirectImport.Err.IsFatal() {
@@ -119,9 +120,8 @@ func singleRootImport(pCtx Context) Func {
 			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
 		}
 
-		nextCtx := pCtx.WithImporterRelativeToFile(fpath)
-
 		importContent := []rune(string(contents))
+		nextCtx := pCtx.WithImporterRelativeToFile(fpath).withCoverageSource(importContent)
 		execRes := parseExecutor(nextCtx)(importContent)
 		if execRes.Err != nil {
 			return Fail(NewFatalError(input, NewImportError(fpath, importContent, execRes.Err)), input)
@@ -204,9 +204,8 @@ func importParser(maps map[string]query.Function, pCtx Context) Func {
 			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
 		}
 
-		nextCtx := pCtx.WithImporterRelativeToFile(fpath)
-
 		importContent := []rune(string(contents))
+		nextCtx := pCtx.WithImporterRelativeToFile(fpath).withCoverageSource(importContent)
 		execRes := parseExecutor(nextCtx)(importContent)
 		if execRes.Err != nil {
 			return Fail(NewFatalError(input, NewImportError(fpath, importContent, execRes.Err)), input)
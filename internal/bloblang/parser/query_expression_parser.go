@@ -110,8 +110,12 @@ func matchExpressionParser(pCtx Context) Func {
 		contextFn, _ := seqSlice[2].(query.Function)
 
 		cases := []query.MatchCase{}
-		for _, caseVal := range seqSlice[4].([]interface{}) {
-			cases = append(cases, caseVal.(query.MatchCase))
+		for i, caseVal := range seqSlice[4].([]interface{}) {
+			c := caseVal.(query.MatchCase)
+			if pCtx.coverage != nil {
+				c = c.WithQuery(pCtx.coverBranch(input, "match", fmt.Sprintf("case %v", i), i, c.Query()))
+			}
+			cases = append(cases, c)
 		}
 
 		res.Payload = query.NewMatchFunction(contextFn, cases...)
@@ -199,6 +203,14 @@ func ifExpressionParser(pCtx Context) Func {
 			elseFn, _ = res.Payload.([]interface{})[5].(query.Function)
 		}
 
+		if pCtx.coverage != nil {
+			ifFn = pCtx.coverBranch(input, "if", "if", 0, ifFn)
+			for i := range elseIfs {
+				elseIfs[i].MapFn = pCtx.coverBranch(input, "if", fmt.Sprintf("else if %v", i+1), i+1, elseIfs[i].MapFn)
+			}
+			elseFn = pCtx.coverBranch(input, "if", "else", len(elseIfs)+1, elseFn)
+		}
+
 		res.Payload = query.NewIfFunction(queryFn, ifFn, elseIfs, elseFn)
 		return res
 	}
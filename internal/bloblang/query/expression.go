@@ -19,6 +19,18 @@ func NewMatchCase(caseFn, queryFn Function) MatchCase {
 	}
 }
 
+// Query returns the query that is executed when the case matches.
+func (m MatchCase) Query() Function {
+	return m.queryFn
+}
+
+// WithQuery returns a copy of the match case with a different query that is
+// executed when the case matches.
+func (m MatchCase) WithQuery(fn Function) MatchCase {
+	m.queryFn = fn
+	return m
+}
+
 // NewMatchFunction takes a contextual mapping and a list of MatchCases, when
 // the function is executed
 func NewMatchFunction(contextFn Function, cases ...MatchCase) Function {
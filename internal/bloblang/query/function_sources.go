@@ -0,0 +1,105 @@
+package query
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+const (
+	nanoidDefaultAlphabet = "_-0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	nanoidDefaultLength   = 21
+)
+
+// RandSource is a deterministic source of random values that can be shared by
+// the functions of a set, where the values generated depend only on the seed
+// and the order in which they are generated.
+type RandSource struct {
+	seed int64
+
+	mut sync.Mutex
+	r   *rand.Rand
+}
+
+// NewRandSource returns a deterministic source of random values from a seed.
+func NewRandSource(seed int64) *RandSource {
+	return &RandSource{
+		seed: seed,
+		r:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (s *RandSource) uuidV4() uuid.UUID {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	var u uuid.UUID
+	_, _ = s.r.Read(u[:])
+	u.SetVersion(uuid.V4)
+	u.SetVariant(uuid.VariantRFC4122)
+	return u
+}
+
+func (s *RandSource) nanoid(alphabet string, length int) (string, error) {
+	chars := []rune(alphabet)
+	if len(chars) == 0 || len(chars) > 255 {
+		return "", errors.New("alphabet must not be empty and contain no more than 255 chars")
+	}
+	if length <= 0 {
+		return "", errors.New("size must be positive integer")
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	id := make([]rune, length)
+	for i := range id {
+		id[i] = chars[s.r.Intn(len(chars))]
+	}
+	return string(id), nil
+}
+
+//------------------------------------------------------------------------------
+
+var clockFunctionCtors = map[string]func(now func() time.Time) FunctionCtor{
+	"now":                 nowFunctionCtor,
+	"timestamp":           timestampFunctionCtor,
+	"timestamp_utc":       timestampUTCFunctionCtor,
+	"timestamp_unix":      timestampUnixFunctionCtor,
+	"timestamp_unix_nano": timestampUnixNanoFunctionCtor,
+}
+
+var randFunctionCtors = map[string]func(src *RandSource) FunctionCtor{
+	"nanoid":     nanoidFunctionCtor,
+	"random_int": randomIntFunctionCtor,
+	"uuid_v4":    uuidV4FunctionCtor,
+}
+
+// WithClock creates a clone of the function set that can be mutated in
+// isolation, where the functions that read the current time instead obtain it
+// from a provided clock.
+func (f *FunctionSet) WithClock(now func() time.Time) *FunctionSet {
+	newSet := f.Without()
+	for name, ctor := range clockFunctionCtors {
+		if _, exists := newSet.constructors[name]; exists {
+			newSet.constructors[name] = ctor(now)
+		}
+	}
+	return newSet
+}
+
+// WithRandSource creates a clone of the function set that can be mutated in
+// isolation, where the functions that generate random values instead obtain
+// them from a deterministic source.
+func (f *FunctionSet) WithRandSource(src *RandSource) *FunctionSet {
+	newSet := f.Without()
+	for name, ctor := range randFunctionCtors {
+		if _, exists := newSet.constructors[name]; exists {
+			newSet.constructors[name] = ctor(src)
+		}
+	}
+	return newSet
+}
@@ -612,10 +612,18 @@ root.second = random_int(1)`,
 			"A seed to use, if a query is provided it will only be resolved once during the lifetime of the mapping.",
 			true,
 		).Default(NewLiteralFunction("", 0))),
-	randomIntFunction,
+	randomIntFunctionCtor(nil),
 )
 
-func randomIntFunction(args *ParsedParams) (Function, error) {
+// randomIntFunctionCtor returns a constructor for random_int where, when a
+// source is provided, its seed is added to the seed of each generator.
+func randomIntFunctionCtor(src *RandSource) FunctionCtor {
+	return func(args *ParsedParams) (Function, error) {
+		return randomIntFunction(args, src)
+	}
+}
+
+func randomIntFunction(args *ParsedParams, src *RandSource) (Function, error) {
 	seedFn, err := args.FieldQuery("seed")
 	if err != nil {
 		return nil, err
@@ -638,6 +646,9 @@ func randomIntFunction(args *ParsedParams) (Function, error) {
 			if err != nil {
 				return nil, fmt.Errorf("failed to seed random number generator: %v", err)
 			}
+			if src != nil {
+				seed += src.seed
+			}
 
 			r = rand.New(rand.NewSource(seed))
 		}
@@ -660,12 +671,16 @@ var _ = registerFunction(
 			`root.received_at = now().format_timestamp("Mon Jan 2 15:04:05 -0700 MST 2006", "UTC")`,
 		),
 	),
-	func(args *ParsedParams) (Function, error) {
+	nowFunctionCtor(time.Now),
+)
+
+func nowFunctionCtor(now func() time.Time) FunctionCtor {
+	return func(args *ParsedParams) (Function, error) {
 		return ClosureFunction("function now", func(_ FunctionContext) (interface{}, error) {
-			return time.Now().Format(time.RFC3339Nano), nil
+			return now().Format(time.RFC3339Nano), nil
 		}, nil), nil
-	},
-)
+	}
+}
 
 var _ = registerFunction(
 	NewDeprecatedFunctionSpec(
@@ -675,16 +690,20 @@ var _ = registerFunction(
 			`root.received_at = timestamp("15:04:05")`,
 		),
 	).Param(ParamString("format", "The format to print as.").Default("Mon Jan 2 15:04:05 -0700 MST 2006")),
-	func(args *ParsedParams) (Function, error) {
+	timestampFunctionCtor(time.Now),
+)
+
+func timestampFunctionCtor(now func() time.Time) FunctionCtor {
+	return func(args *ParsedParams) (Function, error) {
 		format, err := args.FieldString("format")
 		if err != nil {
 			return nil, err
 		}
 		return ClosureFunction("function timestamp", func(_ FunctionContext) (interface{}, error) {
-			return time.Now().Format(format), nil
+			return now().Format(format), nil
 		}, nil), nil
-	},
-)
+	}
+}
 
 var _ = registerFunction(
 	NewDeprecatedFunctionSpec(
@@ -694,18 +713,22 @@ var _ = registerFunction(
 			`root.received_at = timestamp_utc("15:04:05")`,
 		),
 	).Param(ParamString("format", "The format to print as.").Default("Mon Jan 2 15:04:05 -0700 MST 2006")),
-	func(args *ParsedParams) (Function, error) {
+	timestampUTCFunctionCtor(time.Now),
+)
+
+func timestampUTCFunctionCtor(now func() time.Time) FunctionCtor {
+	return func(args *ParsedParams) (Function, error) {
 		format, err := args.FieldString("format")
 		if err != nil {
 			return nil, err
 		}
 		return ClosureFunction("function timestamp_utc", func(_ FunctionContext) (interface{}, error) {
-			return time.Now().In(time.UTC).Format(format), nil
+			return now().In(time.UTC).Format(format), nil
 		}, nil), nil
-	},
-)
+	}
+}
 
-var _ = registerSimpleFunction(
+var _ = registerFunction(
 	NewFunctionSpec(
 		FunctionCategoryEnvironment, "timestamp_unix",
 		"Returns the current unix timestamp in seconds.",
@@ -713,12 +736,18 @@ var _ = registerSimpleFunction(
 			`root.received_at = timestamp_unix()`,
 		),
 	),
-	func(_ FunctionContext) (interface{}, error) {
-		return time.Now().Unix(), nil
-	},
+	timestampUnixFunctionCtor(time.Now),
 )
 
-var _ = registerSimpleFunction(
+func timestampUnixFunctionCtor(now func() time.Time) FunctionCtor {
+	return func(*ParsedParams) (Function, error) {
+		return ClosureFunction("function timestamp_unix", func(_ FunctionContext) (interface{}, error) {
+			return now().Unix(), nil
+		}, nil), nil
+	}
+}
+
+var _ = registerFunction(
 	NewFunctionSpec(
 		FunctionCategoryEnvironment, "timestamp_unix_nano",
 		"Returns the current unix timestamp in nanoseconds.",
@@ -726,11 +755,17 @@ var _ = registerSimpleFunction(
 			`root.received_at = timestamp_unix_nano()`,
 		),
 	),
-	func(_ FunctionContext) (interface{}, error) {
-		return time.Now().UnixNano(), nil
-	},
+	timestampUnixNanoFunctionCtor(time.Now),
 )
 
+func timestampUnixNanoFunctionCtor(now func() time.Time) FunctionCtor {
+	return func(*ParsedParams) (Function, error) {
+		return ClosureFunction("function timestamp_unix_nano", func(_ FunctionContext) (interface{}, error) {
+			return now().UnixNano(), nil
+		}, nil), nil
+	}
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerFunction(
@@ -763,21 +798,30 @@ root.doc.contents = (this.body.content | this.thing.body)`,
 
 //------------------------------------------------------------------------------
 
-var _ = registerSimpleFunction(
+var _ = registerFunction(
 	NewFunctionSpec(
 		FunctionCategoryGeneral, "uuid_v4",
 		"Generates a new RFC-4122 UUID each time it is invoked and prints a string representation.",
 		NewExampleSpec("", `root.id = uuid_v4()`),
 	),
-	func(_ FunctionContext) (interface{}, error) {
-		u4, err := uuid.NewV4()
-		if err != nil {
-			panic(err)
-		}
-		return u4.String(), nil
-	},
+	uuidV4FunctionCtor(nil),
 )
 
+func uuidV4FunctionCtor(src *RandSource) FunctionCtor {
+	return func(*ParsedParams) (Function, error) {
+		return ClosureFunction("function uuid_v4", func(_ FunctionContext) (interface{}, error) {
+			if src != nil {
+				return src.uuidV4().String(), nil
+			}
+			u4, err := uuid.NewV4()
+			if err != nil {
+				panic(err)
+			}
+			return u4.String(), nil
+		}, nil), nil
+	}
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerFunction(
@@ -790,10 +834,16 @@ var _ = registerFunction(
 	).
 		Param(ParamInt64("length", "An optional length.").Optional()).
 		Param(ParamString("alphabet", "An optional custom alphabet to use for generating IDs. When specified the field `length` must also be present.").Optional()),
-	nanoidFunction,
+	nanoidFunctionCtor(nil),
 )
 
-func nanoidFunction(args *ParsedParams) (Function, error) {
+func nanoidFunctionCtor(src *RandSource) FunctionCtor {
+	return func(args *ParsedParams) (Function, error) {
+		return nanoidFunction(args, src)
+	}
+}
+
+func nanoidFunction(args *ParsedParams, src *RandSource) (Function, error) {
 	lenArg, err := args.FieldOptionalInt64("length")
 	if err != nil {
 		return nil, err
@@ -806,6 +856,16 @@ func nanoidFunction(args *ParsedParams) (Function, error) {
 		return nil, errors.New("field length must be specified when an alphabet is specified")
 	}
 	return ClosureFunction("function nanoid", func(ctx FunctionContext) (interface{}, error) {
+		if src != nil {
+			alphabet, length := nanoidDefaultAlphabet, nanoidDefaultLength
+			if alphabetArg != nil {
+				alphabet = *alphabetArg
+			}
+			if lenArg != nil {
+				length = int(*lenArg)
+			}
+			return src.nanoid(alphabet, length)
+		}
 		if alphabetArg != nil {
 			return gonanoid.Generate(*alphabetArg, int(*lenArg))
 		}
@@ -25,6 +25,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/cbor"
+	"github.com/Jeffail/benthos/v3/internal/msgpack"
 	"github.com/Jeffail/benthos/v3/internal/xml"
 	"github.com/OneOfOne/xxhash"
 	"github.com/itchyny/timefmt-go"
@@ -1096,6 +1098,108 @@ var _ = registerSimpleMethod(
 	},
 )
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"parse_msgpack", "",
+	).InCategory(
+		MethodCategoryParsing,
+		"Attempts to parse a byte array as a single [MessagePack](https://msgpack.org/) document and returns the result. Maps with keys that aren't strings are converted into objects with the keys formatted as strings.",
+		NewExampleSpec("",
+			`root.doc = this.doc.decode("hex").parse_msgpack()`,
+			`{"doc":"81a3666f6fa3626172"}`,
+			`{"doc":{"foo":"bar"}}`,
+		),
+	).Beta(),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			var msgpackBytes []byte
+			switch t := v.(type) {
+			case string:
+				msgpackBytes = []byte(t)
+			case []byte:
+				msgpackBytes = t
+			default:
+				return nil, NewTypeError(v, ValueString)
+			}
+			mObj, err := msgpack.Unmarshal(msgpackBytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse value as MessagePack: %w", err)
+			}
+			return mObj, nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"format_msgpack", "",
+	).InCategory(
+		MethodCategoryParsing,
+		"Serializes a target value into a [MessagePack](https://msgpack.org/) byte array.",
+		NewExampleSpec("",
+			`root = this.doc.format_msgpack().encode("hex")`,
+			`{"doc":{"foo":"bar"}}`,
+			`81a3666f6fa3626172`,
+		),
+	).Beta(),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			return msgpack.Marshal(v)
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"parse_cbor", "",
+	).InCategory(
+		MethodCategoryParsing,
+		"Attempts to parse a byte array as a single [CBOR](https://cbor.io/) document and returns the result. Maps with keys that aren't strings are converted into objects with the keys formatted as strings, and tagged values are replaced with their contents.",
+		NewExampleSpec("",
+			`root.doc = this.doc.decode("hex").parse_cbor()`,
+			`{"doc":"a163666f6f63626172"}`,
+			`{"doc":{"foo":"bar"}}`,
+		),
+	).Beta(),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			var cborBytes []byte
+			switch t := v.(type) {
+			case string:
+				cborBytes = []byte(t)
+			case []byte:
+				cborBytes = t
+			default:
+				return nil, NewTypeError(v, ValueString)
+			}
+			cObj, err := cbor.Unmarshal(cborBytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse value as CBOR: %w", err)
+			}
+			return cObj, nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"format_cbor", "",
+	).InCategory(
+		MethodCategoryParsing,
+		"Serializes a target value into a [CBOR](https://cbor.io/) byte array.",
+		NewExampleSpec("",
+			`root = this.doc.format_cbor().encode("hex")`,
+			`{"doc":{"foo":"bar"}}`,
+			`a163666f6f63626172`,
+		),
+	).Beta(),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			return cbor.Marshal(v)
+		}, nil
+	},
+)
+
 //------------------------------------------------------------------------------
 
 var _ = registerSimpleMethod(
@@ -0,0 +1,135 @@
+// Package cbor converts between CBOR documents and the generic structures used
+// for JSON documents within Benthos.
+package cbor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+var (
+	decMode cbor.DecMode
+	encMode cbor.EncMode
+)
+
+func init() {
+	var err error
+	if decMode, err = (cbor.DecOptions{}).DecMode(); err != nil {
+		panic(err)
+	}
+	if encMode, err = (cbor.EncOptions{
+		Sort:          cbor.SortCanonical,
+		ShortestFloat: cbor.ShortestFloat16,
+	}).EncMode(); err != nil {
+		panic(err)
+	}
+}
+
+// Decoder reads a stream of concatenated CBOR documents.
+type Decoder struct {
+	dec *cbor.Decoder
+}
+
+// NewDecoder returns a decoder that reads documents from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{dec: decMode.NewDecoder(r)}
+}
+
+// Decode reads the next document from the stream and returns a generic
+// structure that can be serialized to JSON. Returns io.EOF once the stream is
+// exhausted.
+func (d *Decoder) Decode() (interface{}, error) {
+	var v interface{}
+	if err := d.dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return sanitise(v), nil
+}
+
+// Unmarshal parses a byte slice as a single CBOR document and returns a
+// generic structure that can be serialized to JSON.
+func Unmarshal(b []byte) (interface{}, error) {
+	var v interface{}
+	if err := decMode.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return sanitise(v), nil
+}
+
+// Marshal serializes a generic structure as a CBOR document.
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encMode.NewEncoder(&buf).Encode(prepare(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func sanitise(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[keyString(k)] = sanitise(v)
+		}
+		return m
+	case []interface{}:
+		for i, v := range t {
+			t[i] = sanitise(v)
+		}
+		return t
+	case cbor.Tag:
+		return sanitise(t.Content)
+	case float32:
+		return float64(t)
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case big.Int:
+		return t.String()
+	}
+	return v
+}
+
+func keyString(k interface{}) string {
+	switch t := k.(type) {
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	}
+	return fmt.Sprintf("%v", k)
+}
+
+// prepare converts values that CBOR cannot represent natively, such as
+// json.Number, into their closest equivalent.
+func prepare(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+		return t.String()
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = prepare(v)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, v := range t {
+			a[i] = prepare(v)
+		}
+		return a
+	}
+	return v
+}
@@ -0,0 +1,53 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	input := map[string]interface{}{
+		"str":    "foo",
+		"int":    json.Number("-10"),
+		"uint":   json.Number("10"),
+		"float":  json.Number("1.5"),
+		"bool":   true,
+		"null":   nil,
+		"array":  []interface{}{"a", json.Number("2")},
+		"nested": map[string]interface{}{"bytes": []byte("bar")},
+	}
+
+	b, err := Marshal(input)
+	require.NoError(t, err)
+
+	v, err := Unmarshal(b)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"str":    "foo",
+		"int":    int64(-10),
+		"uint":   uint64(10),
+		"float":  1.5,
+		"bool":   true,
+		"null":   nil,
+		"array":  []interface{}{"a", uint64(2)},
+		"nested": map[string]interface{}{"bytes": []byte("bar")},
+	}, v)
+}
+
+func TestUnmarshalNonStringKeysAndTags(t *testing.T) {
+	// {1: "foo", "bar": 0("2021-10-14T00:00:00Z")}
+	b, err := hex.DecodeString("a20163666f6f63626172c074323032312d31302d31345430303a30303a30305a")
+	require.NoError(t, err)
+
+	v, err := Unmarshal(b)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"1":   "foo",
+		"bar": "2021-10-14T00:00:00Z",
+	}, v)
+}
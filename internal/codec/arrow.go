@@ -0,0 +1,393 @@
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+)
+
+// ArrowSchemaDocs describes the syntax of declared Apache Arrow schemas.
+const ArrowSchemaDocs = "A schema is declared as a comma separated list of `name:type` pairs, where the supported types are `bool`, `int64`, `uint64`, `float64`, `string`, `binary` and `timestamp`. When a schema is not declared it is inferred from the messages of the first batch encoded, where fields are ordered by name and values of mixed types, objects and arrays are encoded as strings."
+
+var arrowTypes = map[string]arrow.DataType{
+	"bool":      arrow.FixedWidthTypes.Boolean,
+	"int64":     arrow.PrimitiveTypes.Int64,
+	"uint64":    arrow.PrimitiveTypes.Uint64,
+	"float64":   arrow.PrimitiveTypes.Float64,
+	"string":    arrow.BinaryTypes.String,
+	"binary":    arrow.BinaryTypes.Binary,
+	"timestamp": arrow.FixedWidthTypes.Timestamp_ms,
+}
+
+// ParseArrowSchema parses a schema declared as a comma separated list of
+// `name:type` pairs. An empty string results in a nil schema, indicating that
+// the schema should be inferred.
+func ParseArrowSchema(str string) (*arrow.Schema, error) {
+	if str == "" {
+		return nil, nil
+	}
+	var fields []arrow.Field
+	for _, fieldStr := range strings.Split(str, ",") {
+		nameAndType := strings.Split(strings.TrimSpace(fieldStr), ":")
+		if len(nameAndType) != 2 || nameAndType[0] == "" {
+			return nil, fmt.Errorf("expected schema field of the form name:type, got: %v", fieldStr)
+		}
+		dtype, exists := arrowTypes[nameAndType[1]]
+		if !exists {
+			return nil, fmt.Errorf("schema field '%v' has unsupported type: %v", nameAndType[0], nameAndType[1])
+		}
+		fields = append(fields, arrow.Field{
+			Name:     nameAndType[0],
+			Type:     dtype,
+			Nullable: true,
+		})
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+//------------------------------------------------------------------------------
+
+// ArrowEncoder converts batches of structured messages into Apache Arrow record
+// batches and writes them in the IPC streaming format.
+type ArrowEncoder struct {
+	schema *arrow.Schema
+	mem    memory.Allocator
+}
+
+// NewArrowEncoder creates an encoder from an optional schema declaration, if
+// the declaration is empty then the schema is inferred from each batch.
+func NewArrowEncoder(schemaStr string) (*ArrowEncoder, error) {
+	schema, err := ParseArrowSchema(schemaStr)
+	if err != nil {
+		return nil, err
+	}
+	return &ArrowEncoder{
+		schema: schema,
+		mem:    memory.NewGoAllocator(),
+	}, nil
+}
+
+// Encode writes a complete IPC stream, consisting of the schema and a single
+// record batch containing each message of the batch as a row.
+func (a *ArrowEncoder) Encode(w io.Writer, parts []types.Part) error {
+	docs, err := arrowDocs(parts)
+	if err != nil {
+		return err
+	}
+
+	schema := a.schema
+	if schema == nil {
+		schema = inferArrowSchema(docs)
+	}
+
+	rec, err := a.record(schema, docs)
+	if err != nil {
+		return err
+	}
+	defer rec.Release()
+
+	ipcW := ipc.NewWriter(w, ipc.WithSchema(schema), ipc.WithAllocator(a.mem))
+	if err := ipcW.Write(rec); err != nil {
+		return err
+	}
+	return ipcW.Close()
+}
+
+func arrowDocs(parts []types.Part) ([]map[string]interface{}, error) {
+	docs := make([]map[string]interface{}, len(parts))
+	for i, p := range parts {
+		v, err := p.JSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse message %v as JSON: %w", i, err)
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected message %v to be a JSON object, got %T", i, v)
+		}
+		docs[i] = obj
+	}
+	return docs, nil
+}
+
+func inferArrowType(v interface{}) arrow.DataType {
+	switch t := v.(type) {
+	case bool:
+		return arrow.FixedWidthTypes.Boolean
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return arrow.PrimitiveTypes.Int64
+		}
+		return arrow.PrimitiveTypes.Float64
+	case float64:
+		if t == math.Trunc(t) && math.Abs(t) < (1<<53) {
+			return arrow.PrimitiveTypes.Int64
+		}
+		return arrow.PrimitiveTypes.Float64
+	case int, int64:
+		return arrow.PrimitiveTypes.Int64
+	}
+	return arrow.BinaryTypes.String
+}
+
+// inferArrowSchema derives a schema from the union of fields of a batch of
+// documents. Fields containing integers and floats are widened to float64 and
+// any other mix of types falls back to string.
+func inferArrowSchema(docs []map[string]interface{}) *arrow.Schema {
+	fieldTypes := map[string]arrow.DataType{}
+	for _, doc := range docs {
+		for k, v := range doc {
+			if v == nil {
+				if _, exists := fieldTypes[k]; !exists {
+					fieldTypes[k] = nil
+				}
+				continue
+			}
+			dtype := inferArrowType(v)
+			existing, exists := fieldTypes[k]
+			if !exists || existing == nil {
+				fieldTypes[k] = dtype
+				continue
+			}
+			if arrow.TypeEqual(existing, dtype) {
+				continue
+			}
+			if isArrowNumber(existing) && isArrowNumber(dtype) {
+				fieldTypes[k] = arrow.PrimitiveTypes.Float64
+			} else {
+				fieldTypes[k] = arrow.BinaryTypes.String
+			}
+		}
+	}
+
+	names := make([]string, 0, len(fieldTypes))
+	for k := range fieldTypes {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	fields := make([]arrow.Field, len(names))
+	for i, name := range names {
+		dtype := fieldTypes[name]
+		if dtype == nil {
+			dtype = arrow.BinaryTypes.String
+		}
+		fields[i] = arrow.Field{Name: name, Type: dtype, Nullable: true}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+func isArrowNumber(dtype arrow.DataType) bool {
+	return arrow.TypeEqual(dtype, arrow.PrimitiveTypes.Int64) ||
+		arrow.TypeEqual(dtype, arrow.PrimitiveTypes.Float64)
+}
+
+func (a *ArrowEncoder) record(schema *arrow.Schema, docs []map[string]interface{}) (array.Record, error) {
+	b := array.NewRecordBuilder(a.mem, schema)
+	defer b.Release()
+
+	for i, field := range schema.Fields() {
+		fb := b.Field(i)
+		for j, doc := range docs {
+			v, exists := doc[field.Name]
+			if !exists || v == nil {
+				fb.AppendNull()
+				continue
+			}
+			if err := appendArrowValue(fb, v); err != nil {
+				return nil, fmt.Errorf("field '%v' of message %v: %w", field.Name, j, err)
+			}
+		}
+	}
+	return b.NewRecord(), nil
+}
+
+func appendArrowValue(b array.Builder, v interface{}) error {
+	switch fb := b.(type) {
+	case *array.BooleanBuilder:
+		switch t := v.(type) {
+		case bool:
+			fb.Append(t)
+		case string:
+			bv, err := strconv.ParseBool(t)
+			if err != nil {
+				return err
+			}
+			fb.Append(bv)
+		default:
+			return fmt.Errorf("expected bool value, got %T", v)
+		}
+	case *array.Int64Builder:
+		iv, err := arrowInt(v)
+		if err != nil {
+			return err
+		}
+		fb.Append(iv)
+	case *array.Uint64Builder:
+		iv, err := arrowInt(v)
+		if err != nil {
+			return err
+		}
+		if iv < 0 {
+			return fmt.Errorf("expected unsigned integer value, got %v", iv)
+		}
+		fb.Append(uint64(iv))
+	case *array.Float64Builder:
+		fv, err := arrowFloat(v)
+		if err != nil {
+			return err
+		}
+		fb.Append(fv)
+	case *array.StringBuilder:
+		fb.Append(arrowString(v))
+	case *array.BinaryBuilder:
+		fb.Append([]byte(arrowString(v)))
+	case *array.TimestampBuilder:
+		ts, err := arrowTimestamp(v)
+		if err != nil {
+			return err
+		}
+		fb.Append(arrow.Timestamp(ts.UnixNano() / int64(time.Millisecond)))
+	default:
+		return fmt.Errorf("unsupported builder type %T", b)
+	}
+	return nil
+}
+
+func arrowInt(v interface{}) (int64, error) {
+	switch t := v.(type) {
+	case json.Number:
+		return t.Int64()
+	case float64:
+		if t != math.Trunc(t) {
+			return 0, fmt.Errorf("expected integer value, got %v", t)
+		}
+		return int64(t), nil
+	case int:
+		return int64(t), nil
+	case int64:
+		return t, nil
+	case string:
+		return strconv.ParseInt(t, 10, 64)
+	}
+	return 0, fmt.Errorf("expected integer value, got %T", v)
+}
+
+func arrowFloat(v interface{}) (float64, error) {
+	switch t := v.(type) {
+	case json.Number:
+		return t.Float64()
+	case float64:
+		return t, nil
+	case int:
+		return float64(t), nil
+	case int64:
+		return float64(t), nil
+	case string:
+		return strconv.ParseFloat(t, 64)
+	}
+	return 0, fmt.Errorf("expected number value, got %T", v)
+}
+
+func arrowString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case bool, float64, int, int64:
+		return fmt.Sprintf("%v", t)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func arrowTimestamp(v interface{}) (time.Time, error) {
+	if s, ok := v.(string); ok {
+		return time.Parse(time.RFC3339Nano, s)
+	}
+	f, err := arrowFloat(v)
+	if err != nil {
+		return time.Time{}, errors.New("expected timestamp as an RFC3339 string or unix seconds")
+	}
+	secs, frac := math.Modf(f)
+	return time.Unix(int64(secs), int64(frac*1e9)).UTC(), nil
+}
+
+//------------------------------------------------------------------------------
+
+var arrowWriterConfig = WriterConfig{
+	Append:         true,
+	AlwaysEndBatch: true,
+}
+
+// arrowWriter writes each batch as a record batch of a single IPC stream, the
+// schema of the stream is either declared or inferred from the first batch.
+type arrowWriter struct {
+	w    io.WriteCloser
+	enc  *ArrowEncoder
+	ipcW *ipc.Writer
+
+	schema  *arrow.Schema
+	pending []types.Part
+}
+
+func newArrowWriter(w io.WriteCloser, enc *ArrowEncoder) (Writer, error) {
+	return &arrowWriter{w: w, enc: enc, schema: enc.schema}, nil
+}
+
+func (a *arrowWriter) Write(ctx context.Context, p types.Part) error {
+	a.pending = append(a.pending, p)
+	return nil
+}
+
+func (a *arrowWriter) EndBatch() error {
+	if len(a.pending) == 0 {
+		return nil
+	}
+	parts := a.pending
+	a.pending = nil
+
+	docs, err := arrowDocs(parts)
+	if err != nil {
+		return err
+	}
+	if a.schema == nil {
+		a.schema = inferArrowSchema(docs)
+	}
+	if a.ipcW == nil {
+		a.ipcW = ipc.NewWriter(a.w, ipc.WithSchema(a.schema), ipc.WithAllocator(a.enc.mem))
+	}
+
+	rec, err := a.enc.record(a.schema, docs)
+	if err != nil {
+		return err
+	}
+	defer rec.Release()
+	return a.ipcW.Write(rec)
+}
+
+func (a *arrowWriter) Close(ctx context.Context) error {
+	err := a.EndBatch()
+	if a.ipcW != nil {
+		if cErr := a.ipcW.Close(); err == nil {
+			err = cErr
+		}
+	}
+	if cErr := a.w.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
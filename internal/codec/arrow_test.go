@@ -0,0 +1,164 @@
+package codec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func arrowTestParts(docs ...string) []types.Part {
+	parts := make([]types.Part, len(docs))
+	for i, d := range docs {
+		parts[i] = message.NewPart([]byte(d))
+	}
+	return parts
+}
+
+func readArrowStream(t *testing.T, b []byte) (string, []string) {
+	t.Helper()
+
+	r, err := ipc.NewReader(bytes.NewReader(b))
+	require.NoError(t, err)
+	defer r.Release()
+
+	var records []string
+	for r.Next() {
+		rec := r.Record()
+		for i, col := range rec.Columns() {
+			records = append(records, rec.ColumnName(i)+": "+arrowColumnString(col))
+		}
+	}
+	require.NoError(t, r.Err())
+	return r.Schema().String(), records
+}
+
+func arrowColumnString(col array.Interface) string {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i := 0; i < col.Len(); i++ {
+		if i > 0 {
+			buf.WriteString(" ")
+		}
+		if col.IsNull(i) {
+			buf.WriteString("null")
+			continue
+		}
+		switch c := col.(type) {
+		case *array.Int64:
+			fmt.Fprintf(&buf, "%v", c.Value(i))
+		case *array.Float64:
+			fmt.Fprintf(&buf, "%v", c.Value(i))
+		case *array.Boolean:
+			fmt.Fprintf(&buf, "%v", c.Value(i))
+		case *array.String:
+			buf.WriteString(c.Value(i))
+		case *array.Timestamp:
+			fmt.Fprintf(&buf, "%v", int64(c.Value(i)))
+		}
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
+
+func TestArrowEncoderInferred(t *testing.T) {
+	enc, err := NewArrowEncoder("")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, enc.Encode(&buf, arrowTestParts(
+		`{"id":1,"name":"foo","score":1.5,"ok":true,"tags":["a"]}`,
+		`{"id":2,"name":"bar","score":2,"ok":false,"extra":null}`,
+		`{"id":3,"score":"nope"}`,
+	)))
+
+	schema, cols := readArrowStream(t, buf.Bytes())
+	assert.Contains(t, schema, "extra: type=utf8, nullable")
+	assert.Contains(t, schema, "id: type=int64, nullable")
+	assert.Contains(t, schema, "score: type=utf8, nullable")
+	assert.Equal(t, []string{
+		"extra: [null null null]",
+		"id: [1 2 3]",
+		"name: [foo bar null]",
+		"ok: [true false null]",
+		"score: [1.5 2 nope]",
+		`tags: [["a"] null null]`,
+	}, cols)
+}
+
+func TestArrowEncoderDeclared(t *testing.T) {
+	enc, err := NewArrowEncoder("id:int64, value:float64,at:timestamp")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, enc.Encode(&buf, arrowTestParts(
+		`{"id":1,"value":1,"at":"2021-10-14T00:00:01Z","ignored":"yep"}`,
+		`{"id":"2","at":1634169602}`,
+	)))
+
+	_, cols := readArrowStream(t, buf.Bytes())
+	assert.Equal(t, []string{
+		"id: [1 2]",
+		"value: [1 null]",
+		"at: [1634169601000 1634169602000]",
+	}, cols)
+
+	err = enc.Encode(&buf, arrowTestParts(`{"id":"nah"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field 'id' of message 0")
+
+	err = enc.Encode(&buf, arrowTestParts(`["not","an","object"]`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected message 0 to be a JSON object")
+}
+
+func TestArrowSchemaErrors(t *testing.T) {
+	_, err := NewArrowEncoder("id")
+	assert.EqualError(t, err, "expected schema field of the form name:type, got: id")
+
+	_, err = NewArrowEncoder("id:int32")
+	assert.EqualError(t, err, "schema field 'id' has unsupported type: int32")
+}
+
+func TestArrowWriter(t *testing.T) {
+	buf := noopWriteCloser{&bytes.Buffer{}}
+
+	ctor, conf, err := GetWriter("arrow")
+	require.NoError(t, err)
+	assert.True(t, conf.AlwaysEndBatch)
+
+	w, err := ctor(buf)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, p := range arrowTestParts(`{"id":1,"name":"foo"}`, `{"id":2,"name":"bar"}`) {
+		require.NoError(t, w.Write(ctx, p))
+	}
+	require.NoError(t, w.EndBatch())
+
+	// Subsequent batches adopt the schema of the first.
+	for _, p := range arrowTestParts(`{"id":3,"other":"baz"}`) {
+		require.NoError(t, w.Write(ctx, p))
+	}
+	require.NoError(t, w.Close(ctx))
+
+	schema, cols := readArrowStream(t, buf.Bytes())
+	assert.Contains(t, schema, "id: type=int64, nullable")
+	assert.NotContains(t, schema, "other")
+	assert.Equal(t, []string{
+		"id: [1 2]",
+		"name: [foo bar]",
+		"id: [3]",
+		"name: [null]",
+	}, cols)
+
+	_, _, err = GetWriter("arrow:id:nope")
+	require.Error(t, err)
+}
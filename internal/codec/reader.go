@@ -7,15 +7,20 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/cbor"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/msgpack"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
@@ -26,26 +31,61 @@ var ReaderDocs = docs.FieldCommon(
 ).HasAnnotatedOptions(
 	"auto", "EXPERIMENTAL: Attempts to derive a codec for each file based on information such as the extension. For example, a .tar.gz file would be consumed with the `gzip/tar` codec. Defaults to all-bytes.",
 	"all-bytes", "Consume the entire file as a single binary message.",
+	"cbor", "Consume a stream of concatenated [CBOR](https://cbor.io/) documents and emit each document as a structured message.",
 	"chunker:x", "Consume the file in chunks of a given number of bytes.",
 	"csv", "Consume structured rows as comma separated values, the first row must be a header row.",
 	"delim:x", "Consume the file in segments divided by a custom delimiter.",
 	"gzip", "Decompress a gzip file, this codec should precede another codec, e.g. `gzip/all-bytes`, `gzip/tar`, `gzip/csv`, etc.",
+	"json_array", "Consume a JSON array and emit each element as a message. Elements are decoded one at a time and therefore the array is never loaded into memory in its entirety.",
+	"json_documents", "Consume a stream of concatenated JSON documents, such as newline delimited JSON, and emit each document as a message. Documents may span multiple lines.",
 	"lines", "Consume the file in segments divided by linebreaks.",
+	"msgpack", "Consume a stream of concatenated [MessagePack](https://msgpack.org/) documents and emit each document as a structured message.",
+	"multiline:x", "Consume the file in records of one or more lines, where a new record begins at each line matching the regular expression x. This is useful for consuming stack traces and other multiple line logs. The size of records and the period after which a trailing record is flushed can be tuned with the `multiline` field where available.",
+	"multiline_continue:x", "Consume the file in records of one or more lines, where each line matching the regular expression x is appended to the record preceding it. For example, `multiline_continue:^\\s` joins indented lines onto the last line that wasn't indented.",
 	"multipart", "Consumes the output of another codec and batches messages together. A batch ends when an empty message is consumed. For example, the codec `lines/multipart` could be used to consume multipart messages where an empty line indicates the end of each batch.",
 	"tar", "Parse the file as a tar archive, and consume each file of the archive as a message.",
+	"zip", "Parse the file as a zip archive, and consume each file of the archive as a message. Entries are read in the order they appear within the archive and therefore the archive is never loaded into memory in its entirety.",
 )
 
 //------------------------------------------------------------------------------
 
+// MultilineDocs is a static field documentation for the settings of multiline
+// codecs.
+var MultilineDocs = docs.FieldAdvanced(
+	"multiline", "Settings that apply to the `multiline:x` and `multiline_continue:x` codecs.",
+).WithChildren(
+	docs.FieldInt("max_lines", "The maximum number of lines within a single record, once reached the record is flushed and subsequent lines begin a new record. Set to `0` to disable this limit.").Advanced(),
+	docs.FieldInt("max_bytes", "The maximum number of bytes within a single record, once reached the record is flushed and subsequent lines begin a new record. Set to `0` to disable this limit.").Advanced(),
+	docs.FieldString("flush_timeout", "A period of time after which a pending record is flushed when no new lines arrive, this prevents the last record of a continuous stream from being held back until the next one begins. Set to an empty string to disable.", "100ms", "5s").Advanced(),
+).AtVersion("3.58.0")
+
+// MultilineConfig contains settings for multiline codecs.
+type MultilineConfig struct {
+	MaxLines     int    `json:"max_lines" yaml:"max_lines"`
+	MaxBytes     int    `json:"max_bytes" yaml:"max_bytes"`
+	FlushTimeout string `json:"flush_timeout" yaml:"flush_timeout"`
+}
+
+// NewMultilineConfig creates a multiline configuration with default values.
+func NewMultilineConfig() MultilineConfig {
+	return MultilineConfig{
+		MaxLines:     1000,
+		MaxBytes:     1000000,
+		FlushTimeout: "1s",
+	}
+}
+
 // ReaderConfig is a general configuration struct that covers all reader codecs.
 type ReaderConfig struct {
 	MaxScanTokenSize int
+	Multiline        MultilineConfig
 }
 
 // NewReaderConfig creates a reader configuration with default values.
 func NewReaderConfig() ReaderConfig {
 	return ReaderConfig{
 		MaxScanTokenSize: bufio.MaxScanTokenSize,
+		Multiline:        NewMultilineConfig(),
 	}
 }
 
@@ -216,6 +256,24 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 		}, true, nil
 	case "tar":
 		return newTarReader, true, nil
+	case "zip":
+		return newZipReader, true, nil
+	case "json_array":
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newJSONReader(r, true, fn)
+		}, true, nil
+	case "json_documents":
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newJSONReader(r, false, fn)
+		}, true, nil
+	case "msgpack":
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newStructuredReader(r, msgpack.NewDecoder(r).Decode, fn)
+		}, true, nil
+	case "cbor":
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newStructuredReader(r, cbor.NewDecoder(r).Decode, fn)
+		}, true, nil
 	}
 	if strings.HasPrefix(codec, "delim:") {
 		by := strings.TrimPrefix(codec, "delim:")
@@ -226,6 +284,26 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 			return newCustomDelimReader(conf, r, by, fn)
 		}, true, nil
 	}
+	if strings.HasPrefix(codec, "multiline:") || strings.HasPrefix(codec, "multiline_continue:") {
+		continuation := strings.HasPrefix(codec, "multiline_continue:")
+		pattern := codec[strings.Index(codec, ":")+1:]
+		if pattern == "" {
+			return nil, false, errors.New("multiline codec requires a non-empty regular expression")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid regular expression for multiline codec: %w", err)
+		}
+		var flushTimeout time.Duration
+		if tStr := conf.Multiline.FlushTimeout; tStr != "" {
+			if flushTimeout, err = time.ParseDuration(tStr); err != nil {
+				return nil, false, fmt.Errorf("invalid flush timeout for multiline codec: %w", err)
+			}
+		}
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newMultilineReader(conf, r, re, continuation, flushTimeout, fn)
+		}, true, nil
+	}
 	if strings.HasPrefix(codec, "chunker:") {
 		chunkSize, err := strconv.ParseUint(strings.TrimPrefix(codec, "chunker:"), 10, 64)
 		if err != nil {
@@ -576,6 +654,221 @@ func (a *customDelimReader) Close(ctx context.Context) error {
 
 //------------------------------------------------------------------------------
 
+type multilineScan struct {
+	line []byte
+	err  error
+}
+
+type multilineReader struct {
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	pattern      *regexp.Regexp
+	continuation bool
+	maxLines     int
+	maxBytes     int
+	flushTimeout time.Duration
+
+	scanRequests chan struct{}
+	scanResults  chan multilineScan
+	scanAwaiting bool
+	closeChan    chan struct{}
+	closeOnce    sync.Once
+
+	record      []byte
+	recordLines int
+	scanErr     error
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+func newMultilineReader(
+	conf ReaderConfig,
+	r io.ReadCloser,
+	pattern *regexp.Regexp,
+	continuation bool,
+	flushTimeout time.Duration,
+	ackFn ReaderAckFn,
+) (Reader, error) {
+	scanner := bufio.NewScanner(r)
+	if conf.MaxScanTokenSize != bufio.MaxScanTokenSize {
+		scanner.Buffer([]byte{}, conf.MaxScanTokenSize)
+	}
+
+	m := &multilineReader{
+		r:            r,
+		sourceAck:    ackOnce(ackFn),
+		pattern:      pattern,
+		continuation: continuation,
+		maxLines:     conf.Multiline.MaxLines,
+		maxBytes:     conf.Multiline.MaxBytes,
+		flushTimeout: flushTimeout,
+		scanRequests: make(chan struct{}),
+		scanResults:  make(chan multilineScan),
+		closeChan:    make(chan struct{}),
+	}
+
+	// Lines are scanned in the background on request so that a pending record
+	// can be flushed after a period of inactivity even whilst a read is
+	// blocked.
+	go func() {
+		for {
+			select {
+			case <-m.scanRequests:
+			case <-m.closeChan:
+				return
+			}
+			var res multilineScan
+			if scanner.Scan() {
+				res.line = make([]byte, len(scanner.Bytes()))
+				copy(res.line, scanner.Bytes())
+			} else if res.err = scanner.Err(); res.err == nil {
+				res.err = io.EOF
+			}
+			select {
+			case m.scanResults <- res:
+			case <-m.closeChan:
+				return
+			}
+			if res.err != nil {
+				return
+			}
+		}
+	}()
+	return m, nil
+}
+
+func (a *multilineReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+// startsRecord returns whether a line should begin a new record rather than
+// be appended to the current one.
+func (a *multilineReader) startsRecord(line []byte) bool {
+	if a.maxLines > 0 && a.recordLines >= a.maxLines {
+		return true
+	}
+	if a.maxBytes > 0 && len(a.record)+1+len(line) > a.maxBytes {
+		return true
+	}
+	matched := a.pattern.Match(line)
+	if a.continuation {
+		return !matched
+	}
+	return matched
+}
+
+func (a *multilineReader) flush() ([]types.Part, ReaderAckFn) {
+	a.mut.Lock()
+	a.pending++
+	a.mut.Unlock()
+
+	part := message.NewPart(a.record)
+	a.record = nil
+	a.recordLines = 0
+	return []types.Part{part}, a.ack
+}
+
+func (a *multilineReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error) {
+	if a.scanErr != nil {
+		return nil, nil, a.scanErr
+	}
+
+	var timeoutChan <-chan time.Time
+	for {
+		if a.recordLines > 0 && a.flushTimeout > 0 && timeoutChan == nil {
+			timer := time.NewTimer(a.flushTimeout)
+			defer timer.Stop()
+			timeoutChan = timer.C
+		}
+
+		if !a.scanAwaiting {
+			select {
+			case a.scanRequests <- struct{}{}:
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+			a.scanAwaiting = true
+		}
+
+		select {
+		case scan := <-a.scanResults:
+			a.scanAwaiting = false
+			if scan.err != nil {
+				a.scanErr = scan.err
+				if scan.err != io.EOF {
+					_ = a.sourceAck(ctx, scan.err)
+					return nil, nil, scan.err
+				}
+
+				// The final record must be counted as pending before the
+				// reader is marked as finished.
+				var parts []types.Part
+				var ackFn ReaderAckFn
+				if a.recordLines > 0 {
+					parts, ackFn = a.flush()
+				}
+
+				a.mut.Lock()
+				a.finished = true
+				a.mut.Unlock()
+
+				if len(parts) > 0 {
+					return parts, ackFn, nil
+				}
+				return nil, nil, io.EOF
+			}
+			if a.recordLines > 0 && a.startsRecord(scan.line) {
+				parts, ackFn := a.flush()
+				a.record, a.recordLines = scan.line, 1
+				return parts, ackFn, nil
+			}
+			if a.recordLines > 0 {
+				a.record = append(a.record, '\n')
+			}
+			a.record = append(a.record, scan.line...)
+			a.recordLines++
+		case <-timeoutChan:
+			parts, ackFn := a.flush()
+			return parts, ackFn, nil
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+func (a *multilineReader) Close(ctx context.Context) error {
+	a.closeOnce.Do(func() {
+		close(a.closeChan)
+	})
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		_ = a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		_ = a.sourceAck(ctx, nil)
+	}
+	return a.r.Close()
+}
+
+//------------------------------------------------------------------------------
+
 type chunkerReader struct {
 	chunkSize uint64
 	buf       []byte
@@ -728,6 +1021,264 @@ func (a *tarReader) Close(ctx context.Context) error {
 
 //------------------------------------------------------------------------------
 
+type jsonReader struct {
+	dec       *json.Decoder
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	isArray bool
+	started bool
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+func newJSONReader(r io.ReadCloser, isArray bool, ackFn ReaderAckFn) (Reader, error) {
+	return &jsonReader{
+		dec:       json.NewDecoder(r),
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+		isArray:   isArray,
+	}, nil
+}
+
+func (a *jsonReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+// nextRaw decodes the next document or array element from the underlying
+// reader, returning io.EOF once the stream or array is exhausted.
+func (a *jsonReader) nextRaw() (json.RawMessage, error) {
+	if !a.isArray {
+		var raw json.RawMessage
+		if err := a.dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	}
+
+	if !a.started {
+		t, err := a.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if d, ok := t.(json.Delim); !ok || d != '[' {
+			return nil, fmt.Errorf("expected the start of a JSON array, got: %v", t)
+		}
+		a.started = true
+	}
+	if !a.dec.More() {
+		if _, err := a.dec.Token(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	var raw json.RawMessage
+	if err := a.dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+func (a *jsonReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error) {
+	raw, err := a.nextRaw()
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err != nil {
+		if err == io.EOF {
+			a.finished = true
+		} else {
+			_ = a.sourceAck(ctx, err)
+		}
+		return nil, nil, err
+	}
+
+	a.pending++
+	return []types.Part{message.NewPart([]byte(raw))}, a.ack, nil
+}
+
+func (a *jsonReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		_ = a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		_ = a.sourceAck(ctx, nil)
+	}
+	return a.r.Close()
+}
+
+//------------------------------------------------------------------------------
+
+// structuredReader emits each document of a stream decoded by a generic decode
+// function as a structured message.
+type structuredReader struct {
+	decode    func() (interface{}, error)
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+func newStructuredReader(r io.ReadCloser, decode func() (interface{}, error), ackFn ReaderAckFn) (Reader, error) {
+	return &structuredReader{
+		decode:    decode,
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+	}, nil
+}
+
+func (a *structuredReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+func (a *structuredReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error) {
+	obj, err := a.decode()
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err != nil {
+		if err == io.EOF {
+			a.finished = true
+		} else {
+			_ = a.sourceAck(ctx, err)
+		}
+		return nil, nil, err
+	}
+
+	part := message.NewPart(nil)
+	if err = part.SetJSON(obj); err != nil {
+		_ = a.sourceAck(ctx, err)
+		return nil, nil, err
+	}
+
+	a.pending++
+	return []types.Part{part}, a.ack, nil
+}
+
+func (a *structuredReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		_ = a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		_ = a.sourceAck(ctx, nil)
+	}
+	return a.r.Close()
+}
+
+//------------------------------------------------------------------------------
+
+func setArchiveMetadata(part types.Part, name string, modified time.Time) {
+	meta := part.Metadata()
+	meta.Set("archive_filename", name)
+	if !modified.IsZero() {
+		meta.Set("archive_last_modified", modified.Format(time.RFC3339))
+		meta.Set("archive_last_modified_unix", strconv.FormatInt(modified.Unix(), 10))
+	}
+}
+
+type zipReader struct {
+	buf       *zipStreamReader
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+func newZipReader(path string, r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
+	return &zipReader{
+		buf:       newZipStreamReader(r),
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+	}, nil
+}
+
+func (a *zipReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+func (a *zipReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error) {
+	entry, err := a.buf.Next()
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err == nil {
+		a.pending++
+		part := message.NewPart(entry.Contents)
+		setArchiveMetadata(part, entry.Name, entry.Modified)
+		return []types.Part{part}, a.ack, nil
+	}
+
+	if err == io.EOF {
+		a.finished = true
+	} else {
+		_ = a.sourceAck(ctx, err)
+	}
+	return nil, nil, err
+}
+
+func (a *zipReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		_ = a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		_ = a.sourceAck(ctx, nil)
+	}
+	return a.r.Close()
+}
+
+//------------------------------------------------------------------------------
+
 type multipartReader struct {
 	child Reader
 }
@@ -2,14 +2,19 @@ package codec
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
+	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/stretchr/testify/assert"
@@ -354,6 +359,171 @@ func TestChunkerReader(t *testing.T) {
 	testReaderSuite(t, "chunker:1", "", data)
 }
 
+func TestMultilineReader(t *testing.T) {
+	data := []byte("2021 foo\n  at bar\n  at baz\n2021 buz\n2021 qux\n  at quz")
+	testReaderSuite(t, "multiline:^\\d{4} ", "", data, "2021 foo\n  at bar\n  at baz", "2021 buz", "2021 qux\n  at quz")
+	testReaderSuite(t, "multiline_continue:^\\s", "", data, "2021 foo\n  at bar\n  at baz", "2021 buz", "2021 qux\n  at quz")
+
+	data = []byte("")
+	testReaderSuite(t, "multiline:^\\d{4} ", "", data)
+}
+
+func TestMultilineReaderLimits(t *testing.T) {
+	data := []byte("foo\n bar\n baz\n buz\nqux\n quz")
+
+	conf := NewReaderConfig()
+	conf.Multiline.MaxLines = 2
+
+	ctor, err := GetReader("multiline_continue:^\\s", conf)
+	require.NoError(t, err)
+
+	r, err := ctor("", noopCloser{bytes.NewReader(data), false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	for _, exp := range []string{"foo\n bar", " baz\n buz", "qux\n quz"} {
+		p, ackFn, err := r.Next(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, ackFn(context.Background(), nil))
+		require.Len(t, p, 1)
+		assert.Equal(t, exp, string(p[0].Get()))
+	}
+
+	_, _, err = r.Next(context.Background())
+	assert.EqualError(t, err, "EOF")
+	assert.NoError(t, r.Close(context.Background()))
+
+	conf = NewReaderConfig()
+	conf.Multiline.MaxBytes = 8
+
+	ctor, err = GetReader("multiline_continue:^\\s", conf)
+	require.NoError(t, err)
+
+	r, err = ctor("", noopCloser{bytes.NewReader(data), false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	for _, exp := range []string{"foo\n bar", " baz", " buz", "qux\n quz"} {
+		p, ackFn, err := r.Next(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, ackFn(context.Background(), nil))
+		require.Len(t, p, 1)
+		assert.Equal(t, exp, string(p[0].Get()))
+	}
+
+	_, _, err = r.Next(context.Background())
+	assert.EqualError(t, err, "EOF")
+	assert.NoError(t, r.Close(context.Background()))
+}
+
+func TestMultilineReaderFlushTimeout(t *testing.T) {
+	pipeReader, pipeWriter := io.Pipe()
+
+	conf := NewReaderConfig()
+	conf.Multiline.FlushTimeout = "10ms"
+
+	ctor, err := GetReader("multiline:^\\S", conf)
+	require.NoError(t, err)
+
+	r, err := ctor("", pipeReader, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	go func() {
+		_, _ = pipeWriter.Write([]byte("foo\n bar\nbaz\n buz\n"))
+	}()
+
+	for _, exp := range []string{"foo\n bar", "baz\n buz"} {
+		p, ackFn, err := r.Next(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, ackFn(context.Background(), nil))
+		require.Len(t, p, 1)
+		assert.Equal(t, exp, string(p[0].Get()))
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer done()
+
+	_, _, err = r.Next(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	require.NoError(t, r.Close(context.Background()))
+}
+
+func TestJSONArrayReader(t *testing.T) {
+	data := []byte(`[{"foo":"bar"}, 10, "baz",
+	[1,2]]`)
+	testReaderSuite(t, "json_array", "", data, `{"foo":"bar"}`, `10`, `"baz"`, `[1,2]`)
+
+	data = []byte(`[]`)
+	testReaderSuite(t, "json_array", "", data)
+
+	data = []byte("")
+	testReaderSuite(t, "json_array", "", data)
+}
+
+func TestJSONArrayReaderNotArray(t *testing.T) {
+	ctor, err := GetReader("json_array", NewReaderConfig())
+	require.NoError(t, err)
+
+	ack := errors.New("default err")
+	r, err := ctor("", noopCloser{bytes.NewReader([]byte(`{"foo":"bar"}`)), false}, func(ctx context.Context, err error) error {
+		ack = err
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, _, err = r.Next(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected the start of a JSON array")
+	assert.Equal(t, err, ack)
+
+	require.NoError(t, r.Close(context.Background()))
+}
+
+func TestJSONDocumentsReader(t *testing.T) {
+	data := []byte(`{"foo":"bar"}
+{"foo":
+  "baz"}{"foo":"buz"}
+10`)
+	testReaderSuite(t, "json_documents", "", data, `{"foo":"bar"}`, `{"foo":
+  "baz"}`, `{"foo":"buz"}`, `10`)
+
+	data = []byte("")
+	testReaderSuite(t, "json_documents", "", data)
+}
+
+func TestJSONDocumentsCompositeReader(t *testing.T) {
+	var gzipBuf bytes.Buffer
+	zw := gzip.NewWriter(&gzipBuf)
+	_, err := zw.Write([]byte("{\"id\":1}\n{\"id\":2}\n"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	testReaderSuite(t, "gzip/json_documents", "", gzipBuf.Bytes(), `{"id":1}`, `{"id":2}`)
+}
+
+func TestMsgpackReader(t *testing.T) {
+	// {"foo":"bar"} {"id":2} [1,true]
+	data, err := hex.DecodeString("81a3666f6fa362617281a26964029201c3")
+	require.NoError(t, err)
+	testReaderSuite(t, "msgpack", "", data, `{"foo":"bar"}`, `{"id":2}`, `[1,true]`)
+
+	testReaderSuite(t, "msgpack", "", []byte{})
+}
+
+func TestCBORReader(t *testing.T) {
+	// {"foo":"bar"} {"id":2} [1,true]
+	data, err := hex.DecodeString("a163666f6f63626172a1626964028201f5")
+	require.NoError(t, err)
+	testReaderSuite(t, "cbor", "", data, `{"foo":"bar"}`, `{"id":2}`, `[1,true]`)
+
+	testReaderSuite(t, "cbor", "", []byte{})
+}
+
 func TestTarReader(t *testing.T) {
 	input := []string{
 		"first document",
@@ -382,6 +552,123 @@ func TestTarReader(t *testing.T) {
 	testReaderSuite(t, "auto", "foo.tar", tarBuf.Bytes(), input...)
 }
 
+func TestZipReader(t *testing.T) {
+	input := []string{
+		"first document",
+		"second document",
+		"third document",
+	}
+
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for i := range input {
+		fw, err := zw.Create(fmt.Sprintf("testfile%v", i))
+		require.NoError(t, err)
+
+		_, err = fw.Write([]byte(input[i]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	testReaderSuite(t, "zip", "", zipBuf.Bytes(), input...)
+}
+
+func TestZipReaderMetadata(t *testing.T) {
+	modified := time.Date(2021, 10, 14, 12, 30, 0, 0, time.UTC)
+
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+
+	_, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     "dir/",
+		Modified: modified,
+	})
+	require.NoError(t, err)
+
+	// Raw entries are written without a modified time.
+	content := []byte("stored document")
+	fw, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "dir/stored.txt",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(content),
+		CompressedSize64:   uint64(len(content)),
+		UncompressedSize64: uint64(len(content)),
+	})
+	require.NoError(t, err)
+	_, err = fw.Write(content)
+	require.NoError(t, err)
+
+	fw, err = zw.CreateHeader(&zip.FileHeader{
+		Name:     "dir/deflated.txt",
+		Method:   zip.Deflate,
+		Modified: modified.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("deflated document"))
+	require.NoError(t, err)
+
+	require.NoError(t, zw.Close())
+
+	ctor, err := GetReader("zip", NewReaderConfig())
+	require.NoError(t, err)
+
+	r, err := ctor("", noopCloser{bytes.NewReader(zipBuf.Bytes()), false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	for _, exp := range []struct {
+		name     string
+		content  string
+		modified time.Time
+	}{
+		{name: "dir/stored.txt", content: "stored document"},
+		{name: "dir/deflated.txt", content: "deflated document", modified: modified.Add(time.Hour)},
+	} {
+		p, ackFn, err := r.Next(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, ackFn(context.Background(), nil))
+		require.Len(t, p, 1)
+		assert.Equal(t, exp.content, string(p[0].Get()))
+		assert.Equal(t, exp.name, p[0].Metadata().Get("archive_filename"))
+		if exp.modified.IsZero() {
+			assert.Equal(t, "", p[0].Metadata().Get("archive_last_modified"))
+		} else {
+			assert.Equal(t, exp.modified.Format(time.RFC3339), p[0].Metadata().Get("archive_last_modified"))
+			assert.Equal(t, strconv.FormatInt(exp.modified.Unix(), 10), p[0].Metadata().Get("archive_last_modified_unix"))
+		}
+	}
+
+	_, _, err = r.Next(context.Background())
+	assert.EqualError(t, err, "EOF")
+	assert.NoError(t, r.Close(context.Background()))
+}
+
+func TestZipReaderCorrupted(t *testing.T) {
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	fw, err := zw.Create("foo")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("hello world"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	ctor, err := GetReader("zip", NewReaderConfig())
+	require.NoError(t, err)
+
+	ack := errors.New("default err")
+	r, err := ctor("", noopCloser{bytes.NewReader(zipBuf.Bytes()[:40]), false}, func(ctx context.Context, err error) error {
+		ack = err
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, _, err = r.Next(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, err, ack)
+	require.NoError(t, r.Close(context.Background()))
+}
+
 func TestTarGzipReader(t *testing.T) {
 	input := []string{
 		"first document",
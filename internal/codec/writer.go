@@ -3,6 +3,7 @@ package codec
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -18,8 +19,10 @@ var WriterDocs = docs.FieldCommon(
 ).HasAnnotatedOptions(
 	"all-bytes", "Only applicable to file based outputs. Writes each message to a file in full, if the file already exists the old content is deleted.",
 	"append", "Append each message to the output stream without any delimiter or special encoding.",
+	"arrow", "EXPERIMENTAL: Append each batch to the output stream as a record batch of an [Apache Arrow IPC stream](https://arrow.apache.org/docs/format/Columnar.html#ipc-streaming-format), where each message must be a JSON object and becomes a row. A schema can be declared with `arrow:x`, where x is the schema. "+ArrowSchemaDocs,
 	"lines", "Append each message to the output stream followed by a line break.",
 	"delim:x", "Append each message to the output stream followed by a custom delimiter.",
+	"json_array", "Append each batch to the output stream as a JSON array of its messages followed by a line break, where each message must be a valid JSON document. A single message batch is written as an array of one element.",
 )
 
 //------------------------------------------------------------------------------
@@ -40,6 +43,10 @@ type WriterConfig struct {
 	Append     bool
 	Truncate   bool
 	CloseAfter bool
+
+	// AlwaysEndBatch indicates that EndBatch should be called after every
+	// batch written, including batches of a single message.
+	AlwaysEndBatch bool
 }
 
 // WriterConstructor creates a writer from an io.WriteCloser.
@@ -58,6 +65,17 @@ func GetWriter(codec string) (WriterConstructor, WriterConfig, error) {
 		}, customDelimConfig, nil
 	case "lines":
 		return newLinesWriter, linesWriterConfig, nil
+	case "json_array":
+		return newJSONArrayWriter, jsonArrayWriterConfig, nil
+	}
+	if codec == "arrow" || strings.HasPrefix(codec, "arrow:") {
+		enc, err := NewArrowEncoder(strings.TrimPrefix(strings.TrimPrefix(codec, "arrow"), ":"))
+		if err != nil {
+			return nil, WriterConfig{}, fmt.Errorf("invalid arrow schema: %w", err)
+		}
+		return func(w io.WriteCloser) (Writer, error) {
+			return newArrowWriter(w, enc)
+		}, arrowWriterConfig, nil
 	}
 	if strings.HasPrefix(codec, "delim:") {
 		by := strings.TrimPrefix(codec, "delim:")
@@ -166,3 +184,55 @@ func (d *customDelimWriter) EndBatch() error {
 func (d *customDelimWriter) Close(ctx context.Context) error {
 	return d.w.Close()
 }
+
+//------------------------------------------------------------------------------
+
+var jsonArrayWriterConfig = WriterConfig{
+	Append:         true,
+	AlwaysEndBatch: true,
+}
+
+type jsonArrayWriter struct {
+	w       io.WriteCloser
+	started bool
+}
+
+func newJSONArrayWriter(w io.WriteCloser) (Writer, error) {
+	return &jsonArrayWriter{w: w}, nil
+}
+
+func (j *jsonArrayWriter) Write(ctx context.Context, p types.Part) error {
+	partBytes := bytes.TrimSpace(p.Get())
+	if !json.Valid(partBytes) {
+		return errors.New("message contents are not valid JSON")
+	}
+	prefix := []byte(",")
+	if !j.started {
+		prefix = []byte("[")
+	}
+	if _, err := j.w.Write(prefix); err != nil {
+		return err
+	}
+	j.started = true
+	_, err := j.w.Write(partBytes)
+	return err
+}
+
+func (j *jsonArrayWriter) EndBatch() error {
+	if !j.started {
+		return nil
+	}
+	j.started = false
+	_, err := j.w.Write([]byte("]\n"))
+	return err
+}
+
+func (j *jsonArrayWriter) Close(ctx context.Context) error {
+	// Terminate any array left open, which can happen when a batch is split
+	// across multiple handles.
+	if err := j.EndBatch(); err != nil {
+		j.w.Close()
+		return err
+	}
+	return j.w.Close()
+}
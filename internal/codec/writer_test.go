@@ -0,0 +1,44 @@
+package codec
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopWriteCloser struct {
+	*bytes.Buffer
+}
+
+func (n noopWriteCloser) Close() error {
+	return nil
+}
+
+func TestJSONArrayWriter(t *testing.T) {
+	buf := noopWriteCloser{&bytes.Buffer{}}
+
+	ctor, conf, err := GetWriter("json_array")
+	require.NoError(t, err)
+	assert.True(t, conf.AlwaysEndBatch)
+
+	w, err := ctor(buf)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, w.Write(ctx, message.NewPart([]byte(`{"id":1}`))))
+	require.NoError(t, w.Write(ctx, message.NewPart([]byte(` "foo"`+"\n"))))
+	require.NoError(t, w.EndBatch())
+
+	require.NoError(t, w.Write(ctx, message.NewPart([]byte(`10`))))
+	require.NoError(t, w.EndBatch())
+
+	require.NoError(t, w.Write(ctx, message.NewPart([]byte(`null`))))
+	assert.EqualError(t, w.Write(ctx, message.NewPart([]byte(`not json`))), "message contents are not valid JSON")
+	require.NoError(t, w.Close(ctx))
+
+	assert.Equal(t, "[{\"id\":1},\"foo\"]\n[10]\n[null]\n", buf.String())
+}
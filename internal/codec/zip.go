@@ -0,0 +1,255 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+const (
+	zipLocalHeaderSig     = 0x04034b50
+	zipCentralHeaderSig   = 0x02014b50
+	zipEndOfCentralDirSig = 0x06054b50
+	zipDataDescriptorSig  = 0x08074b50
+
+	zipLocalHeaderLen = 30
+
+	zipFlagEncrypted      = 0x1
+	zipFlagDataDescriptor = 0x8
+
+	zipMethodStore   = 0
+	zipMethodDeflate = 8
+
+	zipExtraZip64     = 0x0001
+	zipExtraTimestamp = 0x5455
+)
+
+// zipEntry describes a single file extracted from a zip archive.
+type zipEntry struct {
+	Name     string
+	Modified time.Time
+	Contents []byte
+}
+
+// zipStreamReader extracts files from a zip archive by walking the local file
+// headers in order, which allows an archive to be consumed from a stream
+// without buffering it in its entirety or seeking to the central directory.
+type zipStreamReader struct {
+	r *bufio.Reader
+}
+
+func newZipStreamReader(r io.Reader) *zipStreamReader {
+	return &zipStreamReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next file entry of the archive, directories are skipped.
+// Returns io.EOF once the central directory is reached.
+func (z *zipStreamReader) Next() (*zipEntry, error) {
+	for {
+		entry, isDir, err := z.nextEntry()
+		if err != nil {
+			return nil, err
+		}
+		if !isDir {
+			return entry, nil
+		}
+	}
+}
+
+func (z *zipStreamReader) nextEntry() (*zipEntry, bool, error) {
+	var header [zipLocalHeaderLen]byte
+	if _, err := io.ReadFull(z.r, header[:4]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, false, io.EOF
+		}
+		return nil, false, err
+	}
+
+	switch sig := binary.LittleEndian.Uint32(header[:4]); sig {
+	case zipLocalHeaderSig:
+	case zipCentralHeaderSig, zipEndOfCentralDirSig:
+		// We've consumed all file entries, the remainder of the archive is
+		// the central directory which we do not need.
+		_, _ = io.Copy(io.Discard, z.r)
+		return nil, false, io.EOF
+	default:
+		return nil, false, fmt.Errorf("invalid zip file header signature: %#x", sig)
+	}
+
+	if _, err := io.ReadFull(z.r, header[4:]); err != nil {
+		return nil, false, unexpectedEOF(err)
+	}
+
+	flags := binary.LittleEndian.Uint16(header[6:8])
+	method := binary.LittleEndian.Uint16(header[8:10])
+	modTime := binary.LittleEndian.Uint16(header[10:12])
+	modDate := binary.LittleEndian.Uint16(header[12:14])
+	crc := binary.LittleEndian.Uint32(header[14:18])
+	compressedSize := uint64(binary.LittleEndian.Uint32(header[18:22]))
+	uncompressedSize := uint64(binary.LittleEndian.Uint32(header[22:26]))
+	nameLen := int(binary.LittleEndian.Uint16(header[26:28]))
+	extraLen := int(binary.LittleEndian.Uint16(header[28:30]))
+
+	nameAndExtra := make([]byte, nameLen+extraLen)
+	if _, err := io.ReadFull(z.r, nameAndExtra); err != nil {
+		return nil, false, unexpectedEOF(err)
+	}
+
+	entry := &zipEntry{
+		Name:     string(nameAndExtra[:nameLen]),
+		Modified: msDosTimeToTime(modDate, modTime),
+	}
+
+	isZip64 := false
+	for extra := nameAndExtra[nameLen:]; len(extra) >= 4; {
+		tag := binary.LittleEndian.Uint16(extra[:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if len(extra) < 4+size {
+			break
+		}
+		field := extra[4 : 4+size]
+		extra = extra[4+size:]
+
+		switch tag {
+		case zipExtraZip64:
+			isZip64 = true
+			if uncompressedSize == 0xFFFFFFFF && len(field) >= 8 {
+				uncompressedSize = binary.LittleEndian.Uint64(field[:8])
+				field = field[8:]
+			}
+			if compressedSize == 0xFFFFFFFF && len(field) >= 8 {
+				compressedSize = binary.LittleEndian.Uint64(field[:8])
+			}
+		case zipExtraTimestamp:
+			if len(field) >= 5 && field[0]&0x1 != 0 {
+				ts := int64(binary.LittleEndian.Uint32(field[1:5]))
+				entry.Modified = time.Unix(ts, 0).UTC()
+			}
+		}
+	}
+
+	if flags&zipFlagEncrypted != 0 {
+		return nil, false, fmt.Errorf("zip file entry '%v' is encrypted, which is not supported", entry.Name)
+	}
+
+	hasDescriptor := flags&zipFlagDataDescriptor != 0
+
+	var compressed io.Reader
+	var limited *io.LimitedReader
+	if hasDescriptor {
+		// Sizes are only known after the data, which means we can only find
+		// the end of compressed data by decompressing it.
+		if method != zipMethodDeflate {
+			return nil, false, fmt.Errorf("zip file entry '%v' uses a data descriptor with compression method %v, which cannot be streamed", entry.Name, method)
+		}
+		compressed = z.r
+	} else {
+		limited = &io.LimitedReader{R: z.r, N: int64(compressedSize)}
+		compressed = limited
+	}
+
+	var contents io.Reader
+	switch method {
+	case zipMethodStore:
+		contents = compressed
+	case zipMethodDeflate:
+		fr := flate.NewReader(compressed)
+		defer fr.Close()
+		contents = fr
+	default:
+		return nil, false, fmt.Errorf("zip file entry '%v' uses unsupported compression method %v", entry.Name, method)
+	}
+
+	hasher := crc32.NewIEEE()
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(io.TeeReader(contents, hasher)); err != nil {
+		return nil, false, unexpectedEOF(err)
+	}
+	if limited != nil && limited.N > 0 {
+		if _, err := io.Copy(io.Discard, limited); err != nil {
+			return nil, false, unexpectedEOF(err)
+		}
+	}
+
+	if hasDescriptor {
+		var err error
+		if crc, uncompressedSize, err = z.readDataDescriptor(isZip64); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if uint64(buf.Len()) != uncompressedSize {
+		return nil, false, fmt.Errorf("zip file entry '%v' has an unexpected size: %v != %v", entry.Name, buf.Len(), uncompressedSize)
+	}
+	if hasher.Sum32() != crc {
+		return nil, false, fmt.Errorf("zip file entry '%v' failed checksum validation", entry.Name)
+	}
+
+	entry.Contents = buf.Bytes()
+	isDir := len(entry.Name) > 0 && entry.Name[len(entry.Name)-1] == '/'
+	return entry, isDir, nil
+}
+
+func (z *zipStreamReader) readDataDescriptor(isZip64 bool) (crc uint32, uncompressedSize uint64, err error) {
+	var sigOrCRC [4]byte
+	if _, err = io.ReadFull(z.r, sigOrCRC[:]); err != nil {
+		return 0, 0, unexpectedEOF(err)
+	}
+
+	// The data descriptor signature is optional.
+	crc = binary.LittleEndian.Uint32(sigOrCRC[:])
+	if crc == zipDataDescriptorSig {
+		if _, err = io.ReadFull(z.r, sigOrCRC[:]); err != nil {
+			return 0, 0, unexpectedEOF(err)
+		}
+		crc = binary.LittleEndian.Uint32(sigOrCRC[:])
+	}
+
+	sizeLen := 4
+	if isZip64 {
+		sizeLen = 8
+	}
+	sizes := make([]byte, sizeLen*2)
+	if _, err = io.ReadFull(z.r, sizes); err != nil {
+		return 0, 0, unexpectedEOF(err)
+	}
+	if isZip64 {
+		uncompressedSize = binary.LittleEndian.Uint64(sizes[8:])
+	} else {
+		uncompressedSize = uint64(binary.LittleEndian.Uint32(sizes[4:]))
+	}
+	return crc, uncompressedSize, nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// msDosTimeToTime converts an MS-DOS date and time into a time.Time, the
+// resolution is 2s. A zero date and time results in a zero time.Time.
+func msDosTimeToTime(dosDate, dosTime uint16) time.Time {
+	if dosDate == 0 && dosTime == 0 {
+		return time.Time{}
+	}
+	return time.Date(
+		int(dosDate>>9+1980),
+		time.Month(dosDate>>5&0xf),
+		int(dosDate&0x1f),
+
+		int(dosTime>>11),
+		int(dosTime>>5&0x3f),
+		int(dosTime&0x1f*2),
+		0,
+
+		time.UTC,
+	)
+}
@@ -36,6 +36,17 @@ type V2 interface {
 	Close(ctx context.Context) error
 }
 
+// V2CompareAndDelete is an optional extension to V2 for caches that are able to
+// atomically remove a key only when it holds an expected value.
+type V2CompareAndDelete interface {
+	// CompareAndDelete attempts to remove a key only if its current value
+	// matches the provided value, returns types.ErrKeyNotFound if the key does
+	// not exist or holds a different value.
+	CompareAndDelete(ctx context.Context, key string, value []byte) error
+
+	V2
+}
+
 //------------------------------------------------------------------------------
 
 // Implements types.Cache
@@ -63,7 +74,17 @@ type v2ToV1Cache struct {
 }
 
 // NewV2ToV1Cache wraps a cache.V2 with a struct that implements types.Cache.
+// When the cache implements V2CompareAndDelete the returned cache also
+// implements types.CacheWithCompareAndDelete.
 func NewV2ToV1Cache(c V2, stats metrics.Type) types.Cache {
+	a := newV2ToV1Cache(c, stats)
+	if cad, ok := c.(V2CompareAndDelete); ok {
+		return &v2ToV1CacheWithCompareAndDelete{v2ToV1Cache: a, cad: cad}
+	}
+	return a
+}
+
+func newV2ToV1Cache(c V2, stats metrics.Type) *v2ToV1Cache {
 	return &v2ToV1Cache{
 		c: c, sig: shutdown.NewSignaller(),
 
@@ -189,6 +210,24 @@ func (a *v2ToV1Cache) Delete(key string) error {
 	return err
 }
 
+// Implements types.CacheWithCompareAndDelete
+type v2ToV1CacheWithCompareAndDelete struct {
+	*v2ToV1Cache
+	cad V2CompareAndDelete
+}
+
+func (a *v2ToV1CacheWithCompareAndDelete) CompareAndDelete(key string, value []byte) error {
+	started := time.Now()
+	err := a.cad.CompareAndDelete(context.Background(), key, value)
+	a.mDelLatency.Timing(int64(time.Since(started)))
+	if err != nil && !errors.Is(err, types.ErrKeyNotFound) {
+		a.mDelFailed.Incr(1)
+	} else {
+		a.mDelSuccess.Incr(1)
+	}
+	return err
+}
+
 func (a *v2ToV1Cache) CloseAsync() {
 	go func() {
 		if err := a.c.Close(context.Background()); err == nil {
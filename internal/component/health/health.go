@@ -0,0 +1,262 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+)
+
+// Status describes the health of a component.
+type Status string
+
+// Component health statuses.
+const (
+	StatusHealthy   Status = "healthy"
+	StatusUnhealthy Status = "unhealthy"
+)
+
+// Report is a snapshot of the health of a component.
+type Report struct {
+	Type          docs.Type  `json:"type"`
+	Path          string     `json:"path"`
+	Status        Status     `json:"status"`
+	Connected     bool       `json:"connected"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	Errors        int64      `json:"errors"`
+	Reconnects    int64      `json:"reconnects"`
+}
+
+//------------------------------------------------------------------------------
+
+// Tracker records the health of a single component. A component is considered
+// healthy when it is connected and it has not reported an error since its last
+// successful operation.
+type Tracker struct {
+	ctype docs.Type
+	path  string
+	reg   *Registry
+
+	connected func() bool
+
+	mut           sync.Mutex
+	lastErr       string
+	lastErrAt     time.Time
+	lastSuccessAt time.Time
+	errors        int64
+	reconnects    int64
+
+	mStatus     metrics.StatGauge
+	mErrors     metrics.StatCounter
+	mReconnects metrics.StatCounter
+}
+
+// NewTracker creates a health tracker for a component of a given type and
+// path, which is only reported by the registry once the component is wrapped.
+// The health of the component is also exposed through the provided metrics.
+func (r *Registry) NewTracker(ctype docs.Type, path string, stats metrics.Type) *Tracker {
+	return &Tracker{
+		ctype:       ctype,
+		path:        path,
+		reg:         r,
+		mStatus:     stats.GetGauge("health.status"),
+		mErrors:     stats.GetCounter("health.errors"),
+		mReconnects: stats.GetCounter("health.reconnects"),
+	}
+}
+
+// Success records a successful operation of the component.
+func (t *Tracker) Success() {
+	t.mut.Lock()
+	recovered := t.lastErrAt.After(t.lastSuccessAt)
+	t.lastSuccessAt = time.Now()
+	t.mut.Unlock()
+
+	// Only a success following an error can change the status, and this is
+	// called for every message so we avoid updating needlessly.
+	if recovered {
+		t.updateStatus()
+	}
+}
+
+// Connected records that the component has successfully established a
+// connection.
+func (t *Tracker) Connected() {
+	t.mut.Lock()
+	t.lastSuccessAt = time.Now()
+	t.mut.Unlock()
+	t.updateStatus()
+}
+
+// Error records a failed operation of the component.
+func (t *Tracker) Error(err string) {
+	t.mut.Lock()
+	t.lastErr = err
+	t.lastErrAt = time.Now()
+	t.errors++
+	t.mut.Unlock()
+	t.mErrors.Incr(1)
+	t.updateStatus()
+}
+
+// Reconnect records that the component lost its connection and is attempting
+// to reconnect.
+func (t *Tracker) Reconnect() {
+	t.mut.Lock()
+	t.reconnects++
+	t.mut.Unlock()
+	t.mReconnects.Incr(1)
+	t.updateStatus()
+}
+
+func (t *Tracker) updateStatus() {
+	connected := t.isConnected()
+
+	t.mut.Lock()
+	healthy := connected && t.status() == StatusHealthy
+	t.mut.Unlock()
+
+	if healthy {
+		t.mStatus.Set(1)
+	} else {
+		t.mStatus.Set(0)
+	}
+}
+
+func (t *Tracker) isConnected() bool {
+	if t.connected == nil {
+		return true
+	}
+	return t.connected()
+}
+
+// status returns the status of the component ignoring its connection state,
+// the caller must hold the lock.
+func (t *Tracker) status() Status {
+	if t.lastErrAt.IsZero() || t.lastSuccessAt.After(t.lastErrAt) {
+		return StatusHealthy
+	}
+	return StatusUnhealthy
+}
+
+// Report returns a snapshot of the health of the component.
+func (t *Tracker) Report() Report {
+	connected := t.isConnected()
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	r := Report{
+		Type:       t.ctype,
+		Path:       t.path,
+		Status:     t.status(),
+		Connected:  connected,
+		LastError:  t.lastErr,
+		Errors:     t.errors,
+		Reconnects: t.reconnects,
+	}
+	if !t.lastErrAt.IsZero() {
+		errAt := t.lastErrAt
+		r.LastErrorAt = &errAt
+	}
+	if !t.lastSuccessAt.IsZero() {
+		successAt := t.lastSuccessAt
+		r.LastSuccessAt = &successAt
+	}
+	if !connected {
+		r.Status = StatusUnhealthy
+	}
+	return r
+}
+
+//------------------------------------------------------------------------------
+
+// Registry is a collection of health trackers for the components of a service.
+type Registry struct {
+	mut      sync.Mutex
+	trackers map[*Tracker]struct{}
+}
+
+// NewRegistry creates an empty health registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		trackers: map[*Tracker]struct{}{},
+	}
+}
+
+func (r *Registry) add(t *Tracker) {
+	r.mut.Lock()
+	r.trackers[t] = struct{}{}
+	r.mut.Unlock()
+	t.updateStatus()
+}
+
+func (r *Registry) remove(t *Tracker) {
+	r.mut.Lock()
+	delete(r.trackers, t)
+	r.mut.Unlock()
+}
+
+// Reports returns a snapshot of the health of all running components, sorted
+// by their paths.
+func (r *Registry) Reports() []Report {
+	r.mut.Lock()
+	trackers := make([]*Tracker, 0, len(r.trackers))
+	for t := range r.trackers {
+		trackers = append(trackers, t)
+	}
+	r.mut.Unlock()
+
+	reports := make([]Report, 0, len(trackers))
+	for _, t := range trackers {
+		reports = append(reports, t.Report())
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Path == reports[j].Path {
+			return reports[i].Type < reports[j].Type
+		}
+		return reports[i].Path < reports[j].Path
+	})
+	return reports
+}
+
+// HandlerFunc returns an http.HandlerFunc that responds with a JSON object
+// containing the health of all running components, along with an overall
+// status. If any component is unhealthy the status code is 503.
+func (r *Registry) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		reports := r.Reports()
+
+		status := StatusHealthy
+		for _, report := range reports {
+			if report.Status != StatusHealthy {
+				status = StatusUnhealthy
+				break
+			}
+		}
+
+		resBytes, err := json.Marshal(struct {
+			Status     Status   `json:"status"`
+			Components []Report `json:"components"`
+		}{
+			Status:     status,
+			Components: reports,
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if status != StatusHealthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write(resBytes)
+	}
+}
@@ -0,0 +1,247 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockInput struct {
+	types.Input
+	connected bool
+	closed    bool
+}
+
+func (m *mockInput) Connected() bool {
+	return m.connected
+}
+
+func (m *mockInput) WaitForClose(time.Duration) error {
+	if m.closed {
+		return nil
+	}
+	return types.ErrTimeout
+}
+
+func TestTrackerStatus(t *testing.T) {
+	stats := metrics.NewLocal()
+	reg := NewRegistry()
+
+	tracker := reg.NewTracker(docs.TypeInput, "foo.input", stats)
+	in := &mockInput{connected: true}
+	wrapped := tracker.WrapInput(in)
+
+	statusGauge := func() int64 {
+		return stats.GetCounters()["health.status"]
+	}
+
+	reports := reg.Reports()
+	require.Len(t, reports, 1)
+	assert.Equal(t, docs.TypeInput, reports[0].Type)
+	assert.Equal(t, "foo.input", reports[0].Path)
+	assert.Equal(t, StatusHealthy, reports[0].Status)
+	assert.Nil(t, reports[0].LastSuccessAt)
+	assert.Equal(t, int64(1), statusGauge())
+
+	tracker.Error("nope")
+	report := tracker.Report()
+	assert.Equal(t, StatusUnhealthy, report.Status)
+	assert.Equal(t, "nope", report.LastError)
+	assert.NotNil(t, report.LastErrorAt)
+	assert.Equal(t, int64(1), report.Errors)
+	assert.Equal(t, int64(0), statusGauge())
+
+	tracker.Success()
+	report = tracker.Report()
+	assert.Equal(t, StatusHealthy, report.Status)
+	assert.Equal(t, "nope", report.LastError)
+	assert.NotNil(t, report.LastSuccessAt)
+	assert.Equal(t, int64(1), statusGauge())
+
+	in.connected = false
+	tracker.Reconnect()
+	report = tracker.Report()
+	assert.Equal(t, StatusUnhealthy, report.Status)
+	assert.False(t, report.Connected)
+	assert.Equal(t, int64(1), report.Reconnects)
+	assert.Equal(t, int64(0), statusGauge())
+	assert.Equal(t, int64(1), stats.GetCounters()["health.reconnects"])
+
+	in.connected = true
+	tracker.Connected()
+	assert.Equal(t, StatusHealthy, tracker.Report().Status)
+	assert.Equal(t, int64(1), statusGauge())
+
+	assert.Error(t, wrapped.WaitForClose(0))
+	assert.Len(t, reg.Reports(), 1)
+
+	in.closed = true
+	assert.NoError(t, wrapped.WaitForClose(0))
+	assert.Empty(t, reg.Reports())
+}
+
+type mockReportingInput struct {
+	mockInput
+	reporter Reporter
+}
+
+func (m *mockReportingInput) SetHealthReporter(r Reporter) {
+	m.reporter = r
+}
+
+type mockWrappingInput struct {
+	types.Input
+}
+
+func (m *mockWrappingInput) SetHealthReporter(r Reporter) {
+	SetReporter(m.Input, r)
+}
+
+func TestTrackerSetReporter(t *testing.T) {
+	reg := NewRegistry()
+	tracker := reg.NewTracker(docs.TypeInput, "input", metrics.Noop())
+
+	in := &mockReportingInput{mockInput: mockInput{connected: true}}
+	tracker.WrapInput(&mockWrappingInput{Input: in})
+	require.Equal(t, tracker, in.reporter)
+
+	in.reporter.Error("failed to connect: nope")
+	report := tracker.Report()
+	assert.Equal(t, StatusUnhealthy, report.Status)
+	assert.Equal(t, "failed to connect: nope", report.LastError)
+
+	in.reporter.Connected()
+	assert.Equal(t, StatusHealthy, tracker.Report().Status)
+
+	// Inputs that cannot report their health are still tracked.
+	reg.NewTracker(docs.TypeInput, "other", metrics.Noop()).WrapInput(&mockInput{connected: true})
+	assert.Len(t, reg.Reports(), 2)
+}
+
+func TestReporterField(t *testing.T) {
+	var f ReporterField
+
+	// Reports are discarded until a reporter is set.
+	f.Get().Error("nope")
+
+	tracker := NewRegistry().NewTracker(docs.TypeOutput, "output", metrics.Noop())
+	f.Set(tracker)
+	f.Get().Error("nope")
+	assert.Equal(t, int64(1), tracker.Report().Errors)
+}
+
+type mockCache struct {
+	types.Cache
+	err error
+}
+
+func (m *mockCache) Get(key string) ([]byte, error) {
+	return nil, m.err
+}
+
+type mockCacheWithTTL struct {
+	types.CacheWithTTL
+}
+
+type mockCacheWithTTLAndCompareAndDelete struct {
+	types.CacheWithTTL
+}
+
+func (m *mockCacheWithTTLAndCompareAndDelete) CompareAndDelete(key string, value []byte) error {
+	return nil
+}
+
+func TestWrapCache(t *testing.T) {
+	reg := NewRegistry()
+
+	c := &mockCache{err: types.ErrKeyNotFound}
+	tracker := reg.NewTracker(docs.TypeCache, "resource.cache.foo", metrics.Noop())
+	wrapped := tracker.WrapCache(c)
+
+	_, isTTL := wrapped.(types.CacheWithTTL)
+	assert.False(t, isTTL)
+
+	_, err := wrapped.Get("foo")
+	assert.Equal(t, types.ErrKeyNotFound, err)
+	assert.Equal(t, StatusHealthy, tracker.Report().Status)
+
+	c.err = errors.New("connection refused")
+	_, err = wrapped.Get("foo")
+	assert.Equal(t, c.err, err)
+	report := tracker.Report()
+	assert.Equal(t, StatusUnhealthy, report.Status)
+	assert.Equal(t, "connection refused", report.LastError)
+
+	wrapped = reg.NewTracker(docs.TypeCache, "resource.cache.bar", metrics.Noop()).WrapCache(&mockCacheWithTTL{})
+	_, isTTL = wrapped.(types.CacheWithTTL)
+	assert.True(t, isTTL)
+	_, isCAD := wrapped.(types.CacheWithCompareAndDelete)
+	assert.False(t, isCAD)
+
+	wrapped = reg.NewTracker(docs.TypeCache, "resource.cache.baz", metrics.Noop()).WrapCache(&mockCacheWithTTLAndCompareAndDelete{})
+	_, isTTL = wrapped.(types.CacheWithTTL)
+	assert.True(t, isTTL)
+	_, isCAD = wrapped.(types.CacheWithCompareAndDelete)
+	assert.True(t, isCAD)
+
+	inner := &mockCache{}
+	wrapped = reg.NewTracker(docs.TypeCache, "resource.cache.buz", metrics.Noop()).WrapCache(inner)
+	unwrapped := wrapped.(interface {
+		Unwrap() types.Cache
+	}).Unwrap()
+	assert.Equal(t, inner, unwrapped)
+}
+
+type mockRateLimit struct {
+	types.RateLimit
+}
+
+func TestRegistryHandler(t *testing.T) {
+	reg := NewRegistry()
+
+	fooTracker := reg.NewTracker(docs.TypeInput, "foo", metrics.Noop())
+	fooTracker.WrapInput(&mockInput{connected: true})
+	barTracker := reg.NewTracker(docs.TypeRateLimit, "bar", metrics.Noop())
+	barTracker.WrapRateLimit(&mockRateLimit{})
+
+	type healthBody struct {
+		Status     Status   `json:"status"`
+		Components []Report `json:"components"`
+	}
+
+	getHealth := func() (int, healthBody) {
+		t.Helper()
+		response := httptest.NewRecorder()
+		reg.HandlerFunc()(response, httptest.NewRequest("GET", "/health", nil))
+
+		var body healthBody
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &body))
+		return response.Code, body
+	}
+
+	code, body := getHealth()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusHealthy, body.Status)
+	require.Len(t, body.Components, 2)
+	assert.Equal(t, "bar", body.Components[0].Path)
+	assert.Equal(t, docs.TypeRateLimit, body.Components[0].Type)
+	assert.Equal(t, "foo", body.Components[1].Path)
+
+	barTracker.Error("rate limit broke")
+
+	code, body = getHealth()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusUnhealthy, body.Status)
+	assert.Equal(t, StatusUnhealthy, body.Components[0].Status)
+	assert.Equal(t, "rate limit broke", body.Components[0].LastError)
+	assert.Equal(t, StatusHealthy, body.Components[1].Status)
+}
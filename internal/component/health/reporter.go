@@ -0,0 +1,67 @@
+package health
+
+import "sync"
+
+// Reporter receives explicit reports of the health of a component from the
+// results of its connection attempts and its operations.
+type Reporter interface {
+	// Connected reports that the component successfully established a
+	// connection.
+	Connected()
+
+	// Reconnect reports that the component lost its connection and is
+	// attempting to reconnect.
+	Reconnect()
+
+	// Success reports a successful operation of the component.
+	Success()
+
+	// Error reports a failed connection attempt or operation of the
+	// component.
+	Error(err string)
+}
+
+// SetReporter gives a reporter to a component when it supports reporting its
+// health explicitly. Components that wrap another component should support
+// this by forwarding the reporter to the component they wrap.
+func SetReporter(c interface{}, r Reporter) {
+	if s, ok := c.(interface {
+		SetHealthReporter(r Reporter)
+	}); ok {
+		s.SetHealthReporter(r)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type noopReporter struct{}
+
+func (noopReporter) Connected()   {}
+func (noopReporter) Reconnect()   {}
+func (noopReporter) Success()     {}
+func (noopReporter) Error(string) {}
+
+// ReporterField holds the reporter of a component, which can be set after the
+// component has started running. Reports are discarded until it is set.
+type ReporterField struct {
+	mut sync.RWMutex
+	r   Reporter
+}
+
+// Set the reporter that health is reported to.
+func (f *ReporterField) Set(r Reporter) {
+	f.mut.Lock()
+	f.r = r
+	f.mut.Unlock()
+}
+
+// Get the reporter that health should be reported to.
+func (f *ReporterField) Get() Reporter {
+	f.mut.RLock()
+	r := f.r
+	f.mut.RUnlock()
+	if r == nil {
+		return noopReporter{}
+	}
+	return r
+}
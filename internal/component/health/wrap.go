@@ -0,0 +1,225 @@
+package health
+
+import (
+	"errors"
+	"time"
+
+	ioutput "github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// closeTracker removes the tracker from its registry once the closable has
+// finished closing.
+func (t *Tracker) closeTracker(c types.Closable, timeout time.Duration) error {
+	err := c.WaitForClose(timeout)
+	if err == nil {
+		t.reg.remove(t)
+	}
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+type trackedInput struct {
+	types.Input
+	t *Tracker
+}
+
+// WrapInput adds the tracker of an input to its registry, where the health of
+// the input is reported until it is closed. The tracker is given to the input
+// as its reporter when it supports reporting its health explicitly.
+func (t *Tracker) WrapInput(i types.Input) types.Input {
+	if i == nil {
+		return nil
+	}
+	t.connected = i.Connected
+	SetReporter(i, t)
+	t.reg.add(t)
+	return &trackedInput{Input: i, t: t}
+}
+
+func (i *trackedInput) WaitForClose(timeout time.Duration) error {
+	return i.t.closeTracker(i.Input, timeout)
+}
+
+// Unwrap to the underlying input.
+func (i *trackedInput) Unwrap() types.Input {
+	return i.Input
+}
+
+//------------------------------------------------------------------------------
+
+type trackedOutput struct {
+	types.Output
+	t *Tracker
+}
+
+// WrapOutput adds the tracker of an output to its registry, where the health
+// of the output is reported until it is closed. The tracker is given to the
+// output as its reporter when it supports reporting its health explicitly.
+func (t *Tracker) WrapOutput(o types.Output) types.Output {
+	if o == nil {
+		return nil
+	}
+	t.connected = o.Connected
+	SetReporter(o, t)
+	t.reg.add(t)
+	return &trackedOutput{Output: o, t: t}
+}
+
+func (o *trackedOutput) MaxInFlight() (int, bool) {
+	return ioutput.GetMaxInFlight(o.Output)
+}
+
+func (o *trackedOutput) WaitForClose(timeout time.Duration) error {
+	return o.t.closeTracker(o.Output, timeout)
+}
+
+// Unwrap to the underlying output.
+func (o *trackedOutput) Unwrap() types.Output {
+	return o.Output
+}
+
+//------------------------------------------------------------------------------
+
+type trackedCache struct {
+	c types.Cache
+	t *Tracker
+}
+
+type trackedCacheWithTTL struct {
+	*trackedCache
+	cttl types.CacheWithTTL
+}
+
+type trackedCacheWithCompareAndDelete struct {
+	*trackedCache
+	cad types.CacheWithCompareAndDelete
+}
+
+type trackedCacheWithTTLAndCompareAndDelete struct {
+	*trackedCacheWithTTL
+	cad types.CacheWithCompareAndDelete
+}
+
+// WrapCache adds the tracker of a cache to its registry, where the health of
+// the cache is reported until it is closed. Cache operations that fail for
+// reasons other than a missing or duplicate key are tracked as errors.
+func (t *Tracker) WrapCache(c types.Cache) types.Cache {
+	if c == nil {
+		return nil
+	}
+	t.reg.add(t)
+	tc := &trackedCache{c: c, t: t}
+	cad, isCAD := c.(types.CacheWithCompareAndDelete)
+	if cttl, ok := c.(types.CacheWithTTL); ok {
+		tcttl := &trackedCacheWithTTL{trackedCache: tc, cttl: cttl}
+		if isCAD {
+			return &trackedCacheWithTTLAndCompareAndDelete{trackedCacheWithTTL: tcttl, cad: cad}
+		}
+		return tcttl
+	}
+	if isCAD {
+		return &trackedCacheWithCompareAndDelete{trackedCache: tc, cad: cad}
+	}
+	return tc
+}
+
+func (c *trackedCache) track(err error) error {
+	if err != nil && !errors.Is(err, types.ErrKeyNotFound) && !errors.Is(err, types.ErrKeyAlreadyExists) {
+		c.t.Error(err.Error())
+	} else {
+		c.t.Success()
+	}
+	return err
+}
+
+func (c *trackedCache) Get(key string) ([]byte, error) {
+	b, err := c.c.Get(key)
+	return b, c.track(err)
+}
+
+func (c *trackedCache) Set(key string, value []byte) error {
+	return c.track(c.c.Set(key, value))
+}
+
+func (c *trackedCache) SetMulti(items map[string][]byte) error {
+	return c.track(c.c.SetMulti(items))
+}
+
+func (c *trackedCache) Add(key string, value []byte) error {
+	return c.track(c.c.Add(key, value))
+}
+
+func (c *trackedCache) Delete(key string) error {
+	return c.track(c.c.Delete(key))
+}
+
+func (c *trackedCache) CloseAsync() {
+	c.c.CloseAsync()
+}
+
+func (c *trackedCache) WaitForClose(timeout time.Duration) error {
+	return c.t.closeTracker(c.c, timeout)
+}
+
+// Unwrap to the underlying cache.
+func (c *trackedCache) Unwrap() types.Cache {
+	return c.c
+}
+
+func (c *trackedCacheWithTTL) SetWithTTL(key string, value []byte, ttl *time.Duration) error {
+	return c.track(c.cttl.SetWithTTL(key, value, ttl))
+}
+
+func (c *trackedCacheWithTTL) SetMultiWithTTL(items map[string]types.CacheTTLItem) error {
+	return c.track(c.cttl.SetMultiWithTTL(items))
+}
+
+func (c *trackedCacheWithTTL) AddWithTTL(key string, value []byte, ttl *time.Duration) error {
+	return c.track(c.cttl.AddWithTTL(key, value, ttl))
+}
+
+func (c *trackedCacheWithCompareAndDelete) CompareAndDelete(key string, value []byte) error {
+	return c.track(c.cad.CompareAndDelete(key, value))
+}
+
+func (c *trackedCacheWithTTLAndCompareAndDelete) CompareAndDelete(key string, value []byte) error {
+	return c.track(c.cad.CompareAndDelete(key, value))
+}
+
+//------------------------------------------------------------------------------
+
+type trackedRateLimit struct {
+	types.RateLimit
+	t *Tracker
+}
+
+// WrapRateLimit adds the tracker of a rate limit to its registry, where the
+// health of the rate limit is reported until it is closed.
+func (t *Tracker) WrapRateLimit(r types.RateLimit) types.RateLimit {
+	if r == nil {
+		return nil
+	}
+	t.reg.add(t)
+	return &trackedRateLimit{RateLimit: r, t: t}
+}
+
+func (r *trackedRateLimit) Access() (time.Duration, error) {
+	d, err := r.RateLimit.Access()
+	if err != nil {
+		r.t.Error(err.Error())
+	} else {
+		r.t.Success()
+	}
+	return d, err
+}
+
+func (r *trackedRateLimit) WaitForClose(timeout time.Duration) error {
+	return r.t.closeTracker(r.RateLimit, timeout)
+}
+
+// Unwrap to the underlying rate limit.
+func (r *trackedRateLimit) Unwrap() types.RateLimit {
+	return r.RateLimit
+}
@@ -8,7 +8,9 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/config"
 	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/stream"
 	"github.com/Jeffail/gabs/v2"
+	"github.com/fsnotify/fsnotify"
 	"gopkg.in/yaml.v3"
 )
 
@@ -18,6 +20,14 @@ type Reader struct {
 	mainPath      string
 	resourcePaths []string
 	overrides     []string
+
+	// The last successfully applied configs, which are used for detecting
+	// changes and reverting to when watching files.
+	mainConf      stream.Config
+	resourceConfs map[string]manager.Config
+
+	mainUpdateFn MainUpdateFunc
+	watcher      *fsnotify.Watcher
 }
 
 // NewReader creates a new config reader.
@@ -25,6 +35,7 @@ func NewReader(mainPath string, resourcePaths []string, opts ...OptFunc) *Reader
 	r := &Reader{
 		mainPath:      mainPath,
 		resourcePaths: resourcePaths,
+		resourceConfs: map[string]manager.Config{},
 	}
 	for _, opt := range opts {
 		opt(r)
@@ -124,6 +135,10 @@ func (r *Reader) readResources(conf *config.Type) (lints []string, err error) {
 			err = fmt.Errorf("%v: %w", path, err)
 			return
 		}
+		if r.resourceConfs[path], err = rconf.Collapsed(); err != nil {
+			err = fmt.Errorf("%v: %w", path, err)
+			return
+		}
 	}
 	return
 }
@@ -159,6 +174,10 @@ func (r *Reader) Read(conf *config.Type) (lints []string, err error) {
 	if lints, err = r.readMain(conf); err != nil {
 		return
 	}
+	r.mainConf = conf.Config
+	if r.resourceConfs[r.mainPath], err = conf.ResourceConfig.Collapsed(); err != nil {
+		return
+	}
 	var rLints []string
 	if rLints, err = r.readResources(conf); err != nil {
 		return
@@ -0,0 +1,360 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/ratelimit"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/fsnotify/fsnotify"
+)
+
+// ResourceStore is implemented by resource managers that support replacing
+// resources of a running service.
+type ResourceStore interface {
+	StoreCache(ctx context.Context, name string, conf cache.Config) error
+	StoreInput(ctx context.Context, name string, conf input.Config) error
+	StoreProcessor(ctx context.Context, name string, conf processor.Config) error
+	StoreOutput(ctx context.Context, name string, conf output.Config) error
+	StoreRateLimit(ctx context.Context, name string, conf ratelimit.Config) error
+}
+
+// MainUpdateFunc is a closure called with the stream config of a main config
+// file that has changed. If an error is returned then the previous stream config
+// is expected to remain in place.
+type MainUpdateFunc func(conf stream.Config) error
+
+// SubscribeMainUpdates registers a closure to be called whenever the main
+// config file is changed whilst watching files, and the stream fields (input,
+// buffer, pipeline, output) of the new config differ from the previous one.
+func (r *Reader) SubscribeMainUpdates(fn MainUpdateFunc) {
+	r.mainUpdateFn = fn
+}
+
+// How long a file must go without further events before it is read, which
+// prevents reading files that are only partially written.
+const watchDebounce = 200 * time.Millisecond
+
+// The maximum period to wait for a replaced resource to close.
+const resourceCloseTimeout = 30 * time.Second
+
+// BeginFileWatching starts a goroutine that watches the main config file and
+// all resource files for changes. When a change is detected the file is read
+// and linted, and any resources that were added or modified are swapped within
+// the provided store. Changes to the stream fields of the main config are
+// passed to the subscribed MainUpdateFunc.
+//
+// When strict is true updated files containing linting errors are ignored.
+// Files that fail to parse or resources that fail to initialise result in the
+// previous config remaining in place.
+func (r *Reader) BeginFileWatching(store ResourceStore, logger log.Modular, strict bool) error {
+	if r.watcher != nil {
+		return errors.New("a file watcher has already been started")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	// Editors commonly replace files rather than writing to them, which drops
+	// watches placed on the file itself, and so we watch the parent
+	// directories instead.
+	watched := map[string]string{}
+	watchedDirs := map[string]struct{}{}
+	addPath := func(path string) error {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		watched[absPath] = path
+		dir := filepath.Dir(absPath)
+		if _, exists := watchedDirs[dir]; exists {
+			return nil
+		}
+		watchedDirs[dir] = struct{}{}
+		return watcher.Add(dir)
+	}
+
+	if r.mainPath != "" {
+		err = addPath(r.mainPath)
+	}
+	for _, path := range r.resourcePaths {
+		if err != nil {
+			break
+		}
+		err = addPath(path)
+	}
+	if err != nil {
+		watcher.Close()
+		return err
+	}
+
+	r.watcher = watcher
+	go r.watchLoop(watcher, watched, store, logger, strict)
+	return nil
+}
+
+// Close stops watching files, if a watcher was started.
+func (r *Reader) Close(ctx context.Context) error {
+	if r.watcher == nil {
+		return nil
+	}
+	return r.watcher.Close()
+}
+
+func (r *Reader) watchLoop(
+	watcher *fsnotify.Watcher,
+	watched map[string]string,
+	store ResourceStore,
+	logger log.Modular,
+	strict bool,
+) {
+	ticker := time.NewTicker(watchDebounce / 2)
+	defer ticker.Stop()
+
+	pending := map[string]time.Time{}
+	for {
+		select {
+		case event, open := <-watcher.Events:
+			if !open {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if path, exists := watched[filepath.Clean(event.Name)]; exists {
+				pending[path] = time.Now()
+			}
+		case err, open := <-watcher.Errors:
+			if !open {
+				return
+			}
+			logger.Errorf("Config file watcher error: %v\n", err)
+		case <-ticker.C:
+			var ready []string
+			for path, lastEvent := range pending {
+				if time.Since(lastEvent) >= watchDebounce {
+					ready = append(ready, path)
+					delete(pending, path)
+				}
+			}
+			// Resources are updated before the main config as a new stream
+			// config might depend on them.
+			sort.Slice(ready, func(i, j int) bool {
+				return ready[i] != r.mainPath && ready[j] == r.mainPath
+			})
+			for _, path := range ready {
+				if path == r.mainPath {
+					r.reactMainUpdate(store, logger, strict)
+				} else {
+					r.reactResourceUpdate(store, logger, strict, path)
+				}
+			}
+		}
+	}
+}
+
+func lintsPermitUpdate(logger log.Modular, strict bool, lints []string) bool {
+	lintlog := logger.NewModule(".linter")
+	for _, lint := range lints {
+		if strict {
+			lintlog.Errorln(lint)
+		} else {
+			lintlog.Infoln(lint)
+		}
+	}
+	if strict && len(lints) > 0 {
+		logger.Errorln("Rejecting updated config due to linter errors, to allow these changes run Benthos with --chilled")
+		return false
+	}
+	return true
+}
+
+func (r *Reader) reactMainUpdate(store ResourceStore, logger log.Modular, strict bool) {
+	conf := config.New()
+	lints, err := r.readMain(&conf)
+	if err != nil {
+		logger.Errorf("Failed to read updated config: %v\n", err)
+		return
+	}
+	if !lintsPermitUpdate(logger, strict, lints) {
+		return
+	}
+
+	resConf, err := conf.ResourceConfig.Collapsed()
+	if err != nil {
+		logger.Errorf("Failed to read updated config: %v: %v\n", r.mainPath, err)
+		return
+	}
+	r.updateResources(store, logger, r.mainPath, resConf)
+
+	if r.mainUpdateFn == nil || reflect.DeepEqual(r.mainConf, conf.Config) {
+		return
+	}
+
+	logger.Infoln("Main config updated, attempting to update pipeline.")
+	if err := r.mainUpdateFn(conf.Config); err != nil {
+		logger.Errorf("Failed to apply updated config, the previous config remains in place: %v\n", err)
+		return
+	}
+	r.mainConf = conf.Config
+	logger.Infoln("Updated pipeline.")
+}
+
+func (r *Reader) reactResourceUpdate(store ResourceStore, logger log.Modular, strict bool, path string) {
+	rconf := manager.NewResourceConfig()
+	lints, err := readResource(path, &rconf)
+	if err != nil {
+		logger.Errorf("Failed to read updated resources: %v\n", err)
+		return
+	}
+	if !lintsPermitUpdate(logger, strict, lints) {
+		return
+	}
+
+	resConf, err := rconf.Collapsed()
+	if err != nil {
+		logger.Errorf("Failed to read updated resources: %v: %v\n", path, err)
+		return
+	}
+	r.updateResources(store, logger, path, resConf)
+}
+
+// updateResources stores each resource of a config that is new or differs
+// from the previously applied config of the same file. When a resource fails
+// to initialise the previous config of that resource is restored.
+func (r *Reader) updateResources(store ResourceStore, logger log.Modular, path string, newConf manager.Config) {
+	ctx, done := context.WithTimeout(context.Background(), resourceCloseTimeout)
+	defer done()
+
+	prev, exists := r.resourceConfs[path]
+	if !exists {
+		prev = manager.NewConfig()
+	}
+
+	applied := manager.NewConfig()
+	_ = applied.AddFrom(&prev)
+
+	apply := func(kind, name string, changed, existed bool, storeNew, storePrev func() error) bool {
+		if !changed {
+			return false
+		}
+		if err := storeNew(); err != nil {
+			logger.Errorf("Failed to update %v resource '%v': %v\n", kind, name, err)
+			if existed {
+				if err := storePrev(); err != nil {
+					logger.Errorf("Failed to restore previous %v resource '%v': %v\n", kind, name, err)
+				}
+			}
+			return false
+		}
+		logger.Infof("Updated %v resource '%v'.\n", kind, name)
+		return true
+	}
+
+	for k, v := range newConf.RateLimits {
+		k, v := k, v
+		pv, existed := prev.RateLimits[k]
+		if apply("rate limit", k, !existed || !reflect.DeepEqual(pv, v), existed,
+			func() error { return store.StoreRateLimit(ctx, k, v) },
+			func() error { return store.StoreRateLimit(ctx, k, pv) },
+		) {
+			applied.RateLimits[k] = v
+		}
+	}
+	for k, v := range newConf.Caches {
+		k, v := k, v
+		pv, existed := prev.Caches[k]
+		if apply("cache", k, !existed || !reflect.DeepEqual(pv, v), existed,
+			func() error { return store.StoreCache(ctx, k, v) },
+			func() error { return store.StoreCache(ctx, k, pv) },
+		) {
+			applied.Caches[k] = v
+		}
+	}
+	for k, v := range newConf.Processors {
+		k, v := k, v
+		pv, existed := prev.Processors[k]
+		if apply("processor", k, !existed || !reflect.DeepEqual(pv, v), existed,
+			func() error { return store.StoreProcessor(ctx, k, v) },
+			func() error { return store.StoreProcessor(ctx, k, pv) },
+		) {
+			applied.Processors[k] = v
+		}
+	}
+	for k, v := range newConf.Inputs {
+		k, v := k, v
+		pv, existed := prev.Inputs[k]
+		if apply("input", k, !existed || !reflect.DeepEqual(pv, v), existed,
+			func() error { return store.StoreInput(ctx, k, v) },
+			func() error { return store.StoreInput(ctx, k, pv) },
+		) {
+			applied.Inputs[k] = v
+		}
+	}
+	for k, v := range newConf.Outputs {
+		k, v := k, v
+		pv, existed := prev.Outputs[k]
+		if apply("output", k, !existed || !reflect.DeepEqual(pv, v), existed,
+			func() error { return store.StoreOutput(ctx, k, v) },
+			func() error { return store.StoreOutput(ctx, k, pv) },
+		) {
+			applied.Outputs[k] = v
+		}
+	}
+
+	if !reflect.DeepEqual(prev.Conditions, newConf.Conditions) {
+		logger.Warnf("Changes to condition resources of %v require a restart in order to take effect.\n", path)
+	}
+	if !reflect.DeepEqual(prev.Plugins, newConf.Plugins) {
+		logger.Warnf("Changes to plugin resources of %v require a restart in order to take effect.\n", path)
+	}
+	if removed := removedResources(prev, newConf); len(removed) > 0 {
+		logger.Warnf("Resources removed from %v remain active until a restart: %v\n", path, removed)
+	}
+
+	r.resourceConfs[path] = applied
+}
+
+func removedResources(prev, newConf manager.Config) []string {
+	var removed []string
+	for k := range prev.Inputs {
+		if _, exists := newConf.Inputs[k]; !exists {
+			removed = append(removed, k)
+		}
+	}
+	for k := range prev.Processors {
+		if _, exists := newConf.Processors[k]; !exists {
+			removed = append(removed, k)
+		}
+	}
+	for k := range prev.Outputs {
+		if _, exists := newConf.Outputs[k]; !exists {
+			removed = append(removed, k)
+		}
+	}
+	for k := range prev.Caches {
+		if _, exists := newConf.Caches[k]; !exists {
+			removed = append(removed, k)
+		}
+	}
+	for k := range prev.RateLimits {
+		if _, exists := newConf.RateLimits[k]; !exists {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
@@ -0,0 +1,231 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	iconfig "github.com/Jeffail/benthos/v3/internal/config"
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/ratelimit"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStore struct {
+	mut       sync.Mutex
+	caches    []cache.Config
+	failCache func(cache.Config) bool
+}
+
+func (m *mockStore) StoreCache(ctx context.Context, name string, conf cache.Config) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if m.failCache != nil && m.failCache(conf) {
+		return errors.New("nope")
+	}
+	conf.Label = name
+	m.caches = append(m.caches, conf)
+	return nil
+}
+
+func (m *mockStore) getCaches() []cache.Config {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return append([]cache.Config{}, m.caches...)
+}
+
+func (m *mockStore) StoreInput(ctx context.Context, name string, conf input.Config) error {
+	return nil
+}
+
+func (m *mockStore) StoreProcessor(ctx context.Context, name string, conf processor.Config) error {
+	return nil
+}
+
+func (m *mockStore) StoreOutput(ctx context.Context, name string, conf output.Config) error {
+	return nil
+}
+
+func (m *mockStore) StoreRateLimit(ctx context.Context, name string, conf ratelimit.Config) error {
+	return nil
+}
+
+func TestWatcherResourceUpdates(t *testing.T) {
+	dir := t.TempDir()
+
+	resPath := filepath.Join(dir, "res.yaml")
+	require.NoError(t, os.WriteFile(resPath, []byte(`
+cache_resources:
+  - label: foo
+    memory:
+      ttl: 12
+  - label: bar
+    memory:
+      ttl: 13
+`), 0644))
+
+	conf := config.New()
+	rdr := iconfig.NewReader("", []string{resPath})
+	_, err := rdr.Read(&conf)
+	require.NoError(t, err)
+
+	store := &mockStore{}
+	require.NoError(t, rdr.BeginFileWatching(store, log.Noop(), true))
+	t.Cleanup(func() {
+		rdr.Close(context.Background())
+	})
+
+	require.NoError(t, os.WriteFile(resPath, []byte(`
+cache_resources:
+  - label: foo
+    memory:
+      ttl: 14
+  - label: bar
+    memory:
+      ttl: 13
+  - label: baz
+    memory:
+      ttl: 15
+`), 0644))
+
+	assert.Eventually(t, func() bool {
+		return len(store.getCaches()) == 2
+	}, time.Second*5, time.Millisecond*50)
+
+	ttls := map[string]int{}
+	for _, c := range store.getCaches() {
+		ttls[c.Label] = c.Memory.TTL
+	}
+	assert.Equal(t, map[string]int{"foo": 14, "baz": 15}, ttls)
+}
+
+func TestWatcherResourceUpdateFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	resPath := filepath.Join(dir, "res.yaml")
+	require.NoError(t, os.WriteFile(resPath, []byte(`
+cache_resources:
+  - label: foo
+    memory:
+      ttl: 12
+`), 0644))
+
+	conf := config.New()
+	rdr := iconfig.NewReader("", []string{resPath})
+	_, err := rdr.Read(&conf)
+	require.NoError(t, err)
+
+	store := &mockStore{
+		failCache: func(c cache.Config) bool {
+			return c.Memory.TTL == 20
+		},
+	}
+	require.NoError(t, rdr.BeginFileWatching(store, log.Noop(), true))
+	t.Cleanup(func() {
+		rdr.Close(context.Background())
+	})
+
+	require.NoError(t, os.WriteFile(resPath, []byte(`
+cache_resources:
+  - label: foo
+    memory:
+      ttl: 20
+`), 0644))
+
+	// The previous config is restored.
+	assert.Eventually(t, func() bool {
+		return len(store.getCaches()) == 1
+	}, time.Second*5, time.Millisecond*50)
+	assert.Equal(t, 12, store.getCaches()[0].Memory.TTL)
+}
+
+func TestWatcherMainUpdates(t *testing.T) {
+	dir := t.TempDir()
+
+	mainPath := filepath.Join(dir, "main.yaml")
+	require.NoError(t, os.WriteFile(mainPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "first"'
+`), 0644))
+
+	conf := config.New()
+	rdr := iconfig.NewReader(mainPath, nil)
+	_, err := rdr.Read(&conf)
+	require.NoError(t, err)
+
+	var mut sync.Mutex
+	var updates []string
+	rdr.SubscribeMainUpdates(func(conf stream.Config) error {
+		mut.Lock()
+		defer mut.Unlock()
+		updates = append(updates, conf.Input.Generate.Mapping)
+		if conf.Input.Generate.Mapping == `root = "bad"` {
+			return errors.New("nope")
+		}
+		return nil
+	})
+	getUpdates := func() []string {
+		mut.Lock()
+		defer mut.Unlock()
+		return append([]string{}, updates...)
+	}
+
+	require.NoError(t, rdr.BeginFileWatching(&mockStore{}, log.Noop(), true))
+	t.Cleanup(func() {
+		rdr.Close(context.Background())
+	})
+
+	require.NoError(t, os.WriteFile(mainPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "second"'
+`), 0644))
+	assert.Eventually(t, func() bool {
+		return len(getUpdates()) == 1
+	}, time.Second*5, time.Millisecond*50)
+
+	// Linting errors are rejected in strict mode.
+	require.NoError(t, os.WriteFile(mainPath, []byte(`
+input:
+  nope: nah
+  generate:
+    mapping: 'root = "linted"'
+`), 0644))
+	<-time.After(time.Millisecond * 500)
+	assert.Len(t, getUpdates(), 1)
+
+	// Failed updates are not retried.
+	require.NoError(t, os.WriteFile(mainPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "bad"'
+`), 0644))
+	assert.Eventually(t, func() bool {
+		return len(getUpdates()) == 2
+	}, time.Second*5, time.Millisecond*50)
+
+	// Subsequent updates are compared against the last applied config.
+	require.NoError(t, os.WriteFile(mainPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "second"'
+`), 0644))
+	<-time.After(time.Millisecond * 500)
+
+	assert.Equal(t, []string{
+		`root = "second"`,
+		`root = "bad"`,
+	}, getUpdates())
+}
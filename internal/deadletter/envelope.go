@@ -0,0 +1,133 @@
+// Package deadletter implements the envelope format of messages that are sent
+// to a dead letter queue, which captures the context of the failure along with
+// the original message in a form that can be replayed.
+package deadletter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// Stages at which a message can fail before it is dead lettered.
+const (
+	StagePipeline = "pipeline"
+	StageOutput   = "output"
+)
+
+// Metadata keys added to dead lettered messages, allowing the failure to be
+// inspected without parsing the envelope.
+const (
+	MetaError     = "dead_letter_error"
+	MetaStage     = "dead_letter_stage"
+	MetaComponent = "dead_letter_component"
+	MetaAttempts  = "dead_letter_attempts"
+)
+
+// Failure describes why a message was dead lettered.
+type Failure struct {
+	// Error is the error that caused the message to fail.
+	Error string `json:"error"`
+
+	// Stage is either StagePipeline for messages that failed processing, or
+	// StageOutput for messages that could not be delivered.
+	Stage string `json:"stage"`
+
+	// Component is the path of the component where the message failed.
+	Component string `json:"component"`
+
+	// Attempts is the number of delivery attempts made before the message was
+	// dead lettered.
+	Attempts int `json:"attempts"`
+}
+
+// Envelope is the JSON format of a dead lettered message.
+type Envelope struct {
+	Failure
+
+	// Content is the raw contents of the original message, which is base64
+	// encoded when it is not valid UTF-8.
+	Content         string `json:"content"`
+	ContentEncoding string `json:"content_encoding,omitempty"`
+
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Timestamp string            `json:"timestamp"`
+}
+
+// Wrap returns a new message part containing an envelope of the provided part
+// and its failure. The failure is also added to the metadata of the new part.
+func Wrap(p types.Part, f Failure) (types.Part, error) {
+	env := Envelope{
+		Failure:   f,
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	if raw := p.Get(); utf8.Valid(raw) {
+		env.Content = string(raw)
+	} else {
+		env.Content = base64.StdEncoding.EncodeToString(raw)
+		env.ContentEncoding = "base64"
+	}
+
+	_ = p.Metadata().Iter(func(k, v string) error {
+		// The processing error flag and its source are captured as the error
+		// and component of the failure.
+		if k == types.FailFlagKey || k == types.FailFlagSourceKey {
+			return nil
+		}
+		if env.Metadata == nil {
+			env.Metadata = map[string]string{}
+		}
+		env.Metadata[k] = v
+		return nil
+	})
+
+	envBytes, err := json.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := message.NewPart(envBytes)
+	meta := wrapped.Metadata()
+	meta.Set(MetaError, f.Error)
+	meta.Set(MetaStage, f.Stage)
+	meta.Set(MetaComponent, f.Component)
+	meta.Set(MetaAttempts, strconv.Itoa(f.Attempts))
+	return wrapped, nil
+}
+
+// Unwrap parses the envelope of a dead lettered message part and returns the
+// original part along with its failure.
+func Unwrap(p types.Part) (types.Part, Failure, error) {
+	var env Envelope
+	if err := json.Unmarshal(p.Get(), &env); err != nil {
+		return nil, Failure{}, err
+	}
+	if env.Stage == "" {
+		return nil, Failure{}, errors.New("envelope is missing a failure stage")
+	}
+
+	raw := []byte(env.Content)
+	switch env.ContentEncoding {
+	case "":
+	case "base64":
+		var err error
+		if raw, err = base64.StdEncoding.DecodeString(env.Content); err != nil {
+			return nil, Failure{}, err
+		}
+	default:
+		return nil, Failure{}, errors.New("unrecognised content encoding: " + env.ContentEncoding)
+	}
+
+	orig := message.NewPart(raw)
+	for k, v := range env.Metadata {
+		orig.Metadata().Set(k, v)
+	}
+	return orig, env.Failure, nil
+}
@@ -0,0 +1,70 @@
+package deadletter
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		content  []byte
+		metadata map[string]string
+	}{
+		"text": {
+			content:  []byte(`{"id":"foo"}`),
+			metadata: map[string]string{"foo": "bar"},
+		},
+		"binary": {
+			content: []byte{0xff, 0xfe, 0x00, 0x01},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			part := message.NewPart(test.content)
+			for k, v := range test.metadata {
+				part.Metadata().Set(k, v)
+			}
+			part.Metadata().Set(types.FailFlagKey, "it broke")
+
+			failure := Failure{
+				Error:     "it broke",
+				Stage:     StagePipeline,
+				Component: "pipeline",
+			}
+			wrapped, err := Wrap(part, failure)
+			require.NoError(t, err)
+
+			assert.Equal(t, "it broke", wrapped.Metadata().Get(MetaError))
+			assert.Equal(t, StagePipeline, wrapped.Metadata().Get(MetaStage))
+			assert.Equal(t, "pipeline", wrapped.Metadata().Get(MetaComponent))
+			assert.Equal(t, "0", wrapped.Metadata().Get(MetaAttempts))
+
+			orig, unwrappedFailure, err := Unwrap(wrapped)
+			require.NoError(t, err)
+			assert.Equal(t, failure, unwrappedFailure)
+			assert.Equal(t, test.content, orig.Get())
+			assert.Equal(t, "", orig.Metadata().Get(types.FailFlagKey))
+			for k, v := range test.metadata {
+				assert.Equal(t, v, orig.Metadata().Get(k))
+			}
+		})
+	}
+}
+
+func TestUnwrapErrors(t *testing.T) {
+	for _, input := range []string{
+		`not json`,
+		`{"content":"foo"}`,
+		`{"content":"foo","stage":"output","content_encoding":"nope"}`,
+		`{"content":"not base64!","stage":"output","content_encoding":"base64"}`,
+	} {
+		_, _, err := Unwrap(message.NewPart([]byte(input)))
+		assert.Error(t, err, input)
+	}
+}
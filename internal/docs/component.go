@@ -97,6 +97,10 @@ type ComponentSpec struct {
 
 	// Version is the Benthos version this component was introduced.
 	Version string `json:"version,omitempty"`
+
+	// Fixer optionally migrates a config of the component that uses deprecated
+	// fields or behaviour into its modern equivalent.
+	Fixer ComponentFixFunc `json:"-"`
 }
 
 type componentContext struct {
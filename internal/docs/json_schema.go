@@ -1,5 +1,7 @@
 package docs
 
+import "sort"
+
 // JSONSchema serializes a field spec into a JSON schema structure.
 func (f FieldSpec) JSONSchema() interface{} {
 	spec := map[string]interface{}{}
@@ -24,19 +26,23 @@ func (f FieldSpec) JSONSchema() interface{} {
 	default:
 		switch f.Type {
 		case FieldTypeBool:
-			spec["type"] = "boolean"
+			return interpolatedJSONSchema(map[string]interface{}{"type": "boolean"})
 		case FieldTypeString:
 			spec["type"] = "string"
 		case FieldTypeInt:
-			spec["type"] = "number"
+			return interpolatedJSONSchema(map[string]interface{}{"type": "number"})
 		case FieldTypeFloat:
-			spec["type"] = "number"
+			return interpolatedJSONSchema(map[string]interface{}{"type": "number"})
 		case FieldTypeObject:
 			spec["type"] = "object"
+			if len(f.Children) == 0 {
+				// Objects without children accept arbitrary fields.
+				break
+			}
 			spec["properties"] = f.Children.JSONSchema()
 			var required []string
 			for _, child := range f.Children {
-				if !child.IsOptional && child.Default == nil {
+				if child.jsonSchemaRequired() {
 					required = append(required, child.Name)
 				}
 			}
@@ -45,28 +51,63 @@ func (f FieldSpec) JSONSchema() interface{} {
 			}
 			spec["additionalProperties"] = false
 		case FieldTypeInput:
-			spec["$ref"] = "#/$defs/input"
+			spec["$ref"] = "#/definitions/input"
 		case FieldTypeBuffer:
-			spec["$ref"] = "#/$defs/buffer"
+			spec["$ref"] = "#/definitions/buffer"
 		case FieldTypeCache:
-			spec["$ref"] = "#/$defs/cache"
+			spec["$ref"] = "#/definitions/cache"
 		case FieldTypeCondition:
 			return true
 		case FieldTypeProcessor:
-			spec["$ref"] = "#/$defs/processor"
+			spec["$ref"] = "#/definitions/processor"
 		case FieldTypeRateLimit:
-			spec["$ref"] = "#/$defs/rate_limit"
+			spec["$ref"] = "#/definitions/rate_limit"
 		case FieldTypeOutput:
-			spec["$ref"] = "#/$defs/output"
+			spec["$ref"] = "#/definitions/output"
 		case FieldTypeMetrics:
-			spec["$ref"] = "#/$defs/metrics"
+			spec["$ref"] = "#/definitions/metrics"
 		case FieldTypeTracer:
-			spec["$ref"] = "#/$defs/tracer"
+			spec["$ref"] = "#/definitions/tracer"
 		}
 	}
 	return spec
 }
 
+// envInterpolationJSONSchema matches strings containing environment variable
+// interpolations, which are resolved before a config is parsed.
+var envInterpolationJSONSchema = map[string]interface{}{
+	"type":    "string",
+	"pattern": `\$\{[^}]+\}`,
+}
+
+// interpolatedJSONSchema returns a schema that accepts either a value of the
+// provided schema or an environment variable interpolation.
+func interpolatedJSONSchema(spec map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{spec, envInterpolationJSONSchema},
+	}
+}
+
+// jsonSchemaRequired returns whether a field must be present within its parent
+// object, which isn't the case for objects where every child has a default.
+func (f FieldSpec) jsonSchemaRequired() bool {
+	if f.IsOptional || f.IsDeprecated || f.Default != nil || f.Type == FieldTypeCondition {
+		return false
+	}
+	if f.Kind == KindArray || f.Kind == Kind2DArray || f.Kind == KindMap {
+		return true
+	}
+	if f.Type != FieldTypeObject || len(f.Children) == 0 {
+		return true
+	}
+	for _, child := range f.Children {
+		if child.jsonSchemaRequired() {
+			return true
+		}
+	}
+	return false
+}
+
 // JSONSchema serializes a field spec into a JSON schema structure.
 func (f FieldSpecs) JSONSchema() map[string]interface{} {
 	spec := map[string]interface{}{}
@@ -75,3 +116,52 @@ func (f FieldSpecs) JSONSchema() map[string]interface{} {
 	}
 	return spec
 }
+
+// ConfigJSONSchema serializes the spec of a config into a JSON schema
+// document, where fields of a component type reference definitions generated
+// from the provided component specs. This allows editors to validate configs
+// that include plugins and templates.
+func ConfigJSONSchema(config FieldSpecs, components []ComponentSpec) map[string]interface{} {
+	byType := map[Type][]ComponentSpec{}
+	for _, c := range components {
+		byType[c.Type] = append(byType[c.Type], c)
+	}
+
+	defs := map[string]interface{}{}
+	for _, t := range Types() {
+		defs[string(t)] = componentJSONSchema(t, byType[t])
+	}
+
+	// All root fields are optional as omitted sections result in defaults.
+	return map[string]interface{}{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"type":                 "object",
+		"properties":           config.JSONSchema(),
+		"additionalProperties": false,
+		"definitions":          defs,
+	}
+}
+
+func componentJSONSchema(t Type, components []ComponentSpec) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for name, field := range reservedFieldsByType(t) {
+		properties[name] = field.JSONSchema()
+	}
+
+	names := []string{}
+	for _, c := range components {
+		names = append(names, c.Name)
+		properties[c.Name] = c.Config.JSONSchema()
+	}
+	sort.Strings(names)
+
+	properties["type"] = interpolatedJSONSchema(map[string]interface{}{
+		"type": "string",
+		"enum": names,
+	})
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
@@ -0,0 +1,135 @@
+package docs_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigJSONSchema(t *testing.T) {
+	config := docs.FieldSpecs{
+		docs.FieldCommon("input", "").HasType(docs.FieldTypeInput),
+		docs.FieldCommon("pipeline", "").WithChildren(
+			docs.FieldInt("threads", "").HasDefault(1),
+			docs.FieldCommon("processors", "").Array().HasType(docs.FieldTypeProcessor).HasDefault([]interface{}{}),
+		),
+	}
+
+	components := []docs.ComponentSpec{
+		{
+			Name: "foo",
+			Type: docs.TypeInput,
+			Config: docs.FieldComponent().WithChildren(
+				docs.FieldString("url", ""),
+				docs.FieldBool("enabled", "").HasDefault(true),
+				docs.FieldCommon("batching", "").WithChildren(
+					docs.FieldInt("count", "").HasDefault(0),
+				),
+			),
+		},
+		{
+			Name:   "bar",
+			Type:   docs.TypeProcessor,
+			Config: docs.FieldString("", ""),
+		},
+	}
+
+	schemaBytes, err := json.Marshal(docs.ConfigJSONSchema(config, components))
+	require.NoError(t, err)
+
+	// Draft-07 schemas resolve shared components from definitions.
+	assert.Contains(t, string(schemaBytes), `"$ref":"#/definitions/input"`)
+	assert.NotContains(t, string(schemaBytes), "$defs")
+
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schemaBytes))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		config string
+		errors []string
+	}{
+		{
+			name: "valid config",
+			config: `
+input:
+  label: a
+  foo:
+    url: http://example.com
+  processors:
+    - bar: baz
+pipeline:
+  processors:
+    - label: b
+      bar: buz
+`,
+		},
+		{
+			name: "env interpolations",
+			config: `
+input:
+  type: ${INPUT_TYPE}
+  foo:
+    url: ${URL}
+    enabled: ${ENABLED}
+    batching:
+      count: ${COUNT}
+`,
+		},
+		{
+			name: "missing required field",
+			config: `
+input:
+  foo: {}
+`,
+			errors: []string{"input.foo: url is required"},
+		},
+		{
+			name: "unknown component",
+			config: `
+pipeline:
+  processors:
+    - nope: {}
+`,
+			errors: []string{"pipeline.processors.0: Additional property nope is not allowed"},
+		},
+		{
+			name: "wrong field type",
+			config: `
+input:
+  foo:
+    url: http://example.com
+    enabled: nah
+`,
+			errors: []string{
+				"input.foo.enabled: Must validate at least one schema (anyOf)",
+				"input.foo.enabled: Does not match pattern '\\$\\{[^}]+\\}'",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var v interface{}
+			require.NoError(t, yaml.Unmarshal([]byte(test.config), &v))
+
+			jBytes, err := json.Marshal(v)
+			require.NoError(t, err)
+
+			res, err := schema.Validate(gojsonschema.NewBytesLoader(jBytes))
+			require.NoError(t, err)
+
+			var errs []string
+			for _, e := range res.Errors() {
+				errs = append(errs, e.String())
+			}
+			assert.Equal(t, test.errors, errs)
+		})
+	}
+}
@@ -0,0 +1,476 @@
+package docs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/parser"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"gopkg.in/yaml.v3"
+)
+
+// bloblangMigration describes the modern equivalent of a deprecated Bloblang
+// function or method, and how a call to it is rewritten.
+type bloblangMigration struct {
+	use     string
+	migrate func(args []string) string
+}
+
+var deprecatedBloblangFunctions = map[string]bloblangMigration{
+	"timestamp": {
+		use: "now().format_timestamp(format)",
+		migrate: func(args []string) string {
+			return "now().format_timestamp(" + strings.Join(timestampFormatArgs(args, ""), ", ") + ")"
+		},
+	},
+	"timestamp_utc": {
+		use: `now().format_timestamp(format, "UTC")`,
+		migrate: func(args []string) string {
+			return "now().format_timestamp(" + strings.Join(timestampFormatArgs(args, "UTC"), ", ") + ")"
+		},
+	},
+}
+
+var deprecatedBloblangMethods = map[string]bloblangMigration{
+	"parse_timestamp_unix": {
+		use: "parse_timestamp(format).format_timestamp_unix()",
+		migrate: func(args []string) string {
+			if len(args) == 0 {
+				args = []string{strconv.Quote(time.RFC3339Nano)}
+			}
+			return "parse_timestamp(" + strings.Join(args, ", ") + ").format_timestamp_unix()"
+		},
+	},
+}
+
+var namedBloblangArgRegexp = regexp.MustCompile(`^[a-z_]+\s*:`)
+
+// timestampFormatArgs returns the arguments of format_timestamp equivalent to
+// the arguments of the deprecated timestamp functions, which default to a
+// different format, with an optional timezone.
+func timestampFormatArgs(args []string, tz string) []string {
+	if len(args) == 0 {
+		args = []string{strconv.Quote("Mon Jan 2 15:04:05 -0700 MST 2006")}
+	}
+	if tz != "" {
+		if namedBloblangArgRegexp.MatchString(args[0]) {
+			args = append(args, "tz: "+strconv.Quote(tz))
+		} else {
+			args = append(args, strconv.Quote(tz))
+		}
+	}
+	return args
+}
+
+// deprecatedBloblangCall is a call to a deprecated Bloblang function or method
+// found within a mapping or interpolated string, where start and end are the
+// offsets of the call from the name to the closing bracket.
+type deprecatedBloblangCall struct {
+	name       string
+	isMethod   bool
+	start, end int
+	args       []string
+}
+
+func (c deprecatedBloblangCall) migration() (bloblangMigration, bool) {
+	if c.isMethod {
+		m, exists := deprecatedBloblangMethods[c.name]
+		return m, exists
+	}
+	m, exists := deprecatedBloblangFunctions[c.name]
+	return m, exists
+}
+
+func (c deprecatedBloblangCall) String() string {
+	kind := "function"
+	if c.isMethod {
+		kind = "method"
+	}
+	if m, exists := c.migration(); exists {
+		return fmt.Sprintf("%v %v is deprecated, use %v", kind, c.name, m.use)
+	}
+	return fmt.Sprintf("%v %v is deprecated", kind, c.name)
+}
+
+func isBloblangIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// skipBloblangLiteral returns the offset following a string literal or comment
+// that begins at offset i, or i if there isn't one.
+func skipBloblangLiteral(code string, i int) int {
+	switch {
+	case strings.HasPrefix(code[i:], `"""`):
+		if end := strings.Index(code[i+3:], `"""`); end >= 0 {
+			return i + end + 6
+		}
+		return len(code)
+	case code[i] == '"':
+		for j := i + 1; j < len(code); j++ {
+			switch code[j] {
+			case '\\':
+				j++
+			case '"':
+				return j + 1
+			}
+		}
+		return len(code)
+	case code[i] == '#':
+		if end := strings.IndexByte(code[i:], '\n'); end >= 0 {
+			return i + end
+		}
+		return len(code)
+	}
+	return i
+}
+
+// splitBloblangArgs returns the arguments of a call where open is the offset
+// of its opening bracket, along with the offset of its closing bracket.
+func splitBloblangArgs(code string, open int) (args []string, closing int, ok bool) {
+	depth, argStart := 0, open+1
+	for i := open; i < len(code); {
+		if next := skipBloblangLiteral(code, i); next != i {
+			i = next
+			continue
+		}
+		switch code[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth--; depth == 0 {
+				if arg := strings.TrimSpace(code[argStart:i]); arg != "" || len(args) > 0 {
+					args = append(args, arg)
+				}
+				return args, i, true
+			}
+		case ',':
+			if depth == 1 {
+				args = append(args, strings.TrimSpace(code[argStart:i]))
+				argStart = i + 1
+			}
+		}
+		i++
+	}
+	return nil, 0, false
+}
+
+// scanDeprecatedBloblang returns the calls to deprecated functions and methods
+// within Bloblang code starting at offset i. When untilBrace is true the scan
+// ends at an unmatched closing brace, which terminates an interpolation, and
+// the offset of the brace is returned.
+func scanDeprecatedBloblang(deprecated deprecatedBloblangNames, code string, i int, untilBrace bool) (calls []deprecatedBloblangCall, end int) {
+	depth := 0
+	for i < len(code) {
+		if next := skipBloblangLiteral(code, i); next != i {
+			i = next
+			continue
+		}
+		c := code[i]
+		switch {
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			if depth == 0 && untilBrace && c == '}' {
+				return calls, i
+			}
+			depth--
+		case isBloblangIdentChar(c) && (i == 0 || !isBloblangIdentChar(code[i-1])):
+			j := i
+			for j < len(code) && isBloblangIdentChar(code[j]) {
+				j++
+			}
+			call := deprecatedBloblangCall{
+				name:     code[i:j],
+				isMethod: i > 0 && code[i-1] == '.',
+				start:    i,
+			}
+			if j < len(code) && code[j] == '(' && deprecated.contains(call.name, call.isMethod) {
+				if args, closing, ok := splitBloblangArgs(code, j); ok {
+					call.args, call.end = args, closing+1
+					calls = append(calls, call)
+					j = call.end
+				}
+			}
+			i = j
+			continue
+		}
+		i++
+	}
+	return calls, len(code)
+}
+
+// deprecatedBloblangNames contains the names of deprecated Bloblang functions
+// and methods.
+type deprecatedBloblangNames struct {
+	functions, methods map[string]struct{}
+}
+
+func getDeprecatedBloblangNames() deprecatedBloblangNames {
+	names := deprecatedBloblangNames{
+		functions: map[string]struct{}{},
+		methods:   map[string]struct{}{},
+	}
+	for _, spec := range query.AllFunctions.Docs() {
+		if spec.Status == query.StatusDeprecated {
+			names.functions[spec.Name] = struct{}{}
+		}
+	}
+	for _, spec := range query.AllMethods.Docs() {
+		if spec.Status == query.StatusDeprecated {
+			names.methods[spec.Name] = struct{}{}
+		}
+	}
+	return names
+}
+
+func (d deprecatedBloblangNames) contains(name string, isMethod bool) bool {
+	var exists bool
+	if isMethod {
+		_, exists = d.methods[name]
+	} else {
+		_, exists = d.functions[name]
+	}
+	return exists
+}
+
+// modernEnv returns an environment without the deprecated functions and
+// methods.
+func (d deprecatedBloblangNames) modernEnv(env *bloblang.Environment) *bloblang.Environment {
+	var functions, methods []string
+	for k := range d.functions {
+		functions = append(functions, k)
+	}
+	for k := range d.methods {
+		methods = append(methods, k)
+	}
+	return env.WithoutFunctions(functions...).WithoutMethods(methods...)
+}
+
+// findDeprecatedBloblang returns the calls to deprecated functions and methods
+// within the value of a Bloblang field, where interpolated strings are only
+// scanned within their interpolations.
+func findDeprecatedBloblang(deprecated deprecatedBloblangNames, isMapping bool, value string) []deprecatedBloblangCall {
+	if isMapping {
+		calls, _ := scanDeprecatedBloblang(deprecated, value, 0, false)
+		return calls
+	}
+	var calls []deprecatedBloblangCall
+	for i := 0; i < len(value); i++ {
+		if !strings.HasPrefix(value[i:], "${!") {
+			continue
+		}
+		interpCalls, end := scanDeprecatedBloblang(deprecated, value, i+3, true)
+		calls = append(calls, interpCalls...)
+		i = end
+	}
+	return calls
+}
+
+func parseBloblangField(env *bloblang.Environment, isMapping bool, value string) error {
+	var err error
+	if isMapping {
+		_, err = env.NewMapping(value)
+	} else {
+		_, err = env.NewField(value)
+	}
+	return err
+}
+
+// fixDeprecatedBloblang rewrites calls to deprecated Bloblang functions and
+// methods within a scalar node into their modern equivalents. The node is left
+// unchanged if the result cannot be parsed, in which case the deprecated calls
+// are reported by lintDeprecatedBloblang.
+func fixDeprecatedBloblang(ctx LintContext, isMapping bool, node *yaml.Node) bool {
+	if node.Kind != yaml.ScalarNode || node.Value == "" {
+		return false
+	}
+	if err := parseBloblangField(ctx.BloblangEnv, isMapping, node.Value); err != nil {
+		return false
+	}
+
+	calls := findDeprecatedBloblang(getDeprecatedBloblangNames(), isMapping, node.Value)
+
+	var migrated strings.Builder
+	var last int
+	for _, call := range calls {
+		m, exists := call.migration()
+		if !exists {
+			continue
+		}
+		migrated.WriteString(node.Value[last:call.start])
+		migrated.WriteString(m.migrate(call.args))
+		last = call.end
+	}
+	if last == 0 {
+		return false
+	}
+	migrated.WriteString(node.Value[last:])
+
+	if err := parseBloblangField(ctx.BloblangEnv, isMapping, migrated.String()); err != nil {
+		return false
+	}
+	node.Value = migrated.String()
+	return true
+}
+
+func lintDeprecatedBloblang(ctx LintContext, isMapping bool, node *yaml.Node) []Lint {
+	if node.Kind != yaml.ScalarNode || node.Value == "" {
+		return nil
+	}
+
+	deprecated := getDeprecatedBloblangNames()
+	modernEnv := deprecated.modernEnv(ctx.BloblangEnv)
+
+	// Parsing errors are reported by regular linting, and therefore we only
+	// report errors that occur when deprecated functions are removed.
+	if err := parseBloblangField(ctx.BloblangEnv, isMapping, node.Value); err != nil {
+		return nil
+	}
+	err := parseBloblangField(modernEnv, isMapping, node.Value)
+	if err == nil {
+		return nil
+	}
+
+	lineOf := func(offset int) int {
+		line := node.Line + strings.Count(node.Value[:offset], "\n")
+		if node.Style == yaml.LiteralStyle || node.Style == yaml.FoldedStyle {
+			line++
+		}
+		return line
+	}
+
+	var lints []Lint
+	for _, call := range findDeprecatedBloblang(deprecated, isMapping, node.Value) {
+		lints = append(lints, NewLintWarning(lineOf(call.start), call.String()))
+	}
+	if len(lints) > 0 {
+		return lints
+	}
+
+	line := node.Line
+	if pErr, ok := err.(*parser.Error); ok {
+		bline, _ := parser.LineAndColOf([]rune(node.Value), pErr.Input)
+		if node.Style == yaml.LiteralStyle || node.Style == yaml.FoldedStyle {
+			bline++
+		}
+		line += bline - 1
+		err = pErr.Err
+	}
+	return []Lint{NewLintWarning(line, fmt.Sprintf("uses deprecated Bloblang: %v", err))}
+}
+
+// DeprecatedYAML walks a YAML node of a component config and returns a lint
+// for each deprecated component, field and Bloblang function or method that it
+// uses, which can be used in order to report configs that need migrating.
+func DeprecatedYAML(ctx LintContext, cType Type, node *yaml.Node) (lints []Lint) {
+	node = unwrapDocumentNode(node)
+
+	if cType == "condition" {
+		return []Lint{NewLintWarning(node.Line, "conditions are deprecated in favour of Bloblang queries")}
+	}
+
+	if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+		return
+	}
+
+	name, cSpec, exists := fixInferComponent(ctx, cType, node)
+	if !exists {
+		return
+	}
+	if cSpec.Status == StatusDeprecated {
+		lints = append(lints, NewLintWarning(node.Line, fmt.Sprintf("component %v is deprecated", name)))
+	}
+
+	reservedFields := reservedFieldsByType(cType)
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == name {
+			lints = append(lints, cSpec.Config.DeprecatedYAML(ctx, node.Content[i+1])...)
+		} else if spec, exists := reservedFields[node.Content[i].Value]; exists {
+			lints = append(lints, spec.DeprecatedYAML(ctx, node.Content[i+1])...)
+		}
+	}
+	return
+}
+
+// DeprecatedYAML walks a yaml node of a field and returns a lint for each
+// deprecated component, field and Bloblang function or method that it uses.
+func (f FieldSpec) DeprecatedYAML(ctx LintContext, node *yaml.Node) (lints []Lint) {
+	if f.skipLint {
+		return
+	}
+
+	node = unwrapDocumentNode(node)
+
+	switch f.Kind {
+	case Kind2DArray:
+		if node.Kind == yaml.SequenceNode {
+			for i := 0; i < len(node.Content); i++ {
+				lints = append(lints, f.Array().DeprecatedYAML(ctx, node.Content[i])...)
+			}
+		}
+		return
+	case KindArray:
+		if node.Kind == yaml.SequenceNode {
+			for i := 0; i < len(node.Content); i++ {
+				lints = append(lints, f.Scalar().DeprecatedYAML(ctx, node.Content[i])...)
+			}
+		}
+		return
+	case KindMap:
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i < len(node.Content)-1; i += 2 {
+				lints = append(lints, f.Scalar().DeprecatedYAML(ctx, node.Content[i+1])...)
+			}
+		}
+		return
+	}
+
+	if coreType, isCore := f.Type.IsCoreComponent(); isCore {
+		return DeprecatedYAML(ctx, coreType, node)
+	}
+
+	if f.Bloblang || f.Interpolated {
+		return lintDeprecatedBloblang(ctx, f.Bloblang, node)
+	}
+
+	if len(f.Children) > 0 {
+		lints = f.Children.DeprecatedYAML(ctx, node)
+	}
+	return
+}
+
+// DeprecatedYAML walks a yaml node of an object and returns a lint for each
+// deprecated component, field and Bloblang function or method that it uses.
+func (f FieldSpecs) DeprecatedYAML(ctx LintContext, node *yaml.Node) (lints []Lint) {
+	node = unwrapDocumentNode(node)
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	specNames := map[string]FieldSpec{}
+	for _, field := range f {
+		specNames[field.Name] = field
+	}
+
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		spec, exists := specNames[node.Content[i].Value]
+		if !exists {
+			continue
+		}
+		if spec.IsDeprecated {
+			value := node.Content[i+1]
+			isEmpty := (value.Kind == yaml.ScalarNode && value.Value == "") ||
+				(value.Kind != yaml.ScalarNode && len(value.Content) == 0)
+			if _, shouldOmit := spec.shouldOmitYAML(f, value, node); !shouldOmit && !isEmpty {
+				lints = append(lints, NewLintWarning(node.Content[i].Line, fmt.Sprintf("field %v is deprecated", spec.Name)))
+			}
+			continue
+		}
+		lints = append(lints, spec.DeprecatedYAML(ctx, node.Content[i+1])...)
+	}
+	return
+}
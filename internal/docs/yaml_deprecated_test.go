@@ -0,0 +1,105 @@
+package docs_test
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestYAMLDeprecated(t *testing.T) {
+	docs.RegisterDocs(docs.ComponentSpec{
+		Name: "testdeprecatedfoo",
+		Type: docs.TypeProcessor,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("a", "").HasDefault(""),
+			docs.FieldDeprecated("b").HasDefault(""),
+			docs.FieldBloblang("c", "").HasDefault(""),
+			docs.FieldInterpolatedString("d", "").HasDefault(""),
+			docs.FieldCommon("e", "").Array().HasType(docs.FieldTypeProcessor).HasDefault([]interface{}{}),
+		),
+	})
+	docs.RegisterDocs(docs.ComponentSpec{
+		Name:   "testdeprecatedold",
+		Type:   docs.TypeProcessor,
+		Status: docs.StatusDeprecated,
+		Config: docs.FieldString("", ""),
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		lints []docs.Lint
+	}{
+		{
+			name: "nothing deprecated",
+			input: `
+testdeprecatedfoo:
+  a: hello
+  c: root = now()
+  d: ${! now() }
+`,
+		},
+		{
+			name: "empty deprecated field",
+			input: `
+testdeprecatedfoo:
+  b: ""
+`,
+		},
+		{
+			name: "deprecated fields",
+			input: `
+testdeprecatedfoo:
+  b: hello
+  c: |
+    root.a = this.a
+    root.b = timestamp_unix()
+    root.c = timestamp()
+  d: ${! timestamp_utc() }
+`,
+			lints: []docs.Lint{
+				docs.NewLintWarning(3, "field b is deprecated"),
+				docs.NewLintWarning(7, "function timestamp is deprecated, use now().format_timestamp(format)"),
+				docs.NewLintWarning(8, `function timestamp_utc is deprecated, use now().format_timestamp(format, "UTC")`),
+			},
+		},
+		{
+			name: "deprecated bloblang method",
+			input: `
+testdeprecatedfoo:
+  c: 'root.a = "timestamp()".parse_timestamp_unix()'
+  d: timestamp() ${! meta("a") } ${! meta("b").parse_timestamp_unix("2006") }
+`,
+			lints: []docs.Lint{
+				docs.NewLintWarning(3, "method parse_timestamp_unix is deprecated, use parse_timestamp(format).format_timestamp_unix()"),
+				docs.NewLintWarning(4, "method parse_timestamp_unix is deprecated, use parse_timestamp(format).format_timestamp_unix()"),
+			},
+		},
+		{
+			name: "deprecated child components",
+			input: `
+testdeprecatedfoo:
+  e:
+    - testdeprecatedfoo: {}
+    - testdeprecatedold: bar
+`,
+			lints: []docs.Lint{
+				docs.NewLintWarning(5, "component testdeprecatedold is deprecated"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.input), &node))
+
+			lints := docs.DeprecatedYAML(docs.NewLintContext(), docs.TypeProcessor, &node)
+			assert.Equal(t, test.lints, lints)
+		})
+	}
+}
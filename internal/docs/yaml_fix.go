@@ -0,0 +1,371 @@
+package docs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// ComponentFixFunc rewrites the YAML node of a component config, which
+// includes the component type and reserved fields such as `label`, in order to
+// migrate deprecated fields or behaviour into a modern equivalent. The node may
+// be converted into an entirely different component type. Returns true if the
+// node was modified, or an error when the config is deprecated and cannot be
+// migrated automatically, in which case any modifications are discarded.
+type ComponentFixFunc func(node *yaml.Node) (bool, error)
+
+// removeYAMLKeys removes key/value pairs of a mapping node where the provided
+// func returns true. Head and line comments of removed keys are carried over to
+// the next remaining key as head comments so that they aren't lost.
+func removeYAMLKeys(node *yaml.Node, remove func(i int) bool) bool {
+	newContent := make([]*yaml.Node, 0, len(node.Content))
+	var carriedComment string
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if remove(i) {
+			for _, c := range []string{
+				node.Content[i].HeadComment,
+				node.Content[i].LineComment,
+				node.Content[i+1].LineComment,
+			} {
+				if c == "" {
+					continue
+				}
+				if carriedComment != "" {
+					carriedComment += "\n"
+				}
+				carriedComment += c
+			}
+			continue
+		}
+		if carriedComment != "" {
+			if node.Content[i].HeadComment != "" {
+				carriedComment += "\n" + node.Content[i].HeadComment
+			}
+			node.Content[i].HeadComment = carriedComment
+			carriedComment = ""
+		}
+		newContent = append(newContent, node.Content[i], node.Content[i+1])
+	}
+	if len(newContent) == len(node.Content) {
+		return false
+	}
+	node.Content = newContent
+	return true
+}
+
+// ReplaceInterpolatedArgsYAML attempts to replace a list of interpolated
+// strings found within a mapping node at the field argsField with an equivalent
+// Bloblang mapping at the field mappingField, which results in an array of the
+// resolved strings. Returns true if the node was modified.
+func ReplaceInterpolatedArgsYAML(node *yaml.Node, argsField, mappingField string) (bool, error) {
+	argsIndex, mappingIndex := -1, -1
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		switch node.Content[i].Value {
+		case argsField:
+			argsIndex = i
+		case mappingField:
+			mappingIndex = i
+		}
+	}
+	if argsIndex == -1 || node.Content[argsIndex+1].Kind != yaml.SequenceNode {
+		return false, nil
+	}
+
+	args := node.Content[argsIndex+1].Content
+	if len(args) == 0 {
+		return false, nil
+	}
+	if mappingIndex >= 0 && node.Content[mappingIndex+1].Value != "" {
+		return false, fmt.Errorf("cannot migrate field %v as field %v is already set", argsField, mappingField)
+	}
+
+	queries := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg.Kind != yaml.ScalarNode {
+			return false, errors.New("expected string value")
+		}
+		query, err := parser.FieldToQuery(parser.GlobalContext(), arg.Value)
+		if err != nil {
+			return false, fmt.Errorf("failed to migrate field %v: arg %v: %w", argsField, i, err)
+		}
+		queries = append(queries, query)
+	}
+
+	if mappingIndex >= 0 {
+		node.Content = append(node.Content[:mappingIndex], node.Content[mappingIndex+2:]...)
+		if mappingIndex < argsIndex {
+			argsIndex -= 2
+		}
+	}
+	node.Content[argsIndex].Value = mappingField
+	node.Content[argsIndex+1] = &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   "!!str",
+		Value: fmt.Sprintf("root = [ %v ]", strings.Join(queries, ", ")),
+	}
+	return true, nil
+}
+
+func copyYAMLNode(node *yaml.Node) *yaml.Node {
+	newNode := *node
+	if len(node.Content) > 0 {
+		newNode.Content = make([]*yaml.Node, len(node.Content))
+		for i, c := range node.Content {
+			newNode.Content[i] = copyYAMLNode(c)
+		}
+	}
+	return &newNode
+}
+
+func fixInferComponent(ctx LintContext, cType Type, node *yaml.Node) (string, ComponentSpec, bool) {
+	var keys []string
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == "type" {
+			name := node.Content[i+1].Value
+			spec, exists := GetDocs(ctx.DocsProvider, name, cType)
+			return name, spec, exists
+		}
+		keys = append(keys, node.Content[i].Value)
+	}
+	name, spec, err := getInferenceCandidateFromList(ctx.DocsProvider, cType, "", keys)
+	if err != nil {
+		return "", ComponentSpec{}, false
+	}
+	return name, spec, true
+}
+
+// FixYAML takes a yaml.Node of a component config and modifies it in place
+// in order to resolve linting errors where possible. Deprecated components and
+// fields are migrated to their modern equivalents, fields that should be
+// omitted are removed, and the structure of components is normalised such that
+// the type is expressed only by the key of its config. Returns true if the node
+// was modified, and lints describing deprecated configs that could not be fixed
+// automatically.
+func FixYAML(ctx LintContext, cType Type, node *yaml.Node) (changed bool, lints []Lint) {
+	if cType == "condition" {
+		return
+	}
+
+	node = unwrapDocumentNode(node)
+	if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+		return
+	}
+
+	name, cSpec, exists := fixInferComponent(ctx, cType, node)
+	if !exists {
+		return
+	}
+
+	for cSpec.Fixer != nil {
+		// Fixers are applied to a copy so that a failed migration doesn't
+		// leave the config partially modified.
+		fixedNode := copyYAMLNode(node)
+		fixed, err := cSpec.Fixer(fixedNode)
+		if err != nil {
+			lints = append(lints, NewLintWarning(node.Line, err.Error()))
+			break
+		}
+		if !fixed {
+			break
+		}
+		*node = *fixedNode
+		changed = true
+
+		// The fixer might have converted the component into a different
+		// type, which might also need fixing.
+		prevName := name
+		if name, cSpec, exists = fixInferComponent(ctx, cType, node); !exists {
+			return
+		}
+		if name == prevName {
+			break
+		}
+	}
+
+	reservedFields := reservedFieldsByType(cType)
+
+	// Remove config blocks of other components, which were commonly generated
+	// by older versions of Benthos.
+	if removeYAMLKeys(node, func(i int) bool {
+		key := node.Content[i].Value
+		if _, isReserved := reservedFields[key]; isReserved || key == name {
+			return false
+		}
+		_, isComponent := GetDocs(ctx.DocsProvider, key, cType)
+		return isComponent
+	}) {
+		changed = true
+	}
+
+	nameIndex, pluginIndex := -1, -1
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		switch node.Content[i].Value {
+		case name:
+			nameIndex = i
+		case "plugin":
+			pluginIndex = i
+		}
+	}
+
+	// Old style plugin configs are moved into a field of the plugin name.
+	if pluginIndex >= 0 {
+		if nameIndex == -1 && cSpec.Plugin {
+			node.Content[pluginIndex].Value = name
+			nameIndex = pluginIndex
+		} else {
+			removeYAMLKeys(node, func(i int) bool {
+				return i == pluginIndex
+			})
+		}
+		changed = true
+	}
+
+	// A type field is redundant when the config of the component is present,
+	// and an object config can be added in its place otherwise.
+	if nameIndex >= 0 {
+		if removeYAMLKeys(node, func(i int) bool {
+			return node.Content[i].Value == "type"
+		}) {
+			changed = true
+		}
+	} else if len(cSpec.Config.Children) > 0 && cSpec.Config.Kind != KindArray {
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			if node.Content[i].Value == "type" {
+				node.Content[i].Value = name
+				node.Content[i+1] = &yaml.Node{
+					Kind:  yaml.MappingNode,
+					Tag:   "!!map",
+					Style: yaml.FlowStyle,
+				}
+				changed = true
+				break
+			}
+		}
+	}
+
+	omit := map[int]struct{}{}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if spec, exists := reservedFields[node.Content[i].Value]; exists {
+			if _, shouldOmit := spec.shouldOmitYAML(cSpec.Config.Children, node.Content[i+1], node); shouldOmit {
+				omit[i] = struct{}{}
+			}
+		}
+	}
+	if removeYAMLKeys(node, func(i int) bool {
+		_, exists := omit[i]
+		return exists
+	}) {
+		changed = true
+	}
+
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		var fieldChanged bool
+		var fieldLints []Lint
+		if node.Content[i].Value == name {
+			fieldChanged, fieldLints = cSpec.Config.FixYAML(ctx, node.Content[i+1])
+		} else if spec, exists := reservedFields[node.Content[i].Value]; exists {
+			fieldChanged, fieldLints = spec.FixYAML(ctx, node.Content[i+1])
+		}
+		changed = changed || fieldChanged
+		lints = append(lints, fieldLints...)
+	}
+	return
+}
+
+// FixYAML modifies a yaml node of a field in place in order to resolve linting
+// errors where possible. Returns true if the node was modified, and lints
+// describing deprecated configs that could not be fixed automatically.
+func (f FieldSpec) FixYAML(ctx LintContext, node *yaml.Node) (changed bool, lints []Lint) {
+	if f.skipLint {
+		return
+	}
+
+	node = unwrapDocumentNode(node)
+
+	collect := func(c bool, l []Lint) {
+		changed = changed || c
+		lints = append(lints, l...)
+	}
+
+	switch f.Kind {
+	case Kind2DArray:
+		if node.Kind == yaml.SequenceNode {
+			for i := 0; i < len(node.Content); i++ {
+				collect(f.Array().FixYAML(ctx, node.Content[i]))
+			}
+		}
+		return
+	case KindArray:
+		if node.Kind == yaml.SequenceNode {
+			for i := 0; i < len(node.Content); i++ {
+				collect(f.Scalar().FixYAML(ctx, node.Content[i]))
+			}
+		}
+		return
+	case KindMap:
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i < len(node.Content)-1; i += 2 {
+				collect(f.Scalar().FixYAML(ctx, node.Content[i+1]))
+			}
+		}
+		return
+	}
+
+	if coreType, isCore := f.Type.IsCoreComponent(); isCore {
+		collect(FixYAML(ctx, coreType, node))
+		return
+	}
+
+	if f.Bloblang || f.Interpolated {
+		changed = fixDeprecatedBloblang(ctx, f.Bloblang, node)
+		return
+	}
+
+	if len(f.Children) > 0 {
+		collect(f.Children.FixYAML(ctx, node))
+	}
+	return
+}
+
+// FixYAML modifies a yaml node of an object in place in order to resolve
+// linting errors where possible, which includes removing fields that ought to
+// be omitted. Returns true if the node was modified, and lints describing
+// deprecated configs that could not be fixed automatically.
+func (f FieldSpecs) FixYAML(ctx LintContext, node *yaml.Node) (changed bool, lints []Lint) {
+	node = unwrapDocumentNode(node)
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	specNames := map[string]FieldSpec{}
+	for _, field := range f {
+		specNames[field.Name] = field
+	}
+
+	omit := map[int]struct{}{}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if spec, exists := specNames[node.Content[i].Value]; exists {
+			if _, shouldOmit := spec.shouldOmitYAML(f, node.Content[i+1], node); shouldOmit {
+				omit[i] = struct{}{}
+			}
+		}
+	}
+	changed = removeYAMLKeys(node, func(i int) bool {
+		_, exists := omit[i]
+		return exists
+	})
+
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		spec, exists := specNames[node.Content[i].Value]
+		if !exists {
+			continue
+		}
+		fieldChanged, fieldLints := spec.FixYAML(ctx, node.Content[i+1])
+		changed = changed || fieldChanged
+		lints = append(lints, fieldLints...)
+	}
+	return
+}
@@ -0,0 +1,237 @@
+package docs_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestYAMLFix(t *testing.T) {
+	docs.RegisterDocs(docs.ComponentSpec{
+		Name: "testfixfoo",
+		Type: docs.TypeProcessor,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("a", "").HasDefault(""),
+			docs.FieldString("b", "").OmitWhen(func(field, parent interface{}) (string, bool) {
+				return "field b is redundant", field == "drop me"
+			}).HasDefault(""),
+			docs.FieldCommon("c", "").Array().HasType(docs.FieldTypeProcessor).HasDefault([]interface{}{}),
+		),
+	})
+	docs.RegisterDocs(docs.ComponentSpec{
+		Name:   "testfixbar",
+		Type:   docs.TypeProcessor,
+		Config: docs.FieldString("", ""),
+	})
+	docs.RegisterDocs(docs.ComponentSpec{
+		Name:   "testfixplugin",
+		Type:   docs.TypeProcessor,
+		Plugin: true,
+		Config: docs.FieldComponent().Unlinted(),
+	})
+	docs.RegisterDocs(docs.ComponentSpec{
+		Name:   "testfixold",
+		Type:   docs.TypeProcessor,
+		Config: docs.FieldString("", ""),
+		Fixer: func(node *yaml.Node) (bool, error) {
+			for i := 0; i < len(node.Content)-1; i += 2 {
+				if node.Content[i].Value == "testfixold" {
+					if node.Content[i+1].Value == "nope" {
+						return false, errors.New("cannot migrate nope")
+					}
+					node.Content[i].Value = "testfixbar"
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+	})
+
+	tests := []struct {
+		name    string
+		input   string
+		output  string
+		changed bool
+		lints   []docs.Lint
+	}{
+		{
+			name: "nothing to fix",
+			input: `
+# Foo
+testfixfoo:
+  a: hello # world
+`,
+			output: `# Foo
+testfixfoo:
+  a: hello # world
+`,
+		},
+		{
+			name: "omitted fields",
+			input: `
+label: foo
+testfixfoo:
+  # Comment for b
+  b: drop me
+  # Comment for a
+  a: hello
+  c:
+    - type: testfixbar
+      testfixbar: baz
+      testfixfoo:
+        a: not used
+`,
+			output: `label: foo
+testfixfoo:
+  # Comment for b
+  # Comment for a
+  a: hello
+  c:
+    - testfixbar: baz
+`,
+			changed: true,
+		},
+		{
+			name: "type without config",
+			input: `
+type: testfixfoo
+`,
+			output: `testfixfoo: {}
+`,
+			changed: true,
+		},
+		{
+			name: "old style plugin",
+			input: `
+type: testfixplugin
+plugin:
+  foo: bar
+`,
+			output: `testfixplugin:
+  foo: bar
+`,
+			changed: true,
+		},
+		{
+			name: "migrated component",
+			input: `
+label: foo
+testfixold: bar # keep me
+`,
+			output: `label: foo
+testfixbar: bar # keep me
+`,
+			changed: true,
+		},
+		{
+			name: "migration error",
+			input: `
+testfixfoo:
+  c:
+    - testfixold: nope
+`,
+			output: `testfixfoo:
+  c:
+    - testfixold: nope
+`,
+			lints: []docs.Lint{
+				docs.NewLintWarning(4, "cannot migrate nope"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.input), &node))
+
+			changed, lints := docs.FixYAML(docs.NewLintContext(), docs.TypeProcessor, &node)
+			assert.Equal(t, test.changed, changed)
+			assert.Equal(t, test.lints, lints)
+
+			var buf bytes.Buffer
+			enc := yaml.NewEncoder(&buf)
+			enc.SetIndent(2)
+			require.NoError(t, enc.Encode(&node))
+			assert.Equal(t, test.output, buf.String())
+		})
+	}
+}
+
+func TestReplaceInterpolatedArgsYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		output  string
+		changed bool
+		err     string
+	}{
+		{
+			name: "no args",
+			input: `
+query: INSERT INTO foo VALUES (?)
+args_mapping: root = [ this.id ]
+`,
+			output: `query: INSERT INTO foo VALUES (?)
+args_mapping: root = [ this.id ]
+`,
+		},
+		{
+			name: "interpolated args",
+			input: `
+query: INSERT INTO foo VALUES (?, ?)
+args:
+  - ${! this.id }
+  - topic-${! meta("topic") }
+args_mapping: ""
+`,
+			output: `query: INSERT INTO foo VALUES (?, ?)
+args_mapping: root = [ (this.id).string(), "topic-" + (meta("topic")).string() ]
+`,
+			changed: true,
+		},
+		{
+			name: "both args and mapping",
+			input: `
+args: [ foo ]
+args_mapping: root = [ "bar" ]
+`,
+			err: "cannot migrate field args as field args_mapping is already set",
+		},
+		{
+			name: "bad interpolation",
+			input: `
+args: [ "${! not a query }" ]
+`,
+			err: `failed to migrate field args: arg 0: failed to convert interpolation "${! not a query }": line 1 char 4: expected end of expression`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.input), &node))
+
+			changed, err := docs.ReplaceInterpolatedArgsYAML(node.Content[0], "args", "args_mapping")
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.changed, changed)
+
+			var buf bytes.Buffer
+			enc := yaml.NewEncoder(&buf)
+			enc.SetIndent(2)
+			require.NoError(t, enc.Encode(&node))
+			assert.Equal(t, test.output, buf.String())
+		})
+	}
+}
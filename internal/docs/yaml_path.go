@@ -283,3 +283,85 @@ func (f FieldSpec) YAMLLabelsToPaths(docsProvider Provider, node *yaml.Node, lab
 		}
 	}
 }
+
+// WalkYAML walks a YAML tree using a field spec as a reference point, calling
+// a closure for each node of the tree that has a matching field spec along with
+// the path of the node. Elements of array and map fields are visited with the
+// scalar form of the field spec. If the closure returns an error the walk is
+// abandoned and the error is returned.
+func (f FieldSpecs) WalkYAML(docsProvider Provider, node *yaml.Node, path []string, fn func(path []string, spec FieldSpec, node *yaml.Node) error) error {
+	node = unwrapDocumentNode(node)
+
+	fieldMap := map[string]FieldSpec{}
+	for _, spec := range f {
+		fieldMap[spec.Name] = spec
+	}
+
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		key := node.Content[i].Value
+		if spec, exists := fieldMap[key]; exists {
+			if err := spec.WalkYAML(docsProvider, node.Content[i+1], append(path, key), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WalkYAML walks a YAML tree using a field spec as a reference point, calling
+// a closure for each node of the tree that has a matching field spec along with
+// the path of the node. Elements of array and map fields are visited with the
+// scalar form of the field spec. If the closure returns an error the walk is
+// abandoned and the error is returned.
+func (f FieldSpec) WalkYAML(docsProvider Provider, node *yaml.Node, path []string, fn func(path []string, spec FieldSpec, node *yaml.Node) error) error {
+	node = unwrapDocumentNode(node)
+
+	if err := fn(path, f, node); err != nil {
+		return err
+	}
+
+	switch f.Kind {
+	case Kind2DArray:
+		nextSpec := f.Array()
+		for i, child := range node.Content {
+			if err := nextSpec.WalkYAML(docsProvider, child, append(path, strconv.Itoa(i)), fn); err != nil {
+				return err
+			}
+		}
+	case KindArray:
+		nextSpec := f.Scalar()
+		for i, child := range node.Content {
+			if err := nextSpec.WalkYAML(docsProvider, child, append(path, strconv.Itoa(i)), fn); err != nil {
+				return err
+			}
+		}
+	case KindMap:
+		nextSpec := f.Scalar()
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			key := node.Content[i].Value
+			if err := nextSpec.WalkYAML(docsProvider, node.Content[i+1], append(path, key), fn); err != nil {
+				return err
+			}
+		}
+	default:
+		if coreType, isCore := f.Type.IsCoreComponent(); isCore {
+			if docsProvider == nil {
+				docsProvider = globalProvider
+			}
+			coreFields := FieldSpecs{}
+			for _, f := range reservedFieldsByType(coreType) {
+				coreFields = append(coreFields, f)
+			}
+			if inferred, cSpec, err := GetInferenceCandidateFromYAML(docsProvider, coreType, "", node); err == nil {
+				conf := cSpec.Config
+				conf.Name = inferred
+				coreFields = append(coreFields, conf)
+			}
+			return coreFields.WalkYAML(docsProvider, node, path, fn)
+		}
+		if len(f.Children) > 0 {
+			return f.Children.WalkYAML(docsProvider, node, path, fn)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package docs_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
@@ -585,3 +587,68 @@ pipeline:
 		})
 	}
 }
+
+func TestWalkYAML(t *testing.T) {
+	mockProv := docs.NewMappedDocsProvider()
+	mockProv.RegisterDocs(docs.ComponentSpec{
+		Name: "nats",
+		Type: docs.TypeOutput,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("urls", "").Array(),
+			docs.FieldString("subject", ""),
+		),
+	})
+	mockProv.RegisterDocs(docs.ComponentSpec{
+		Name: "workflow",
+		Type: docs.TypeProcessor,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldCommon("things", "").HasType(docs.FieldTypeProcessor).Map(),
+		),
+	})
+	mockProv.RegisterDocs(docs.ComponentSpec{
+		Name: "http",
+		Type: docs.TypeProcessor,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("url", ""),
+		),
+	})
+
+	input := `
+pipeline:
+  processors:
+    - workflow:
+        things:
+          foo:
+            http:
+              url: http://foo
+output:
+  nats:
+    urls: [ nats://a, nats://b ]
+    subject: benthos_messages
+`
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(input), &node))
+
+	visited := map[string]string{}
+	require.NoError(t, config.Spec().WalkYAML(mockProv, &node, nil, func(path []string, spec docs.FieldSpec, node *yaml.Node) error {
+		if spec.Kind == docs.KindScalar && node.Kind == yaml.ScalarNode && (spec.Name == "url" || spec.Name == "urls") {
+			visited[strings.Join(path, ".")] = node.Value
+		}
+		return nil
+	}))
+	assert.Equal(t, map[string]string{
+		"pipeline.processors.0.workflow.things.foo.http.url": "http://foo",
+		"output.nats.urls.0": "nats://a",
+		"output.nats.urls.1": "nats://b",
+	}, visited)
+
+	errWalk := errors.New("stop")
+	err := config.Spec().WalkYAML(mockProv, &node, nil, func(path []string, spec docs.FieldSpec, node *yaml.Node) error {
+		if spec.Name == "subject" {
+			return errWalk
+		}
+		return nil
+	})
+	assert.Equal(t, errWalk, err)
+}
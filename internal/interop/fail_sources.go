@@ -0,0 +1,16 @@
+package interop
+
+import (
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// WithFailSources returns a manager where processors record the source of the
+// failures that they flag, if the provided manager supports it.
+func WithFailSources(mgr types.Manager) types.Manager {
+	if m, ok := mgr.(interface {
+		WithFailSources() types.Manager
+	}); ok {
+		return m.WithFailSources()
+	}
+	return mgr
+}
@@ -0,0 +1,140 @@
+// Package msgpack converts between MessagePack documents and the generic
+// structures used for JSON documents within Benthos.
+package msgpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Decoder reads a stream of concatenated MessagePack documents.
+type Decoder struct {
+	dec *msgpack.Decoder
+}
+
+// NewDecoder returns a decoder that reads documents from r.
+func NewDecoder(r io.Reader) *Decoder {
+	dec := msgpack.NewDecoder(r)
+	dec.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+		return d.DecodeUntypedMap()
+	})
+	return &Decoder{dec: dec}
+}
+
+// Decode reads the next document from the stream and returns a generic
+// structure that can be serialized to JSON. Returns io.EOF once the stream is
+// exhausted.
+func (d *Decoder) Decode() (interface{}, error) {
+	v, err := d.dec.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return sanitise(v), nil
+}
+
+// Unmarshal parses a byte slice as a single MessagePack document and returns
+// a generic structure that can be serialized to JSON.
+func Unmarshal(b []byte) (interface{}, error) {
+	r := bytes.NewReader(b)
+	v, err := NewDecoder(r).Decode()
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("unexpected %v bytes following document", r.Len())
+	}
+	return v, nil
+}
+
+// Marshal serializes a generic structure as a MessagePack document.
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.UseCompactInts(true)
+	enc.UseCompactFloats(true)
+	if err := enc.Encode(prepare(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func sanitise(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[keyString(k)] = sanitise(v)
+		}
+		return m
+	case map[string]interface{}:
+		for k, v := range t {
+			t[k] = sanitise(v)
+		}
+		return t
+	case []interface{}:
+		for i, v := range t {
+			t[i] = sanitise(v)
+		}
+		return t
+	case int8:
+		return int64(t)
+	case int16:
+		return int64(t)
+	case int32:
+		return int64(t)
+	case uint8:
+		return uint64(t)
+	case uint16:
+		return uint64(t)
+	case uint32:
+		return uint64(t)
+	case float32:
+		return float64(t)
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+func keyString(k interface{}) string {
+	switch t := k.(type) {
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	}
+	return fmt.Sprintf("%v", k)
+}
+
+// prepare converts values that MessagePack cannot represent natively, such as
+// json.Number, into their closest equivalent.
+func prepare(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+		return t.String()
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = prepare(v)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, v := range t {
+			a[i] = prepare(v)
+		}
+		return a
+	}
+	return v
+}
@@ -0,0 +1,56 @@
+package msgpack
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	input := map[string]interface{}{
+		"str":    "foo",
+		"int":    json.Number("-10"),
+		"float":  json.Number("1.5"),
+		"bool":   true,
+		"null":   nil,
+		"array":  []interface{}{"a", json.Number("2")},
+		"nested": map[string]interface{}{"bytes": []byte("bar")},
+	}
+
+	b, err := Marshal(input)
+	require.NoError(t, err)
+
+	v, err := Unmarshal(b)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"str":    "foo",
+		"int":    int64(-10),
+		"float":  1.5,
+		"bool":   true,
+		"null":   nil,
+		"array":  []interface{}{"a", int64(2)},
+		"nested": map[string]interface{}{"bytes": []byte("bar")},
+	}, v)
+}
+
+func TestUnmarshalNonStringKeys(t *testing.T) {
+	// {1: "foo", "bar": {2: true}}
+	b, err := hex.DecodeString("8201a3666f6fa36261728102c3")
+	require.NoError(t, err)
+
+	v, err := Unmarshal(b)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"1":   "foo",
+		"bar": map[string]interface{}{"2": true},
+	}, v)
+}
+
+func TestUnmarshalTrailingData(t *testing.T) {
+	_, err := Unmarshal([]byte{0x01, 0x02})
+	assert.EqualError(t, err, "unexpected 1 bytes following document")
+}
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/parser"
-	"github.com/Jeffail/benthos/v3/internal/component/metrics"
+	imetrics "github.com/Jeffail/benthos/v3/internal/component/metrics"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/service/test"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/fatih/color"
 	"github.com/nsf/jsondiff"
@@ -32,6 +34,26 @@ type TestConfig struct {
 	Name     string    `yaml:"name"`
 	Config   yaml.Node `yaml:"config"`
 	Expected yaml.Node `yaml:"expected,omitempty"`
+
+	MockCaches    map[string]map[string]string      `yaml:"mock_caches,omitempty"`
+	MockHTTP      []test.HTTPResponder              `yaml:"mock_http,omitempty"`
+	InputBatch    []test.InputPart                  `yaml:"input_batch,omitempty"`
+	OutputBatches [][]test.ConditionsMap            `yaml:"output_batches,omitempty"`
+	Outputs       map[string][][]test.ConditionsMap `yaml:"outputs,omitempty"`
+
+	line int
+}
+
+// UnmarshalYAML extracts a TestConfig from a YAML node.
+func (t *TestConfig) UnmarshalYAML(value *yaml.Node) error {
+	type testAlias TestConfig
+	var aliased testAlias
+	if err := value.Decode(&aliased); err != nil {
+		return err
+	}
+	*t = TestConfig(aliased)
+	t.line = value.Line
+	return nil
 }
 
 // Config describes a Benthos component template.
@@ -46,6 +68,10 @@ type Config struct {
 	Mapping        string        `yaml:"mapping"`
 	MetricsMapping string        `yaml:"metrics_mapping"`
 	Tests          []TestConfig  `yaml:"tests"`
+
+	// path is the path of the template file, files referenced by tests are
+	// read relative to its directory.
+	path string
 }
 
 // FieldSpec creates a documentation field spec from a template field config.
@@ -114,9 +140,9 @@ func (c Config) compile() (*compiled, error) {
 		}
 		return nil, fmt.Errorf("parse mapping: %w", err)
 	}
-	var metricsMapping *metrics.Mapping
+	var metricsMapping *imetrics.Mapping
 	if c.MetricsMapping != "" {
-		if metricsMapping, err = metrics.NewMapping(types.NoopMgr(), c.MetricsMapping, log.Noop()); err != nil {
+		if metricsMapping, err = imetrics.NewMapping(types.NoopMgr(), c.MetricsMapping, log.Noop()); err != nil {
 			return nil, fmt.Errorf("parse metrics mapping: %w", err)
 		}
 	}
@@ -152,32 +178,123 @@ func diffYAMLNodesAsJSON(expNode, actNode *yaml.Node) (string, error) {
 // Test ensures that the template compiles, and executes any unit test
 // definitions within the config.
 func (c Config) Test() ([]string, error) {
-	compiled, err := c.compile()
+	_, caseFailures, err := c.runTests()
 	if err != nil {
 		return nil, err
 	}
-
 	var failures []string
-	for _, test := range c.Tests {
-		outConf, err := compiled.ExpandToNode(&test.Config)
+	for _, f := range caseFailures {
+		failures = append(failures, fmt.Sprintf("test '%v': %v", f.Name, f.Reason))
+	}
+	return failures, nil
+}
+
+// runTests ensures that the template compiles, and executes any unit test
+// definitions within the config. Returns the tests as a definition of test
+// cases along with any failures.
+func (c Config) runTests() (test.Definition, []test.CaseFailure, error) {
+	var def test.Definition
+
+	compiled, err := c.compile()
+	if err != nil {
+		return def, nil, err
+	}
+
+	var failures []test.CaseFailure
+	for _, tConf := range c.Tests {
+		tCase := test.NewCase()
+		tCase.Name = tConf.Name
+		def.Cases = append(def.Cases, tCase.AtLine(tConf.line))
+
+		outConf, err := compiled.ExpandToNode(&tConf.Config)
 		if err != nil {
-			return nil, fmt.Errorf("test '%v': %w", test.Name, err)
+			return def, nil, fmt.Errorf("test '%v': %w", tConf.Name, err)
 		}
 		for _, lint := range docs.LintYAML(docs.NewLintContext(), docs.Type(c.Type), outConf) {
-			failures = append(failures, fmt.Sprintf("test '%v': lint error in resulting config: line %v: %v", test.Name, lint.Line, lint.What))
+			failures = append(failures, test.CaseFailure{
+				Name:     tConf.Name,
+				TestLine: tConf.line,
+				Reason:   fmt.Sprintf("lint error in resulting config: line %v: %v", lint.Line, lint.What),
+			})
 		}
-		if len(test.Expected.Content) > 0 {
-			diff, err := diffYAMLNodesAsJSON(&test.Expected, outConf)
+		if len(tConf.Expected.Content) > 0 {
+			diff, err := diffYAMLNodesAsJSON(&tConf.Expected, outConf)
 			if err != nil {
-				return nil, fmt.Errorf("test '%v': %w", test.Name, err)
+				return def, nil, fmt.Errorf("test '%v': %w", tConf.Name, err)
 			}
 			if diff != "" {
 				diff = color.New(color.Reset).SprintFunc()(diff)
-				return nil, fmt.Errorf("test '%v': mismatch between expected and actual resulting config: %v", test.Name, diff)
+				return def, nil, fmt.Errorf("test '%v': mismatch between expected and actual resulting config: %v", tConf.Name, diff)
+			}
+		}
+		if len(tConf.InputBatch) > 0 || len(tConf.OutputBatches) > 0 || len(tConf.Outputs) > 0 {
+			caseFailures, err := c.testBehaviour(tConf, outConf)
+			if err != nil {
+				return def, nil, fmt.Errorf("test '%v': %w", tConf.Name, err)
 			}
+			failures = append(failures, caseFailures...)
 		}
 	}
-	return failures, nil
+	return def, failures, nil
+}
+
+// testBehaviour runs a stream built around the component resulting from the
+// template and checks the batches it produces. Processor templates are placed
+// within the pipeline and output templates are the output, where the input
+// batch is sent through the stream. Input templates are the input of the
+// stream, and the output batches are checked against the first batches that
+// it produces.
+func (c Config) testBehaviour(tConf TestConfig, outConf *yaml.Node) ([]test.CaseFailure, error) {
+	streamConf := map[string]interface{}{
+		"output": map[string]interface{}{
+			"drop": map[string]interface{}{},
+		},
+	}
+	switch docs.Type(c.Type) {
+	case docs.TypeProcessor:
+		streamConf["pipeline"] = map[string]interface{}{
+			"processors": []*yaml.Node{outConf},
+		}
+	case docs.TypeInput:
+		if len(tConf.InputBatch) > 0 {
+			return nil, errors.New("input batches are not supported for templates of type input, output batches are checked against the messages produced by the input")
+		}
+		streamConf["input"] = outConf
+	case docs.TypeOutput:
+		if len(tConf.OutputBatches) > 0 {
+			return nil, errors.New("output batches are not supported for templates of type output, use outputs in order to capture outputs within the resulting config")
+		}
+		streamConf["output"] = outConf
+	default:
+		return nil, fmt.Errorf("input batches are not supported for templates of type %v", c.Type)
+	}
+	if docs.Type(c.Type) != docs.TypeInput && len(tConf.InputBatch) == 0 {
+		return nil, fmt.Errorf("an input batch is required in order to test templates of type %v", c.Type)
+	}
+
+	confBytes, err := yaml.Marshal(streamConf)
+	if err != nil {
+		return nil, err
+	}
+
+	tCase := test.NewCase()
+	tCase.Name = tConf.Name
+	tCase.MockCaches = tConf.MockCaches
+	tCase.MockHTTP = tConf.MockHTTP
+	tCase.Outputs = map[string][][]test.ConditionsMap{}
+	for k, v := range tConf.Outputs {
+		tCase.Outputs[k] = v
+	}
+	if len(tConf.InputBatch) > 0 {
+		tCase.InputBatches = [][]test.InputPart{tConf.InputBatch}
+	}
+	if len(tConf.OutputBatches) > 0 {
+		tCase.Outputs["/output"] = tConf.OutputBatches
+	}
+	tCase = tCase.AtLine(tConf.line)
+
+	provider := test.NewProcessorsProvider(c.path, test.OptProcessorsProviderSetConfig(confBytes))
+	return tCase.ExecuteFrom(filepath.Dir(c.path), provider)
 }
 
 // ReadConfig attempts to read a template configuration file.
@@ -190,6 +307,7 @@ func ReadConfig(path string) (conf Config, lints []string, err error) {
 	if err = yaml.Unmarshal(templateBytes, &conf); err != nil {
 		return
 	}
+	conf.path = path
 
 	var node yaml.Node
 	if err = yaml.Unmarshal(templateBytes, &node); err != nil {
@@ -201,6 +319,9 @@ func ReadConfig(path string) (conf Config, lints []string, err error) {
 			lints = append(lints, fmt.Sprintf("line %v: %v", l.Line, l.What))
 		}
 	}
+	if conf.MetricsMapping != "" && docs.Type(conf.Type) == docs.TypeTracer {
+		lints = append(lints, "field metrics_mapping is ineffective for templates of type tracer")
+	}
 	return
 }
 
@@ -229,7 +350,7 @@ func ConfigSpec() docs.FieldSpecs {
 		docs.FieldString(
 			"type", "The type of the component this template will create.",
 		).HasOptions(
-			"cache", "input", "output", "processor", "rate_limit",
+			"buffer", "cache", "input", "metrics", "output", "processor", "rate_limit", "tracer",
 		).LintOptions(),
 		docs.FieldString(
 			"status", "The stability of the template describing the likelihood that the configuration spec of the template, or it's behaviour, will change.",
@@ -247,13 +368,18 @@ func ConfigSpec() docs.FieldSpecs {
 		docs.FieldBloblang(
 			"mapping", "A [Bloblang](/docs/guides/bloblang/about) mapping that translates the fields of the template into a valid Benthos configuration for the target component type.",
 		),
-		metrics.MappingFieldSpec(),
+		imetrics.MappingFieldSpec(),
 		docs.FieldCommon(
-			"tests", "Optional unit test definitions for the template that verify certain configurations produce valid configs. These tests are executed with the command `benthos template lint`.",
+			"tests", "Optional unit test definitions for the template that verify certain configurations produce valid configs, and optionally that messages are processed as expected by the resulting component. These tests are executed with the command `benthos template lint`, and by `benthos test` for templates imported with the `--templates` flag.",
 		).Array().WithChildren(
 			docs.FieldString("name", "A name to identify the test."),
 			docs.FieldCommon("config", "A configuration to run this test with, the config resulting from applying the template with this config will be linted.").HasType(docs.FieldTypeObject),
 			docs.FieldCommon("expected", "An optional configuration describing the expected result of applying the template, when specified the result will be diffed and any mismatching fields will be reported as a test error.").HasType(docs.FieldTypeObject).Optional(),
+			docs.FieldCommon("mock_caches", "An optional map of cache labels to key/value pairs, where each is added as an in-memory cache resource available to the components of the test.").HasType(docs.FieldTypeObject).Optional(),
+			docs.FieldCommon("mock_http", "An optional list of responders to HTTP requests, where the URLs of HTTP components within the resulting config are redirected to a server that responds with them, each defined with the same fields as the [`mock_http` responders of config unit tests](/docs/configuration/unit_testing#mocking-resources).").Array().HasType(docs.FieldTypeObject).Optional(),
+			docs.FieldCommon("input_batch", "An optional batch of messages to send through a stream built around the resulting component, each defined with the same fields as the [input definitions of config unit tests](/docs/configuration/unit_testing#input-definitions). Processor templates are placed within the pipeline of the stream and output templates are the output of the stream. Input templates are the input of the stream and therefore do not support an input batch, the messages produced by the input are checked instead.").Array().HasType(docs.FieldTypeObject).Optional(),
+			docs.FieldCommon("output_batches", "The batches expected to be produced by the stream, where each message is defined as a map of [output conditions of config unit tests](/docs/configuration/unit_testing#output-conditions). Output templates do not support output batches, instead the batch must be delivered by the output, and outputs within it can be checked with `outputs`.").ArrayOfArrays().HasType(docs.FieldTypeObject).Optional(),
+			docs.FieldCommon("outputs", "An optional map of outputs within the resulting config, targeted either by label or JSON Pointer, to the batches they are expected to receive, where the outputs are replaced with captures as described in [config unit tests](/docs/configuration/unit_testing#testing-streams).").HasType(docs.FieldTypeObject).Optional(),
 		).HasDefault([]interface{}{}),
 	}
 }
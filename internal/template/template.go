@@ -3,19 +3,24 @@ package template
 import (
 	"fmt"
 	"io/fs"
+	"net/http"
 	"sync"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
 	"github.com/Jeffail/benthos/v3/internal/bundle"
-	"github.com/Jeffail/benthos/v3/internal/component/metrics"
+	imetrics "github.com/Jeffail/benthos/v3/internal/component/metrics"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/buffer"
 	"github.com/Jeffail/benthos/v3/lib/cache"
 	"github.com/Jeffail/benthos/v3/lib/input"
 	"github.com/Jeffail/benthos/v3/lib/manager"
 	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output"
 	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/ratelimit"
+	"github.com/Jeffail/benthos/v3/lib/service/test"
+	"github.com/Jeffail/benthos/v3/lib/tracer"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/benthos/v3/template"
 	"gopkg.in/yaml.v3"
@@ -79,17 +84,41 @@ func InitTemplates(templatesPaths ...string) ([]string, error) {
 		if err := registerTemplate(tmpl); err != nil {
 			return nil, fmt.Errorf("template %v: %w", tPath, err)
 		}
+
+		if len(tmplConf.Tests) > 0 {
+			testedTemplatesMut.Lock()
+			testedTemplates[tPath] = tmplConf
+			testedTemplatesMut.Unlock()
+		}
 	}
 	return lints, nil
 }
 
+var (
+	testedTemplates    = map[string]Config{}
+	testedTemplatesMut sync.Mutex
+)
+
+// TestTargets returns functions that execute the unit tests of templates
+// registered with InitTemplates, keyed by the path of each template file.
+func TestTargets() map[string]test.TargetFunc {
+	testedTemplatesMut.Lock()
+	defer testedTemplatesMut.Unlock()
+
+	targets := make(map[string]test.TargetFunc, len(testedTemplates))
+	for k, v := range testedTemplates {
+		targets[k] = v.runTests
+	}
+	return targets
+}
+
 //------------------------------------------------------------------------------
 
 // Compiled is a template that has been compiled from a config.
 type compiled struct {
 	spec           docs.ComponentSpec
 	mapping        *mapping.Executor
-	metricsMapping *metrics.Mapping
+	metricsMapping *imetrics.Mapping
 }
 
 // ExpandToNode attempts to apply the template to a provided YAML node and
@@ -131,29 +160,54 @@ func (c *compiled) ExpandToNode(node *yaml.Node) (*yaml.Node, error) {
 // component types.
 func registerTemplate(tmpl *compiled) error {
 	switch tmpl.spec.Type {
+	case docs.TypeBuffer:
+		return registerBufferTemplate(tmpl, bundle.AllBuffers)
 	case docs.TypeCache:
 		return registerCacheTemplate(tmpl, bundle.AllCaches)
 	case docs.TypeInput:
 		return registerInputTemplate(tmpl, bundle.AllInputs)
+	case docs.TypeMetrics:
+		return registerMetricsTemplate(tmpl, bundle.AllMetrics)
 	case docs.TypeOutput:
 		return registerOutputTemplate(tmpl, bundle.AllOutputs)
 	case docs.TypeProcessor:
 		return registerProcessorTemplate(tmpl, bundle.AllProcessors)
 	case docs.TypeRateLimit:
 		return registerRateLimitTemplate(tmpl, bundle.AllRateLimits)
+	case docs.TypeTracer:
+		return registerTracerTemplate(tmpl, bundle.AllTracers)
 	}
 	return fmt.Errorf("unable to register template for component type %v", tmpl.spec.Type)
 }
 
 // WithMetricsMapping attempts to wrap the metrics of a manager with a metrics
 // mapping.
-func WithMetricsMapping(nm bundle.NewManagement, m *metrics.Mapping) bundle.NewManagement {
+func WithMetricsMapping(nm bundle.NewManagement, m *imetrics.Mapping) bundle.NewManagement {
 	if t, ok := nm.(*manager.Type); ok {
 		return t.WithMetricsMapping(m)
 	}
 	return nm
 }
 
+func registerBufferTemplate(tmpl *compiled, set *bundle.BufferSet) error {
+	return set.Add(func(c buffer.Config, nm bundle.NewManagement) (buffer.Type, error) {
+		newNode, err := tmpl.ExpandToNode(c.Plugin.(*yaml.Node))
+		if err != nil {
+			return nil, err
+		}
+
+		conf := buffer.NewConfig()
+		if err := newNode.Decode(&conf); err != nil {
+			return nil, err
+		}
+
+		if tmpl.metricsMapping != nil {
+			nm = WithMetricsMapping(nm, tmpl.metricsMapping)
+		}
+		return nm.NewBuffer(conf)
+	}, tmpl.spec)
+}
+
 func registerCacheTemplate(tmpl *compiled, set *bundle.CacheSet) error {
 	return set.Add(func(c cache.Config, nm bundle.NewManagement) (types.Cache, error) {
 		newNode, err := tmpl.ExpandToNode(c.Plugin.(*yaml.Node))
@@ -195,6 +249,47 @@ func registerInputTemplate(tmpl *compiled, set *bundle.InputSet) error {
 	}, tmpl.spec)
 }
 
+func registerMetricsTemplate(tmpl *compiled, set *bundle.MetricsSet) error {
+	return set.Add(func(c metrics.Config, opts ...func(metrics.Type)) (metrics.Type, error) {
+		newNode, err := tmpl.ExpandToNode(c.Plugin.(*yaml.Node))
+		if err != nil {
+			return nil, err
+		}
+
+		conf := metrics.NewConfig()
+		if err := newNode.Decode(&conf); err != nil {
+			return nil, err
+		}
+
+		m, err := set.Init(conf, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		// Metrics templates have no manager to wrap, and so the mapping is
+		// applied to the aggregator itself.
+		if tmpl.metricsMapping != nil {
+			mapped := imetrics.NewNamespaced(m).WithMapping(tmpl.metricsMapping)
+			if h, ok := m.(metrics.WithHandlerFunc); ok {
+				return mappedMetricsWithHandler{Namespaced: mapped, handler: h}, nil
+			}
+			return mapped, nil
+		}
+		return m, nil
+	}, tmpl.spec)
+}
+
+// mappedMetricsWithHandler retains the HTTP handler of a metrics aggregator
+// that has been wrapped with a metrics mapping.
+type mappedMetricsWithHandler struct {
+	*imetrics.Namespaced
+	handler metrics.WithHandlerFunc
+}
+
+func (m mappedMetricsWithHandler) HandlerFunc() http.HandlerFunc {
+	return m.handler.HandlerFunc()
+}
+
 func registerOutputTemplate(tmpl *compiled, set *bundle.OutputSet) error {
 	return set.Add(func(c output.Config, nm bundle.NewManagement, pcf ...types.PipelineConstructorFunc) (output.Type, error) {
 		newNode, err := tmpl.ExpandToNode(c.Plugin.(*yaml.Node))
@@ -254,3 +349,18 @@ func registerRateLimitTemplate(tmpl *compiled, set *bundle.RateLimitSet) error {
 		return nm.NewRateLimit(conf)
 	}, tmpl.spec)
 }
+
+func registerTracerTemplate(tmpl *compiled, set *bundle.TracerSet) error {
+	return set.Add(func(c tracer.Config, opts ...func(tracer.Type)) (tracer.Type, error) {
+		newNode, err := tmpl.ExpandToNode(c.Plugin.(*yaml.Node))
+		if err != nil {
+			return nil, err
+		}
+
+		conf := tracer.NewConfig()
+		if err := newNode.Decode(&conf); err != nil {
+			return nil, err
+		}
+		return set.Init(conf, opts...)
+	}, tmpl.spec)
+}
@@ -1,14 +1,24 @@
 package template_test
 
 import (
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bundle"
 	"github.com/Jeffail/benthos/v3/internal/template"
+	"github.com/Jeffail/benthos/v3/lib/buffer"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/tracer"
+	"github.com/Jeffail/benthos/v3/lib/types"
 	_ "github.com/Jeffail/benthos/v3/public/components/all"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestTemplateTesting(t *testing.T) {
@@ -28,3 +38,238 @@ func TestTemplateTesting(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateBehaviouralTests(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tmplPath := filepath.Join(tmpDir, "upper.yaml")
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "expected.txt"), []byte("HELLO WORLD"), 0644))
+	require.NoError(t, os.WriteFile(tmplPath, []byte(`
+name: upper_suffix
+type: processor
+fields:
+  - name: suffix
+    type: string
+mapping: |
+  root.bloblang = "root = content().uppercase().string() + %q".format(this.suffix)
+tests:
+  - name: passes
+    config:
+      suffix: ""
+    input_batch:
+      - content: hello world
+    output_batches:
+      - - file_equals: ./expected.txt
+  - name: fails
+    config:
+      suffix: "!"
+    input_batch:
+      - content: hello world
+      - content: foo
+    output_batches:
+      - - content_equals: HELLO WORLD
+        - content_equals: FOO!
+`), 0644))
+
+	conf, lints, err := template.ReadConfig(tmplPath)
+	require.NoError(t, err)
+	assert.Empty(t, lints)
+
+	testErrs, err := conf.Test()
+	require.NoError(t, err)
+	require.Len(t, testErrs, 1)
+	assert.Contains(t, testErrs[0], "test 'fails': output '/output' batch 0 message 0: content_equals: content mismatch")
+}
+
+func TestTemplateBehaviouralTestsOutput(t *testing.T) {
+	tmplPath := filepath.Join(t.TempDir(), "routed.yaml")
+	require.NoError(t, os.WriteFile(tmplPath, []byte(`
+name: routed_webhook
+type: output
+fields:
+  - name: url
+    type: string
+mapping: |
+  root.switch.cases = [
+    {
+      "check": "content() == \"drop me\"",
+      "output": { "label": "dropped", "drop": {} }
+    },
+    {
+      "output": {
+        "http_client": { "url": this.url, "verb": "POST", "retries": 0 },
+        "processors": [ { "bloblang": "root = content().uppercase()" } ]
+      }
+    }
+  ]
+tests:
+  - name: routes
+    config:
+      url: http://${! meta("host") }/hooks
+    mock_http:
+      - method: POST
+        path: /hooks
+    input_batch:
+      - content: drop me
+      - content: send me
+    outputs:
+      dropped:
+        - - content_equals: drop me
+`), 0644))
+
+	conf, lints, err := template.ReadConfig(tmplPath)
+	require.NoError(t, err)
+	assert.Empty(t, lints)
+
+	testErrs, err := conf.Test()
+	require.NoError(t, err)
+	assert.Empty(t, testErrs)
+}
+
+func TestTemplateTestTargets(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tmplPath := filepath.Join(tmpDir, "targets.yaml")
+	require.NoError(t, os.WriteFile(tmplPath, []byte(`
+name: targets_test_upper
+type: processor
+mapping: 'root.bloblang = "root = content().uppercase()"'
+tests:
+  - name: uppers
+    config: {}
+    input_batch:
+      - content: hello
+    output_batches:
+      - - content_equals: nope
+`), 0644))
+
+	_, err := template.InitTemplates(tmplPath)
+	require.NoError(t, err)
+
+	targets := template.TestTargets()
+	require.Contains(t, targets, tmplPath)
+
+	def, failures, err := targets[tmplPath]()
+	require.NoError(t, err)
+	require.Len(t, def.Cases, 1)
+	assert.Equal(t, "uppers", def.Cases[0].Name)
+	require.Len(t, failures, 1)
+	assert.Equal(t, "uppers", failures[0].Name)
+	assert.Equal(t, 6, failures[0].TestLine)
+	assert.Contains(t, failures[0].Reason, "content_equals: content mismatch")
+}
+
+func TestTemplateBehaviouralTestsUnsupported(t *testing.T) {
+	tmplPath := filepath.Join(t.TempDir(), "cache.yaml")
+	require.NoError(t, os.WriteFile(tmplPath, []byte(`
+name: memory_alias
+type: cache
+mapping: 'root.memory = {}'
+tests:
+  - name: nope
+    config: {}
+    input_batch:
+      - content: hello world
+`), 0644))
+
+	conf, _, err := template.ReadConfig(tmplPath)
+	require.NoError(t, err)
+
+	_, err = conf.Test()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "test 'nope': input batches are not supported for templates of type cache")
+}
+
+func TestTemplateComponentKinds(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeTemplate := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(tmpDir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	lints, err := template.InitTemplates(
+		writeTemplate("metrics.yaml", `
+name: kinds_test_prometheus
+type: metrics
+mapping: 'root.prometheus.prefix = "kinds"'
+metrics_mapping: 'root = if this.has_prefix("dropped") { deleted() }'
+`),
+		writeTemplate("buffer.yaml", `
+name: kinds_test_buffer
+type: buffer
+fields:
+  - name: limit
+    type: int
+mapping: 'root.memory.limit = this.limit'
+`),
+		writeTemplate("tracer.yaml", `
+name: kinds_test_tracer
+type: tracer
+mapping: 'root.none = {}'
+`),
+	)
+	require.NoError(t, err)
+	assert.Empty(t, lints)
+
+	var conf struct {
+		Buffer  buffer.Config  `yaml:"buffer"`
+		Metrics metrics.Config `yaml:"metrics"`
+		Tracer  tracer.Config  `yaml:"tracer"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+buffer:
+  kinds_test_buffer:
+    limit: 1000
+metrics:
+  kinds_test_prometheus: {}
+tracer:
+  kinds_test_tracer: {}
+`), &conf))
+
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	buf, err := mgr.NewBuffer(conf.Buffer)
+	require.NoError(t, err)
+	require.NoError(t, buf.Consume(make(chan types.Transaction)))
+	buf.CloseAsync()
+	require.NoError(t, buf.WaitForClose(time.Second))
+
+	stats, err := bundle.AllMetrics.Init(conf.Metrics)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, stats.Close())
+	})
+
+	stats.GetCounter("kept").Incr(1)
+	stats.GetCounter("dropped").Incr(1)
+
+	wHandler, ok := stats.(metrics.WithHandlerFunc)
+	require.True(t, ok)
+
+	rec := httptest.NewRecorder()
+	wHandler.HandlerFunc()(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), "kinds_kept 1")
+	assert.NotContains(t, rec.Body.String(), "dropped")
+
+	trac, err := bundle.AllTracers.Init(conf.Tracer)
+	require.NoError(t, err)
+	require.NoError(t, trac.Close())
+}
+
+func TestTemplateTracerMetricsMappingLint(t *testing.T) {
+	tmplPath := filepath.Join(t.TempDir(), "tracer.yaml")
+	require.NoError(t, os.WriteFile(tmplPath, []byte(`
+name: mapped_tracer
+type: tracer
+mapping: 'root.none = {}'
+metrics_mapping: 'root = "foo"'
+`), 0644))
+
+	_, lints, err := template.ReadConfig(tmplPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"field metrics_mapping is ineffective for templates of type tracer"}, lints)
+}
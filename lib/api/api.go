@@ -12,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component/health"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/gorilla/handlers"
@@ -89,6 +90,8 @@ type Type struct {
 	handlers    map[string]http.HandlerFunc
 	handlersMut sync.RWMutex
 
+	health *health.Registry
+
 	log    log.Modular
 	mux    *mux.Router
 	server *http.Server
@@ -141,6 +144,7 @@ func New(
 		mux:       gMux,
 		server:    server,
 		log:       log,
+		health:    health.NewRegistry(),
 	}
 	t.ctx, t.cancel = context.WithCancel(context.Background())
 
@@ -245,6 +249,11 @@ func New(
 	t.RegisterEndpoint("/ping", "Ping me.", handlePing)
 	t.RegisterEndpoint("/version", "Returns the service version.", handleVersion)
 	t.RegisterEndpoint("/endpoints", "Returns this map of endpoints.", handleEndpoints)
+	t.RegisterEndpoint(
+		"/health", "Returns a JSON object describing the health of each input,"+
+			" output, cache and rate limit, responds with a 503 if any are unhealthy.",
+		t.health.HandlerFunc(),
+	)
 
 	// If we want to expose a JSON stats endpoint we register the endpoints.
 	if wHandlerFunc, ok := stats.(metrics.WithHandlerFunc); ok {
@@ -289,6 +298,12 @@ func (t *Type) RegisterEndpoint(path, desc string, handlerFunc http.HandlerFunc)
 	t.handlers[path] = handlerFunc
 }
 
+// Health returns the registry used for tracking the health of components,
+// which is exposed by the /health endpoint.
+func (t *Type) Health() *health.Registry {
+	return t.health
+}
+
 // ListenAndServe launches the API and blocks until the server closes or fails.
 func (t *Type) ListenAndServe() error {
 	if !t.conf.Enabled {
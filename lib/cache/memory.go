@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"sync"
@@ -242,6 +243,19 @@ func (m *memoryV2) Delete(_ context.Context, key string) error {
 	return nil
 }
 
+func (m *memoryV2) CompareAndDelete(_ context.Context, key string, value []byte) error {
+	shard := m.getShard(key)
+	shard.Lock()
+	defer shard.Unlock()
+	k, exists := shard.items[key]
+	if !exists || shard.isExpired(k) || !bytes.Equal(k.value, value) {
+		return types.ErrKeyNotFound
+	}
+	delete(shard.items, key)
+	shard.mKeys.Set(int64(len(shard.items)))
+	return nil
+}
+
 func (m *memoryV2) Close(context.Context) error {
 	return nil
 }
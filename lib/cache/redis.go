@@ -349,6 +349,47 @@ func (r *Redis) Delete(key string) error {
 	return err
 }
 
+// redisCompareAndDelete removes a key only if it holds the expected value,
+// which must be done within a script in order for it to be atomic.
+var redisCompareAndDelete = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// CompareAndDelete attempts to remove a key only if its current value matches
+// the provided value, returns an error if the key does not exist or holds a
+// different value, or if the operation fails.
+func (r *Redis) CompareAndDelete(key string, value []byte) error {
+	r.mDelCount.Incr(1)
+	tStarted := time.Now()
+
+	key = r.prefix + key
+
+	deleted, err := redisCompareAndDelete.Run(r.client, []string{key}, value).Int64()
+	for i := 0; i < r.conf.Redis.Retries && err != nil; i++ {
+		r.log.Errorf("Delete command failed: %v\n", err)
+		<-time.After(r.retryPeriod)
+		r.mDelRetry.Incr(1)
+		deleted, err = redisCompareAndDelete.Run(r.client, []string{key}, value).Int64()
+	}
+	if err != nil {
+		r.mDelFailedErr.Incr(1)
+	} else if deleted == 0 {
+		r.mDelNotFound.Incr(1)
+		err = types.ErrKeyNotFound
+	} else {
+		r.mDelSuccess.Incr(1)
+	}
+
+	latency := int64(time.Since(tStarted))
+	r.mDelLatency.Timing(latency)
+	r.mLatency.Timing(latency)
+
+	return err
+}
+
 // CloseAsync shuts down the cache.
 func (r *Redis) CloseAsync() {
 }
@@ -0,0 +1,438 @@
+package condition
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+
+// ToBloblang attempts to convert a condition config into an equivalent
+// Bloblang query that returns a boolean, which can be used in place of the
+// condition within fields such as `check`. The resulting query is executed
+// against each message individually, and therefore conditions that target a
+// message of a batch other than the first (with the field `part`) cannot be
+// converted. An error is returned when a condition cannot be expressed as a
+// query.
+func ToBloblang(conf Config) (string, error) {
+	switch conf.Type {
+	case TypeAnd:
+		return joinBloblang("and", " && ", conf.And)
+	case TypeOr:
+		return joinBloblang("or", " || ", conf.Or)
+	case TypeXor:
+		if len(conf.Xor) == 0 {
+			return "false", nil
+		}
+		children := make([]string, 0, len(conf.Xor))
+		for i, child := range conf.Xor {
+			query, err := ToBloblang(child)
+			if err != nil {
+				return "", fmt.Errorf("xor child %v: %w", i, err)
+			}
+			children = append(children, query)
+		}
+		return fmt.Sprintf("[ %v ].filter(v -> v).length() == 1", strings.Join(children, ", ")), nil
+	case TypeNot:
+		if conf.Not.Config == nil {
+			return "", fmt.Errorf("not condition is missing a child")
+		}
+		query, err := ToBloblang(*conf.Not.Config)
+		if err != nil {
+			return "", fmt.Errorf("not: %w", err)
+		}
+		return "!" + wrapBloblang(query), nil
+	case TypeBloblang:
+		if len(conf.Bloblang) == 0 {
+			return "", fmt.Errorf("bloblang condition is empty")
+		}
+		return strings.TrimSpace(string(conf.Bloblang)), nil
+	case TypeStatic:
+		return strconv.FormatBool(conf.Static), nil
+	case TypeProcessorFailed:
+		if err := checkBloblangPart(conf.ProcessorFailed.Part); err != nil {
+			return "", err
+		}
+		return "errored()", nil
+	case TypeText:
+		if err := checkBloblangPart(conf.Text.Part); err != nil {
+			return "", err
+		}
+		return textToBloblang(conf.Text)
+	case TypeMetadata:
+		if err := checkBloblangPart(conf.Metadata.Part); err != nil {
+			return "", err
+		}
+		return metadataToBloblang(conf.Metadata)
+	case TypeNumber:
+		if err := checkBloblangPart(conf.Number.Part); err != nil {
+			return "", err
+		}
+		return numberToBloblang(conf.Number)
+	case TypeJSON:
+		if err := checkBloblangPart(conf.JSON.Part); err != nil {
+			return "", err
+		}
+		return jsonToBloblang(conf.JSON)
+	}
+	return "", fmt.Errorf("condition type %v cannot be converted into a Bloblang query", conf.Type)
+}
+
+// ToBloblangYAML attempts to convert a YAML node of a condition config into an
+// equivalent Bloblang query following the same rules as ToBloblang.
+func ToBloblangYAML(node *yaml.Node) (string, error) {
+	conf := NewConfig()
+	if err := node.Decode(&conf); err != nil {
+		return "", err
+	}
+	return ToBloblang(conf)
+}
+
+// ReplaceYAMLWithCheck attempts to replace a condition config found within a
+// mapping node at the field condField with an equivalent Bloblang query at the
+// field checkField. Conditions that match the provided default config are
+// removed without being replaced, as components treat them as being unset.
+// Returns true if the node was modified.
+func ReplaceYAMLWithCheck(node *yaml.Node, condField, checkField string, defaultConf Config) (bool, error) {
+	condIndex, checkIndex := -1, -1
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		switch node.Content[i].Value {
+		case condField:
+			condIndex = i
+		case checkField:
+			checkIndex = i
+		}
+	}
+	if condIndex == -1 {
+		return false, nil
+	}
+
+	conf := NewConfig()
+	if err := node.Content[condIndex+1].Decode(&conf); err != nil {
+		return false, err
+	}
+
+	defaultBytes, _ := yaml.Marshal(defaultConf)
+	confBytes, _ := yaml.Marshal(conf)
+	if string(defaultBytes) == string(confBytes) {
+		node.Content = append(node.Content[:condIndex], node.Content[condIndex+2:]...)
+		return true, nil
+	}
+
+	if checkIndex >= 0 && node.Content[checkIndex+1].Value != "" {
+		return false, fmt.Errorf("cannot migrate field %v as field %v is already set", condField, checkField)
+	}
+
+	query, err := ToBloblang(conf)
+	if err != nil {
+		return false, fmt.Errorf("failed to migrate field %v: %w", condField, err)
+	}
+
+	if checkIndex >= 0 {
+		node.Content = append(node.Content[:checkIndex], node.Content[checkIndex+2:]...)
+		if checkIndex < condIndex {
+			condIndex -= 2
+		}
+	}
+	node.Content[condIndex].Value = checkField
+	node.Content[condIndex+1] = BloblangYAMLNode(query)
+	return true, nil
+}
+
+// IsSetYAML returns true if a mapping node contains a condition config at the
+// field condField that differs from the provided default config, as components
+// treat a default condition as being unset.
+func IsSetYAML(node *yaml.Node, condField string, defaultConf Config) (bool, error) {
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value != condField {
+			continue
+		}
+		conf := NewConfig()
+		if err := node.Content[i+1].Decode(&conf); err != nil {
+			return false, err
+		}
+		defaultBytes, _ := yaml.Marshal(defaultConf)
+		confBytes, _ := yaml.Marshal(conf)
+		return string(defaultBytes) != string(confBytes), nil
+	}
+	return false, nil
+}
+
+// BloblangYAMLNode returns a scalar YAML node containing a Bloblang query or
+// mapping, using a literal style when it spans multiple lines.
+func BloblangYAMLNode(query string) *yaml.Node {
+	node := &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   "!!str",
+		Value: query,
+	}
+	if strings.Contains(query, "\n") {
+		node.Style = yaml.LiteralStyle
+	}
+	return node
+}
+
+func checkBloblangPart(part int) error {
+	if part != 0 {
+		return fmt.Errorf("conditions that target message %v of a batch cannot be converted into a Bloblang query", part)
+	}
+	return nil
+}
+
+// wrapBloblang surrounds a query in brackets unless it's a single term, in
+// order to avoid ambiguity when it's combined with operators. A query is a
+// single term when it contains no whitespace or operators outside of brackets
+// and string literals.
+func wrapBloblang(query string) string {
+	depth, inString := 0, false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if inString {
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '!':
+			if i > 0 && depth == 0 {
+				return "(" + query + ")"
+			}
+		case ' ', '\n', '\t', '\r', '=', '<', '>', '&', '|', '+', '-', '*', '/', '%':
+			if depth == 0 {
+				return "(" + query + ")"
+			}
+		}
+	}
+	return query
+}
+
+func joinBloblang(name, op string, children []Config) (string, error) {
+	if len(children) == 0 {
+		return "", fmt.Errorf("%v condition has no children", name)
+	}
+	queries := make([]string, 0, len(children))
+	for i, child := range children {
+		query, err := ToBloblang(child)
+		if err != nil {
+			return "", fmt.Errorf("%v child %v: %w", name, i, err)
+		}
+		if len(children) > 1 {
+			query = wrapBloblang(query)
+		}
+		queries = append(queries, query)
+	}
+	return strings.Join(queries, op), nil
+}
+
+func stringSliceToBloblang(arg interface{}) ([]string, error) {
+	entries, err := cast.ToStringSliceE(arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse argument as string slice: %v", err)
+	}
+	quoted := make([]string, 0, len(entries))
+	for _, e := range entries {
+		quoted = append(quoted, strconv.Quote(e))
+	}
+	return quoted, nil
+}
+
+func anyOfBloblang(target, method string, args []string) string {
+	if len(args) == 0 {
+		return "false"
+	}
+	queries := make([]string, 0, len(args))
+	for _, arg := range args {
+		queries = append(queries, fmt.Sprintf("%v.%v(%v)", target, method, arg))
+	}
+	return strings.Join(queries, " || ")
+}
+
+func textToBloblang(conf TextConfig) (string, error) {
+	switch conf.Operator {
+	case "contains_any", "contains_any_cs":
+		args, err := stringSliceToBloblang(conf.Arg)
+		if err != nil {
+			return "", err
+		}
+		target := "content()"
+		if conf.Operator == "contains_any" {
+			target = "content().lowercase()"
+			for i, arg := range args {
+				args[i] = strings.ToLower(arg)
+			}
+		}
+		return anyOfBloblang(target, "contains", args), nil
+	case "enum":
+		args, err := stringSliceToBloblang(conf.Arg)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("[%v].contains(content().string())", strings.Join(args, ", ")), nil
+	}
+
+	argStr, ok := conf.Arg.(string)
+	if !ok {
+		return "", fmt.Errorf("expected string as text operator argument, received: %T", conf.Arg)
+	}
+	arg, lowerArg := strconv.Quote(argStr), strconv.Quote(strings.ToLower(argStr))
+
+	switch conf.Operator {
+	case "equals_cs":
+		return "content() == " + arg, nil
+	case "equals":
+		return "content().lowercase() == " + lowerArg, nil
+	case "contains_cs":
+		return "content().contains(" + arg + ")", nil
+	case "contains":
+		return "content().lowercase().contains(" + lowerArg + ")", nil
+	case "prefix_cs":
+		return "content().has_prefix(" + arg + ")", nil
+	case "prefix":
+		return "content().lowercase().has_prefix(" + lowerArg + ")", nil
+	case "suffix_cs":
+		return "content().has_suffix(" + arg + ")", nil
+	case "suffix":
+		return "content().lowercase().has_suffix(" + lowerArg + ")", nil
+	case "regexp_partial":
+		return "content().re_match(" + arg + ")", nil
+	case "regexp_exact":
+		return "content().re_match(" + strconv.Quote("^(?:"+argStr+")$") + ")", nil
+	}
+	return "", fmt.Errorf("text operator %v cannot be converted into a Bloblang query", conf.Operator)
+}
+
+func metadataToBloblang(conf MetadataConfig) (string, error) {
+	value := fmt.Sprintf("meta(%v).or(\"\")", strconv.Quote(conf.Key))
+
+	switch conf.Operator {
+	case "exists":
+		return value + ` != ""`, nil
+	case "enum":
+		args, err := stringSliceToBloblang(conf.Arg)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("[%v].contains(%v)", strings.Join(args, ", "), value), nil
+	case "greater_than", "less_than":
+		v, err := cast.ToFloat64E(conf.Arg)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse argument as float64: %v", err)
+		}
+		op := ">"
+		if conf.Operator == "less_than" {
+			op = "<"
+		}
+		return fmt.Sprintf("(%v.number() %v %v).catch(false)", value, op, strconv.FormatFloat(v, 'f', -1, 64)), nil
+	case "has_prefix":
+		if prefix, ok := conf.Arg.(string); ok {
+			return fmt.Sprintf("%v.has_prefix(%v)", value, strconv.Quote(prefix)), nil
+		}
+		args, err := stringSliceToBloblang(conf.Arg)
+		if err != nil {
+			return "", err
+		}
+		return anyOfBloblang(value, "has_prefix", args), nil
+	}
+
+	argStr, err := cast.ToStringE(conf.Arg)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse argument as string: %v", err)
+	}
+
+	switch conf.Operator {
+	case "equals_cs":
+		return fmt.Sprintf("%v == %v", value, strconv.Quote(argStr)), nil
+	case "equals":
+		return fmt.Sprintf("%v.lowercase() == %v", value, strconv.Quote(strings.ToLower(argStr))), nil
+	case "regexp_partial":
+		return fmt.Sprintf("%v.re_match(%v)", value, strconv.Quote(argStr)), nil
+	case "regexp_exact":
+		return fmt.Sprintf("%v.re_match(%v)", value, strconv.Quote("^(?:"+argStr+")$")), nil
+	}
+	return "", fmt.Errorf("metadata operator %v cannot be converted into a Bloblang query", conf.Operator)
+}
+
+func numberToBloblang(conf NumberConfig) (string, error) {
+	var op string
+	switch conf.Operator {
+	case "equals":
+		op = "=="
+	case "greater_than":
+		op = ">"
+	case "less_than":
+		op = "<"
+	default:
+		return "", fmt.Errorf("number operator %v cannot be converted into a Bloblang query", conf.Operator)
+	}
+	return fmt.Sprintf("(content().number() %v %v).catch(false)", op, strconv.FormatFloat(conf.Arg, 'f', -1, 64)), nil
+}
+
+var bloblangPathSegmentRegexp = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// pathToBloblang converts a dot path into a Bloblang field reference from the
+// given root, quoting any path segments that contain special characters.
+func pathToBloblang(root, path string) string {
+	if path == "" || path == "." {
+		return root
+	}
+	segments := strings.Split(path, ".")
+	for i, s := range segments {
+		if !bloblangPathSegmentRegexp.MatchString(s) {
+			segments[i] = strconv.Quote(s)
+		}
+	}
+	return root + "." + strings.Join(segments, ".")
+}
+
+func jsonArgToBloblang(arg interface{}) (string, error) {
+	if f, isNum := toFloat64(arg); isNum {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	switch t := arg.(type) {
+	case string:
+		return strconv.Quote(t), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case nil:
+		return "null", nil
+	}
+	return "", fmt.Errorf("json argument of type %T cannot be converted into a Bloblang query", arg)
+}
+
+func jsonToBloblang(conf JSONConfig) (string, error) {
+	if conf.Path == "" || conf.Path == "." {
+		return "", fmt.Errorf("json conditions that target the root of a document cannot be converted into a Bloblang query")
+	}
+	switch conf.Operator {
+	case "exists":
+		return fmt.Sprintf("this.exists(%v).catch(false)", strconv.Quote(conf.Path)), nil
+	case "equals":
+		arg, err := jsonArgToBloblang(conf.Arg)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%v == %v).catch(false)", pathToBloblang("this", conf.Path), arg), nil
+	case "contains":
+		arg, err := jsonArgToBloblang(conf.Arg)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v.contains(%v).catch(false)", pathToBloblang("this", conf.Path), arg), nil
+	}
+	return "", fmt.Errorf("json operator %v cannot be converted into a Bloblang query", conf.Operator)
+}
@@ -0,0 +1,196 @@
+package condition
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestToBloblang(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		query    string
+		messages []string
+	}{
+		{
+			name: "text equals",
+			config: `
+text:
+  operator: equals
+  arg: FOO
+`,
+			query:    `content().lowercase() == "foo"`,
+			messages: []string{"foo", "Foo", "bar"},
+		},
+		{
+			name: "text contains any",
+			config: `
+text:
+  operator: contains_any_cs
+  arg: [ foo, "b\"ar" ]
+`,
+			query:    `content().contains("foo") || content().contains("b\"ar")`,
+			messages: []string{"a foo", `b"ar`, "bar"},
+		},
+		{
+			name: "text enum",
+			config: `
+text:
+  operator: enum
+  arg: [ foo, bar ]
+`,
+			query:    `["foo", "bar"].contains(content().string())`,
+			messages: []string{"foo", "bar", "baz"},
+		},
+		{
+			name: "metadata greater than",
+			config: `
+metadata:
+  operator: greater_than
+  key: num
+  arg: 5
+`,
+			query:    `(meta("num").or("").number() > 5).catch(false)`,
+			messages: []string{"foo"},
+		},
+		{
+			name: "number",
+			config: `
+number:
+  operator: less_than
+  arg: 10.5
+`,
+			query:    `(content().number() < 10.5).catch(false)`,
+			messages: []string{"5", "11", "nope"},
+		},
+		{
+			name: "json equals",
+			config: `
+json:
+  operator: equals
+  path: doc.type
+  arg: foo
+`,
+			query:    `(this.doc.type == "foo").catch(false)`,
+			messages: []string{`{"doc":{"type":"foo"}}`, `{"doc":{"type":"bar"}}`, "nope"},
+		},
+		{
+			name: "json exists",
+			config: `
+json:
+  operator: exists
+  path: doc.id
+`,
+			query:    `this.exists("doc.id").catch(false)`,
+			messages: []string{`{"doc":{"id":"foo"}}`, `{"doc":{}}`, "nope"},
+		},
+		{
+			name: "logical operators",
+			config: `
+and:
+  - not:
+      processor_failed: {}
+  - or:
+      - text:
+          operator: prefix_cs
+          arg: foo
+      - bloblang: content() == "bar"
+`,
+			query:    `!errored() && (content().has_prefix("foo") || (content() == "bar"))`,
+			messages: []string{"foo", "bar", "baz"},
+		},
+		{
+			name: "not without whitespace",
+			config: `
+not:
+  bloblang: this.a>5
+`,
+			query:    `!(this.a>5)`,
+			messages: []string{`{"a":3}`, `{"a":7}`},
+		},
+		{
+			name: "xor",
+			config: `
+xor:
+  - text:
+      operator: contains_cs
+      arg: a
+  - text:
+      operator: contains_cs
+      arg: b
+`,
+			query:    `[ content().contains("a"), content().contains("b") ].filter(v -> v).length() == 1`,
+			messages: []string{"a", "b", "ab", "c"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewConfig()
+			require.NoError(t, yaml.Unmarshal([]byte(test.config), &conf))
+
+			query, err := ToBloblang(conf)
+			require.NoError(t, err)
+			assert.Equal(t, test.query, query)
+
+			queryConf := NewConfig()
+			queryConf.Type = TypeBloblang
+			queryConf.Bloblang = BloblangConfig(query)
+
+			cond, err := New(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			queryCond, err := New(queryConf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			for _, m := range test.messages {
+				msg := message.New([][]byte{[]byte(m)})
+				msg.Get(0).Metadata().Set("num", "10")
+				assert.Equal(t, cond.Check(msg), queryCond.Check(msg), m)
+			}
+		})
+	}
+}
+
+func TestToBloblangErrors(t *testing.T) {
+	tests := map[string]string{
+		"unsupported type": `
+count:
+  arg: 10
+`,
+		"nested unsupported type": `
+not:
+  jmespath:
+    query: foo
+`,
+		"non-zero part": `
+text:
+  operator: equals_cs
+  part: 1
+  arg: foo
+`,
+		"unsupported operator": `
+text:
+  operator: is
+  arg: ip
+`,
+	}
+
+	for name, config := range tests {
+		config := config
+		t.Run(name, func(t *testing.T) {
+			conf := NewConfig()
+			require.NoError(t, yaml.Unmarshal([]byte(config), &conf))
+
+			_, err := ToBloblang(conf)
+			assert.Error(t, err)
+		})
+	}
+}
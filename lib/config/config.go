@@ -27,6 +27,7 @@ type Type struct {
 	Metrics                metrics.Config `json:"metrics" yaml:"metrics"`
 	Tracer                 tracer.Config  `json:"tracer" yaml:"tracer"`
 	SystemCloseTimeout     string         `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	SystemDrainTimeout     string         `json:"shutdown_drain_timeout" yaml:"shutdown_drain_timeout"`
 	Tests                  []interface{}  `json:"tests,omitempty" yaml:"tests,omitempty"`
 }
 
@@ -40,6 +41,7 @@ func New() Type {
 		Metrics:            metrics.NewConfig(),
 		Tracer:             tracer.NewConfig(),
 		SystemCloseTimeout: "20s",
+		SystemDrainTimeout: "0s",
 		Tests:              nil,
 	}
 }
@@ -58,6 +60,7 @@ type SanitisedConfig struct {
 	Metrics            interface{} `json:"metrics" yaml:"metrics"`
 	Tracer             interface{} `json:"tracer" yaml:"tracer"`
 	SystemCloseTimeout interface{} `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	SystemDrainTimeout interface{} `json:"shutdown_drain_timeout" yaml:"shutdown_drain_timeout"`
 	Tests              interface{} `json:"tests,omitempty" yaml:"tests,omitempty"`
 }
 
@@ -158,6 +161,7 @@ func (c Type) sanitised(skipDeprecated bool) (*SanitisedConfig, error) {
 		Metrics:            metConf,
 		Tracer:             tracConf,
 		SystemCloseTimeout: c.SystemCloseTimeout,
+		SystemDrainTimeout: c.SystemDrainTimeout,
 		Tests:              c.Tests,
 	}, nil
 }
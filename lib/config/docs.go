@@ -20,6 +20,7 @@ func Spec() docs.FieldSpecs {
 		docs.FieldCommon("metrics", "A mechanism for exporting metrics.").HasType(docs.FieldTypeMetrics),
 		docs.FieldCommon("tracer", "A mechanism for exporting traces.").HasType(docs.FieldTypeTracer),
 		docs.FieldString("shutdown_timeout", "The maximum period of time to wait for a clean shutdown. If this time is exceeded Benthos will forcefully close.").HasDefault("20s"),
+		docs.FieldString("shutdown_drain_timeout", "The maximum period of time to wait for in-flight messages to be flushed and acknowledged after a shutdown is triggered, during which no new messages are consumed. The `shutdown_timeout` period begins once this period has finished. Draining is disabled by default with `0s`, as messages in flight must be tracked whilst it is enabled. A drain can also be triggered with a `POST` request to the `/drain` endpoint, which shuts the service down without waiting when draining is disabled.").HasDefault("0s").Advanced(),
 		docs.FieldCommon("tests", "Optional unit tests for the config, to be run with the `benthos test` subcommand.").Array().HasType(docs.FieldTypeUnknown).HasDefault([]interface{}{}),
 	}...)
 
@@ -3,6 +3,7 @@ package config
 import (
 	"bytes"
 	"fmt"
+	"sort"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"gopkg.in/yaml.v3"
@@ -28,3 +29,88 @@ func Lint(rawBytes []byte, _ Type) ([]string, error) {
 	}
 	return lintStrs, nil
 }
+
+// Fix attempts to resolve linting errors within a user config by modifying the
+// parsed YAML tree, which preserves comments. Redundant fields are removed and
+// deprecated components and fields are migrated to their modern equivalents
+// where possible. Returns the fixed config, whether it was modified, and lints
+// describing deprecated configs that could not be migrated automatically.
+func Fix(rawBytes []byte) ([]byte, bool, []string, error) {
+	if bytes.HasPrefix(rawBytes, []byte("# BENTHOS LINT DISABLE")) {
+		return rawBytes, false, nil, nil
+	}
+
+	var rawNode yaml.Node
+	if err := yaml.Unmarshal(rawBytes, &rawNode); err != nil {
+		return nil, false, nil, err
+	}
+
+	changed, lints := Spec().FixYAML(docs.NewLintContext(), &rawNode)
+
+	var lintStrs []string
+	for _, lint := range lints {
+		lintStrs = append(lintStrs, fmt.Sprintf("line %v: %v", lint.Line, lint.What))
+	}
+	if !changed {
+		return rawBytes, false, lintStrs, nil
+	}
+
+	fixedBytes, err := encodeYAML(&rawNode)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return fixedBytes, true, lintStrs, nil
+}
+
+// Migrate attempts to convert deprecated components, fields and Bloblang
+// functions within a user config into their modern equivalents by modifying
+// the parsed YAML tree, which preserves comments. Returns the migrated config,
+// whether it was modified, and a report of deprecated configs that remain and
+// therefore need to be migrated manually.
+func Migrate(rawBytes []byte) ([]byte, bool, []string, error) {
+	if bytes.HasPrefix(rawBytes, []byte("# BENTHOS LINT DISABLE")) {
+		return rawBytes, false, nil, nil
+	}
+
+	var rawNode yaml.Node
+	if err := yaml.Unmarshal(rawBytes, &rawNode); err != nil {
+		return nil, false, nil, err
+	}
+
+	lCtx := docs.NewLintContext()
+	changed, lints := Spec().FixYAML(lCtx, &rawNode)
+	lints = append(lints, Spec().DeprecatedYAML(lCtx, &rawNode)...)
+	sort.SliceStable(lints, func(i, j int) bool {
+		return lints[i].Line < lints[j].Line
+	})
+
+	var report []string
+	seen := map[string]struct{}{}
+	for _, lint := range lints {
+		str := fmt.Sprintf("line %v: %v", lint.Line, lint.What)
+		if _, exists := seen[str]; exists {
+			continue
+		}
+		seen[str] = struct{}{}
+		report = append(report, str)
+	}
+	if !changed {
+		return rawBytes, false, report, nil
+	}
+
+	migratedBytes, err := encodeYAML(&rawNode)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return migratedBytes, true, report, nil
+}
+
+func encodeYAML(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/Jeffail/benthos/v3/lib/config"
 	_ "github.com/Jeffail/benthos/v3/public/components/all"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //------------------------------------------------------------------------------
@@ -168,3 +170,333 @@ tests:
 }
 
 //------------------------------------------------------------------------------
+
+func TestConfigFix(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    string
+		output  string
+		changed bool
+		lints   []string
+	}{
+		{
+			name: "nothing to fix",
+			conf: `input:
+  stdin: {}
+`,
+			output: `input:
+  stdin: {}
+`,
+		},
+		{
+			name: "lint disabled",
+			conf: `# BENTHOS LINT DISABLE
+input:
+  type: stdin
+  kafka: {}
+`,
+			output: `# BENTHOS LINT DISABLE
+input:
+  type: stdin
+  kafka: {}
+`,
+		},
+		{
+			name: "redundant fields",
+			conf: `input:
+  type: stdin # stdin
+  stdin: {}
+  kafka:
+    addresses: [ foo ]
+`,
+			output: `input:
+  # stdin
+  stdin: {}
+`,
+			changed: true,
+		},
+		{
+			name: "deprecated processors",
+			conf: `pipeline:
+  processors:
+    # Only foos
+    - filter_parts:
+        text:
+          operator: equals_cs
+          arg: foo
+    - conditional:
+        condition:
+          bloblang: this.a == 1
+        processors:
+          - bloblang: root = "a"
+        else_processors:
+          - bloblang: root = "b"
+    - switch:
+        - condition:
+            static: true
+          processors: []
+        - condition:
+            json:
+              operator: exists
+              path: doc.id
+          processors: []
+    - switch:
+        - condition:
+            static: true
+          processors: []
+`,
+			output: `pipeline:
+  processors:
+    # Only foos
+    - bloblang: root = if !(content() == "foo") { deleted() }
+    - conditional:
+        condition:
+          bloblang: this.a == 1
+        processors:
+          - bloblang: root = "a"
+        else_processors:
+          - bloblang: root = "b"
+    - switch:
+        - condition:
+            static: true
+          processors: []
+        - condition:
+            json:
+              operator: exists
+              path: doc.id
+          processors: []
+    - switch:
+        - processors: []
+`,
+			changed: true,
+			lints: []string{
+				"line 8: the conditional processor checks its condition against whole batches whereas the switch processor checks each message individually, and therefore cannot be migrated automatically",
+				"line 15: case 1: field condition is checked against whole batches whereas field check is checked against each message individually, and therefore cannot be migrated automatically",
+			},
+		},
+		{
+			name: "workflow stages",
+			conf: `pipeline:
+  processors:
+    - workflow:
+        stages:
+          foo:
+            premap:
+              id: doc.id
+            processors:
+              - noop: {}
+            postmap_optional:
+              results.foo: .
+`,
+			output: `pipeline:
+  processors:
+    - workflow:
+        branches:
+          foo:
+            request_map: root.id = this.doc.id.not_null()
+            processors:
+              - noop: {}
+            result_map: root.results.foo = this
+`,
+			changed: true,
+		},
+		{
+			name: "switch output",
+			conf: `output:
+  switch:
+    outputs:
+      - fallthrough: true
+        output:
+          stdout: {}
+      - output:
+          drop: {}
+`,
+			output: `output:
+  switch:
+    cases:
+      - continue: true
+        output:
+          stdout: {}
+      - output:
+          drop: {}
+`,
+			changed: true,
+		},
+		{
+			name: "switch output conditions",
+			conf: `output:
+  switch:
+    outputs:
+      - condition:
+          text:
+            operator: prefix_cs
+            arg: foo
+        output:
+          stdout: {}
+`,
+			output: `output:
+  switch:
+    outputs:
+      - condition:
+          text:
+            operator: prefix_cs
+            arg: foo
+        output:
+          stdout: {}
+`,
+			lints: []string{"line 2: output 0: field condition is checked against whole batches whereas field check is checked against each message individually, and therefore cannot be migrated automatically"},
+		},
+		{
+			name: "unsupported migration",
+			conf: `pipeline:
+  processors:
+    - while:
+        condition:
+          resource: foo
+        processors: []
+`,
+			output: `pipeline:
+  processors:
+    - while:
+        condition:
+          resource: foo
+        processors: []
+`,
+			lints: []string{"line 3: failed to migrate field condition: condition type resource cannot be converted into a Bloblang query"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			output, changed, lints, err := config.Fix([]byte(test.conf))
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(output))
+			assert.Equal(t, test.changed, changed)
+			assert.Equal(t, test.lints, lints)
+		})
+	}
+}
+
+func TestConfigMigrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    string
+		output  string
+		changed bool
+		report  []string
+	}{
+		{
+			name: "nothing to migrate",
+			conf: `input:
+  stdin: {}
+pipeline:
+  processors:
+    - bloblang: root = this
+`,
+			output: `input:
+  stdin: {}
+pipeline:
+  processors:
+    - bloblang: root = this
+`,
+		},
+		{
+			name: "sql args",
+			conf: `output:
+  sql:
+    driver: mysql
+    data_source_name: foo
+    query: INSERT INTO footable (foo, bar) VALUES (?, ?)
+    args:
+      - ${! this.foo }
+      - bar-${! meta("kafka_key") }
+`,
+			output: `output:
+  sql:
+    driver: mysql
+    data_source_name: foo
+    query: INSERT INTO footable (foo, bar) VALUES (?, ?)
+    args_mapping: root = [ (this.foo).string(), "bar-" + (meta("kafka_key")).string() ]
+`,
+			changed: true,
+		},
+		{
+			name: "partial migration",
+			conf: `pipeline:
+  processors:
+    - while:
+        condition:
+          resource: foo
+        processors:
+          - sql:
+              driver: mysql
+              dsn: foo
+              query: SELECT * FROM footable
+              args: [ "${! this.id }" ]
+    - filter_parts:
+        bloblang: this.a == 1
+    - bloblang: root.ts = timestamp()
+`,
+			output: `pipeline:
+  processors:
+    - while:
+        condition:
+          resource: foo
+        processors:
+          - sql:
+              driver: mysql
+              dsn: foo
+              query: SELECT * FROM footable
+              args: ["${! this.id }"]
+    - bloblang: root = if !(this.a == 1) { deleted() }
+    - bloblang: root.ts = now().format_timestamp("Mon Jan 2 15:04:05 -0700 MST 2006")
+`,
+			changed: true,
+			report: []string{
+				"line 3: failed to migrate field condition: condition type resource cannot be converted into a Bloblang query",
+				"line 4: field condition is deprecated",
+				"line 7: field dsn is deprecated in favour of data_source_name, which processes message batches differently and therefore cannot be migrated automatically",
+				"line 9: field dsn is deprecated",
+				"line 11: field args is deprecated",
+			},
+		},
+		{
+			name: "deprecated bloblang functions",
+			conf: `pipeline:
+  processors:
+    - bloblang: |
+        root.a = timestamp("15:04:05")
+        root.b = timestamp_utc(format: "15:04:05")
+        root.c = this.c.parse_timestamp_unix()
+        root.d = "timestamp()"
+output:
+  file:
+    path: ./${! timestamp_utc("2006-01-02") }/${! meta("id") }.txt
+`,
+			output: `pipeline:
+  processors:
+    - bloblang: |
+        root.a = now().format_timestamp("15:04:05")
+        root.b = now().format_timestamp(format: "15:04:05", tz: "UTC")
+        root.c = this.c.parse_timestamp("2006-01-02T15:04:05.999999999Z07:00").format_timestamp_unix()
+        root.d = "timestamp()"
+output:
+  file:
+    path: ./${! now().format_timestamp("2006-01-02", "UTC") }/${! meta("id") }.txt
+`,
+			changed: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			output, changed, report, err := config.Migrate([]byte(test.conf))
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(output))
+			assert.Equal(t, test.changed, changed)
+			assert.Equal(t, test.report, report)
+		})
+	}
+}
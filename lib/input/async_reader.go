@@ -2,10 +2,12 @@ package input
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component/health"
 	"github.com/Jeffail/benthos/v3/internal/shutdown"
 	"github.com/Jeffail/benthos/v3/lib/input/reader"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -29,8 +31,9 @@ type AsyncReader struct {
 	typeStr string
 	reader  reader.Async
 
-	stats metrics.Type
-	log   log.Modular
+	stats  metrics.Type
+	log    log.Modular
+	health health.ReporterField
 
 	transactions chan types.Transaction
 	shutSig      *shutdown.Signaller
@@ -107,6 +110,7 @@ func (r *AsyncReader) loop() {
 					return false
 				}
 				r.log.Errorf("Failed to connect to %v: %v\n", r.typeStr, err)
+				r.health.Get().Error(fmt.Sprintf("failed to connect: %v", err))
 				mFailedConn.Incr(1)
 				select {
 				case <-time.After(r.connBackoff.NextBackOff()):
@@ -123,6 +127,7 @@ func (r *AsyncReader) loop() {
 		return
 	}
 	mConn.Incr(1)
+	r.health.Get().Connected()
 	atomic.StoreInt32(&r.connected, 1)
 
 	for {
@@ -133,6 +138,7 @@ func (r *AsyncReader) loop() {
 		// If our reader says it is not connected.
 		if err == types.ErrNotConnected {
 			mLostConn.Incr(1)
+			r.health.Get().Reconnect()
 			atomic.StoreInt32(&r.connected, 0)
 
 			// Continue to try to reconnect while still active.
@@ -140,6 +146,7 @@ func (r *AsyncReader) loop() {
 				return
 			}
 			mConn.Incr(1)
+			r.health.Get().Connected()
 			atomic.StoreInt32(&r.connected, 1)
 		}
 
@@ -151,6 +158,7 @@ func (r *AsyncReader) loop() {
 		if err != nil || msg == nil {
 			if err != nil && err != types.ErrTimeout && err != types.ErrNotConnected {
 				r.log.Errorf("Failed to read message: %v\n", err)
+				r.health.Get().Error(fmt.Sprintf("failed to read message: %v", err))
 			}
 			select {
 			case <-time.After(r.connBackoff.NextBackOff()):
@@ -163,6 +171,7 @@ func (r *AsyncReader) loop() {
 			mCount.Incr(1)
 			mPartsRcvd.Incr(int64(msg.Len()))
 			mRcvd.Incr(1)
+			r.health.Get().Success()
 			r.log.Tracef("Consumed %v messages from '%v'.\n", msg.Len(), r.typeStr)
 		}
 
@@ -205,6 +214,7 @@ func (r *AsyncReader) loop() {
 			ackCtx, ackDone := r.shutSig.CloseNowCtx(context.Background())
 			if err = aFn(ackCtx, res); err != nil {
 				r.log.Errorf("Failed to acknowledge message: %v\n", err)
+				r.health.Get().Error(fmt.Sprintf("failed to acknowledge message: %v", err))
 			}
 			ackDone()
 		}(msg, ackFn, resChan)
@@ -217,6 +227,12 @@ func (r *AsyncReader) TransactionChan() <-chan types.Transaction {
 	return r.transactions
 }
 
+// SetHealthReporter sets the reporter that the health of the input is reported
+// to from the results of connecting, reading and acknowledging messages.
+func (r *AsyncReader) SetHealthReporter(rep health.Reporter) {
+	r.health.Set(rep)
+}
+
 // Connected returns a boolean indicating whether this input is currently
 // connected to its target.
 func (r *AsyncReader) Connected() bool {
@@ -881,3 +881,65 @@ func TestAsyncReaderSkipAcksALO(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+type mockHealthReporter struct {
+	events chan string
+}
+
+func (m *mockHealthReporter) Connected()       { m.events <- "connected" }
+func (m *mockHealthReporter) Reconnect()       { m.events <- "reconnect" }
+func (m *mockHealthReporter) Success()         { m.events <- "success" }
+func (m *mockHealthReporter) Error(err string) { m.events <- "error: " + err }
+
+func TestAsyncReaderHealth(t *testing.T) {
+	readerImpl := newMockAsyncReader()
+	reporter := &mockHealthReporter{events: make(chan string, 10)}
+
+	r, err := NewAsyncReader(
+		"foo", true, readerImpl,
+		log.Noop(), metrics.Noop(),
+	)
+	require.NoError(t, err)
+	r.(*AsyncReader).SetHealthReporter(reporter)
+
+	nextEvent := func() string {
+		t.Helper()
+		select {
+		case e := <-reporter.events:
+			return e
+		case <-time.After(time.Second * 5):
+			t.Fatal("Timed out")
+		}
+		return ""
+	}
+
+	readerImpl.connChan <- errors.New("nope")
+	assert.Equal(t, "error: failed to connect: nope", nextEvent())
+
+	readerImpl.connChan <- nil
+	assert.Equal(t, "connected", nextEvent())
+
+	readerImpl.readChan <- errors.New("nope")
+	assert.Equal(t, "error: failed to read message: nope", nextEvent())
+
+	go func() {
+		readerImpl.readChan <- nil
+		readerImpl.ackChan <- errors.New("nope")
+	}()
+	assert.Equal(t, "success", nextEvent())
+
+	ts := <-r.TransactionChan()
+	ts.ResponseChan <- response.NewAck()
+	assert.Equal(t, "error: failed to acknowledge message: nope", nextEvent())
+
+	go func() {
+		readerImpl.connChan <- nil
+	}()
+	close(readerImpl.readChan)
+	assert.Equal(t, "reconnect", nextEvent())
+	assert.Equal(t, "connected", nextEvent())
+
+	r.CloseAsync()
+	close(readerImpl.connChan)
+	require.NoError(t, r.WaitForClose(time.Second*5))
+}
@@ -89,6 +89,7 @@ You can access these metadata fields using [function interpolation](/docs/config
 			docs.FieldAdvanced("force_path_style_urls", "Forces the client API to use path style URLs for downloading keys, which is often required when connecting to custom endpoints."),
 			docs.FieldAdvanced("delete_objects", "Whether to delete downloaded objects from the bucket once they are processed."),
 			codec.ReaderDocs,
+			codec.MultilineDocs,
 			docs.FieldCommon("sqs", "Consume SQS messages in order to trigger key downloads.").WithChildren(
 				docs.FieldCommon("url", "An optional SQS URL to connect to. When specified this queue will control which objects are downloaded."),
 				docs.FieldAdvanced("endpoint", "A custom endpoint to use when connecting to SQS."),
@@ -145,6 +146,8 @@ type AWSS3Config struct {
 	ForcePathStyleURLs bool           `json:"force_path_style_urls" yaml:"force_path_style_urls"`
 	DeleteObjects      bool           `json:"delete_objects" yaml:"delete_objects"`
 	SQS                AWSS3SQSConfig `json:"sqs" yaml:"sqs"`
+
+	Multiline codec.MultilineConfig `json:"multiline" yaml:"multiline"`
 }
 
 // NewAWSS3Config creates a new AWSS3Config with default values.
@@ -157,6 +160,7 @@ func NewAWSS3Config() AWSS3Config {
 		ForcePathStyleURLs: false,
 		DeleteObjects:      false,
 		SQS:                NewAWSS3SQSConfig(),
+		Multiline:          codec.NewMultilineConfig(),
 	}
 }
 
@@ -588,7 +592,9 @@ func newAmazonS3(
 		stats: stats,
 	}
 	var err error
-	if s.objectScannerCtor, err = codec.GetReader(conf.Codec, codec.NewReaderConfig()); err != nil {
+	codecConf := codec.NewReaderConfig()
+	codecConf.Multiline = conf.Multiline
+	if s.objectScannerCtor, err = codec.GetReader(conf.Codec, codecConf); err != nil {
 		return nil, err
 	}
 	if len(conf.SQS.DelayPeriod) > 0 {
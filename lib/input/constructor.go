@@ -45,6 +45,7 @@ type TypeSpec struct {
 	config      docs.FieldSpec
 	FieldSpecs  docs.FieldSpecs
 	Examples    []docs.AnnotatedExample
+	fixer       docs.ComponentFixFunc
 }
 
 // ConstructorFunc is a func signature able to construct an input.
@@ -70,6 +71,7 @@ func WalkConstructors(fn func(ConstructorFunc, docs.ComponentSpec)) {
 			Examples:    v.Examples,
 			Status:      v.Status,
 			Version:     v.Version,
+			Fixer:       v.fixer,
 		}
 		if len(v.Categories) > 0 {
 			spec.Categories = make([]string, 0, len(v.Categories))
@@ -30,6 +30,7 @@ Consumes data from files on disk, emitting messages according to a chosen codec.
 			docs.FieldString("paths", "A list of paths to consume sequentially. Glob patterns are supported, including super globs (double star).").Array(),
 			codec.ReaderDocs,
 			docs.FieldAdvanced("max_buffer", "The largest token size expected when consuming delimited files."),
+			codec.MultilineDocs,
 			docs.FieldDeprecated("path"),
 			docs.FieldDeprecated("delimiter"),
 			docs.FieldDeprecated("multipart"),
@@ -75,6 +76,8 @@ type FileConfig struct {
 	MaxBuffer      int      `json:"max_buffer" yaml:"max_buffer"`
 	Delim          string   `json:"delimiter" yaml:"delimiter"`
 	DeleteOnFinish bool     `json:"delete_on_finish" yaml:"delete_on_finish"`
+
+	Multiline codec.MultilineConfig `json:"multiline" yaml:"multiline"`
 }
 
 // NewFileConfig creates a new FileConfig with default values.
@@ -88,6 +91,7 @@ func NewFileConfig() FileConfig {
 		MaxBuffer:      1000000,
 		Delim:          "",
 		DeleteOnFinish: false,
+		Multiline:      codec.NewMultilineConfig(),
 	}
 }
 
@@ -134,6 +138,7 @@ func newFileConsumer(conf FileConfig, log log.Modular) (*fileConsumer, error) {
 
 	codecConf := codec.NewReaderConfig()
 	codecConf.MaxScanTokenSize = conf.MaxBuffer
+	codecConf.Multiline = conf.Multiline
 	ctor, err := codec.GetReader(conf.Codec, codecConf)
 	if err != nil {
 		return nil, err
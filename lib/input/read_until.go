@@ -24,6 +24,13 @@ import (
 func init() {
 	Constructors[TypeReadUntil] = TypeSpec{
 		constructor: fromSimpleConstructor(NewReadUntil),
+		fixer: func(node *yaml.Node) (bool, error) {
+			conf, err := docs.GetYAMLPath(node, TypeReadUntil)
+			if err != nil || conf.Kind != yaml.MappingNode {
+				return false, nil
+			}
+			return condition.ReplaceYAMLWithCheck(conf, "condition", "check", condition.NewConfig())
+		},
 		Summary: `
 Reads messages from a child input until a consumed message passes a [Bloblang query](/docs/guides/bloblang/about/), at which point the input closes.`,
 		Description: `
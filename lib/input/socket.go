@@ -35,6 +35,7 @@ Connects to a tcp or unix socket and consumes a continuous stream of messages.`,
 			docs.FieldDeprecated("delimiter"),
 			docs.FieldDeprecated("multipart"),
 			docs.FieldAdvanced("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed."),
+			codec.MultilineDocs,
 		},
 		Categories: []Category{
 			CategoryNetwork,
@@ -50,6 +51,9 @@ type SocketConfig struct {
 	Address   string `json:"address" yaml:"address"`
 	Codec     string `json:"codec" yaml:"codec"`
 	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
+
+	Multiline codec.MultilineConfig `json:"multiline" yaml:"multiline"`
+
 	// TODO: V4 remove these fields.
 	Multipart bool   `json:"multipart" yaml:"multipart"`
 	Delim     string `json:"delimiter" yaml:"delimiter"`
@@ -64,6 +68,7 @@ func NewSocketConfig() SocketConfig {
 		Multipart: false,
 		MaxBuffer: 1000000,
 		Delim:     "",
+		Multiline: codec.NewMultilineConfig(),
 	}
 }
 
@@ -110,6 +115,7 @@ func newSocketClient(conf SocketConfig, logger log.Modular) (*socketClient, erro
 
 	codecConf := codec.NewReaderConfig()
 	codecConf.MaxScanTokenSize = conf.MaxBuffer
+	codecConf.Multiline = conf.Multiline
 	ctor, err := codec.GetReader(conf.Codec, codecConf)
 	if err != nil {
 		return nil, err
@@ -32,6 +32,7 @@ If the delimiter field is left empty then line feed (\n) is used.`,
 		FieldSpecs: docs.FieldSpecs{
 			codec.ReaderDocs.AtVersion("3.42.0"),
 			docs.FieldAdvanced("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed."),
+			codec.MultilineDocs,
 			docs.FieldDeprecated("delimiter"),
 			docs.FieldDeprecated("multipart"),
 		},
@@ -49,6 +50,8 @@ type STDINConfig struct {
 	Multipart bool   `json:"multipart" yaml:"multipart"`
 	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
 	Delim     string `json:"delimiter" yaml:"delimiter"`
+
+	Multiline codec.MultilineConfig `json:"multiline" yaml:"multiline"`
 }
 
 // NewSTDINConfig creates a STDINConfig populated with default values.
@@ -58,6 +61,7 @@ func NewSTDINConfig() STDINConfig {
 		Multipart: false,
 		MaxBuffer: 1000000,
 		Delim:     "",
+		Multiline: codec.NewMultilineConfig(),
 	}
 }
 
@@ -92,6 +96,7 @@ func newStdinConsumer(conf STDINConfig) (*stdinConsumer, error) {
 
 	codecConf := codec.NewReaderConfig()
 	codecConf.MaxScanTokenSize = conf.MaxBuffer
+	codecConf.Multiline = conf.Multiline
 	ctor, err := codec.GetReader(conf.Codec, codecConf)
 	if err != nil {
 		return nil, err
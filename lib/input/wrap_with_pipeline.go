@@ -3,6 +3,7 @@ package input
 import (
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component/health"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -53,6 +54,11 @@ func (i *WithPipeline) TransactionChan() <-chan types.Transaction {
 	return i.pipe.TransactionChan()
 }
 
+// SetHealthReporter forwards a health reporter to the wrapped input.
+func (i *WithPipeline) SetHealthReporter(r health.Reporter) {
+	health.SetReporter(i.in, r)
+}
+
 // Connected returns a boolean indicating whether this input is currently
 // connected to its target.
 func (i *WithPipeline) Connected() bool {
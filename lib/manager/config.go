@@ -120,6 +120,18 @@ func (r *ResourceConfig) collapsed() (ResourceConfig, error) {
 	}, nil
 }
 
+// Collapsed returns the resources of the config as maps keyed by their names,
+// where labelled resources defined as lists are merged with those defined
+// under the `resources` field. Returns an error if any labels are duplicated
+// or empty.
+func (r *ResourceConfig) Collapsed() (Config, error) {
+	c, err := r.collapsed()
+	if err != nil {
+		return Config{}, err
+	}
+	return c.Manager, nil
+}
+
 // AddFrom takes another Config and adds all of its resources to itself. If
 // there are any resource name collisions an error is returned.
 func (r *ResourceConfig) AddFrom(extra *ResourceConfig) error {
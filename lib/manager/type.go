@@ -10,6 +10,7 @@ import (
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bundle"
+	"github.com/Jeffail/benthos/v3/internal/component/health"
 	imetrics "github.com/Jeffail/benthos/v3/internal/component/metrics"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/buffer"
@@ -73,6 +74,13 @@ type Type struct {
 	logger log.Modular
 	stats  *imetrics.Namespaced
 
+	// Tracks the health of inputs, outputs, caches and rate limits.
+	health *health.Registry
+
+	// Whether processors record their label or path as the source of the
+	// failures they flag, which is only needed by dead_letter outputs.
+	failSources bool
+
 	pipes    map[string]<-chan types.Transaction
 	pipeLock *sync.RWMutex
 
@@ -136,11 +144,29 @@ func NewV2(conf ResourceConfig, apiReg APIReg, log log.Modular, stats metrics.Ty
 		opt(t)
 	}
 
+	// Share the health registry of the API when it has one so that it can be
+	// exposed.
+	if hr, ok := apiReg.(interface {
+		Health() *health.Registry
+	}); ok {
+		t.health = hr.Health()
+	} else {
+		t.health = health.NewRegistry()
+	}
+
 	conf, err := conf.collapsed()
 	if err != nil {
 		return nil, err
 	}
 
+	// Any stream might write to a dead_letter output resource, and therefore
+	// all processors must record the sources of their failures.
+	for _, oConf := range conf.Manager.Outputs {
+		if output.ContainsDeadLetter(oConf) {
+			t.failSources = true
+		}
+	}
+
 	// Sometimes resources of a type might refer to other resources of the same
 	// type. When they are constructed they will check with the manager to
 	// ensure the resource they point to is valid, but not keep the reference.
@@ -253,6 +279,29 @@ func (t *Type) forStream(id string) *Type {
 	return &newT
 }
 
+// ForDryRun returns a variant of this manager for constructing components only
+// in order to validate their configs. Resources can still be accessed, but the
+// endpoints, pipes, metrics and health of the components are not exposed
+// alongside those of running components.
+func (t *Type) ForDryRun() types.Manager {
+	newT := *t
+	newT.apiReg = nil
+	newT.stats = imetrics.NewNamespaced(metrics.Noop())
+	newT.health = health.NewRegistry()
+	newT.pipes = map[string]<-chan types.Transaction{}
+	newT.pipeLock = &sync.RWMutex{}
+	return &newT
+}
+
+// WithFailSources returns a variant of this manager where processors record
+// their label, or their path when they have no label, as the source of the
+// failures that they flag.
+func (t *Type) WithFailSources() types.Manager {
+	newT := *t
+	newT.failSources = true
+	return &newT
+}
+
 // ForComponent returns a variant of this manager to be used by a particular
 // component identifer, where observability components will be automatically
 // tagged with the label.
@@ -305,6 +354,16 @@ func (t *Type) Label() string {
 	return t.component
 }
 
+// newHealthTracker returns a tracker for the health of a new component of a
+// type, which is given to the component when it is wrapped.
+func (t *Type) newHealthTracker(ctype docs.Type) *health.Tracker {
+	path := t.component
+	if len(t.stream) > 0 {
+		path = t.stream + "." + path
+	}
+	return t.health.NewTracker(ctype, path, t.stats)
+}
+
 //------------------------------------------------------------------------------
 
 // RegisterEndpoint registers a server wide HTTP endpoint.
@@ -438,7 +497,12 @@ func (t *Type) NewCache(conf cache.Config) (types.Cache, error) {
 		}
 		mgr = t.forComponent(conf.Label)
 	}
-	return t.env.Caches.Init(conf, mgr)
+	tracker := mgr.newHealthTracker(docs.TypeCache)
+	c, err := t.env.Caches.Init(conf, mgr)
+	if err != nil {
+		return nil, err
+	}
+	return tracker.WrapCache(c), nil
 }
 
 // StoreCache attempts to store a new cache resource. If an existing resource
@@ -504,7 +568,12 @@ func (t *Type) NewInput(conf input.Config, hasBatchProc bool, pipelines ...types
 		}
 		mgr = t.forComponent(conf.Label)
 	}
-	return t.env.Inputs.Init(hasBatchProc, conf, mgr, pipelines...)
+	tracker := mgr.newHealthTracker(docs.TypeInput)
+	i, err := t.env.Inputs.Init(hasBatchProc, conf, mgr, pipelines...)
+	if err != nil {
+		return nil, err
+	}
+	return tracker.WrapInput(i), nil
 }
 
 // StoreInput attempts to store a new input resource. If an existing resource
@@ -573,7 +642,11 @@ func (t *Type) NewProcessor(conf processor.Config) (types.Processor, error) {
 		}
 		mgr = t.forComponent(conf.Label)
 	}
-	return t.env.Processors.Init(conf, mgr)
+	p, err := t.env.Processors.Init(conf, mgr)
+	if err != nil || !mgr.failSources || mgr.component == "" {
+		return p, err
+	}
+	return processor.WithFailSource(mgr.component, p), nil
 }
 
 // StoreProcessor attempts to store a new processor resource. If an existing
@@ -641,7 +714,12 @@ func (t *Type) NewOutput(conf output.Config, pipelines ...types.PipelineConstruc
 		}
 		mgr = t.forComponent(conf.Label)
 	}
-	return t.env.Outputs.Init(conf, mgr, pipelines...)
+	tracker := mgr.newHealthTracker(docs.TypeOutput)
+	o, err := t.env.Outputs.Init(conf, mgr, pipelines...)
+	if err != nil {
+		return nil, err
+	}
+	return tracker.WrapOutput(o), nil
 }
 
 // StoreOutput attempts to store a new output resource. If an existing resource
@@ -713,7 +791,12 @@ func (t *Type) NewRateLimit(conf ratelimit.Config) (types.RateLimit, error) {
 		}
 		mgr = t.forComponent(conf.Label)
 	}
-	return t.env.RateLimits.Init(conf, mgr)
+	tracker := mgr.newHealthTracker(docs.TypeRateLimit)
+	r, err := t.env.RateLimits.Init(conf, mgr)
+	if err != nil {
+		return nil, err
+	}
+	return tracker.WrapRateLimit(r), nil
 }
 
 // StoreRateLimit attempts to store a new rate limit resource. If an existing
@@ -2,14 +2,19 @@ package manager_test
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component/health"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/api"
 	"github.com/Jeffail/benthos/v3/lib/cache"
 	"github.com/Jeffail/benthos/v3/lib/condition"
 	"github.com/Jeffail/benthos/v3/lib/input"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output"
 	"github.com/Jeffail/benthos/v3/lib/processor"
@@ -64,6 +69,70 @@ func TestManagerProcessorLabels(t *testing.T) {
 	}
 }
 
+func TestManagerProcessorFailSources(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = processor.TypeBloblang
+	conf.Bloblang = `root = throw("nope")`
+	conf.Label = "foo"
+
+	getSource := func(mgr types.Manager) string {
+		t.Helper()
+		proc, err := mgr.(interface {
+			NewProcessor(processor.Config) (types.Processor, error)
+		}).NewProcessor(conf)
+		require.NoError(t, err)
+
+		msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("hello world")}))
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+		require.True(t, processor.HasFailed(msgs[0].Get(0)))
+		return processor.GetFailSource(msgs[0].Get(0))
+	}
+
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, "", getSource(mgr))
+	assert.Equal(t, "foo", getSource(mgr.WithFailSources()))
+
+	childConf := output.NewConfig()
+	childConf.Type = output.TypeDrop
+	dlConf := output.NewConfig()
+	dlConf.Type = output.TypeDeadLetter
+	dlConf.DeadLetter.Output = &childConf
+	dlConf.DeadLetter.DeadLetter = &childConf
+
+	resConf := manager.NewResourceConfig()
+	resConf.ResourceOutputs = append(resConf.ResourceOutputs, dlConf)
+	resConf.ResourceOutputs[0].Label = "bar"
+
+	mgr, err = manager.NewV2(resConf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, "foo", getSource(mgr))
+}
+
+func TestManagerForDryRun(t *testing.T) {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	dMgr := mgr.ForDryRun().(*manager.Type)
+
+	outConf := output.NewConfig()
+	outConf.Type = output.TypeInproc
+	outConf.Inproc = "foo"
+	out, err := dMgr.NewOutput(outConf)
+	require.NoError(t, err)
+	require.NoError(t, out.Consume(make(chan types.Transaction)))
+
+	// Pipes of the dry run are not visible to running components.
+	_, err = dMgr.GetPipe("foo")
+	assert.NoError(t, err)
+	_, err = mgr.GetPipe("foo")
+	assert.Equal(t, types.ErrPipeNotFound, err)
+
+	out.CloseAsync()
+	require.NoError(t, out.WaitForClose(time.Second*5))
+}
+
 func TestManagerCache(t *testing.T) {
 	testLog := log.Noop()
 
@@ -515,3 +584,78 @@ func TestManagerPipeGetSet(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestManagerHealth(t *testing.T) {
+	apiServer, err := api.New("", "", api.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := manager.NewResourceConfig()
+	conf.ResourceCaches = append(conf.ResourceCaches, cache.NewConfig())
+	conf.ResourceCaches[0].Label = "foo"
+	conf.ResourceCaches[0].Type = cache.TypeMemory
+
+	conf.ResourceRateLimits = append(conf.ResourceRateLimits, ratelimit.NewConfig())
+	conf.ResourceRateLimits[0].Label = "bar"
+	conf.ResourceRateLimits[0].Type = ratelimit.TypeLocal
+
+	mgr, err := manager.NewV2(conf, apiServer, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	inConf := input.NewConfig()
+	inConf.Type = input.TypeGenerate
+	inConf.Generate.Mapping = `root = "hello world"`
+
+	sMgr := mgr.ForStream("baz").(*manager.Type)
+	in, err := sMgr.ForComponent("input").(*manager.Type).NewInput(inConf, false)
+	require.NoError(t, err)
+
+	outConf := output.NewConfig()
+	outConf.Type = output.TypeFile
+	outConf.File.Path = filepath.Join(t.TempDir(), "out.txt")
+	out, err := sMgr.ForComponent("output").(*manager.Type).NewOutput(outConf)
+	require.NoError(t, err)
+	require.NoError(t, out.Consume(in.TransactionChan()))
+
+	paths := func() (p []string) {
+		for _, r := range apiServer.Health().Reports() {
+			p = append(p, string(r.Type)+":"+r.Path)
+		}
+		return
+	}
+	assert.Equal(t, []string{
+		"rate_limit:bar",
+		"input:baz.input",
+		"output:baz.output",
+		"cache:foo",
+	}, paths())
+
+	assert.Eventually(t, func() bool {
+		succeeded := 0
+		for _, r := range apiServer.Health().Reports() {
+			if (r.Path == "baz.input" || r.Path == "baz.output") && r.Connected && r.LastSuccessAt != nil {
+				succeeded++
+			}
+		}
+		return succeeded == 2
+	}, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, mgr.AccessCache(context.Background(), "foo", func(c types.Cache) {
+		_, err := c.Get("not_exist")
+		assert.Equal(t, types.ErrKeyNotFound, err)
+	}))
+	for _, r := range apiServer.Health().Reports() {
+		assert.Equal(t, health.StatusHealthy, r.Status, r.Path)
+	}
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second*5))
+	require.NoError(t, out.WaitForClose(time.Second*5))
+	assert.Equal(t, []string{
+		"rate_limit:bar",
+		"cache:foo",
+	}, paths())
+
+	mgr.CloseAsync()
+	require.NoError(t, mgr.WaitForClose(time.Second*5))
+	assert.Empty(t, paths())
+}
@@ -101,6 +101,7 @@ type Config struct {
 	Statsd        StatsdConfig     `json:"statsd" yaml:"statsd"`
 	Stdout        StdoutConfig     `json:"stdout" yaml:"stdout"`
 	Whitelist     WhitelistConfig  `json:"whitelist" yaml:"whitelist"`
+	Plugin        interface{}      `json:"plugin,omitempty" yaml:"plugin,omitempty"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
@@ -118,6 +119,7 @@ func NewConfig() Config {
 		Statsd:        NewStatsdConfig(),
 		Stdout:        NewStdoutConfig(),
 		Whitelist:     NewWhitelistConfig(),
+		Plugin:        nil,
 	}
 }
 
@@ -172,10 +174,21 @@ func (conf *Config) UnmarshalYAML(value *yaml.Node) error {
 		return fmt.Errorf("line %v: %v", value.Line, err)
 	}
 
-	if aliased.Type, _, err = docs.GetInferenceCandidateFromYAML(nil, docs.TypeMetrics, aliased.Type, value); err != nil {
+	var spec docs.ComponentSpec
+	if aliased.Type, spec, err = docs.GetInferenceCandidateFromYAML(nil, docs.TypeMetrics, aliased.Type, value); err != nil {
 		return fmt.Errorf("line %v: %w", value.Line, err)
 	}
 
+	if spec.Plugin {
+		pluginNode, err := docs.GetPluginConfigYAML(aliased.Type, value)
+		if err != nil {
+			return fmt.Errorf("line %v: %v", value.Line, err)
+		}
+		aliased.Plugin = &pluginNode
+	} else {
+		aliased.Plugin = nil
+	}
+
 	*conf = Config(aliased)
 	return nil
 }
@@ -2,12 +2,14 @@ package output
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/component/health"
 	"github.com/Jeffail/benthos/v3/internal/interop"
 	"github.com/Jeffail/benthos/v3/internal/shutdown"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -48,9 +50,10 @@ type AsyncWriter struct {
 
 	injectTracingMap *mapping.Executor
 
-	mgr   types.Manager
-	log   log.Modular
-	stats metrics.Type
+	mgr    types.Manager
+	log    log.Modular
+	stats  metrics.Type
+	health health.ReporterField
 
 	transactions <-chan types.Transaction
 
@@ -203,6 +206,7 @@ func (w *AsyncWriter) loop() {
 					return false
 				}
 				w.log.Errorf("Failed to connect to %v: %v\n", w.typeStr, err)
+				w.health.Get().Error(fmt.Sprintf("failed to connect: %v", err))
 				mFailedConn.Incr(1)
 				select {
 				case <-time.After(connBackoff.NextBackOff()):
@@ -219,6 +223,7 @@ func (w *AsyncWriter) loop() {
 		return
 	}
 	mConn.Incr(1)
+	w.health.Get().Connected()
 	atomic.StoreInt32(&w.isConnected, 1)
 
 	wg := sync.WaitGroup{}
@@ -239,6 +244,7 @@ func (w *AsyncWriter) loop() {
 			}
 		}
 		mLostConn.Incr(1)
+		w.health.Get().Reconnect()
 
 		// Continue to try to reconnect while still active.
 		for {
@@ -249,6 +255,7 @@ func (w *AsyncWriter) loop() {
 			if latency, err = w.latencyMeasuringWrite(msg); err != types.ErrNotConnected {
 				atomic.StoreInt32(&w.isConnected, 1)
 				mConn.Incr(1)
+				w.health.Get().Connected()
 				return
 			}
 		}
@@ -291,6 +298,7 @@ func (w *AsyncWriter) loop() {
 					// TODO: Maybe reintroduce a sleep here if we encounter a
 					// busy retry loop.
 					w.log.Errorf("Failed to send message to %v: %v\n", w.typeStr, err)
+					w.health.Get().Error(fmt.Sprintf("failed to send message: %v", err))
 				} else {
 					w.log.Debugf("Rejecting message: %v\n", err)
 				}
@@ -299,6 +307,7 @@ func (w *AsyncWriter) loop() {
 				mPartsSent.Incr(int64(batch.MessageCollapsedCount(ts.Payload)))
 				mBytesSent.Incr(int64(message.GetAllBytesLen(ts.Payload)))
 				mLatency.Timing(latency)
+				w.health.Get().Success()
 				w.log.Tracef("Successfully wrote %v messages to '%v'.\n", ts.Payload.Len(), w.typeStr)
 			}
 
@@ -330,6 +339,12 @@ func (w *AsyncWriter) Consume(ts <-chan types.Transaction) error {
 	return nil
 }
 
+// SetHealthReporter sets the reporter that the health of the output is
+// reported to from the results of connecting and writing messages.
+func (w *AsyncWriter) SetHealthReporter(r health.Reporter) {
+	w.health.Set(r)
+}
+
 // Connected returns a boolean indicating whether this output is currently
 // connected to its target.
 func (w *AsyncWriter) Connected() bool {
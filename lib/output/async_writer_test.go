@@ -7,10 +7,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component/health"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //------------------------------------------------------------------------------
@@ -635,3 +638,69 @@ func TestAsyncWriterSadPath(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+
+type mockHealthReporter struct {
+	events chan string
+}
+
+func (m *mockHealthReporter) Connected()       { m.events <- "connected" }
+func (m *mockHealthReporter) Reconnect()       { m.events <- "reconnect" }
+func (m *mockHealthReporter) Success()         { m.events <- "success" }
+func (m *mockHealthReporter) Error(err string) { m.events <- "error: " + err }
+
+func TestAsyncWriterHealth(t *testing.T) {
+	t.Parallel()
+
+	writerImpl := newAsyncMockWriter()
+	reporter := &mockHealthReporter{events: make(chan string, 10)}
+
+	w, err := NewAsyncWriter(
+		"foo", 1, writerImpl,
+		log.Noop(), metrics.Noop(),
+	)
+	require.NoError(t, err)
+
+	// The reporter should be forwarded by wrappers of the writer.
+	w = OnlySinglePayloads(w)
+	health.SetReporter(w, reporter)
+
+	msgChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, w.Consume(msgChan))
+
+	nextEvent := func() string {
+		t.Helper()
+		select {
+		case e := <-reporter.events:
+			return e
+		case <-time.After(time.Second * 5):
+			t.Fatal("Timed out")
+		}
+		return ""
+	}
+
+	writerImpl.connChan <- errors.New("nope")
+	assert.Equal(t, "error: failed to connect: nope", nextEvent())
+
+	writerImpl.connChan <- nil
+	assert.Equal(t, "connected", nextEvent())
+
+	msgChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan)
+	writerImpl.writeChan <- errors.New("nope")
+	assert.Equal(t, "error: failed to send message: nope", nextEvent())
+	assert.Error(t, (<-resChan).Error())
+
+	msgChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan)
+	writerImpl.writeChan <- types.ErrNotConnected
+	assert.Equal(t, "reconnect", nextEvent())
+	writerImpl.connChan <- nil
+	writerImpl.writeChan <- nil
+	assert.Equal(t, "connected", nextEvent())
+	assert.Equal(t, "success", nextEvent())
+	assert.NoError(t, (<-resChan).Error())
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second*5))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component/health"
 	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/internal/interop"
 	"github.com/Jeffail/benthos/v3/internal/shutdown"
@@ -164,6 +165,11 @@ func (m *Batcher) loop() {
 	}
 }
 
+// SetHealthReporter forwards a health reporter to the wrapped output.
+func (m *Batcher) SetHealthReporter(r health.Reporter) {
+	health.SetReporter(m.child, r)
+}
+
 // Connected returns a boolean indicating whether this output is currently
 // connected to its target.
 func (m *Batcher) Connected() bool {
@@ -23,6 +23,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/util/retries"
 	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 	"github.com/gocql/gocql"
+	"gopkg.in/yaml.v3"
 )
 
 //------------------------------------------------------------------------------
@@ -42,6 +43,13 @@ func init() {
 			}
 			return NewBatcherFromConfig(conf.Cassandra.Batching, w, mgr, log, stats)
 		}),
+		fixer: func(node *yaml.Node) (bool, error) {
+			conf, err := docs.GetYAMLPath(node, TypeCassandra)
+			if err != nil || conf.Kind != yaml.MappingNode {
+				return false, nil
+			}
+			return docs.ReplaceInterpolatedArgsYAML(conf, "args", "args_mapping")
+		},
 		Status:  docs.StatusBeta,
 		Batches: true,
 		Async:   true,
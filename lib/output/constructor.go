@@ -52,6 +52,7 @@ type TypeSpec struct {
 	FieldSpecs  docs.FieldSpecs
 	Examples    []docs.AnnotatedExample
 	Version     string
+	fixer       docs.ComponentFixFunc
 }
 
 // AppendProcessorsFromConfig takes a variant arg of pipeline constructor
@@ -126,6 +127,7 @@ func WalkConstructors(fn func(ConstructorFunc, docs.ComponentSpec)) {
 			Examples:    v.Examples,
 			Status:      v.Status,
 			Version:     v.Version,
+			Fixer:       v.fixer,
 		}
 		if len(v.Categories) > 0 {
 			spec.Categories = make([]string, 0, len(v.Categories))
@@ -171,6 +173,7 @@ const (
 	TypeBroker             = "broker"
 	TypeCache              = "cache"
 	TypeCassandra          = "cassandra"
+	TypeDeadLetter         = "dead_letter"
 	TypeDrop               = "drop"
 	TypeDropOn             = "drop_on"
 	TypeDropOnError        = "drop_on_error"
@@ -243,6 +246,7 @@ type Config struct {
 	Broker             BrokerConfig                   `json:"broker" yaml:"broker"`
 	Cache              writer.CacheConfig             `json:"cache" yaml:"cache"`
 	Cassandra          CassandraConfig                `json:"cassandra" yaml:"cassandra"`
+	DeadLetter         DeadLetterConfig               `json:"dead_letter" yaml:"dead_letter"`
 	Drop               writer.DropConfig              `json:"drop" yaml:"drop"`
 	DropOn             DropOnConfig                   `json:"drop_on" yaml:"drop_on"`
 	DropOnError        DropOnErrorConfig              `json:"drop_on_error" yaml:"drop_on_error"`
@@ -316,6 +320,7 @@ func NewConfig() Config {
 		Broker:             NewBrokerConfig(),
 		Cache:              writer.NewCacheConfig(),
 		Cassandra:          NewCassandraConfig(),
+		DeadLetter:         NewDeadLetterConfig(),
 		Drop:               writer.NewDropConfig(),
 		DropOn:             NewDropOnConfig(),
 		DropOnError:        NewDropOnErrorConfig(),
@@ -0,0 +1,548 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/internal/deadletter"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/internal/shutdown"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
+	"github.com/cenkalti/backoff/v4"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeDeadLetter] = TypeSpec{
+		constructor: fromSimpleConstructor(NewDeadLetter),
+		Status:      docs.StatusExperimental,
+		Summary: `
+Writes messages to a child output and routes any messages that fail, either
+during processing or when being written, to a dead letter output along with the
+context of their failure.`,
+		Description: `
+Messages that have been flagged with a processing error, which can be checked
+with the Bloblang function ` + "`errored()`" + `, are sent directly to the
+` + "`dead_letter`" + ` output. All other messages are written to the child
+` + "`output`" + `, and if a write fails it is retried according to the retry
+fields, after which the messages are sent to the ` + "`dead_letter`" + ` output.
+A message is only acknowledged once it has been written to either output.
+
+### Envelope
+
+Each message sent to the ` + "`dead_letter`" + ` output is wrapped in a JSON
+envelope containing the original contents and metadata of the message along with
+the context of the failure:
+
+` + "```json" + `
+{
+  "error": "failed to connect to foo",
+  "stage": "output",
+  "component": "http_client",
+  "attempts": 4,
+  "content": "{\"id\":\"foo\"}",
+  "metadata": {"kafka_key": "foo"},
+  "timestamp": "2021-06-01T10:00:00Z"
+}
+` + "```" + `
+
+The ` + "`stage`" + ` is either ` + "`pipeline`" + ` for messages that failed
+processing or ` + "`output`" + ` for messages that could not be written. For
+messages that failed processing the ` + "`component`" + ` is the label of the
+processor that failed, or its path (e.g. ` + "`pipeline.processor.0`" + `) when it
+has no label. For messages that could not be written it is the label of the
+child output, or its type when it has no label. The contents of a message are
+base64 encoded when they are not valid UTF-8, in which case the field
+` + "`content_encoding`" + ` is set to ` + "`base64`" + `.
+
+The fields ` + "`error`, `stage`, `component` and `attempts`" + ` are also
+added to the envelope message as the metadata fields
+` + "`dead_letter_error`, `dead_letter_stage`, `dead_letter_component` and `dead_letter_attempts`" + `
+respectively.
+
+### Replaying
+
+Dead lettered messages can be replayed with the ` + "`benthos dlq replay`" + `
+subcommand, which unwraps their envelopes and writes them to the output of a
+config:
+
+` + "```sh" + `
+benthos -c ./config.yaml dlq replay ./dead_letters.jsonl
+` + "```" + `
+
+The content of a message that failed processing is captured after the pipeline
+has run, and therefore replayed messages skip the pipeline by default. The
+` + "`--reprocess`" + ` flag sends messages that failed processing through the
+pipeline again, which is only safe when the processors can be run more than
+once on the same message.`,
+		FieldSpecs: retries.FieldSpecs().Add(
+			docs.FieldCommon("output", "The child output to write messages to.").HasType(docs.FieldTypeOutput),
+			docs.FieldCommon("dead_letter", "An output to write failed messages to, wrapped in an envelope describing their failure.").HasType(docs.FieldTypeOutput),
+		),
+		Categories: []Category{
+			CategoryUtility,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Dead Letter File",
+				Summary: `
+Here we write messages to Kafka and write any messages that fail, either because
+a processor errored or because they could not be delivered after four attempts,
+to a file as line delimited JSON.`,
+				Config: `
+pipeline:
+  processors:
+    - bloblang: 'root = this.without("secret")'
+
+output:
+  dead_letter:
+    output:
+      kafka:
+        addresses: [ localhost:9092 ]
+        topic: foo
+    dead_letter:
+      file:
+        path: ./dead_letters.jsonl
+        codec: lines
+    max_retries: 3
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// DeadLetterConfig contains configuration values for the DeadLetter output
+// type.
+type DeadLetterConfig struct {
+	Output         *Config `json:"output" yaml:"output"`
+	DeadLetter     *Config `json:"dead_letter" yaml:"dead_letter"`
+	retries.Config `json:",inline" yaml:",inline"`
+}
+
+// NewDeadLetterConfig creates a new DeadLetterConfig with default values.
+func NewDeadLetterConfig() DeadLetterConfig {
+	rConf := retries.NewConfig()
+	rConf.MaxRetries = 3
+	return DeadLetterConfig{
+		Output:     nil,
+		DeadLetter: nil,
+		Config:     rConf,
+	}
+}
+
+// ContainsDeadLetter returns whether an output config, or any output nested
+// within it, is a dead_letter output.
+func ContainsDeadLetter(conf Config) bool {
+	if conf.Type == TypeDeadLetter {
+		return true
+	}
+
+	// Only the config of the selected type can contain nested outputs.
+	v := reflect.ValueOf(conf)
+	for i := 0; i < v.NumField(); i++ {
+		tag := strings.Split(v.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == conf.Type {
+			return containsDeadLetterValue(v.Field(i))
+		}
+	}
+	return false
+}
+
+func containsDeadLetterValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return containsDeadLetterValue(v.Elem())
+		}
+	case reflect.Struct:
+		if v.CanInterface() {
+			if conf, ok := v.Interface().(Config); ok {
+				return ContainsDeadLetter(conf)
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if containsDeadLetterValue(v.Field(i)) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if containsDeadLetterValue(v.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if containsDeadLetterValue(iter.Value()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+type dummyDeadLetterConfig struct {
+	Output         interface{} `json:"output" yaml:"output"`
+	DeadLetter     interface{} `json:"dead_letter" yaml:"dead_letter"`
+	retries.Config `json:",inline" yaml:",inline"`
+}
+
+func (d DeadLetterConfig) dummy() dummyDeadLetterConfig {
+	dummy := dummyDeadLetterConfig{
+		Output:     d.Output,
+		DeadLetter: d.DeadLetter,
+		Config:     d.Config,
+	}
+	if d.Output == nil {
+		dummy.Output = struct{}{}
+	}
+	if d.DeadLetter == nil {
+		dummy.DeadLetter = struct{}{}
+	}
+	return dummy
+}
+
+// MarshalJSON prints empty objects instead of nil.
+func (d DeadLetterConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.dummy())
+}
+
+// MarshalYAML prints empty objects instead of nil.
+func (d DeadLetterConfig) MarshalYAML() (interface{}, error) {
+	return d.dummy(), nil
+}
+
+//------------------------------------------------------------------------------
+
+// DeadLetter is an output type that writes messages to a child output, and
+// routes messages that fail processing or writing to a dead letter output.
+type DeadLetter struct {
+	running   int32
+	component string
+
+	primary     Type
+	deadLetter  Type
+	backoffCtor func() backoff.BackOff
+	maxInFlight int
+
+	stats metrics.Type
+	log   log.Modular
+
+	transactionsIn <-chan types.Transaction
+	primaryOut     chan types.Transaction
+	deadLetterOut  chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewDeadLetter creates a new DeadLetter output type.
+func NewDeadLetter(
+	conf Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (Type, error) {
+	if conf.DeadLetter.Output == nil {
+		return nil, errors.New("cannot create dead_letter output without a child output")
+	}
+	if conf.DeadLetter.DeadLetter == nil {
+		return nil, errors.New("cannot create dead_letter output without a dead_letter output")
+	}
+
+	pMgr, pLog, pStats := interop.LabelChild("dead_letter.output", mgr, log, stats)
+	primary, err := New(*conf.DeadLetter.Output, pMgr, pLog, pStats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output '%v': %v", conf.DeadLetter.Output.Type, err)
+	}
+
+	dMgr, dLog, dStats := interop.LabelChild("dead_letter.dead_letter", mgr, log, stats)
+	deadLetter, err := New(*conf.DeadLetter.DeadLetter, dMgr, dLog, dStats)
+	if err != nil {
+		primary.CloseAsync()
+		return nil, fmt.Errorf("failed to create dead letter output '%v': %v", conf.DeadLetter.DeadLetter.Type, err)
+	}
+
+	var boffCtor func() backoff.BackOff
+	if boffCtor, err = conf.DeadLetter.GetCtor(); err != nil {
+		primary.CloseAsync()
+		deadLetter.CloseAsync()
+		return nil, err
+	}
+
+	component := conf.DeadLetter.Output.Label
+	if component == "" {
+		component = conf.DeadLetter.Output.Type
+	}
+
+	maxInFlight := 1
+	if mif, ok := output.GetMaxInFlight(primary); ok && mif > maxInFlight {
+		maxInFlight = mif
+	}
+
+	return &DeadLetter{
+		running:   1,
+		component: component,
+
+		primary:     primary,
+		deadLetter:  deadLetter,
+		backoffCtor: boffCtor,
+		maxInFlight: maxInFlight,
+
+		log:           log,
+		stats:         stats,
+		primaryOut:    make(chan types.Transaction),
+		deadLetterOut: make(chan types.Transaction),
+
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// send writes a message to an output until it succeeds, or until the backoff
+// is exhausted, and returns the number of attempts made along with the last
+// error.
+func (d *DeadLetter) send(out chan types.Transaction, msg types.Message, boff backoff.BackOff) (attempts int, err error) {
+	for {
+		attempts++
+
+		resChan := make(chan types.Response)
+		select {
+		case out <- types.NewTransaction(msg, resChan):
+		case <-d.closeChan:
+			return attempts, types.ErrTypeClosed
+		}
+
+		select {
+		case res := <-resChan:
+			err = res.Error()
+		case <-d.closeChan:
+			return attempts, types.ErrTypeClosed
+		}
+		if err == nil || boff == nil {
+			return
+		}
+
+		nextBackoff := boff.NextBackOff()
+		if nextBackoff == backoff.Stop {
+			return
+		}
+		d.log.Warnf("Failed to send message: %v\n", err)
+		select {
+		case <-time.After(nextBackoff):
+		case <-d.closeChan:
+			return attempts, types.ErrTypeClosed
+		}
+	}
+}
+
+// handle writes a message to the child output and its failed messages to the
+// dead letter output, returning an error only when a failed message could not
+// be written to the dead letter output.
+func (d *DeadLetter) handle(msg types.Message, mSent, mDeadLettered metrics.StatCounter) error {
+	valid := message.New(nil)
+	var deadParts []types.Part
+
+	var wrapErr error
+	_ = msg.Iter(func(i int, p types.Part) error {
+		failErr := p.Metadata().Get(types.FailFlagKey)
+		if failErr == "" {
+			valid.Append(p)
+			return nil
+		}
+		component := p.Metadata().Get(types.FailFlagSourceKey)
+		if component == "" {
+			component = deadletter.StagePipeline
+		}
+		var wrapped types.Part
+		if wrapped, wrapErr = deadletter.Wrap(p, deadletter.Failure{
+			Error:     failErr,
+			Stage:     deadletter.StagePipeline,
+			Component: component,
+		}); wrapErr != nil {
+			return wrapErr
+		}
+		deadParts = append(deadParts, wrapped)
+		return nil
+	})
+	if wrapErr != nil {
+		return wrapErr
+	}
+
+	if valid.Len() > 0 {
+		attempts, sendErr := d.send(d.primaryOut, valid, d.backoffCtor())
+		if sendErr == types.ErrTypeClosed {
+			return sendErr
+		}
+		if sendErr == nil {
+			mSent.Incr(int64(valid.Len()))
+		} else {
+			d.log.Errorf("Failed to send message, routing to dead letter output: %v\n", sendErr)
+			if wrapErr = valid.Iter(func(i int, p types.Part) error {
+				wrapped, err := deadletter.Wrap(p, deadletter.Failure{
+					Error:     sendErr.Error(),
+					Stage:     deadletter.StageOutput,
+					Component: d.component,
+					Attempts:  attempts,
+				})
+				if err != nil {
+					return err
+				}
+				deadParts = append(deadParts, wrapped)
+				return nil
+			}); wrapErr != nil {
+				return wrapErr
+			}
+		}
+	}
+
+	if len(deadParts) == 0 {
+		return nil
+	}
+
+	deadMsg := message.New(nil)
+	deadMsg.SetAll(deadParts)
+	if _, err := d.send(d.deadLetterOut, deadMsg, nil); err != nil {
+		return err
+	}
+	mDeadLettered.Incr(int64(len(deadParts)))
+	return nil
+}
+
+func (d *DeadLetter) loop() {
+	var (
+		mRunning      = d.stats.GetGauge("dead_letter.running")
+		mCount        = d.stats.GetCounter("dead_letter.count")
+		mSent         = d.stats.GetCounter("dead_letter.sent")
+		mDeadLettered = d.stats.GetCounter("dead_letter.dead_lettered")
+		mError        = d.stats.GetCounter("dead_letter.error")
+	)
+
+	wg := sync.WaitGroup{}
+	inFlightSem := make(chan struct{}, d.maxInFlight)
+
+	defer func() {
+		wg.Wait()
+		close(d.primaryOut)
+		close(d.deadLetterOut)
+		d.primary.CloseAsync()
+		d.deadLetter.CloseAsync()
+		_ = d.primary.WaitForClose(shutdown.MaximumShutdownWait())
+		_ = d.deadLetter.WaitForClose(shutdown.MaximumShutdownWait())
+		mRunning.Decr(1)
+		close(d.closedChan)
+	}()
+	mRunning.Incr(1)
+
+	for atomic.LoadInt32(&d.running) == 1 {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-d.transactionsIn:
+			if !open {
+				return
+			}
+			mCount.Incr(1)
+		case <-d.closeChan:
+			return
+		}
+
+		select {
+		case inFlightSem <- struct{}{}:
+		case <-d.closeChan:
+			return
+		}
+
+		wg.Add(1)
+		go func(ts types.Transaction) {
+			defer func() {
+				<-inFlightSem
+				wg.Done()
+			}()
+
+			var res types.Response = response.NewAck()
+			if err := d.handle(ts.Payload, mSent, mDeadLettered); err != nil {
+				if err == types.ErrTypeClosed {
+					return
+				}
+				mError.Incr(1)
+				d.log.Errorf("Failed to send message to dead letter output: %v\n", err)
+				res = response.NewError(err)
+			}
+
+			select {
+			case ts.ResponseChan <- res:
+			case <-d.closeChan:
+			}
+		}(tran)
+	}
+}
+
+// Consume assigns a messages channel for the output to read.
+func (d *DeadLetter) Consume(ts <-chan types.Transaction) error {
+	if d.transactionsIn != nil {
+		return types.ErrAlreadyStarted
+	}
+	if err := d.primary.Consume(d.primaryOut); err != nil {
+		return err
+	}
+	if err := d.deadLetter.Consume(d.deadLetterOut); err != nil {
+		return err
+	}
+	d.transactionsIn = ts
+	go d.loop()
+	return nil
+}
+
+// Connected returns a boolean indicating whether both the child output and the
+// dead letter output are currently connected to their targets.
+func (d *DeadLetter) Connected() bool {
+	return d.primary.Connected() && d.deadLetter.Connected()
+}
+
+// MaxInFlight returns the maximum number of in flight messages permitted by the
+// output. This value can be used to determine a sensible value for parent
+// outputs, but should not be relied upon as part of dispatcher logic.
+func (d *DeadLetter) MaxInFlight() (int, bool) {
+	return d.maxInFlight, true
+}
+
+// CloseAsync shuts down the DeadLetter output and stops processing messages.
+func (d *DeadLetter) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&d.running, 1, 0) {
+		close(d.closeChan)
+	}
+}
+
+// WaitForClose blocks until the DeadLetter output has closed down.
+func (d *DeadLetter) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-d.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,237 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/deadletter"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterConfigErrs(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeDeadLetter
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	childConf := NewConfig()
+	childConf.Type = TypeDrop
+	conf.DeadLetter.Output = &childConf
+
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.DeadLetter.DeadLetter = &childConf
+	conf.DeadLetter.Backoff.InitialInterval = "not a time period"
+
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func newTestDeadLetter(t *testing.T) (*DeadLetter, *mockOutput, *mockOutput) {
+	t.Helper()
+
+	conf := NewConfig()
+	childConf := NewConfig()
+	childConf.Type = TypeDrop
+	childConf.Label = "foo"
+	conf.DeadLetter.Output = &childConf
+	conf.DeadLetter.DeadLetter = &childConf
+	conf.DeadLetter.MaxRetries = 1
+	conf.DeadLetter.Backoff.InitialInterval = "1ms"
+	conf.DeadLetter.Backoff.MaxInterval = "1ms"
+
+	output, err := NewDeadLetter(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	dl, ok := output.(*DeadLetter)
+	require.True(t, ok)
+
+	dl.primary.CloseAsync()
+	dl.deadLetter.CloseAsync()
+
+	primary, deadLetter := &mockOutput{}, &mockOutput{}
+	dl.primary, dl.deadLetter = primary, deadLetter
+	return dl, primary, deadLetter
+}
+
+func receiveTran(t *testing.T, ts <-chan types.Transaction) types.Transaction {
+	t.Helper()
+	select {
+	case tran := <-ts:
+		return tran
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	return types.Transaction{}
+}
+
+func sendRes(t *testing.T, resChan chan<- types.Response, res types.Response) {
+	t.Helper()
+	select {
+	case resChan <- res:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+}
+
+func TestContainsDeadLetter(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeDrop
+	assert.False(t, ContainsDeadLetter(conf))
+
+	childConf := NewConfig()
+	childConf.Type = TypeDrop
+	dlConf := NewConfig()
+	dlConf.Type = TypeDeadLetter
+	dlConf.DeadLetter.Output = &childConf
+	dlConf.DeadLetter.DeadLetter = &childConf
+	assert.True(t, ContainsDeadLetter(dlConf))
+
+	conf = NewConfig()
+	conf.Type = TypeBroker
+	conf.Broker.Outputs = append(conf.Broker.Outputs, childConf)
+	assert.False(t, ContainsDeadLetter(conf))
+
+	conf.Broker.Outputs = append(conf.Broker.Outputs, dlConf)
+	assert.True(t, ContainsDeadLetter(conf))
+
+	retryConf := NewConfig()
+	retryConf.Type = TypeRetry
+	retryConf.Retry.Output = &conf
+	assert.True(t, ContainsDeadLetter(retryConf))
+
+	// Configs of types other than the selected one are ignored.
+	retryConf.Type = TypeDrop
+	assert.False(t, ContainsDeadLetter(retryConf))
+}
+
+func TestDeadLetterRouting(t *testing.T) {
+	dl, primary, deadLetter := newTestDeadLetter(t)
+
+	tChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, dl.Consume(tChan))
+
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+	msg.Get(1).Metadata().Set(types.FailFlagKey, "bar failed")
+	msg.Get(1).Metadata().Set(types.FailFlagSourceKey, "pipeline.processor.1")
+	msg.Get(2).Metadata().Set("baz_key", "baz_value")
+
+	select {
+	case tChan <- types.NewTransaction(msg, resChan):
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	// Messages that failed processing should skip the primary output.
+	tran := receiveTran(t, primary.ts)
+	assert.Equal(t, [][]byte{[]byte("foo"), []byte("baz")}, message.GetAllBytes(tran.Payload))
+	sendRes(t, tran.ResponseChan, response.NewError(errors.New("first")))
+
+	tran = receiveTran(t, primary.ts)
+	sendRes(t, tran.ResponseChan, response.NewError(errors.New("second")))
+
+	tran = receiveTran(t, deadLetter.ts)
+	require.Equal(t, 3, tran.Payload.Len())
+
+	var envs []deadletter.Envelope
+	for i := 0; i < tran.Payload.Len(); i++ {
+		var env deadletter.Envelope
+		require.NoError(t, json.Unmarshal(tran.Payload.Get(i).Get(), &env))
+		envs = append(envs, env)
+	}
+
+	assert.Equal(t, "bar", envs[0].Content)
+	assert.Equal(t, deadletter.Failure{
+		Error:     "bar failed",
+		Stage:     deadletter.StagePipeline,
+		Component: "pipeline.processor.1",
+	}, envs[0].Failure)
+	assert.Empty(t, envs[0].Metadata)
+
+	assert.Equal(t, "foo", envs[1].Content)
+	assert.Equal(t, deadletter.Failure{
+		Error:     "second",
+		Stage:     deadletter.StageOutput,
+		Component: "foo",
+		Attempts:  2,
+	}, envs[1].Failure)
+
+	assert.Equal(t, "baz", envs[2].Content)
+	assert.Equal(t, map[string]string{"baz_key": "baz_value"}, envs[2].Metadata)
+	assert.Equal(t, "2", tran.Payload.Get(2).Metadata().Get(deadletter.MetaAttempts))
+
+	// Acknowledging is held until the dead letter output succeeds.
+	select {
+	case <-resChan:
+		t.Fatal("acknowledged before dead letter was written")
+	default:
+	}
+	sendRes(t, tran.ResponseChan, response.NewAck())
+
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	close(tChan)
+	require.NoError(t, dl.WaitForClose(time.Second*5))
+}
+
+func TestDeadLetterHappyAndSadPath(t *testing.T) {
+	dl, primary, deadLetter := newTestDeadLetter(t)
+
+	tChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, dl.Consume(tChan))
+
+	send := func(msg types.Message) {
+		t.Helper()
+		select {
+		case tChan <- types.NewTransaction(msg, resChan):
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+	}
+
+	send(message.New([][]byte{[]byte("foo")}))
+	tran := receiveTran(t, primary.ts)
+	sendRes(t, tran.ResponseChan, response.NewAck())
+
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	// A failure to write to the dead letter output is propagated upstream.
+	msg := message.New([][]byte{[]byte("bar")})
+	msg.Get(0).Metadata().Set(types.FailFlagKey, "bar failed")
+	send(msg)
+
+	tran = receiveTran(t, deadLetter.ts)
+	sendRes(t, tran.ResponseChan, response.NewError(errors.New("dead letter failed")))
+
+	select {
+	case res := <-resChan:
+		assert.EqualError(t, res.Error(), "dead letter failed")
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	dl.CloseAsync()
+	require.NoError(t, dl.WaitForClose(time.Second*5))
+}
@@ -180,7 +180,7 @@ func (w *fileWriter) WriteWithContext(ctx context.Context, msg types.Message) er
 		return err
 	}
 
-	if msg.Len() > 1 {
+	if msg.Len() > 1 || w.codecConf.AlwaysEndBatch {
 		w.handleMut.Lock()
 		if w.handle != nil {
 			w.handle.EndBatch()
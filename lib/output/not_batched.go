@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/component/health"
 	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/internal/shutdown"
 	"github.com/Jeffail/benthos/v3/lib/message"
@@ -162,6 +163,10 @@ func (n *notBatchedOutput) MaxInFlight() (int, bool) {
 	return output.GetMaxInFlight(n.out)
 }
 
+func (n *notBatchedOutput) SetHealthReporter(r health.Reporter) {
+	health.SetReporter(n.out, r)
+}
+
 func (n *notBatchedOutput) Connected() bool {
 	return n.out.Connected()
 }
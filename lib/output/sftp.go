@@ -153,7 +153,7 @@ func (s *sftpWriter) WriteWithContext(ctx context.Context, msg types.Message) er
 		return types.ErrNotConnected
 	}
 
-	return writer.IterateBatchedSend(msg, func(i int, p types.Part) error {
+	err := writer.IterateBatchedSend(msg, func(i int, p types.Part) error {
 		path := s.path.String(i, msg)
 
 		s.handleMut.Lock()
@@ -204,6 +204,18 @@ func (s *sftpWriter) WriteWithContext(ctx context.Context, msg types.Message) er
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	if msg.Len() > 1 || s.codecConf.AlwaysEndBatch {
+		s.handleMut.Lock()
+		if s.handle != nil {
+			s.handle.EndBatch()
+		}
+		s.handleMut.Unlock()
+	}
+	return nil
 }
 
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
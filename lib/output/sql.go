@@ -18,6 +18,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"gopkg.in/yaml.v3"
 
 	// SQL Drivers
 	_ "github.com/ClickHouse/clickhouse-go"
@@ -47,6 +48,13 @@ func init() {
 			}
 			return NewBatcherFromConfig(conf.SQL.Batching, w, mgr, log, stats)
 		}),
+		fixer: func(node *yaml.Node) (bool, error) {
+			conf, err := docs.GetYAMLPath(node, TypeSQL)
+			if err != nil || conf.Kind != yaml.MappingNode {
+				return false, nil
+			}
+			return docs.ReplaceInterpolatedArgsYAML(conf, "args", "args_mapping")
+		},
 		Status:  docs.StatusBeta,
 		Batches: true,
 		Async:   true,
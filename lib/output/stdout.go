@@ -85,12 +85,13 @@ func NewSTDOUT(conf Config, mgr types.Manager, log log.Modular, stats metrics.Ty
 }
 
 type stdoutWriter struct {
-	handle  codec.Writer
-	shutSig *shutdown.Signaller
+	handle    codec.Writer
+	codecConf codec.WriterConfig
+	shutSig   *shutdown.Signaller
 }
 
 func newStdoutWriter(codecStr string, log log.Modular, stats metrics.Type) (*stdoutWriter, error) {
-	codec, _, err := codec.GetWriter(codecStr)
+	codec, codecConf, err := codec.GetWriter(codecStr)
 	if err != nil {
 		return nil, err
 	}
@@ -101,8 +102,9 @@ func newStdoutWriter(codecStr string, log log.Modular, stats metrics.Type) (*std
 	}
 
 	return &stdoutWriter{
-		handle:  handle,
-		shutSig: shutdown.NewSignaller(),
+		handle:    handle,
+		codecConf: codecConf,
+		shutSig:   shutdown.NewSignaller(),
 	}, nil
 }
 
@@ -117,7 +119,7 @@ func (w *stdoutWriter) WriteWithContext(ctx context.Context, msg types.Message)
 	if err != nil {
 		return err
 	}
-	if msg.Len() > 1 {
+	if msg.Len() > 1 || w.codecConf.AlwaysEndBatch {
 		if w.handle != nil {
 			w.handle.EndBatch()
 		}
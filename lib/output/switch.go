@@ -23,6 +23,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/util/throttle"
 	"github.com/Jeffail/gabs/v2"
 	"golang.org/x/sync/errgroup"
+	"gopkg.in/yaml.v3"
 )
 
 //------------------------------------------------------------------------------
@@ -44,6 +45,7 @@ var (
 func init() {
 	Constructors[TypeSwitch] = TypeSpec{
 		constructor: fromSimpleConstructor(NewSwitch),
+		fixer:       fixSwitchYAML,
 		Summary: `
 The switch output type allows you to route messages to different outputs based on their contents.`,
 		Description: `
@@ -197,6 +199,68 @@ output:
 
 //------------------------------------------------------------------------------
 
+// fixSwitchYAML migrates the deprecated outputs field of a switch output into
+// the field cases.
+func fixSwitchYAML(node *yaml.Node) (bool, error) {
+	conf, err := docs.GetYAMLPath(node, TypeSwitch)
+	if err != nil || conf.Kind != yaml.MappingNode {
+		return false, nil
+	}
+
+	outputsIndex := -1
+	for i := 0; i < len(conf.Content)-1; i += 2 {
+		if conf.Content[i].Value == "outputs" && len(conf.Content[i+1].Content) > 0 {
+			outputsIndex = i
+		}
+	}
+	if outputsIndex == -1 {
+		return false, nil
+	}
+	for i := 0; i < len(conf.Content)-1; i += 2 {
+		if conf.Content[i].Value == "cases" && len(conf.Content[i+1].Content) > 0 {
+			return false, errors.New("cannot migrate field outputs as field cases is already set")
+		}
+	}
+
+	// Outputs with conditions are checked against the batch as a whole,
+	// whereas cases with checks are checked against each message.
+	defaultCond := NewSwitchConfigOutput().Condition
+	for i, out := range conf.Content[outputsIndex+1].Content {
+		if out.Kind != yaml.MappingNode {
+			continue
+		}
+		if isSet, _ := condition.IsSetYAML(out, "condition", defaultCond); isSet {
+			return false, fmt.Errorf("output %v: field condition is checked against whole batches whereas field check is checked against each message individually, and therefore cannot be migrated automatically", i)
+		}
+	}
+	for i, out := range conf.Content[outputsIndex+1].Content {
+		if out.Kind != yaml.MappingNode {
+			continue
+		}
+		if _, err := condition.ReplaceYAMLWithCheck(out, "condition", "check", defaultCond); err != nil {
+			return false, fmt.Errorf("output %v: %w", i, err)
+		}
+		for j := 0; j < len(out.Content)-1; j += 2 {
+			if out.Content[j].Value == "fallthrough" {
+				out.Content[j].Value = "continue"
+			}
+		}
+	}
+
+	newContent := make([]*yaml.Node, 0, len(conf.Content))
+	for i := 0; i < len(conf.Content)-1; i += 2 {
+		if conf.Content[i].Value == "cases" {
+			continue
+		}
+		if i == outputsIndex {
+			conf.Content[i].Value = "cases"
+		}
+		newContent = append(newContent, conf.Content[i], conf.Content[i+1])
+	}
+	conf.Content = newContent
+	return true, nil
+}
+
 // SwitchConfig contains configuration fields for the Switch output type.
 type SwitchConfig struct {
 	RetryUntilSuccess bool                 `json:"retry_until_success" yaml:"retry_until_success"`
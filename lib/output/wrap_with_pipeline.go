@@ -3,6 +3,7 @@ package output
 import (
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/component/health"
 	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/internal/shutdown"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -62,6 +63,11 @@ func (i *WithPipeline) MaxInFlight() (int, bool) {
 	return output.GetMaxInFlight(i.out)
 }
 
+// SetHealthReporter forwards a health reporter to the wrapped output.
+func (i *WithPipeline) SetHealthReporter(r health.Reporter) {
+	health.SetReporter(i.out, r)
+}
+
 // Connected returns a boolean indicating whether this output is currently
 // connected to its target.
 func (i *WithPipeline) Connected() bool {
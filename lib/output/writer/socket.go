@@ -130,7 +130,7 @@ func (s *Socket) WriteWithContext(ctx context.Context, msg types.Message) error
 		}
 		return serr
 	})
-	if err == nil && msg.Len() > 1 {
+	if err == nil && (msg.Len() > 1 || s.codecConf.AlwaysEndBatch) {
 		if err = w.EndBatch(); err != nil {
 			s.writerMut.Lock()
 			s.writer.Close(ctx)
@@ -10,6 +10,7 @@ import (
 
 	"github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/codec"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/internal/interop"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -44,11 +45,15 @@ of the batch.`,
 		},
 		UsesBatches: true,
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("format", "The archiving [format](#formats) to apply.").HasOptions("tar", "zip", "binary", "lines", "json_array", "concatenate"),
+			docs.FieldCommon("format", "The archiving [format](#formats) to apply.").HasOptions("tar", "zip", "binary", "lines", "json_array", "concatenate", "arrow"),
 			docs.FieldCommon(
 				"path", "The path to set for each message in the archive (when applicable).",
 				"${!count(\"files\")}-${!timestamp_unix_nano()}.txt", "${!meta(\"kafka_key\")}-${!json(\"id\")}.json",
 			).IsInterpolated(),
+			docs.FieldAdvanced(
+				"schema", "An optional schema to use with the `arrow` format, when empty the schema is inferred from each batch. "+codec.ArrowSchemaDocs,
+				"id:int64,name:string,created_at:timestamp",
+			).AtVersion("3.58.0"),
 		},
 		Footnotes: `
 ## Formats
@@ -83,6 +88,14 @@ Join the raw contents of each message and insert a line break between each one.
 Attempt to parse each message as a JSON document and append the result to an
 array, which becomes the contents of the resulting message.
 
+### ` + "`arrow`" + `
+
+EXPERIMENTAL: Parse each message as a JSON object and encode the batch as a
+single record batch in the [Apache Arrow IPC streaming format](https://arrow.apache.org/docs/format/Columnar.html#ipc-streaming-format),
+where each message becomes a row. The schema of the record batch can be
+declared with the field ` + "`schema`" + `, otherwise it is inferred from the
+batch.
+
 ## Examples
 
 If we had JSON messages in a batch each of the form:
@@ -109,6 +122,7 @@ archive:
 type ArchiveConfig struct {
 	Format string `json:"format" yaml:"format"`
 	Path   string `json:"path" yaml:"path"`
+	Schema string `json:"schema" yaml:"schema"`
 }
 
 // NewArchiveConfig returns a ArchiveConfig with default values.
@@ -117,6 +131,7 @@ func NewArchiveConfig() ArchiveConfig {
 		// TODO: V4 change this default
 		Format: "binary",
 		Path:   `${!count("files")}-${!timestamp_unix_nano()}.txt`,
+		Schema: "",
 	}
 }
 
@@ -236,8 +251,31 @@ func jsonArrayArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
 	return newPart, nil
 }
 
-func strToArchiver(str string) (archiveFunc, error) {
-	switch str {
+func arrowArchiver(schema string) (archiveFunc, error) {
+	enc, err := codec.NewArrowEncoder(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse arrow schema: %w", err)
+	}
+	return func(hFunc headerFunc, msg types.Message) (types.Part, error) {
+		parts := make([]types.Part, msg.Len())
+		_ = msg.Iter(func(i int, part types.Part) error {
+			parts[i] = part
+			return nil
+		})
+
+		var buf bytes.Buffer
+		if err := enc.Encode(&buf, parts); err != nil {
+			return nil, err
+		}
+
+		newPart := msg.Get(0).Copy()
+		newPart.Set(buf.Bytes())
+		return newPart, nil
+	}, nil
+}
+
+func strToArchiver(conf ArchiveConfig) (archiveFunc, error) {
+	switch conf.Format {
 	case "tar":
 		return tarArchive, nil
 	case "zip":
@@ -250,8 +288,10 @@ func strToArchiver(str string) (archiveFunc, error) {
 		return jsonArrayArchive, nil
 	case "concatenate":
 		return concatenateArchive, nil
+	case "arrow":
+		return arrowArchiver(conf.Schema)
 	}
-	return nil, fmt.Errorf("archive format not recognised: %v", str)
+	return nil, fmt.Errorf("archive format not recognised: %v", conf.Format)
 }
 
 //------------------------------------------------------------------------------
@@ -282,7 +322,7 @@ func NewArchive(
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse path expression: %v", err)
 	}
-	archiver, err := strToArchiver(conf.Archive.Format)
+	archiver, err := strToArchiver(conf.Archive)
 	if err != nil {
 		return nil, err
 	}
@@ -14,6 +14,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
 	"github.com/stretchr/testify/require"
 )
 
@@ -264,6 +266,40 @@ func TestArchiveJSONArray(t *testing.T) {
 	}
 }
 
+func TestArchiveArrow(t *testing.T) {
+	conf := NewConfig()
+	conf.Archive.Format = "arrow"
+	conf.Archive.Schema = "id:int64,name:string"
+
+	proc, err := NewArchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"id":1,"name":"foo"}`),
+		[]byte(`{"id":2}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+	require.Equal(t, 2, batch.CollapsedCount(msgs[0].Get(0)))
+
+	r, err := ipc.NewReader(bytes.NewReader(msgs[0].Get(0).Get()))
+	require.NoError(t, err)
+	defer r.Release()
+
+	require.True(t, r.Next())
+	rec := r.Record()
+	require.Equal(t, int64(2), rec.NumRows())
+	require.Equal(t, []int64{1, 2}, rec.Column(0).(*array.Int64).Int64Values())
+	require.Equal(t, "foo", rec.Column(1).(*array.String).Value(0))
+	require.True(t, rec.Column(1).IsNull(1))
+	require.False(t, r.Next())
+
+	conf.Archive.Schema = "id:nope"
+	_, err = NewArchive(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
+
 func TestArchiveBinary(t *testing.T) {
 	conf := NewConfig()
 	conf.Archive.Format = "binary"
@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message/tracing"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"gopkg.in/yaml.v3"
 )
 
 //------------------------------------------------------------------------------
@@ -18,6 +20,7 @@ import (
 func init() {
 	Constructors[TypeConditional] = TypeSpec{
 		constructor: NewConditional,
+		fixer:       fixConditionalYAML,
 		Status:      docs.StatusDeprecated,
 		Summary: `
 Executes a set of child processors when a [condition](/docs/components/conditions/about)
@@ -45,6 +48,19 @@ processor with the ` + "[`for_each`](/docs/components/processors/for_each)" + `
 
 //------------------------------------------------------------------------------
 
+// fixConditionalYAML reports that a conditional processor must be migrated
+// manually, as its condition is checked once against a batch as a whole which
+// is then processed by either processors or else_processors, whereas the cases
+// of a switch processor are checked against each message individually.
+func fixConditionalYAML(node *yaml.Node) (bool, error) {
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == TypeConditional {
+			return false, errors.New("the conditional processor checks its condition against whole batches whereas the switch processor checks each message individually, and therefore cannot be migrated automatically")
+		}
+	}
+	return false, nil
+}
+
 // ConditionalConfig is a config struct containing fields for the Conditional
 // processor.
 type ConditionalConfig struct {
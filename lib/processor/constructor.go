@@ -52,6 +52,7 @@ type TypeSpec struct {
 	config      docs.FieldSpec
 	FieldSpecs  docs.FieldSpecs
 	Examples    []docs.AnnotatedExample
+	fixer       docs.ComponentFixFunc
 }
 
 // ConstructorFunc is a func signature able to construct a processor.
@@ -77,6 +78,7 @@ func WalkConstructors(fn func(ConstructorFunc, docs.ComponentSpec)) {
 			Config:      conf,
 			Status:      v.Status,
 			Version:     v.Version,
+			Fixer:       v.fixer,
 		}
 		if len(v.Categories) > 0 {
 			spec.Categories = make([]string, 0, len(v.Categories))
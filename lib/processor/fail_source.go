@@ -0,0 +1,51 @@
+package processor
+
+import (
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+type failSourceProc struct {
+	source string
+	proc   types.Processor
+}
+
+// WithFailSource wraps a processor so that message parts it flags as failed,
+// including those flagged by its child processors, have their failure source
+// set to the provided label or path. Parts that already have a failure source
+// are left unchanged, and therefore the source is the innermost processor that
+// flagged the failure.
+func WithFailSource(source string, proc types.Processor) types.Processor {
+	return &failSourceProc{source: source, proc: proc}
+}
+
+func (f *failSourceProc) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	msgs, res := f.proc.ProcessMessage(msg)
+	for _, m := range msgs {
+		_ = m.Iter(func(i int, p types.Part) error {
+			if HasFailed(p) && GetFailSource(p) == "" {
+				p.Metadata().Set(FailFlagSourceKey, f.source)
+			}
+			return nil
+		})
+	}
+	return msgs, res
+}
+
+func (f *failSourceProc) CloseAsync() {
+	f.proc.CloseAsync()
+}
+
+func (f *failSourceProc) WaitForClose(timeout time.Duration) error {
+	return f.proc.WaitForClose(timeout)
+}
+
+// Unwrap to the underlying processor.
+func (f *failSourceProc) Unwrap() types.Processor {
+	return f.proc
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,48 @@
+package processor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailSource(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeBloblang
+	conf.Bloblang = `root = if this.fail { throw("nope") } else { this }`
+
+	proc, err := New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	wrapped := WithFailSource("pipeline.processor.0", proc)
+	msgs, res := wrapped.ProcessMessage(message.New([][]byte{
+		[]byte(`{"fail":false}`),
+		[]byte(`{"fail":true}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, "", GetFailSource(msgs[0].Get(0)))
+	assert.True(t, HasFailed(msgs[0].Get(1)))
+	assert.Equal(t, "pipeline.processor.0", GetFailSource(msgs[0].Get(1)))
+
+	// A source set by a child processor takes precedence.
+	part := message.NewPart([]byte(`{"fail":false}`))
+	FlagErr(part, errors.New("child failed"))
+	part.Metadata().Set(FailFlagSourceKey, "child")
+	msg := message.New(nil)
+	msg.Append(part)
+	msgs, _ = wrapped.ProcessMessage(msg)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "child", GetFailSource(msgs[0].Get(0)))
+
+	ClearFail(msgs[0].Get(0))
+	assert.False(t, HasFailed(msgs[0].Get(0)))
+	assert.Equal(t, "", GetFailSource(msgs[0].Get(0)))
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	olog "github.com/opentracing/opentracing-go/log"
+	"gopkg.in/yaml.v3"
 )
 
 //------------------------------------------------------------------------------
@@ -20,7 +21,22 @@ import (
 func init() {
 	Constructors[TypeFilterParts] = TypeSpec{
 		constructor: NewFilterParts,
-		Status:      docs.StatusDeprecated,
+		fixer: func(node *yaml.Node) (bool, error) {
+			for i := 0; i < len(node.Content)-1; i += 2 {
+				if node.Content[i].Value != TypeFilterParts {
+					continue
+				}
+				query, err := condition.ToBloblangYAML(node.Content[i+1])
+				if err != nil {
+					return false, fmt.Errorf("failed to migrate condition: %w", err)
+				}
+				node.Content[i].Value = TypeBloblang
+				node.Content[i+1] = condition.BloblangYAMLNode(fmt.Sprintf("root = if !(%v) { deleted() }", query))
+				return true, nil
+			}
+			return false, nil
+		},
+		Status: docs.StatusDeprecated,
 		Footnotes: `
 ## Alternatives
 
@@ -26,6 +26,13 @@ import (
 func init() {
 	Constructors[TypeGroupBy] = TypeSpec{
 		constructor: NewGroupBy,
+		fixer: func(node *yaml.Node) (bool, error) {
+			groups, err := docs.GetYAMLPath(node, TypeGroupBy)
+			if err != nil {
+				return false, nil
+			}
+			return replaceCaseConditionsYAML(groups, condition.NewConfig())
+		},
 		Categories: []Category{
 			CategoryComposition,
 		},
@@ -16,6 +16,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/opentracing/opentracing-go"
 	"github.com/quipo/dependencysolver"
+	"gopkg.in/yaml.v3"
 )
 
 //------------------------------------------------------------------------------
@@ -23,6 +24,30 @@ import (
 func init() {
 	Constructors[TypeProcessDAG] = TypeSpec{
 		constructor: NewProcessDAG,
+		fixer: func(node *yaml.Node) (bool, error) {
+			for i := 0; i < len(node.Content)-1; i += 2 {
+				if node.Content[i].Value != TypeProcessDAG {
+					continue
+				}
+				branches, err := stagesToBranchesYAML(node.Content[i+1])
+				if err != nil {
+					return false, err
+				}
+				node.Content[i].Value = TypeWorkflow
+				node.Content[i+1] = &yaml.Node{
+					Kind: yaml.MappingNode,
+					Tag:  "!!map",
+					Content: []*yaml.Node{
+						{Kind: yaml.ScalarNode, Tag: "!!str", Value: "meta_path"},
+						{Kind: yaml.ScalarNode, Tag: "!!str", Value: "", Style: yaml.DoubleQuotedStyle},
+						{Kind: yaml.ScalarNode, Tag: "!!str", Value: "branches"},
+						branches,
+					},
+				}
+				return true, nil
+			}
+			return false, nil
+		},
 		Summary: `
 A processor that manages a map of ` + "`process_map`" + ` processors and
 calculates a Directed Acyclic Graph (DAG) of their dependencies by referring to
@@ -104,6 +129,26 @@ executed in parallel, and once they are both finished we may proceed onto baz.`,
 
 //------------------------------------------------------------------------------
 
+// stagesToBranchesYAML converts the YAML node of a map of process_map stages
+// into a map of equivalent branch configs.
+func stagesToBranchesYAML(stages *yaml.Node) (*yaml.Node, error) {
+	if stages.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected object value")
+	}
+	branches := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Tag:  "!!map",
+	}
+	for i := 0; i < len(stages.Content)-1; i += 2 {
+		branch, err := processMapToBranchYAML(stages.Content[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("stage %v: %w", stages.Content[i].Value, err)
+		}
+		branches.Content = append(branches.Content, stages.Content[i], branch)
+	}
+	return branches, nil
+}
+
 // DAGDepsConfig is a config containing dependency based configuration values
 // for a ProcessDAG child.
 type DAGDepsConfig struct {
@@ -4,6 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
@@ -14,6 +18,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message/tracing"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"gopkg.in/yaml.v3"
 )
 
 //------------------------------------------------------------------------------
@@ -53,6 +58,21 @@ func init() {
 		constructor: func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
 			return NewProcessMap(conf.ProcessMap, mgr, log, stats)
 		},
+		fixer: func(node *yaml.Node) (bool, error) {
+			for i := 0; i < len(node.Content)-1; i += 2 {
+				if node.Content[i].Value != TypeProcessMap {
+					continue
+				}
+				branch, err := processMapToBranchYAML(node.Content[i+1])
+				if err != nil {
+					return false, err
+				}
+				node.Content[i].Value = TypeBranch
+				node.Content[i+1] = branch
+				return true, nil
+			}
+			return false, nil
+		},
 		FieldSpecs: processMapFields,
 		Summary: `
 A processor that extracts and maps fields identified via
@@ -242,6 +262,144 @@ func (p *ProcessMapConfig) UnmarshalYAML(unmarshal func(interface{}) error) erro
 
 //------------------------------------------------------------------------------
 
+var bloblangPathSegmentRegexp = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// dotPathToBloblang converts a dot path into a Bloblang field reference from
+// the given root, quoting any path segments that contain special characters.
+func dotPathToBloblang(root, path string) string {
+	if path == "" || path == "." {
+		return root
+	}
+	segments := strings.Split(path, ".")
+	for i, s := range segments {
+		if !bloblangPathSegmentRegexp.MatchString(s) {
+			segments[i] = strconv.Quote(s)
+		}
+	}
+	return root + "." + strings.Join(segments, ".")
+}
+
+// pathMappingsToBloblang converts a map of destination to source dot paths
+// into Bloblang assignments. Missing required sources result in a mapping
+// error, missing optional sources are skipped. When guard is not empty the
+// required assignments are only executed when the guard variable is false.
+func pathMappingsToBloblang(required, optional map[string]string, guard string) []string {
+	var lines []string
+	assign := func(paths map[string]string, isOptional bool) {
+		dests := make([]string, 0, len(paths))
+		for k := range paths {
+			dests = append(dests, k)
+		}
+		sort.Strings(dests)
+		for _, dest := range dests {
+			src := paths[dest]
+			target, value := dotPathToBloblang("root", dest), dotPathToBloblang("this", src)
+			switch {
+			case isOptional && value != "this":
+				value = fmt.Sprintf("if this.exists(%v) { %v }", strconv.Quote(src), value)
+			case !isOptional && guard != "":
+				value = fmt.Sprintf("if !$%v { %v.not_null() }", guard, value)
+			case !isOptional:
+				value += ".not_null()"
+			}
+			lines = append(lines, fmt.Sprintf("%v = %v", target, value))
+		}
+	}
+	assign(required, false)
+	assign(optional, true)
+	return lines
+}
+
+// processMapToBranchYAML converts the YAML node of a process_map config into
+// the YAML node of an equivalent branch config.
+func processMapToBranchYAML(node *yaml.Node) (*yaml.Node, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, errors.New("expected object value")
+	}
+
+	var conditions []string
+	var premap, premapOpt, postmap, postmapOpt map[string]string
+	var procsKey, procsValue *yaml.Node
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		var err error
+		switch key.Value {
+		case "parts":
+			var parts []int
+			if err = value.Decode(&parts); err == nil && len(parts) > 0 {
+				err = errors.New("field parts is not supported by the branch processor")
+			}
+		case "dependencies":
+			var deps []string
+			if err = value.Decode(&deps); err == nil && len(deps) > 0 {
+				err = errors.New("field dependencies is not supported by the branch processor")
+			}
+		case "conditions":
+			for j, c := range value.Content {
+				var query string
+				if query, err = condition.ToBloblangYAML(c); err != nil {
+					err = fmt.Errorf("condition %v: %w", j, err)
+					break
+				}
+				if len(value.Content) > 1 {
+					query = "(" + query + ")"
+				}
+				conditions = append(conditions, query)
+			}
+		case "premap":
+			err = value.Decode(&premap)
+		case "premap_optional":
+			err = value.Decode(&premapOpt)
+		case "postmap":
+			err = value.Decode(&postmap)
+		case "postmap_optional":
+			err = value.Decode(&postmapOpt)
+		case "processors":
+			procsKey, procsValue = key, value
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate field %v: %w", key.Value, err)
+		}
+	}
+
+	var requestLines []string
+	if len(conditions) > 0 {
+		requestLines = append(requestLines, fmt.Sprintf("let skip = !(%v)", strings.Join(conditions, " && ")))
+		requestLines = append(requestLines, pathMappingsToBloblang(premap, premapOpt, "skip")...)
+		requestLines = append(requestLines, "root = if $skip { deleted() }")
+	} else {
+		requestLines = pathMappingsToBloblang(premap, premapOpt, "")
+	}
+
+	resultLines := pathMappingsToBloblang(postmap, postmapOpt, "")
+	if len(resultLines) == 0 {
+		resultLines = []string{"meta = meta()", "root = content()"}
+	}
+
+	branch := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Tag:  "!!map",
+	}
+	addField := func(key *yaml.Node, value *yaml.Node) {
+		branch.Content = append(branch.Content, key, value)
+	}
+	if len(requestLines) > 0 {
+		addField(
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "request_map"},
+			condition.BloblangYAMLNode(strings.Join(requestLines, "\n")),
+		)
+	}
+	if procsValue != nil {
+		addField(procsKey, procsValue)
+	}
+	addField(
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "result_map"},
+		condition.BloblangYAMLNode(strings.Join(resultLines, "\n")),
+	)
+	return branch, nil
+}
+
 // ProcessMap is a processor that applies a list of child processors to a new
 // payload mapped from the original, and after processing attempts to overlay
 // the results back onto the original payloads according to more mappings.
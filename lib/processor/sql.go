@@ -16,6 +16,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/opentracing/opentracing-go"
+	"gopkg.in/yaml.v3"
 
 	// SQL Drivers
 	_ "github.com/ClickHouse/clickhouse-go"
@@ -28,6 +29,16 @@ import (
 func init() {
 	Constructors[TypeSQL] = TypeSpec{
 		constructor: NewSQL,
+		fixer: func(node *yaml.Node) (bool, error) {
+			conf, err := docs.GetYAMLPath(node, TypeSQL)
+			if err != nil || conf.Kind != yaml.MappingNode {
+				return false, nil
+			}
+			if dsn, err := docs.GetYAMLPath(conf, "dsn"); err == nil && dsn.Value != "" {
+				return false, errors.New("field dsn is deprecated in favour of data_source_name, which processes message batches differently and therefore cannot be migrated automatically")
+			}
+			return docs.ReplaceInterpolatedArgsYAML(conf, "args", "args_mapping")
+		},
 		Categories: []Category{
 			CategoryIntegration,
 		},
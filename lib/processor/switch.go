@@ -18,6 +18,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"gopkg.in/yaml.v3"
 )
 
 //------------------------------------------------------------------------------
@@ -25,6 +26,23 @@ import (
 func init() {
 	Constructors[TypeSwitch] = TypeSpec{
 		constructor: NewSwitch,
+		fixer: func(node *yaml.Node) (bool, error) {
+			cases, err := docs.GetYAMLPath(node, TypeSwitch)
+			if err != nil || cases.Kind != yaml.SequenceNode {
+				return false, nil
+			}
+			// Cases with conditions are checked against the batch as a whole,
+			// whereas cases with checks are checked against each message.
+			for i, c := range cases.Content {
+				if c.Kind != yaml.MappingNode {
+					continue
+				}
+				if isSet, _ := condition.IsSetYAML(c, "condition", NewSwitchCaseConfig().Condition); isSet {
+					return false, fmt.Errorf("case %v: field condition is checked against whole batches whereas field check is checked against each message individually, and therefore cannot be migrated automatically", i)
+				}
+			}
+			return replaceCaseConditionsYAML(cases, NewSwitchCaseConfig().Condition)
+		},
 		Categories: []Category{
 			CategoryComposition,
 		},
@@ -92,6 +110,25 @@ pipeline:
 
 //------------------------------------------------------------------------------
 
+// replaceCaseConditionsYAML migrates the deprecated condition field of each
+// element of a sequence node into an equivalent Bloblang check.
+func replaceCaseConditionsYAML(cases *yaml.Node, defaultConf condition.Config) (changed bool, err error) {
+	if cases.Kind != yaml.SequenceNode {
+		return false, nil
+	}
+	for i, c := range cases.Content {
+		if c.Kind != yaml.MappingNode {
+			continue
+		}
+		cChanged, cErr := condition.ReplaceYAMLWithCheck(c, "condition", "check", defaultConf)
+		if cErr != nil {
+			return false, fmt.Errorf("case %v: %w", i, cErr)
+		}
+		changed = changed || cChanged
+	}
+	return changed, nil
+}
+
 // SwitchCaseConfig contains a condition, processors and other fields for an
 // individual case in the Switch processor.
 type SwitchCaseConfig struct {
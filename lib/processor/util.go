@@ -136,6 +136,11 @@ func FlagErr(part types.Part, err error) {
 	}
 }
 
+// FailFlagSourceKey is a metadata key used for recording the label of the
+// processor that flagged a message part as failed, or its path when it has no
+// label.
+var FailFlagSourceKey = types.FailFlagSourceKey
+
 // GetFail returns an error string for a message part if it has failed, or an
 // empty string if not.
 func GetFail(part types.Part) string {
@@ -147,9 +152,16 @@ func HasFailed(part types.Part) bool {
 	return len(part.Metadata().Get(FailFlagKey)) > 0
 }
 
+// GetFailSource returns the label or path of the processor that flagged a
+// message part as failed, or an empty string if it is unknown.
+func GetFailSource(part types.Part) string {
+	return part.Metadata().Get(FailFlagSourceKey)
+}
+
 // ClearFail removes any existing failure flags from a message part.
 func ClearFail(part types.Part) {
 	part.Metadata().Delete(FailFlagKey)
+	part.Metadata().Delete(FailFlagSourceKey)
 }
 
 //------------------------------------------------------------------------------
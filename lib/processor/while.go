@@ -25,6 +25,13 @@ import (
 func init() {
 	Constructors[TypeWhile] = TypeSpec{
 		constructor: NewWhile,
+		fixer: func(node *yaml.Node) (bool, error) {
+			conf, err := docs.GetYAMLPath(node, TypeWhile)
+			if err != nil || conf.Kind != yaml.MappingNode {
+				return false, nil
+			}
+			return condition.ReplaceYAMLWithCheck(conf, "condition", "check", condition.NewConfig())
+		},
 		Categories: []Category{
 			CategoryComposition,
 		},
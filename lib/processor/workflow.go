@@ -12,6 +12,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/gabs/v2"
+	"gopkg.in/yaml.v3"
 )
 
 //------------------------------------------------------------------------------
@@ -19,6 +20,49 @@ import (
 func init() {
 	Constructors[TypeWorkflow] = TypeSpec{
 		constructor: NewWorkflow,
+		fixer: func(node *yaml.Node) (bool, error) {
+			conf, err := docs.GetYAMLPath(node, TypeWorkflow)
+			if err != nil || conf.Kind != yaml.MappingNode {
+				return false, nil
+			}
+			stagesIndex := -1
+			for i := 0; i < len(conf.Content)-1; i += 2 {
+				if conf.Content[i].Value == "stages" && len(conf.Content[i+1].Content) > 0 {
+					stagesIndex = i
+				}
+			}
+			if stagesIndex == -1 {
+				return false, nil
+			}
+			for i := 0; i < len(conf.Content)-1; i += 2 {
+				switch conf.Content[i].Value {
+				case "branches", "order":
+					if len(conf.Content[i+1].Content) > 0 {
+						return false, fmt.Errorf("cannot migrate field stages as field %v is already set", conf.Content[i].Value)
+					}
+				}
+			}
+			branches, err := stagesToBranchesYAML(conf.Content[stagesIndex+1])
+			if err != nil {
+				return false, err
+			}
+			conf.Content[stagesIndex].Value = "branches"
+			conf.Content[stagesIndex+1] = branches
+
+			// Remove the now redundant empty fields.
+			newContent := make([]*yaml.Node, 0, len(conf.Content))
+			for i := 0; i < len(conf.Content)-1; i += 2 {
+				switch conf.Content[i].Value {
+				case "branches", "order":
+					if i != stagesIndex {
+						continue
+					}
+				}
+				newContent = append(newContent, conf.Content[i], conf.Content[i+1])
+			}
+			conf.Content = newContent
+			return true, nil
+		},
 		Categories: []Category{
 			CategoryComposition,
 		},
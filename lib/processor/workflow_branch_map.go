@@ -182,6 +182,11 @@ func (r *resourcedBranch) lock() (branch *Branch, unlockFn func()) {
 
 	go func() {
 		_ = interop.AccessProcessor(context.Background(), r.mgr, r.name, func(p types.Processor) {
+			if u, ok := p.(interface {
+				Unwrap() types.Processor
+			}); ok {
+				p = u.Unwrap()
+			}
 			branch, _ = p.(*Branch)
 			openOnce.Do(func() {
 				close(open)
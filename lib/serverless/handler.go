@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bundle"
 	"github.com/Jeffail/benthos/v3/internal/interop"
 	"github.com/Jeffail/benthos/v3/lib/config"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -128,7 +129,7 @@ func NewHandler(conf config.Type) (*Handler, error) {
 	}
 
 	// Create our metrics type.
-	stats, err := metrics.New(conf.Metrics, metrics.OptSetLogger(logger))
+	stats, err := bundle.AllMetrics.Init(conf.Metrics, metrics.OptSetLogger(logger))
 	if err != nil {
 		logger.Errorf("Failed to connect metrics aggregator: %v\n", err)
 		stats = metrics.Noop()
@@ -136,7 +137,7 @@ func NewHandler(conf config.Type) (*Handler, error) {
 
 	// Create our tracer type.
 	var trac tracer.Type
-	if trac, err = tracer.New(conf.Tracer); err != nil {
+	if trac, err = bundle.AllTracers.Init(conf.Tracer); err != nil {
 		logger.Errorf("Failed to initialise tracer: %v\n", err)
 		trac = tracer.Noop()
 	}
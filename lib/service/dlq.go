@@ -0,0 +1,246 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/deadletter"
+	"github.com/Jeffail/benthos/v3/internal/filepath"
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+
+// dlqReplayProc unwraps dead letter envelopes and passes them straight to the
+// output. When processors are provided messages that failed processing are
+// reprocessed by them instead.
+type dlqReplayProc struct {
+	procs []types.Processor
+	log   log.Modular
+
+	mUnwrapErr metrics.StatCounter
+}
+
+func (r *dlqReplayProc) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	reprocess, passthrough := message.New(nil), message.New(nil)
+	_ = msg.Iter(func(i int, p types.Part) error {
+		orig, failure, err := deadletter.Unwrap(p)
+		if err != nil {
+			r.mUnwrapErr.Incr(1)
+			r.log.Errorf("Skipping message that is not a dead letter envelope: %v\n", err)
+			return nil
+		}
+		if failure.Stage == deadletter.StagePipeline && len(r.procs) > 0 {
+			reprocess.Append(orig)
+		} else {
+			passthrough.Append(orig)
+		}
+		return nil
+	})
+
+	var msgs []types.Message
+	if passthrough.Len() > 0 {
+		msgs = append(msgs, passthrough)
+	}
+	if reprocess.Len() > 0 {
+		resMsgs, res := processor.ExecuteAll(r.procs, reprocess)
+		if len(resMsgs) == 0 && res != nil && res.Error() != nil {
+			return nil, res
+		}
+		msgs = append(msgs, resMsgs...)
+	}
+	if len(msgs) == 0 {
+		return nil, response.NewAck()
+	}
+	return msgs, nil
+}
+
+func (r *dlqReplayProc) CloseAsync() {
+	for _, p := range r.procs {
+		p.CloseAsync()
+	}
+}
+
+func (r *dlqReplayProc) WaitForClose(timeout time.Duration) error {
+	stopBy := time.Now().Add(timeout)
+	for _, p := range r.procs {
+		if err := p.WaitForClose(time.Until(stopBy)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func dlqReplay(inputPath string, reprocess bool, paths []string) int {
+	inConf := input.NewConfig()
+	switch {
+	case inputPath != "" && len(paths) > 0:
+		fmt.Fprintln(os.Stderr, "Dead letter files cannot be combined with the --input flag")
+		return 1
+	case inputPath != "":
+		inBytes, err := ioutil.ReadFile(inputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read input config: %v\n", err)
+			return 1
+		}
+		if err = yaml.Unmarshal(inBytes, &inConf); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to parse input config: %v\n", err)
+			return 1
+		}
+	case len(paths) > 0:
+		inConf.Type = input.TypeFile
+		inConf.File.Paths = paths
+		inConf.File.Codec = "lines"
+	default:
+		fmt.Fprintln(os.Stderr, "Either dead letter files or an --input flag must be specified")
+		return 1
+	}
+
+	logger, err := log.NewV2(os.Stderr, conf.Logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+		return 1
+	}
+	stats := metrics.Noop()
+
+	mgr, err := manager.NewV2(conf.ResourceConfig, types.NoopMgr(), logger, stats)
+	if err != nil {
+		logger.Errorf("Failed to create resources: %v\n", err)
+		return 1
+	}
+
+	strmConf := conf.Config
+	strmConf.Input = inConf
+	procConfs := strmConf.Pipeline.Processors
+	strmConf.Pipeline.Processors = nil
+	if !reprocess {
+		procConfs = nil
+	}
+
+	var procMgr types.Manager = mgr
+	if output.ContainsDeadLetter(strmConf.Output) {
+		procMgr = interop.WithFailSources(mgr)
+	}
+
+	pMgr, pLog, pStats := interop.LabelChild("pipeline", procMgr, logger, stats)
+	replayCtor := func() (types.Processor, error) {
+		procs := make([]types.Processor, len(procConfs))
+		for i, pConf := range procConfs {
+			iMgr, iLog, iStats := interop.LabelChild(fmt.Sprintf("processor.%v", i), pMgr, pLog, pStats)
+			var err error
+			if procs[i], err = processor.New(pConf, iMgr, iLog, iStats); err != nil {
+				return nil, fmt.Errorf("failed to create processor '%v': %v", pConf.Type, err)
+			}
+		}
+		return &dlqReplayProc{
+			procs:      procs,
+			log:        pLog,
+			mUnwrapErr: pStats.GetCounter("dead_letter.replay.unwrap_error"),
+		}, nil
+	}
+
+	closedChan := make(chan struct{})
+	strm, err := stream.New(
+		strmConf,
+		stream.OptSetLogger(logger),
+		stream.OptSetStats(stats),
+		stream.OptSetManager(mgr),
+		stream.OptAddProcessors(replayCtor),
+		stream.OptOnClose(func() {
+			close(closedChan)
+		}),
+	)
+	if err != nil {
+		logger.Errorf("Failed to create replay stream: %v\n", err)
+		return 1
+	}
+	logger.Infoln("Replaying dead lettered messages, use CTRL+C to close.")
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case <-closedChan:
+		logger.Infoln("Finished replaying dead lettered messages.")
+	case <-sigChan:
+		logger.Infoln("Received SIGTERM, the replay is closing.")
+	}
+
+	exitCode := 0
+	if err = strm.Stop(time.Second * 20); err != nil {
+		exitCode = 1
+	}
+	mgr.CloseAsync()
+	if err = mgr.WaitForClose(time.Second * 5); err != nil {
+		logger.Warnf("Failed to close resources: %v\n", err)
+	}
+	return exitCode
+}
+
+func dlqCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "dlq",
+		Usage: "Work with messages written to a dead letter output",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "replay",
+				Usage: "Replay dead lettered messages to the output of a config",
+				Description: `
+   Reads messages written by a dead_letter output, either from files of line
+   delimited envelopes or from an input described by a YAML file, and writes
+   them to the output of a config. Messages that failed processing were
+   captured after the pipeline ran, and therefore skip the pipeline unless the
+   --reprocess flag is set.
+
+   benthos -c ./config.yaml dlq replay ./dead_letters.jsonl
+   benthos -c ./config.yaml dlq replay --reprocess ./dead_letters.jsonl
+   benthos -c ./config.yaml dlq replay --input ./dlq_input.yaml
+
+   The input and input processors of the config are ignored, and when replaying
+   from files Benthos exits once they have been consumed.`[4:],
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "input",
+						Value: "",
+						Usage: "A path to a YAML file containing an input config to read dead lettered messages from, instead of files.",
+					},
+					&cli.BoolFlag{
+						Name:  "reprocess",
+						Value: false,
+						Usage: "Send messages that failed processing through the pipeline of the config again, which is only safe when the processors can be run more than once on the same message.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					resourcesPaths, err := filepath.Globs(c.StringSlice("resources"))
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Failed to resolve resource glob pattern: %v\n", err)
+						os.Exit(1)
+					}
+					readConfig(c.String("config"), resourcesPaths, c.StringSlice("set"))
+					os.Exit(dlqReplay(c.String("input"), c.Bool("reprocess"), c.Args().Slice()))
+					return nil
+				},
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
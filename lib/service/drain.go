@@ -0,0 +1,198 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+)
+
+type drainableStreams interface {
+	Drain(timeout time.Duration) error
+}
+
+type drainState string
+
+const (
+	drainStateRunning  drainState = "running"
+	drainStateDraining drainState = "draining"
+	drainStateDrained  drainState = "drained"
+	drainStateFailed   drainState = "failed"
+)
+
+// drainer coordinates the drain phase of a service shutdown, where streams stop
+// consuming new messages and flush those already consumed before the service
+// is closed. A drain can be requested either by a termination signal or via the
+// HTTP API, and the progress of a drain can be observed via the HTTP API.
+type drainer struct {
+	strms   stoppableStreams
+	timeout time.Duration
+	logger  log.Modular
+
+	requestOnce sync.Once
+	requestChan chan struct{}
+
+	drainOnce sync.Once
+	doneChan  chan struct{}
+
+	mut       sync.Mutex
+	state     drainState
+	startedAt time.Time
+	endedAt   time.Time
+	err       error
+}
+
+func newDrainer(strms stoppableStreams, timeout time.Duration, logger log.Modular) *drainer {
+	return &drainer{
+		strms:       strms,
+		timeout:     timeout,
+		logger:      logger,
+		requestChan: make(chan struct{}),
+		doneChan:    make(chan struct{}),
+		state:       drainStateRunning,
+	}
+}
+
+// request that the service is drained and shut down, subsequent requests are
+// ignored.
+func (d *drainer) request() {
+	d.requestOnce.Do(func() {
+		close(d.requestChan)
+	})
+}
+
+// requested returns a channel that is closed once a drain has been requested.
+func (d *drainer) requested() <-chan struct{} {
+	return d.requestChan
+}
+
+// drain the streams, blocking until either all in-flight messages have been
+// acknowledged or the timeout is exceeded. Only the first call performs the
+// drain, subsequent calls block until it has finished and return its result.
+func (d *drainer) drain() error {
+	d.drainOnce.Do(func() {
+		defer close(d.doneChan)
+
+		strms, ok := d.strms.(drainableStreams)
+		if !ok || d.timeout <= 0 {
+			return
+		}
+
+		d.mut.Lock()
+		d.state = drainStateDraining
+		d.startedAt = time.Now()
+		d.mut.Unlock()
+
+		d.logger.Infof("Draining in-flight messages, waiting up to %v.\n", d.timeout)
+		err := strms.Drain(d.timeout)
+
+		d.mut.Lock()
+		d.endedAt = time.Now()
+		if d.err = err; err != nil {
+			d.state = drainStateFailed
+		} else {
+			d.state = drainStateDrained
+		}
+		d.mut.Unlock()
+
+		if err != nil {
+			d.logger.Warnf("Failed to drain in-flight messages: %v\n", err)
+		} else {
+			d.logger.Infoln("Drained all in-flight messages.")
+		}
+	})
+	<-d.doneChan
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	return d.err
+}
+
+type drainProgress struct {
+	State     drainState                   `json:"state"`
+	StartedAt *time.Time                   `json:"started_at,omitempty"`
+	Deadline  *time.Time                   `json:"deadline,omitempty"`
+	Elapsed   string                       `json:"elapsed,omitempty"`
+	Stage     stream.DrainStage            `json:"stage,omitempty"`
+	InFlight  *int                         `json:"in_flight,omitempty"`
+	Streams   map[string]stream.DrainStage `json:"streams,omitempty"`
+	Error     string                       `json:"error,omitempty"`
+}
+
+func (d *drainer) progress() drainProgress {
+	d.mut.Lock()
+	p := drainProgress{State: d.state}
+	if !d.startedAt.IsZero() {
+		startedAt, deadline := d.startedAt, d.startedAt.Add(d.timeout)
+		p.StartedAt, p.Deadline = &startedAt, &deadline
+		if d.endedAt.IsZero() {
+			p.Elapsed = time.Since(d.startedAt).String()
+		} else {
+			p.Elapsed = d.endedAt.Sub(d.startedAt).String()
+		}
+	}
+	if d.err != nil {
+		p.Error = d.err.Error()
+	}
+	d.mut.Unlock()
+
+	if p.State == drainStateRunning {
+		return p
+	}
+	switch t := d.strms.(type) {
+	case interface {
+		DrainStage() stream.DrainStage
+		InFlight() (int, int64)
+	}:
+		p.Stage = t.DrainStage()
+		inFlight, _ := t.InFlight()
+		p.InFlight = &inFlight
+	case interface {
+		DrainStages() map[string]stream.DrainStage
+	}:
+		p.Streams = t.DrainStages()
+	}
+	return p
+}
+
+// handler returns an HTTP handler where a POST request triggers a drain and a
+// GET request reports the progress of a drain. A POST request with the URL
+// param wait=true blocks until the drain has finished.
+func (d *drainer) handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+		case "POST":
+			d.request()
+			if r.URL.Query().Get("wait") == "true" {
+				select {
+				case <-d.doneChan:
+				case <-r.Context().Done():
+					return
+				}
+			}
+		default:
+			http.Error(w, "Method not supported", http.StatusMethodNotAllowed)
+			return
+		}
+
+		p := d.progress()
+		resBytes, err := json.Marshal(p)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case p.State == drainStateFailed:
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.Method == "POST" && p.State != drainStateDrained:
+			w.WriteHeader(http.StatusAccepted)
+		}
+		w.Write(resBytes)
+	}
+}
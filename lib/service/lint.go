@@ -32,11 +32,86 @@ func resolveLintPath(path string) (string, bool) {
 	return path, recurse
 }
 
+func resolveLintTargets(paths []string) ([]string, error) {
+	var targets []string
+	for _, p := range paths {
+		var recurse bool
+		if p, recurse = resolveLintPath(p); recurse {
+			if err := filepath.Walk(p, func(path string, info os.FileInfo, werr error) error {
+				if werr != nil {
+					return werr
+				}
+				if info.IsDir() {
+					return nil
+				}
+				if strings.HasSuffix(path, ".yaml") ||
+					strings.HasSuffix(path, ".yml") {
+					targets = append(targets, path)
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+		} else {
+			targets = append(targets, p)
+		}
+	}
+	return targets, nil
+}
+
 type pathLint struct {
 	source string
 	line   int
 	lint   string
 	err    string
+	warn   bool
+}
+
+func fixFile(path string) (pathLints []pathLint) {
+	info, err := os.Stat(path)
+	if err != nil {
+		pathLints = append(pathLints, pathLint{
+			source: path,
+			err:    err.Error(),
+		})
+		return
+	}
+
+	rawBytes, err := os.ReadFile(path)
+	if err != nil {
+		pathLints = append(pathLints, pathLint{
+			source: path,
+			err:    err.Error(),
+		})
+		return
+	}
+
+	fixedBytes, changed, lints, err := config.Fix(rawBytes)
+	if err != nil {
+		pathLints = append(pathLints, pathLint{
+			source: path,
+			err:    err.Error(),
+		})
+		return
+	}
+	for _, l := range lints {
+		pathLints = append(pathLints, pathLint{
+			source: path,
+			lint:   l,
+			warn:   true,
+		})
+	}
+	if !changed {
+		return
+	}
+
+	if err := os.WriteFile(path, fixedBytes, info.Mode()); err != nil {
+		pathLints = append(pathLints, pathLint{
+			source: path,
+			err:    err.Error(),
+		})
+	}
+	return
 }
 
 func lintFile(path string) (pathLints []pathLint) {
@@ -134,31 +209,28 @@ func lintCliCommand() *cli.Command {
    benthos lint ./configs/...
    
    If a path ends with '...' then Benthos will walk the target and lint any
-   files with the .yaml or .yml extension.`[4:],
+   files with the .yaml or .yml extension.
+
+   When the --fix flag is set each config file is rewritten in place in order
+   to resolve linting errors where possible before being linted. Redundant
+   fields are removed and deprecated components, fields and Bloblang functions
+   are migrated to their modern equivalents in the same way as the migrate
+   subcommand, any deprecated components that cannot be migrated
+   automatically are reported as warnings.`[4:],
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "fix",
+				Value: false,
+				Usage: "Rewrite config files in place in order to resolve linting errors where possible.",
+			},
+		},
 		Action: func(c *cli.Context) error {
-			var targets []string
-			for _, p := range c.Args().Slice() {
-				var recurse bool
-				if p, recurse = resolveLintPath(p); recurse {
-					if err := filepath.Walk(p, func(path string, info os.FileInfo, werr error) error {
-						if werr != nil {
-							return werr
-						}
-						if info.IsDir() {
-							return nil
-						}
-						if strings.HasSuffix(path, ".yaml") ||
-							strings.HasSuffix(path, ".yml") {
-							targets = append(targets, path)
-						}
-						return nil
-					}); err != nil {
-						fmt.Fprintf(os.Stderr, "Filesystem walk error: %v\n", err)
-						os.Exit(1)
-					}
-				} else {
-					targets = append(targets, p)
-				}
+			fix := c.Bool("fix")
+
+			targets, err := resolveLintTargets(c.Args().Slice())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Filesystem walk error: %v\n", err)
+				os.Exit(1)
 			}
 			if conf := c.String("config"); len(conf) > 0 {
 				targets = append(targets, conf)
@@ -183,7 +255,10 @@ func lintCliCommand() *cli.Command {
 						if path.Ext(target) == ".md" {
 							lints = lintMDSnippets(target)
 						} else {
-							lints = lintFile(target)
+							if fix {
+								lints = fixFile(target)
+							}
+							lints = append(lints, lintFile(target)...)
 						}
 						if len(lints) > 0 {
 							pathLintMut.Lock()
@@ -197,7 +272,11 @@ func lintCliCommand() *cli.Command {
 			if len(pathLints) == 0 {
 				os.Exit(0)
 			}
+			failed := false
 			for _, lint := range pathLints {
+				if !lint.warn {
+					failed = true
+				}
 				message := yellow(lint.lint)
 				if len(lint.err) > 0 {
 					message = red(lint.err)
@@ -208,6 +287,9 @@ func lintCliCommand() *cli.Command {
 					fmt.Fprintf(os.Stderr, "%v: %v\n", lint.source, message)
 				}
 			}
+			if !failed {
+				os.Exit(0)
+			}
 			os.Exit(1)
 			return nil
 		},
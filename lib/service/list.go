@@ -146,5 +146,28 @@ func listComponents(c *cli.Context) {
 			panic(err)
 		}
 		fmt.Println(string(jsonBytes))
+	case "jsonschema":
+		jsonBytes, err := json.Marshal(schema.jsonSchema())
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(jsonBytes))
+	}
+}
+
+func (f *fullSchema) jsonSchema() map[string]interface{} {
+	var components []docs.ComponentSpec
+	for _, specs := range [][]docs.ComponentSpec{
+		f.Buffers,
+		f.Caches,
+		f.Inputs,
+		f.Outputs,
+		f.Processors,
+		f.RateLimits,
+		f.Metrics,
+		f.Tracers,
+	} {
+		components = append(components, specs...)
 	}
+	return docs.ConfigJSONSchema(f.Config, components)
 }
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/urfave/cli/v2"
+)
+
+func migrateFile(path string, dryRun bool) (migrated bool, pathLints []pathLint) {
+	info, err := os.Stat(path)
+	if err != nil {
+		pathLints = append(pathLints, pathLint{
+			source: path,
+			err:    err.Error(),
+		})
+		return
+	}
+
+	rawBytes, err := os.ReadFile(path)
+	if err != nil {
+		pathLints = append(pathLints, pathLint{
+			source: path,
+			err:    err.Error(),
+		})
+		return
+	}
+
+	migratedBytes, changed, report, err := config.Migrate(rawBytes)
+	if err != nil {
+		pathLints = append(pathLints, pathLint{
+			source: path,
+			err:    err.Error(),
+		})
+		return
+	}
+	for _, l := range report {
+		pathLints = append(pathLints, pathLint{
+			source: path,
+			lint:   l,
+			warn:   true,
+		})
+	}
+	if !changed || dryRun {
+		return changed, pathLints
+	}
+
+	if err := os.WriteFile(path, migratedBytes, info.Mode()); err != nil {
+		pathLints = append(pathLints, pathLint{
+			source: path,
+			err:    err.Error(),
+		})
+		return
+	}
+	return true, pathLints
+}
+
+func migrateCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Convert deprecated components and fields within Benthos configs to their modern equivalents",
+		Description: `
+   Rewrites config files in place, converting deprecated components and fields
+   into their modern equivalents where possible:
+
+   benthos -c target.yaml migrate
+   benthos migrate ./configs/*.yaml
+   benthos migrate ./configs/...
+
+   If a path ends with '...' then Benthos will walk the target and migrate any
+   files with the .yaml or .yml extension.
+
+   Conditions are converted into Bloblang queries where the query is checked
+   against the same messages, the deprecated filter_parts, process_map and
+   process_dag processors are converted into bloblang, branch and workflow
+   processors, and so on. Deprecated Bloblang functions such as timestamp are
+   converted into their modern equivalents such as now().format_timestamp().
+
+   These are the same migrations applied by lint --fix, but a report is also
+   printed of any deprecated components, fields and Bloblang functions that
+   remain and therefore need to be migrated by hand.
+
+   When the --dry-run flag is set files are not modified, instead the files
+   that would be changed are listed along with the report.
+
+   Exits with a status code 1 if any config could not be read or written.`[4:],
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Value: false,
+				Usage: "Report the changes that would be made without modifying any files.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			dryRun := c.Bool("dry-run")
+
+			targets, err := resolveLintTargets(c.Args().Slice())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Filesystem walk error: %v\n", err)
+				os.Exit(1)
+			}
+			if conf := c.String("config"); len(conf) > 0 {
+				targets = append(targets, conf)
+			}
+
+			failed := false
+			for _, target := range targets {
+				if target == "" {
+					continue
+				}
+				migrated, lints := migrateFile(target, dryRun)
+				if migrated {
+					if dryRun {
+						fmt.Printf("%v: would be migrated\n", target)
+					} else {
+						fmt.Printf("%v: migrated\n", target)
+					}
+				}
+				for _, lint := range lints {
+					message := yellow(lint.lint)
+					if len(lint.err) > 0 {
+						failed = true
+						message = red(lint.err)
+					}
+					fmt.Fprintf(os.Stderr, "%v: %v\n", lint.source, message)
+				}
+			}
+			if failed {
+				os.Exit(1)
+			}
+			os.Exit(0)
+			return nil
+		},
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"runtime/debug"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang/parser"
 	clitemplate "github.com/Jeffail/benthos/v3/internal/cli/template"
@@ -14,6 +15,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/config"
 	"github.com/Jeffail/benthos/v3/lib/service/blobl"
 	"github.com/Jeffail/benthos/v3/lib/service/test"
+	strmmgr "github.com/Jeffail/benthos/v3/lib/stream/manager"
 	uconfig "github.com/Jeffail/benthos/v3/lib/util/config"
 	"github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v3"
@@ -150,6 +152,12 @@ func Run() {
 			Value: false,
 			Usage: "continue to execute a config containing linter errors",
 		},
+		&cli.BoolFlag{
+			Name:    "watcher",
+			Aliases: []string{"w"},
+			Value:   false,
+			Usage:   "EXPERIMENTAL: watch config and resource files for changes and apply them without restarting",
+		},
 	}
 	if len(customFlags) > 0 {
 		flags = append(flags, customFlags...)
@@ -215,8 +223,10 @@ func Run() {
 				c.StringSlice("set"),
 				c.String("log.level"),
 				!c.Bool("chilled"),
+				c.Bool("watcher"),
 				false,
 				nil,
+				streamsModeOpts{},
 			))
 			return nil
 		},
@@ -254,6 +264,7 @@ func Run() {
 				},
 			},
 			lintCliCommand(),
+			migrateCliCommand(),
 			{
 				Name:  "streams",
 				Usage: "Run Benthos in streams mode",
@@ -272,6 +283,48 @@ func Run() {
 
    For more information check out the docs at:
    https://benthos.dev/docs/guides/streams_mode/about`[4:],
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "state-dir",
+						Value: "",
+						Usage: "A directory to persist stream and resource configs created via the REST API to, which are restored at startup.",
+					},
+					&cli.StringFlag{
+						Name:  "state-cache",
+						Value: "",
+						Usage: "The name of a cache resource to persist stream and resource configs created via the REST API to, which are restored at startup.",
+					},
+					&cli.StringFlag{
+						Name:  "cluster-cache",
+						Value: "",
+						Usage: "EXPERIMENTAL: The name of a cache resource shared by a cluster of Benthos instances, where each stream config is run by exactly one live instance.",
+					},
+					&cli.StringFlag{
+						Name:  "cluster-node-id",
+						Value: "",
+						Usage: "A unique identifier of this instance within a cluster, defaults to the hostname.",
+					},
+					&cli.IntFlag{
+						Name:  "stream-max-in-flight",
+						Value: 0,
+						Usage: "The maximum number of messages each stream may have consumed from its input but not yet acknowledged, zero is unlimited.",
+					},
+					&cli.Int64Flag{
+						Name:  "stream-max-in-flight-bytes",
+						Value: 0,
+						Usage: "The maximum total size in bytes of messages each stream may have consumed from its input but not yet acknowledged, zero is unlimited. Messages are acknowledged once written to a buffer.",
+					},
+					&cli.IntFlag{
+						Name:  "stream-max-processor-threads",
+						Value: 0,
+						Usage: "The maximum number of processing threads of each stream pipeline, zero is unlimited.",
+					},
+					&cli.DurationFlag{
+						Name:  "cluster-interval",
+						Value: 5 * time.Second,
+						Usage: "The period between heartbeats of this instance within a cluster, an instance is considered dead after three missed heartbeats.",
+					},
+				},
 				Action: func(c *cli.Context) error {
 					os.Exit(cmdService(
 						c.String("config"),
@@ -279,8 +332,21 @@ func Run() {
 						c.StringSlice("set"),
 						c.String("log.level"),
 						!c.Bool("chilled"),
+						c.Bool("watcher"),
 						true,
 						c.Args().Slice(),
+						streamsModeOpts{
+							stateDir:        c.String("state-dir"),
+							stateCache:      c.String("state-cache"),
+							clusterCache:    c.String("cluster-cache"),
+							clusterNodeID:   c.String("cluster-node-id"),
+							clusterInterval: c.Duration("cluster-interval"),
+							limits: strmmgr.StreamLimits{
+								MaxInFlight:         c.Int("stream-max-in-flight"),
+								MaxInFlightBytes:    c.Int64("stream-max-in-flight-bytes"),
+								MaxProcessorThreads: c.Int("stream-max-processor-threads"),
+							},
+						},
 					))
 					return nil
 				},
@@ -294,12 +360,17 @@ func Run() {
 
    benthos list
    benthos list --format json inputs output
-   benthos list rate-limits buffers`[4:],
+   benthos list rate-limits buffers
+
+   The format jsonschema prints a JSON Schema for the entire config, including
+   any plugins and templates, which can be used by editors for validation:
+
+   benthos -t "./templates/*.yaml" list --format jsonschema > ./schema.json`[4:],
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:  "format",
 						Value: "text",
-						Usage: "Print the component list in a specific format. Options are text or json.",
+						Usage: "Print the component list in a specific format. Options are text, json or jsonschema.",
 					},
 				},
 				Action: func(c *cli.Context) error {
@@ -309,7 +380,8 @@ func Run() {
 				},
 			},
 			createCliCommand(),
-			test.CliCommand(testSuffix),
+			dlqCliCommand(),
+			test.CliCommand(testSuffix, template.TestTargets),
 			clitemplate.CliCommand(),
 			blobl.CliCommand(),
 		},
@@ -340,7 +412,7 @@ func Run() {
 		}
 
 		deprecatedExecute(*configPath, testSuffix)
-		os.Exit(cmdService(*configPath, nil, nil, "", false, false, nil))
+		os.Exit(cmdService(*configPath, nil, nil, "", false, false, false, nil, streamsModeOpts{}))
 		return nil
 	}
 
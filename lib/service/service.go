@@ -12,9 +12,11 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bundle"
 	iconfig "github.com/Jeffail/benthos/v3/internal/config"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/internal/filepath"
+	"github.com/Jeffail/benthos/v3/internal/interop"
 	"github.com/Jeffail/benthos/v3/lib/api"
 	"github.com/Jeffail/benthos/v3/lib/config"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -94,7 +96,7 @@ func OptOnManagerInit(fn ManagerInitFunc) func() {
 
 //------------------------------------------------------------------------------
 
-func readConfig(path string, resourcesPaths, overrides []string) (lints []string) {
+func readConfig(path string, resourcesPaths, overrides []string) (rdr *iconfig.Reader, lints []string) {
 	if path == "" {
 		// Iterate default config paths
 		for _, dpath := range []string{
@@ -110,8 +112,10 @@ func readConfig(path string, resourcesPaths, overrides []string) (lints []string
 		}
 	}
 
+	rdr = iconfig.NewReader(path, resourcesPaths, iconfig.OptAddOverrides(overrides...))
+
 	var err error
-	if lints, err = iconfig.NewReader(path, resourcesPaths, iconfig.OptAddOverrides(overrides...)).Read(&conf); err != nil {
+	if lints, err = rdr.Read(&conf); err != nil {
 		fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
 		os.Exit(1)
 	}
@@ -120,21 +124,37 @@ func readConfig(path string, resourcesPaths, overrides []string) (lints []string
 
 //------------------------------------------------------------------------------
 
+// streamsModeOpts describes where the state of streams mode is persisted, at
+// most one of stateDir and stateCache should be set, optionally a cluster to
+// join, and the default limits of streams.
+type streamsModeOpts struct {
+	stateDir   string
+	stateCache string
+
+	clusterCache    string
+	clusterNodeID   string
+	clusterInterval time.Duration
+
+	limits strmmgr.StreamLimits
+}
+
 func cmdService(
 	confPath string,
 	resourcesPaths []string,
 	confOverrides []string,
 	overrideLogLevel string,
 	strict bool,
+	watching bool,
 	streamsMode bool,
 	streamsConfigs []string,
+	streamsOpts streamsModeOpts,
 ) int {
 	var err error
 	if resourcesPaths, err = filepath.Globs(resourcesPaths); err != nil {
 		fmt.Printf("Failed to resolve resource glob pattern: %v\n", err)
 		return 1
 	}
-	lints := readConfig(confPath, resourcesPaths, confOverrides)
+	confReader, lints := readConfig(confPath, resourcesPaths, confOverrides)
 	if strict && len(lints) > 0 {
 		for _, lint := range lints {
 			fmt.Fprintln(os.Stderr, lint)
@@ -171,11 +191,11 @@ func cmdService(
 
 	// Create our metrics type.
 	var stats metrics.Type
-	stats, err = metrics.New(conf.Metrics, metrics.OptSetLogger(logger))
+	stats, err = bundle.AllMetrics.Init(conf.Metrics, metrics.OptSetLogger(logger))
 	for err != nil {
 		logger.Errorf("Failed to connect to metrics aggregator: %v\n", err)
 		<-time.After(time.Second)
-		stats, err = metrics.New(conf.Metrics, metrics.OptSetLogger(logger))
+		stats, err = bundle.AllMetrics.Init(conf.Metrics, metrics.OptSetLogger(logger))
 	}
 	defer func() {
 		if sCloseErr := stats.Close(); sCloseErr != nil {
@@ -185,7 +205,7 @@ func cmdService(
 
 	// Create our tracer type.
 	var trac tracer.Type
-	if trac, err = tracer.New(conf.Tracer); err != nil {
+	if trac, err = bundle.AllTracers.Init(conf.Tracer); err != nil {
 		logger.Errorf("Failed to initialise tracer: %v\n", err)
 		return 1
 	}
@@ -219,6 +239,24 @@ func cmdService(
 		return 1
 	}
 
+	var exitTimeout time.Duration
+	if tout := conf.SystemCloseTimeout; len(tout) > 0 {
+		var err error
+		if exitTimeout, err = time.ParseDuration(tout); err != nil {
+			logger.Errorf("Failed to parse shutdown timeout period string: %v\n", err)
+			return 1
+		}
+	}
+
+	var drainTimeout time.Duration
+	if tout := conf.SystemDrainTimeout; len(tout) > 0 {
+		var err error
+		if drainTimeout, err = time.ParseDuration(tout); err != nil {
+			logger.Errorf("Failed to parse shutdown drain timeout period string: %v\n", err)
+			return 1
+		}
+	}
+
 	var dataStream stoppableStreams
 	dataStreamClosedChan := make(chan struct{})
 
@@ -231,12 +269,56 @@ func cmdService(
 
 	// Create data streams.
 	if streamsMode {
-		streamMgr := strmmgr.New(
+		var stateStore strmmgr.StateStore
+		switch {
+		case streamsOpts.stateDir != "" && streamsOpts.stateCache != "":
+			logger.Errorln("Only one of --state-dir and --state-cache can be specified")
+			return 1
+		case streamsOpts.stateDir != "":
+			if stateStore, err = strmmgr.NewDirStateStore(streamsOpts.stateDir); err != nil {
+				logger.Errorf("Failed to create state directory: %v\n", err)
+				return 1
+			}
+		case streamsOpts.stateCache != "":
+			if err = interop.ProbeCache(context.Background(), manager, streamsOpts.stateCache); err != nil {
+				logger.Errorf("Failed to access state cache: %v\n", err)
+				return 1
+			}
+			stateStore = strmmgr.NewCacheStateStore(manager, streamsOpts.stateCache, "benthos_streams_state")
+		}
+
+		clustered := streamsOpts.clusterCache != ""
+		if clustered {
+			if stateStore != nil || watching {
+				logger.Errorln("Clustered streams mode cannot currently be combined with --state-dir, --state-cache or --watcher")
+				return 1
+			}
+			if err = interop.ProbeCache(context.Background(), manager, streamsOpts.clusterCache); err != nil {
+				logger.Errorf("Failed to access cluster cache: %v\n", err)
+				return 1
+			}
+			if streamsOpts.clusterNodeID == "" {
+				if streamsOpts.clusterNodeID, err = os.Hostname(); err != nil {
+					logger.Errorf("Failed to obtain hostname for cluster node id: %v\n", err)
+					return 1
+				}
+			}
+		}
+
+		strmMgrOpts := []func(*strmmgr.Type){
 			strmmgr.OptSetAPITimeout(strmAPITimeout),
 			strmmgr.OptSetLogger(logger),
 			strmmgr.OptSetManager(manager),
 			strmmgr.OptSetStats(stats),
-		)
+			strmmgr.OptSetStreamLimits(streamsOpts.limits),
+		}
+		if stateStore != nil {
+			strmMgrOpts = append(strmMgrOpts, strmmgr.OptSetStateStore(stateStore))
+		}
+		if drainTimeout > 0 {
+			strmMgrOpts = append(strmMgrOpts, strmmgr.OptTrackInFlight())
+		}
+		streamMgr := strmmgr.New(strmMgrOpts...)
 		streamConfs := map[string]stream.Config{}
 		var streamLints []string
 		for _, path := range streamsConfigs {
@@ -262,29 +344,90 @@ func cmdService(
 		}
 
 		dataStream = streamMgr
-		for id, conf := range streamConfs {
-			if err = streamMgr.Create(id, conf); err != nil {
-				logger.Errorf("Failed to create stream (%v): %v\n", id, err)
+		if clustered {
+			discovery, err := strmmgr.NewCacheClusterDiscovery(manager, streamsOpts.clusterCache, "benthos_cluster")
+			if err != nil {
+				logger.Errorf("Failed to join cluster: %v\n", err)
+				return 1
+			}
+			if err = streamMgr.StartCluster(discovery, streamsOpts.clusterNodeID, streamsOpts.clusterInterval, streamConfs); err != nil {
+				logger.Errorf("Failed to join cluster: %v\n", err)
+				return 1
+			}
+			logger.Infof("Joined cluster as node '%v'\n", streamsOpts.clusterNodeID)
+		} else {
+			for id, conf := range streamConfs {
+				if err = streamMgr.CreateStatic(id, conf); err != nil {
+					logger.Errorf("Failed to create stream (%v): %v\n", id, err)
+					return 1
+				}
+			}
+		}
+		if stateStore != nil {
+			if err = streamMgr.RestoreState(context.Background()); err != nil {
+				logger.Errorf("Failed to restore streams state: %v\n", err)
+				return 1
+			}
+		}
+		if watching && len(streamsConfigs) > 0 {
+			if err = streamMgr.WatchStreamConfigPaths(streamConfs, testSuffix, strict, streamsConfigs...); err != nil {
+				logger.Errorf("Failed to create stream config watcher: %v\n", err)
 				return 1
 			}
 		}
 		logger.Infoln("Launching benthos in streams mode, use CTRL+C to close.")
+	} else if watching {
+		strmOpts := []func(*stream.Type){
+			stream.OptSetLogger(logger),
+			stream.OptSetStats(stats),
+			stream.OptSetManager(manager),
+		}
+		if drainTimeout > 0 {
+			strmOpts = append(strmOpts, stream.OptTrackInFlight())
+		}
+		swapStream := newSwappableStream(exitTimeout, dataStreamClosedChan, strmOpts...)
+		if err = swapStream.Swap(conf.Config); err != nil {
+			logger.Errorf("Service closing due to: %v\n", err)
+			return 1
+		}
+		dataStream = swapStream
+		confReader.SubscribeMainUpdates(swapStream.Swap)
+		logger.Infoln("Launching a benthos instance, use CTRL+C to close.")
 	} else {
-		if dataStream, err = stream.New(
-			conf.Config,
+		strmOpts := []func(*stream.Type){
 			stream.OptSetLogger(logger),
 			stream.OptSetStats(stats),
 			stream.OptSetManager(manager),
 			stream.OptOnClose(func() {
 				close(dataStreamClosedChan)
 			}),
-		); err != nil {
+		}
+		if drainTimeout > 0 {
+			strmOpts = append(strmOpts, stream.OptTrackInFlight())
+		}
+		if dataStream, err = stream.New(conf.Config, strmOpts...); err != nil {
 			logger.Errorf("Service closing due to: %v\n", err)
 			return 1
 		}
 		logger.Infoln("Launching a benthos instance, use CTRL+C to close.")
 	}
 
+	if watching {
+		if err = confReader.BeginFileWatching(manager, logger, strict); err != nil {
+			logger.Errorf("Failed to create config file watcher: %v\n", err)
+			return 1
+		}
+		defer confReader.Close(context.Background())
+		logger.Infoln("Watching config and resource files for changes.")
+	}
+
+	drain := newDrainer(dataStream, drainTimeout, logger)
+	httpServer.RegisterEndpoint(
+		"/drain",
+		"Stop consuming new messages and shut down the service once all in-flight messages have been flushed, a POST request begins a drain and a GET request reports its progress.",
+		drain.handler(),
+	)
+
 	// Start HTTP server.
 	httpServerClosedChan := make(chan struct{})
 	go func() {
@@ -295,17 +438,16 @@ func cmdService(
 		close(httpServerClosedChan)
 	}()
 
-	var exitTimeout time.Duration
-	if tout := conf.SystemCloseTimeout; len(tout) > 0 {
-		var err error
-		if exitTimeout, err = time.ParseDuration(tout); err != nil {
-			logger.Errorf("Failed to parse shutdown timeout period string: %v\n", err)
-			return 1
-		}
-	}
-
 	// Defer clean up.
+	var dataStreamTerminated bool
 	defer func() {
+		// The HTTP server remains open whilst draining so that responses to
+		// in-flight requests can be delivered and progress can be reported.
+		// There's nothing to drain once the pipeline has terminated.
+		if !dataStreamTerminated {
+			_ = drain.drain()
+		}
+
 		go func() {
 			httpServer.Shutdown(context.Background())
 			select {
@@ -347,7 +489,10 @@ func cmdService(
 	select {
 	case <-sigChan:
 		logger.Infoln("Received SIGTERM, the service is closing.")
+	case <-drain.requested():
+		logger.Infoln("Received drain request, the service is closing.")
 	case <-dataStreamClosedChan:
+		dataStreamTerminated = true
 		logger.Infoln("Pipeline has terminated. Shutting down the service.")
 	case <-httpServerClosedChan:
 		logger.Infoln("HTTP Server has terminated. Shutting down the service.")
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/stream"
+)
+
+// swappableStream wraps a stream that can be replaced by a new stream built
+// from an updated config. The previous stream is stopped before the new one is
+// created, as both might otherwise contend for resources such as listening
+// ports, and the previous config is rebuilt when the new one fails.
+type swappableStream struct {
+	mut         sync.Mutex
+	strm        *stream.Type
+	conf        stream.Config
+	generation  int
+	stopTimeout time.Duration
+	opts        []func(*stream.Type)
+
+	closedChan chan struct{}
+	closed     bool
+}
+
+func newSwappableStream(stopTimeout time.Duration, closedChan chan struct{}, opts ...func(*stream.Type)) *swappableStream {
+	return &swappableStream{
+		stopTimeout: stopTimeout,
+		opts:        opts,
+		closedChan:  closedChan,
+	}
+}
+
+func (s *swappableStream) newStream(conf stream.Config, generation int) (*stream.Type, error) {
+	opts := append([]func(*stream.Type){}, s.opts...)
+	opts = append(opts, stream.OptOnClose(func() {
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		if s.generation == generation && !s.closed {
+			s.closed = true
+			close(s.closedChan)
+		}
+	}))
+	return stream.New(conf, opts...)
+}
+
+// Swap replaces the current stream, if one exists, with a stream built from
+// the provided config. The current stream is stopped first, and if the new
+// stream cannot be created then the stream of the previous config is rebuilt
+// in its place.
+func (s *swappableStream) Swap(conf stream.Config) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	// Bumping the generation prevents the closure of the previous stream from
+	// being mistaken for the pipeline terminating.
+	s.generation++
+
+	var stopErr error
+	if s.strm != nil {
+		// A stream cannot be resumed once it has begun stopping, and therefore
+		// the swap continues even when it fails to stop in time.
+		if stopErr = s.strm.Stop(s.stopTimeout); stopErr != nil {
+			stopErr = fmt.Errorf("failed to stop previous pipeline: %w", stopErr)
+		}
+	}
+
+	strm, err := s.newStream(conf, s.generation)
+	if err == nil {
+		s.strm, s.conf = strm, conf
+		return stopErr
+	}
+	if s.strm == nil {
+		return err
+	}
+
+	prevStrm, prevErr := s.newStream(s.conf, s.generation)
+	if prevErr != nil {
+		// Without a stream the service has nothing left to run.
+		s.strm = nil
+		if !s.closed {
+			s.closed = true
+			close(s.closedChan)
+		}
+		return fmt.Errorf("failed to create pipeline: %v, and failed to restore previous pipeline: %v", err, prevErr)
+	}
+	s.strm = prevStrm
+	return fmt.Errorf("failed to create pipeline, restored previous pipeline: %w", err)
+}
+
+// Drain the current stream.
+func (s *swappableStream) Drain(timeout time.Duration) error {
+	// The lock isn't held whilst draining so that the progress of the drain
+	// can be observed.
+	s.mut.Lock()
+	strm := s.strm
+	s.mut.Unlock()
+	if strm == nil {
+		return nil
+	}
+	return strm.Drain(timeout)
+}
+
+// DrainStage returns the drain stage of the current stream.
+func (s *swappableStream) DrainStage() stream.DrainStage {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.strm == nil {
+		return stream.DrainStageNone
+	}
+	return s.strm.DrainStage()
+}
+
+// InFlight returns the number of messages, and their total size in bytes, that
+// are in flight within the current stream.
+func (s *swappableStream) InFlight() (int, int64) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.strm == nil {
+		return 0, 0
+	}
+	return s.strm.InFlight()
+}
+
+// Stop the current stream.
+func (s *swappableStream) Stop(timeout time.Duration) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.strm == nil {
+		return nil
+	}
+	return s.strm.Stop(timeout)
+}
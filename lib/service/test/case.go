@@ -1,9 +1,12 @@
 package test
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/message/metadata"
@@ -14,6 +17,12 @@ import (
 
 //------------------------------------------------------------------------------
 
+// streamTimeout is the maximum period to wait for a batch to pass through a
+// stream, and for a stream to stop.
+const streamTimeout = time.Second * 10
+
+//------------------------------------------------------------------------------
+
 // InputPart defines an input part for a test case.
 type InputPart struct {
 	Content  string            `yaml:"content"`
@@ -76,7 +85,21 @@ type Case struct {
 	InputBatch       []InputPart          `yaml:"input_batch"`
 	OutputBatches    [][]ConditionsMap    `yaml:"output_batches"`
 
+	InputBatches  [][]InputPart                `yaml:"input_batches,omitempty"`
+	Outputs       map[string][][]ConditionsMap `yaml:"outputs,omitempty"`
+	SyncResponses [][]ConditionsMap            `yaml:"sync_responses,omitempty"`
+
+	MockCaches map[string]map[string]string `yaml:"mock_caches,omitempty"`
+	MockHTTP   []HTTPResponder              `yaml:"mock_http,omitempty"`
+
+	Clock      string `yaml:"clock,omitempty"`
+	RandomSeed *int64 `yaml:"random_seed,omitempty"`
+
 	line int
+
+	// updateSnapshots results in snapshot conditions being overwritten with
+	// the messages they are checked against.
+	updateSnapshots bool
 }
 
 // AtLine returns a test case at a given line.
@@ -137,17 +160,64 @@ type mockedProcProvider interface {
 	ProvideMocked(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node) ([]types.Processor, error)
 }
 
+type resourceMockedProcProvider interface {
+	ProvideMockedResources(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node, resources ResourceMocks) ([]types.Processor, error)
+	ProvideBloblangMockedResources(path string, resources ResourceMocks) ([]types.Processor, error)
+}
+
+func (c *Case) hasResourceMocks() bool {
+	return len(c.MockCaches) > 0 || len(c.MockHTTP) > 0 || c.Clock != "" || c.RandomSeed != nil
+}
+
 // Execute attempts to execute a test case against a Benthos configuration.
 func (c *Case) Execute(provider ProcProvider) (failures []CaseFailure, err error) {
 	return c.executeFrom("", provider)
 }
 
+// ExecuteFrom attempts to execute a test case against a Benthos configuration,
+// where files referenced by the test case are relative to a directory.
+func (c *Case) ExecuteFrom(dir string, provider ProcProvider) (failures []CaseFailure, err error) {
+	return c.executeFrom(dir, provider)
+}
+
 func (c *Case) executeFrom(dir string, provider ProcProvider) (failures []CaseFailure, err error) {
+	resources := ResourceMocks{
+		Caches:     c.MockCaches,
+		RandomSeed: c.RandomSeed,
+	}
+	if c.Clock != "" {
+		clock, err := time.Parse(time.RFC3339Nano, c.Clock)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse clock: %v", err)
+		}
+		resources.Clock = &clock
+	}
+	if len(c.MockHTTP) > 0 {
+		server := newMockHTTPServer(c.MockHTTP)
+		defer server.Close()
+		resources.HTTPURL = server.URL
+	}
+
+	if len(c.InputBatches) > 0 || (len(c.Outputs) > 0 && len(c.InputBatch) == 0) {
+		return c.executeStreamFrom(dir, provider, resources)
+	}
+
+	resourceMockedProcProv, resourceMockable := provider.(resourceMockedProcProvider)
+	resourceMockable = resourceMockable && c.hasResourceMocks()
+
 	var procSet []types.Processor
-	if c.TargetMapping != "" {
+	if c.TargetMapping != "" && resourceMockable {
+		if procSet, err = resourceMockedProcProv.ProvideBloblangMockedResources(c.TargetMapping, resources); err != nil {
+			return nil, fmt.Errorf("failed to initialise Bloblang mapping '%v': %v", c.TargetMapping, err)
+		}
+	} else if c.TargetMapping != "" {
 		if procSet, err = provider.ProvideBloblang(c.TargetMapping); err != nil {
 			return nil, fmt.Errorf("failed to initialise Bloblang mapping '%v': %v", c.TargetMapping, err)
 		}
+	} else if resourceMockable {
+		if procSet, err = resourceMockedProcProv.ProvideMockedResources(c.TargetProcessors, c.Environment, c.Mocks, resources); err != nil {
+			return nil, fmt.Errorf("failed to initialise processors '%v': %v", c.TargetProcessors, err)
+		}
 	} else if mockedProcProv, ok := provider.(mockedProcProvider); ok {
 		if procSet, err = mockedProcProv.ProvideMocked(c.TargetProcessors, c.Environment, c.Mocks); err != nil {
 			return nil, fmt.Errorf("failed to initialise processors '%v': %v", c.TargetProcessors, err)
@@ -164,20 +234,10 @@ func (c *Case) executeFrom(dir string, provider ProcProvider) (failures []CaseFa
 		})
 	}
 
-	parts := make([]types.Part, len(c.InputBatch))
-	for i, v := range c.InputBatch {
-		var content string
-		if content, err = v.getContent(dir); err != nil {
-			err = fmt.Errorf("failed to create mock input %v: %w", i, err)
-			return
-		}
-		part := message.NewPart([]byte(content))
-		part.SetMetadata(metadata.New(v.Metadata))
-		parts[i] = part
+	var inputMsg types.Message
+	if inputMsg, err = newInputBatch(dir, c.InputBatch); err != nil {
+		return
 	}
-
-	inputMsg := message.New(nil)
-	inputMsg.SetAll(parts)
 	outputBatches, result := processor.ExecuteAll(procSet, inputMsg)
 	if result != nil {
 		if len(c.OutputBatches) == 0 {
@@ -191,34 +251,124 @@ func (c *Case) executeFrom(dir string, provider ProcProvider) (failures []CaseFa
 		return
 	}
 
-	if lExp, lAct := len(c.OutputBatches), len(outputBatches); lAct < lExp {
-		reportFailure(fmt.Sprintf("wrong batch count, expected %v, got %v", lExp, lAct))
+	checkBatches(dir, "", c.updateSnapshots, c.OutputBatches, outputBatches, reportFailure)
+	return
+}
+
+//------------------------------------------------------------------------------
+
+func newInputBatch(dir string, inputParts []InputPart) (types.Message, error) {
+	parts := make([]types.Part, len(inputParts))
+	for i, v := range inputParts {
+		content, err := v.getContent(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create mock input %v: %w", i, err)
+		}
+		part := message.NewPart([]byte(content))
+		part.SetMetadata(metadata.New(v.Metadata))
+		parts[i] = part
 	}
 
-	for i, v := range outputBatches {
-		if len(c.OutputBatches) <= i {
-			reportFailure(fmt.Sprintf("unexpected batch: %s", message.GetAllBytes(v)))
+	inputMsg := message.New(nil)
+	inputMsg.SetAll(parts)
+	return inputMsg, nil
+}
+
+func checkBatches(dir, prefix string, updateSnapshots bool, expected [][]ConditionsMap, actual []types.Message, reportFailure func(string)) {
+	if lExp, lAct := len(expected), len(actual); lAct < lExp {
+		reportFailure(fmt.Sprintf("%vwrong batch count, expected %v, got %v", prefix, lExp, lAct))
+	}
+
+	for i, v := range actual {
+		if len(expected) <= i {
+			reportFailure(fmt.Sprintf("%vunexpected batch: %s", prefix, message.GetAllBytes(v)))
 			continue
 		}
-		expectedBatch := c.OutputBatches[i]
+		expectedBatch := expected[i]
 		if lExp, lAct := len(expectedBatch), v.Len(); lExp != lAct {
-			reportFailure(fmt.Sprintf("mismatch of output batch %v message counts, expected %v, got %v", i, lExp, lAct))
+			reportFailure(fmt.Sprintf("%vmismatch of output batch %v message counts, expected %v, got %v", prefix, i, lExp, lAct))
 		}
 		v.Iter(func(i2 int, part types.Part) error {
 			if len(expectedBatch) <= i2 {
-				reportFailure(fmt.Sprintf("unexpected message from batch %v: %s", i, part.Get()))
+				reportFailure(fmt.Sprintf("%vunexpected message from batch %v: %s", prefix, i, part.Get()))
 				return nil
 			}
-			condErrs := expectedBatch[i2].checkAllFrom(dir, part)
+			condErrs := expectedBatch[i2].checkAllFrom(dir, updateSnapshots, part)
 			for _, condErr := range condErrs {
-				reportFailure(fmt.Sprintf("batch %v message %v: %v", i, i2, condErr))
+				reportFailure(fmt.Sprintf("%vbatch %v message %v: %v", prefix, i, i2, condErr))
 			}
 			if procErr := processor.GetFail(part); len(procErr) > 0 && len(condErrs) > 0 {
-				reportFailure(fmt.Sprintf("batch %v message %v: %v", i, i2, red(procErr)))
+				reportFailure(fmt.Sprintf("%vbatch %v message %v: %v", prefix, i, i2, red(procErr)))
 			}
 			return nil
 		})
 	}
+}
+
+// executeStreamFrom runs a case against the full stream of a config, where
+// input batches are sent through the input, pipeline and outputs of the
+// config, and the batches received by captured outputs are checked. When the
+// case has no input batches the input of the config is kept, and the case
+// waits for each captured output to receive the expected number of batches.
+func (c *Case) executeStreamFrom(dir string, provider ProcProvider, resources ResourceMocks) (failures []CaseFailure, err error) {
+	streamProv, ok := provider.(StreamProvider)
+	if !ok {
+		return nil, errors.New("test cases with input_batches are not supported by this provider")
+	}
+
+	captures := make([]string, 0, len(c.Outputs))
+	for k := range c.Outputs {
+		captures = append(captures, k)
+	}
+	sort.Strings(captures)
+
+	var strm *MockedStream
+	mockInput := len(c.InputBatches) > 0
+	if strm, err = streamProv.ProvideStream(c.Environment, c.Mocks, resources, captures, mockInput); err != nil {
+		return nil, fmt.Errorf("failed to initialise stream: %v", err)
+	}
+	defer func() {
+		if stopErr := strm.Stop(streamTimeout); stopErr != nil && err == nil {
+			err = fmt.Errorf("failed to stop stream: %v", stopErr)
+		}
+	}()
+
+	reportFailure := func(reason string) {
+		failures = append(failures, CaseFailure{
+			Name:     c.Name,
+			TestLine: c.line,
+			Reason:   reason,
+		})
+	}
+
+	var responses []types.Message
+	for i, batch := range c.InputBatches {
+		var inputMsg types.Message
+		if inputMsg, err = newInputBatch(dir, batch); err != nil {
+			return
+		}
+		resMsgs, sendErr := strm.Send(inputMsg, streamTimeout)
+		if sendErr != nil {
+			reportFailure(fmt.Sprintf("input batch %v was rejected: %v", i, sendErr))
+		}
+		responses = append(responses, resMsgs...)
+	}
+
+	for _, k := range captures {
+		captured := strm.Captured(k)
+		if !mockInput {
+			// The input of the config continues to produce messages, and
+			// therefore only the expected number of batches are checked.
+			strm.WaitForCaptured(k, len(c.Outputs[k]), streamTimeout)
+			if captured = strm.Captured(k); len(captured) > len(c.Outputs[k]) {
+				captured = captured[:len(c.Outputs[k])]
+			}
+		}
+		checkBatches(dir, fmt.Sprintf("output '%v' ", k), c.updateSnapshots, c.Outputs[k], captured, reportFailure)
+	}
+	if c.SyncResponses != nil {
+		checkBatches(dir, "sync response ", c.updateSnapshots, c.SyncResponses, responses, reportFailure)
+	}
 	return
 }
 
@@ -336,3 +336,59 @@ output_batches:
 		},
 	}, fails)
 }
+
+func TestCaseRewriteURLs(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+pipeline:
+  processors:
+    - http:
+        url: http://${! meta("host") }/users/${! json("id") }?a=${! meta("b") }
+        verb: GET
+output:
+  elasticsearch:
+    urls:
+      - http://localhost:9200,https://localhost:9201/foo
+      - tcp://localhost:9300
+`), &root))
+
+	mocks := ResourceMocks{HTTPURL: "http://127.0.0.1:1234/"}
+	require.NoError(t, mocks.rewriteURLs(&root))
+
+	var conf struct {
+		Pipeline struct {
+			Processors []struct {
+				HTTP struct {
+					URL string `yaml:"url"`
+				} `yaml:"http"`
+			} `yaml:"processors"`
+		} `yaml:"pipeline"`
+		Output struct {
+			Elasticsearch struct {
+				URLs []string `yaml:"urls"`
+			} `yaml:"elasticsearch"`
+		} `yaml:"output"`
+	}
+	require.NoError(t, root.Decode(&conf))
+
+	assert.Equal(t, `http://127.0.0.1:1234/users/${! json("id") }?a=${! meta("b") }`, conf.Pipeline.Processors[0].HTTP.URL)
+	assert.Equal(t, []string{
+		"http://127.0.0.1:1234,http://127.0.0.1:1234/foo",
+		"tcp://localhost:9300",
+	}, conf.Output.Elasticsearch.URLs)
+
+	for _, badURL := range []string{
+		`${! meta("url") }`,
+		`http://${! meta("host") /foo`,
+	} {
+		var root yaml.Node
+		require.NoError(t, yaml.Unmarshal([]byte(`
+output:
+  http_client:
+    url: '`+badURL+`'
+`), &root))
+		err := mocks.rewriteURLs(&root)
+		require.Error(t, err, badURL)
+		assert.Contains(t, err.Error(), "output.http_client.url")
+	}
+}
@@ -9,8 +9,10 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-// CliCommand is a cli.Command definition for unit testing.
-func CliCommand(testSuffix string) *cli.Command {
+// CliCommand is a cli.Command definition for unit testing. Functions that
+// provide tests other than those of config files, such as the tests of
+// templates, can be added and are executed alongside the config tests.
+func CliCommand(testSuffix string, extraTargets ...func() map[string]TargetFunc) *cli.Command {
 	return &cli.Command{
 		Name:  "test",
 		Usage: "Execute Benthos unit tests",
@@ -21,6 +23,12 @@ func CliCommand(testSuffix string) *cli.Command {
    benthos test ./path/to/configs/...
    benthos test ./foo_configs ./bar_configs
    benthos test ./foo.yaml
+   benthos test --format junit --coverage ./path/to/configs/... > report.xml
+
+   The tests of any templates imported with the --templates (-t) flag are also
+   executed:
+
+   benthos -t "./templates/*.yaml" test ./path/to/configs/...
 
    For more information check out the docs at:
    https://benthos.dev/docs/configuration/unit_testing`[4:],
@@ -35,6 +43,26 @@ func CliCommand(testSuffix string) *cli.Command {
 				Value: "",
 				Usage: "allow components to write logs at a provided level to stdout.",
 			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: FormatDefault,
+				Usage: "the format to report results in, options are default, junit, tap and json.",
+			},
+			&cli.BoolFlag{
+				Name:  "coverage",
+				Value: false,
+				Usage: "report which processors, switch cases and Bloblang branches were executed by tests.",
+			},
+			&cli.Float64Flag{
+				Name:  "coverage-min",
+				Value: 0,
+				Usage: "fail when the percentage of processors, switch cases and Bloblang branches executed by tests is below a minimum, implies --coverage.",
+			},
+			&cli.BoolFlag{
+				Name:  "update-snapshots",
+				Value: false,
+				Usage: "create or overwrite the files of snapshot conditions with the messages they are checked against.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if c.Bool("generate") {
@@ -56,14 +84,28 @@ func CliCommand(testSuffix string) *cli.Command {
 				fmt.Printf("Failed to resolve resource glob pattern: %v\n", err)
 				os.Exit(1)
 			}
+			conf := runConfig{
+				lint:           true,
+				logger:         log.Noop(),
+				resourcesPaths: resourcesPaths,
+				format:         c.String("format"),
+				coverage:       c.Bool("coverage"),
+				coverageMin:    c.Float64("coverage-min"),
+
+				updateSnapshots: c.Bool("update-snapshots"),
+				extraTargets:    map[string]TargetFunc{},
+			}
+			for _, fn := range extraTargets {
+				for k, v := range fn() {
+					conf.extraTargets[k] = v
+				}
+			}
 			if logLevel := c.String("log"); len(logLevel) > 0 {
 				logConf := log.NewConfig()
 				logConf.LogLevel = logLevel
-				logger := log.New(os.Stdout, logConf)
-				if runAll(c.Args().Slice(), testSuffix, true, logger, resourcesPaths) {
-					os.Exit(0)
-				}
-			} else if runAll(c.Args().Slice(), testSuffix, true, log.Noop(), resourcesPaths) {
+				conf.logger = log.New(os.Stdout, logConf)
+			}
+			if runAll(c.Args().Slice(), testSuffix, conf) {
 				os.Exit(0)
 			}
 			os.Exit(1)
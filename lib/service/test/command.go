@@ -2,10 +2,12 @@ package test
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/config"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -157,17 +159,70 @@ func Run(path, testSuffix string, lint bool) bool {
 // a config file, a config files test definition file, a directory, or the
 // wildcard pattern './...'.
 func RunAll(paths []string, testSuffix string, lint bool) bool {
-	return runAll(paths, testSuffix, lint, log.Noop(), nil)
+	return runAll(paths, testSuffix, runConfig{
+		lint:   lint,
+		logger: log.Noop(),
+		format: FormatDefault,
+	})
 }
 
 // RunAllWithLogger executes the test command for a slice of paths. The path can
 // either be a config file, a config files test definition file, a directory, or
 // the wildcard pattern './...'.
 func RunAllWithLogger(paths []string, testSuffix string, lint bool, logger log.Modular) bool {
-	return runAll(paths, testSuffix, lint, logger, nil)
+	return runAll(paths, testSuffix, runConfig{
+		lint:   lint,
+		logger: logger,
+		format: FormatDefault,
+	})
+}
+
+type runConfig struct {
+	lint           bool
+	logger         log.Modular
+	resourcesPaths []string
+
+	// format is the format that results are reported in.
+	format string
+
+	// coverage enables a coverage report, and when coverageMin is greater than
+	// zero the run fails if the percentage of covered items is lower.
+	coverage    bool
+	coverageMin float64
+
+	// updateSnapshots results in snapshot conditions being overwritten rather
+	// than checked.
+	updateSnapshots bool
+
+	// extraTargets are tests other than those of config files, such as the
+	// tests of templates, keyed by the path of the file they belong to.
+	extraTargets map[string]TargetFunc
+
+	// out and errOut are where reports and errors are written, which default
+	// to stdout and stderr respectively.
+	out, errOut io.Writer
 }
 
-func runAll(paths []string, testSuffix string, lint bool, logger log.Modular, resourcesPaths []string) bool {
+// TargetFunc executes the tests of a target other than a config file, such as
+// a template, and returns a definition of its test cases along with any
+// failures.
+type TargetFunc func() (Definition, []CaseFailure, error)
+
+func runAll(paths []string, testSuffix string, conf runConfig) bool {
+	if conf.out == nil {
+		conf.out = os.Stdout
+	}
+	if conf.errOut == nil {
+		conf.errOut = os.Stderr
+	}
+	if conf.format == "" {
+		conf.format = FormatDefault
+	}
+	if !validFormat(conf.format) {
+		fmt.Fprintf(conf.errOut, "Unrecognised test output format: %v\n", conf.format)
+		return false
+	}
+
 	targets := map[string]Definition{}
 
 	for _, path := range paths {
@@ -175,7 +230,7 @@ func runAll(paths []string, testSuffix string, lint bool, logger log.Modular, re
 		path, recurse = resolveTestPath(path)
 		lTargets, err := GetTestTargets(path, testSuffix, recurse)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to obtain test targets: %v\n", err)
+			fmt.Fprintf(conf.errOut, "Failed to obtain test targets: %v\n", err)
 			return false
 		}
 		for k, v := range lTargets {
@@ -183,17 +238,19 @@ func runAll(paths []string, testSuffix string, lint bool, logger log.Modular, re
 		}
 	}
 
-	if len(targets) == 0 {
-		fmt.Printf("%v\n", yellow("No tests were found"))
+	if len(targets) == 0 && len(conf.extraTargets) == 0 {
+		if conf.format == FormatDefault {
+			fmt.Fprintf(conf.out, "%v\n", yellow("No tests were found"))
+		} else {
+			fmt.Fprintln(conf.errOut, "No tests were found")
+		}
 		return false
 	}
 
-	type failedTarget struct {
-		target string
-		lints  []string
-		cases  []CaseFailure
+	var cov *Coverage
+	if conf.coverage || conf.coverageMin > 0 {
+		cov = NewCoverage()
 	}
-	fails := []failedTarget{}
 
 	targetPaths := make([]string, 0, len(targets))
 	for k := range targets {
@@ -201,61 +258,123 @@ func runAll(paths []string, testSuffix string, lint bool, logger log.Modular, re
 	}
 	sort.Strings(targetPaths)
 
-	var err error
+	passed := true
+	results := make([]targetResult, 0, len(targetPaths)+len(conf.extraTargets))
+	addResult := func(res targetResult) {
+		results = append(results, res)
+		passed = passed && res.Passed
+
+		if conf.format == FormatDefault {
+			if res.Passed {
+				fmt.Fprintf(conf.out, "Test '%v' %v\n", res.Target, green("succeeded"))
+			} else {
+				fmt.Fprintf(conf.out, "Test '%v' %v\n", res.Target, red("failed"))
+			}
+		}
+	}
+
 	for _, target := range targetPaths {
 		var lints []string
-		var failCases []CaseFailure
-		if lint {
+		var err error
+		if conf.lint {
 			if lints, err = lintTarget(target, testSuffix); err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to execute test target '%v': %v\n", target, err)
+				fmt.Fprintf(conf.errOut, "Failed to execute test target '%v': %v\n", target, err)
 				return false
 			}
 		}
-		if failCases, err = targets[target].execute(target, resourcesPaths, logger); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to execute test target '%v': %v\n", target, err)
+		started := time.Now()
+		failCases, err := targets[target].execute(target, executeConfig{
+			resourcesPaths:  conf.resourcesPaths,
+			logger:          conf.logger,
+			coverage:        cov,
+			updateSnapshots: conf.updateSnapshots,
+		})
+		if err != nil {
+			fmt.Fprintf(conf.errOut, "Failed to execute test target '%v': %v\n", target, err)
 			return false
 		}
-		if len(lints) > 0 || len(failCases) > 0 {
-			fails = append(fails, failedTarget{
-				target: target,
-				lints:  lints,
-				cases:  failCases,
-			})
-			fmt.Printf("Test '%v' %v\n", target, red("failed"))
-		} else {
-			fmt.Printf("Test '%v' %v\n", target, green("succeeded"))
+		addResult(newTargetResult(target, targets[target], lints, failCases, time.Since(started)))
+	}
+
+	extraPaths := make([]string, 0, len(conf.extraTargets))
+	for k := range conf.extraTargets {
+		extraPaths = append(extraPaths, k)
+	}
+	sort.Strings(extraPaths)
+
+	for _, target := range extraPaths {
+		started := time.Now()
+		def, failCases, err := conf.extraTargets[target]()
+		if err != nil {
+			fmt.Fprintf(conf.errOut, "Failed to execute test target '%v': %v\n", target, err)
+			return false
 		}
+		addResult(newTargetResult(target, def, nil, failCases, time.Since(started)))
+	}
+
+	var covReport *CoverageReport
+	if cov != nil {
+		r := cov.Report()
+		covReport = &r
+	}
+
+	if conf.format == FormatDefault {
+		writeFailures(conf.out, results)
+	} else if err := writeReport(conf.out, conf.format, results, covReport); err != nil {
+		fmt.Fprintf(conf.errOut, "Failed to write test report: %v\n", err)
+		return false
 	}
-	if len(fails) > 0 {
-		fmt.Printf("\nFailures:\n\n")
-		for i, fail := range fails {
-			if i > 0 {
-				fmt.Println("")
+
+	if covReport != nil {
+		// Reports in a structured format are written to stdout and therefore
+		// the human readable coverage summary is written to stderr instead.
+		switch conf.format {
+		case FormatDefault:
+			fmt.Fprintln(conf.out, "")
+			writeCoverage(conf.out, *covReport)
+		case FormatJUnit, FormatTAP:
+			writeCoverage(conf.errOut, *covReport)
+		}
+		if pct := covReport.Percentage(); pct < conf.coverageMin {
+			fmt.Fprintf(conf.errOut, "Coverage of %.1f%% is below the minimum of %.1f%%\n", pct, conf.coverageMin)
+			passed = false
+		}
+	}
+	return passed
+}
+
+// writeFailures prints the lint errors and failed cases of failed targets.
+func writeFailures(w io.Writer, results []targetResult) {
+	first := true
+	for _, res := range results {
+		if res.Passed {
+			continue
+		}
+		if first {
+			fmt.Fprintf(w, "\nFailures:\n\n")
+			first = false
+		} else {
+			fmt.Fprintln(w, "")
+		}
+		fmt.Fprintf(w, "--- %v ---\n\n", res.Target)
+		for _, lint := range res.Lints {
+			fmt.Fprintf(w, "Lint: %v\n", lint)
+		}
+		printedCase := false
+		for _, c := range res.Cases {
+			if c.Passed {
+				continue
 			}
-			fmt.Printf("--- %v ---\n\n", fail.target)
-			for _, lint := range fail.lints {
-				fmt.Printf("Lint: %v\n", lint)
+			if printedCase || len(res.Lints) > 0 {
+				fmt.Fprintln(w, "")
 			}
-			if len(fail.cases) > 0 {
-				if len(fail.lints) > 0 {
-					fmt.Println("")
-				}
-				var namePrev string
-				for i, fail := range fail.cases {
-					if namePrev != fail.Name {
-						if i > 0 {
-							fmt.Println("")
-						}
-						fmt.Printf("%v [line %v]:\n", fail.Name, fail.TestLine)
-						namePrev = fail.Name
-					}
-					fmt.Println(fail.Reason)
-				}
+			printedCase = true
+			fmt.Fprintf(w, "%v [line %v]:\n", c.Name, c.Line)
+			for _, f := range c.Failures {
+				fmt.Fprintln(w, f)
 			}
 		}
-		return false
 	}
-	return true
 }
 
 //------------------------------------------------------------------------------
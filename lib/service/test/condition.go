@@ -5,16 +5,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
 	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/nsf/jsondiff"
+	jsonschema "github.com/xeipuuv/gojsonschema"
 	yaml "gopkg.in/yaml.v3"
 )
 
@@ -72,12 +76,61 @@ func (c *ConditionsMap) UnmarshalYAML(value *yaml.Node) error {
 				return fmt.Errorf("line %v: %v", v.Line, err)
 			}
 			cond = val
+		case "json_schema":
+			val, err := parseJSONSchemaCondition(&v)
+			if err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			cond = val
+		case "json_approx_equals", "json_approx_contains":
+			val := &JSONApproxCondition{}
+			if err := v.Decode(val); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			val.Contains = k == "json_approx_contains"
+			cond = val
 		case "file_equals":
 			val := FileEqualsCondition("")
 			if err := v.Decode(&val); err != nil {
 				return fmt.Errorf("line %v: %v", v.Line, err)
 			}
 			cond = val
+		case "file_json_equals":
+			val := FileJSONEqualsCondition("")
+			if err := v.Decode(&val); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			cond = val
+		case "file_json_contains":
+			val := FileJSONContainsCondition("")
+			if err := v.Decode(&val); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			cond = val
+		case "snapshot":
+			val := SnapshotCondition("")
+			if err := v.Decode(&val); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			cond = val
+		case "errored":
+			val := ErroredCondition(false)
+			if err := v.Decode(&val); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			cond = val
+		case "error_equals":
+			val := ErrorEqualsCondition("")
+			if err := v.Decode(&val); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			cond = val
+		case "error_contains":
+			val := ErrorContainsCondition("")
+			if err := v.Decode(&val); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			cond = val
 		case "metadata_equals":
 			val := MetadataEqualsCondition{}
 			if err := v.Decode(&val); err != nil {
@@ -95,17 +148,24 @@ func (c *ConditionsMap) UnmarshalYAML(value *yaml.Node) error {
 // CheckAll checks all conditions against a message part. Conditions are
 // executed in alphabetical order.
 func (c ConditionsMap) CheckAll(part types.Part) (errs []error) {
-	return c.checkAllFrom("", part)
+	return c.checkAllFrom("", false, part)
 }
 
-func (c ConditionsMap) checkAllFrom(dir string, part types.Part) (errs []error) {
+// checkAllFrom checks all conditions against a message part where file paths
+// are relative to a directory. When updateSnapshots is true snapshot conditions
+// are overwritten with the contents of the message part rather than checked.
+func (c ConditionsMap) checkAllFrom(dir string, updateSnapshots bool, part types.Part) (errs []error) {
 	condTypes := []string{}
 	for k := range c {
 		condTypes = append(condTypes, k)
 	}
 	sort.Strings(condTypes)
 	for _, k := range condTypes {
-		if relCheck, ok := c[k].(interface {
+		if snapshot, ok := c[k].(SnapshotCondition); ok && updateSnapshots {
+			if err := snapshot.updateFrom(dir, part); err != nil {
+				errs = append(errs, fmt.Errorf("%v: %v", k, err))
+			}
+		} else if relCheck, ok := c[k].(interface {
 			checkFrom(string, types.Part) error
 		}); ok {
 			if err := relCheck.checkFrom(dir, part); err != nil {
@@ -243,6 +303,255 @@ func (c FileEqualsCondition) checkFrom(dir string, p types.Part) error {
 
 //------------------------------------------------------------------------------
 
+// FileJSONEqualsCondition is a string condition that reads a file at the
+// string path and compares it against the contents of a message using JSON
+// comparison, and is true if both documents are valid JSON and deeply equal.
+type FileJSONEqualsCondition string
+
+// Check this condition against a message part.
+func (c FileJSONEqualsCondition) Check(p types.Part) error {
+	return c.checkFrom("", p)
+}
+
+func (c FileJSONEqualsCondition) checkFrom(dir string, p types.Part) error {
+	fileContent, err := os.ReadFile(filepath.Join(dir, string(c)))
+	if err != nil {
+		return fmt.Errorf("failed to read comparison file: %w", err)
+	}
+	return ContentJSONEqualsCondition(fileContent).Check(p)
+}
+
+//------------------------------------------------------------------------------
+
+// FileJSONContainsCondition is a string condition that reads a file at the
+// string path and compares it against the contents of a message using JSON
+// comparison, and is true if both documents are valid JSON and the message is
+// a superset of the file.
+type FileJSONContainsCondition string
+
+// Check this condition against a message part.
+func (c FileJSONContainsCondition) Check(p types.Part) error {
+	return c.checkFrom("", p)
+}
+
+func (c FileJSONContainsCondition) checkFrom(dir string, p types.Part) error {
+	fileContent, err := os.ReadFile(filepath.Join(dir, string(c)))
+	if err != nil {
+		return fmt.Errorf("failed to read comparison file: %w", err)
+	}
+	return ContentJSONContainsCondition(fileContent).Check(p)
+}
+
+//------------------------------------------------------------------------------
+
+// SnapshotCondition is a string condition that reads a snapshot file at the
+// string path and compares it against the contents of a message. Snapshots are
+// created and updated by running tests with snapshot updates enabled.
+type SnapshotCondition string
+
+// Check this condition against a message part.
+func (c SnapshotCondition) Check(p types.Part) error {
+	return c.checkFrom("", p)
+}
+
+func (c SnapshotCondition) checkFrom(dir string, p types.Part) error {
+	fileContent, err := os.ReadFile(filepath.Join(dir, string(c)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("snapshot '%v' does not exist, run the tests with --update-snapshots in order to create it", string(c))
+		}
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	if exp, act := string(fileContent), string(p.Get()); exp != act {
+		return fmt.Errorf("snapshot mismatch, run the tests with --update-snapshots if this change is intended\n  expected: %v\n  received: %v", blue(exp), red(act))
+	}
+	return nil
+}
+
+func (c SnapshotCondition) updateFrom(dir string, p types.Part) error {
+	snapshotPath := filepath.Join(dir, string(c))
+	if err := os.MkdirAll(filepath.Dir(snapshotPath), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := os.WriteFile(snapshotPath, p.Get(), 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// JSONSchemaCondition validates the contents of a message against a JSON
+// schema.
+type JSONSchemaCondition struct {
+	schema *jsonschema.Schema
+}
+
+func parseJSONSchemaCondition(n *yaml.Node) (*JSONSchemaCondition, error) {
+	var schemaStr string
+	if err := yamlNodeToTestString(n, &schemaStr); err != nil {
+		return nil, err
+	}
+	schema, err := jsonschema.NewSchema(jsonschema.NewStringLoader(schemaStr))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON schema: %v", err)
+	}
+	return &JSONSchemaCondition{schema: schema}, nil
+}
+
+// Check this condition against a message part.
+func (c *JSONSchemaCondition) Check(p types.Part) error {
+	result, err := c.schema.Validate(jsonschema.NewBytesLoader(p.Get()))
+	if err != nil {
+		return fmt.Errorf("failed to validate content: %v", err)
+	}
+	if result.Valid() {
+		return nil
+	}
+	var buf bytes.Buffer
+	buf.WriteString("JSON schema mismatch")
+	for _, desc := range result.Errors() {
+		fmt.Fprintf(&buf, "\n  %v", red(desc.String()))
+	}
+	return errors.New(buf.String())
+}
+
+//------------------------------------------------------------------------------
+
+// JSONApproxCondition compares the contents of a message against a JSON value
+// where numbers are considered equal when they differ by no more than a
+// tolerance. When Contains is true the message may be a superset of the value.
+type JSONApproxCondition struct {
+	Value     interface{} `yaml:"value"`
+	Tolerance float64     `yaml:"tolerance"`
+	Contains  bool        `yaml:"-"`
+}
+
+// Check this condition against a message part.
+func (c *JSONApproxCondition) Check(p types.Part) error {
+	// Normalise the expected value into the structure of a parsed JSON
+	// document.
+	expBytes, err := json.Marshal(c.Value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal expected value: %v", err)
+	}
+	var exp, act interface{}
+	if err = json.Unmarshal(expBytes, &exp); err != nil {
+		return fmt.Errorf("failed to parse expected value: %v", err)
+	}
+	if err = json.Unmarshal(p.Get(), &act); err != nil {
+		return fmt.Errorf("failed to parse content as JSON: %v", err)
+	}
+	return c.compare("root", exp, act)
+}
+
+func (c *JSONApproxCondition) compare(path string, exp, act interface{}) error {
+	mismatch := func() error {
+		expBytes, _ := json.Marshal(exp)
+		actBytes, _ := json.Marshal(act)
+		return fmt.Errorf("JSON mismatch at %v\n  expected: %v\n  received: %s", path, blue(string(expBytes)), red(string(actBytes)))
+	}
+
+	switch e := exp.(type) {
+	case map[string]interface{}:
+		a, ok := act.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		keys := make([]string, 0, len(e))
+		for k := range e {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			av, exists := a[k]
+			if !exists {
+				return fmt.Errorf("JSON mismatch at %v.%v\n  expected key was missing", path, k)
+			}
+			if err := c.compare(path+"."+k, e[k], av); err != nil {
+				return err
+			}
+		}
+		if !c.Contains && len(a) > len(e) {
+			for k := range a {
+				if _, exists := e[k]; !exists {
+					return fmt.Errorf("JSON mismatch at %v.%v\n  unexpected key", path, k)
+				}
+			}
+		}
+	case []interface{}:
+		a, ok := act.([]interface{})
+		if !ok || len(a) != len(e) {
+			return mismatch()
+		}
+		for i := range e {
+			if err := c.compare(fmt.Sprintf("%v.%v", path, i), e[i], a[i]); err != nil {
+				return err
+			}
+		}
+	case float64:
+		a, ok := act.(float64)
+		if !ok || math.Abs(e-a) > c.Tolerance {
+			return mismatch()
+		}
+	default:
+		if exp != act {
+			return mismatch()
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// ErroredCondition checks whether a message has been flagged as having failed
+// a processing step.
+type ErroredCondition bool
+
+// Check this condition against a message part.
+func (c ErroredCondition) Check(p types.Part) error {
+	failed := processor.HasFailed(p)
+	if bool(c) && !failed {
+		return errors.New("expected message to have failed processing")
+	}
+	if !bool(c) && failed {
+		return fmt.Errorf("expected message not to have failed processing\n  received: %v", red(processor.GetFail(p)))
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// ErrorEqualsCondition is a string condition that tests the string against the
+// error of a message that has failed a processing step.
+type ErrorEqualsCondition string
+
+// Check this condition against a message part.
+func (c ErrorEqualsCondition) Check(p types.Part) error {
+	if exp, act := string(c), processor.GetFail(p); exp != act {
+		return fmt.Errorf("error mismatch\n  expected: %v\n  received: %v", blue(exp), red(act))
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// ErrorContainsCondition is a string condition that tests whether the error of
+// a message that has failed a processing step contains the string.
+type ErrorContainsCondition string
+
+// Check this condition against a message part.
+func (c ErrorContainsCondition) Check(p types.Part) error {
+	act := processor.GetFail(p)
+	if !processor.HasFailed(p) || !strings.Contains(act, string(c)) {
+		return fmt.Errorf("error mismatch\n  expected to contain: %v\n  received: %v", blue(string(c)), red(act))
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
 // MetadataEqualsCondition checks whether a metadata keys contents matches a
 // value.
 type MetadataEqualsCondition map[string]string
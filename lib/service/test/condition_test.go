@@ -9,6 +9,8 @@ import (
 	"testing"
 
 	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/fatih/color"
 	"github.com/nsf/jsondiff"
 	"github.com/stretchr/testify/assert"
@@ -480,3 +482,294 @@ func TestFileEqualsCondition(t *testing.T) {
 		})
 	}
 }
+
+func TestFileJSONConditions(t *testing.T) {
+	color.NoColor = true
+
+	tmpDir, err := os.MkdirTemp("", "test_file_json_condition")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "doc.json"), []byte(`{"foo":"bar","baz":[1,2]}`), 0644))
+
+	type testCase struct {
+		name string
+		cond interface {
+			checkFrom(string, types.Part) error
+		}
+		input       string
+		errContains string
+	}
+
+	tests := []testCase{
+		{
+			name:  "equals positive",
+			cond:  FileJSONEqualsCondition("./doc.json"),
+			input: `{"baz":[1,2],"foo":"bar"}`,
+		},
+		{
+			name:        "equals negative",
+			cond:        FileJSONEqualsCondition("./doc.json"),
+			input:       `{"baz":[1,2],"foo":"bar","extra":true}`,
+			errContains: "JSON content mismatch",
+		},
+		{
+			name:  "contains positive",
+			cond:  FileJSONContainsCondition("./doc.json"),
+			input: `{"baz":[1,2],"foo":"bar","extra":true}`,
+		},
+		{
+			name:        "contains negative",
+			cond:        FileJSONContainsCondition("./doc.json"),
+			input:       `{"baz":[1,3],"foo":"bar"}`,
+			errContains: "JSON superset mismatch",
+		},
+		{
+			name:        "missing file",
+			cond:        FileJSONEqualsCondition("./nope.json"),
+			input:       `{}`,
+			errContains: "failed to read comparison file",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(tt *testing.T) {
+			actErr := test.cond.checkFrom(tmpDir, message.NewPart([]byte(test.input)))
+			if test.errContains == "" {
+				assert.NoError(tt, actErr)
+			} else {
+				require.Error(tt, actErr)
+				assert.Contains(tt, actErr.Error(), test.errContains)
+			}
+		})
+	}
+}
+
+func TestSnapshotCondition(t *testing.T) {
+	color.NoColor = true
+
+	tmpDir, err := os.MkdirTemp("", "test_snapshot_condition")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	conds := ConditionsMap{
+		"snapshot": SnapshotCondition("./snapshots/foo.txt"),
+	}
+
+	errs := conds.checkAllFrom(tmpDir, false, message.NewPart([]byte("first")))
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "snapshot './snapshots/foo.txt' does not exist")
+
+	assert.Empty(t, conds.checkAllFrom(tmpDir, true, message.NewPart([]byte("first"))))
+	assert.Empty(t, conds.checkAllFrom(tmpDir, false, message.NewPart([]byte("first"))))
+
+	errs = conds.checkAllFrom(tmpDir, false, message.NewPart([]byte("second")))
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "snapshot mismatch")
+
+	assert.Empty(t, conds.checkAllFrom(tmpDir, true, message.NewPart([]byte("second"))))
+
+	snapshot, err := os.ReadFile(filepath.Join(tmpDir, "snapshots", "foo.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(snapshot))
+}
+
+func TestJSONSchemaCondition(t *testing.T) {
+	color.NoColor = true
+
+	var conds ConditionsMap
+	require.NoError(t, yaml.Unmarshal([]byte(`
+json_schema:
+  type: object
+  properties:
+    id:
+      type: string
+    count:
+      type: integer
+      minimum: 0
+  required: [ id ]
+`), &conds))
+
+	assert.Empty(t, conds.CheckAll(message.NewPart([]byte(`{"id":"foo","count":3}`))))
+
+	errs := conds.CheckAll(message.NewPart([]byte(`{"count":-1}`)))
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "json_schema: JSON schema mismatch")
+	assert.Contains(t, errs[0].Error(), "id is required")
+	assert.Contains(t, errs[0].Error(), "count: Must be greater than or equal to 0")
+
+	errs = conds.CheckAll(message.NewPart([]byte(`not json`)))
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "json_schema: failed to validate content")
+
+	err := yaml.Unmarshal([]byte(`json_schema: '{"type":"nope"}'`), &conds)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse JSON schema")
+}
+
+func TestJSONApproxConditions(t *testing.T) {
+	color.NoColor = true
+
+	type testCase struct {
+		name        string
+		cond        string
+		input       string
+		errContains string
+	}
+
+	tests := []testCase{
+		{
+			name: "equals within tolerance",
+			cond: `
+json_approx_equals:
+  tolerance: 0.01
+  value: { price: 10.5, items: [ 1, 2.001 ], name: foo }
+`,
+			input: `{"price":10.505,"items":[1.009,2],"name":"foo"}`,
+		},
+		{
+			name: "equals outside tolerance",
+			cond: `
+json_approx_equals:
+  tolerance: 0.01
+  value: { price: 10.5, items: [ 1, 2 ] }
+`,
+			input:       `{"price":10.5,"items":[1,2.02]}`,
+			errContains: "JSON mismatch at root.items.1\n  expected: 2\n  received: 2.02",
+		},
+		{
+			name: "equals unexpected key",
+			cond: `
+json_approx_equals:
+  value: { price: 10.5 }
+`,
+			input:       `{"price":10.5,"name":"foo"}`,
+			errContains: "JSON mismatch at root.name\n  unexpected key",
+		},
+		{
+			name: "equals without tolerance",
+			cond: `
+json_approx_equals:
+  value: { price: 10.5 }
+`,
+			input:       `{"price":10.50001}`,
+			errContains: "JSON mismatch at root.price",
+		},
+		{
+			name: "contains within tolerance",
+			cond: `
+json_approx_contains:
+  tolerance: 0.5
+  value: { nested: { price: 10 } }
+`,
+			input: `{"nested":{"price":10.4,"name":"foo"},"other":true}`,
+		},
+		{
+			name: "contains missing key",
+			cond: `
+json_approx_contains:
+  tolerance: 0.5
+  value: { nested: { price: 10 } }
+`,
+			input:       `{"nested":{"name":"foo"}}`,
+			errContains: "JSON mismatch at root.nested.price\n  expected key was missing",
+		},
+		{
+			name: "not json",
+			cond: `
+json_approx_contains:
+  value: { price: 10 }
+`,
+			input:       `nope`,
+			errContains: "failed to parse content as JSON",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(tt *testing.T) {
+			var conds ConditionsMap
+			require.NoError(tt, yaml.Unmarshal([]byte(test.cond), &conds))
+
+			errs := conds.CheckAll(message.NewPart([]byte(test.input)))
+			if test.errContains == "" {
+				assert.Empty(tt, errs)
+			} else {
+				require.Len(tt, errs, 1)
+				assert.Contains(tt, errs[0].Error(), test.errContains)
+			}
+		})
+	}
+}
+
+func TestErrorConditions(t *testing.T) {
+	color.NoColor = true
+
+	failed := message.NewPart([]byte(`foo`))
+	processor.FlagErr(failed, errors.New("failed to do the thing: boom"))
+	passed := message.NewPart([]byte(`foo`))
+
+	type testCase struct {
+		name        string
+		cond        Condition
+		part        types.Part
+		errContains string
+	}
+
+	tests := []testCase{
+		{name: "errored positive", cond: ErroredCondition(true), part: failed},
+		{name: "not errored positive", cond: ErroredCondition(false), part: passed},
+		{
+			name:        "errored negative",
+			cond:        ErroredCondition(true),
+			part:        passed,
+			errContains: "expected message to have failed processing",
+		},
+		{
+			name:        "not errored negative",
+			cond:        ErroredCondition(false),
+			part:        failed,
+			errContains: "expected message not to have failed processing\n  received: failed to do the thing: boom",
+		},
+		{name: "equals positive", cond: ErrorEqualsCondition("failed to do the thing: boom"), part: failed},
+		{
+			name:        "equals negative",
+			cond:        ErrorEqualsCondition("boom"),
+			part:        failed,
+			errContains: "error mismatch",
+		},
+		{name: "contains positive", cond: ErrorContainsCondition("boom"), part: failed},
+		{
+			name:        "contains negative",
+			cond:        ErrorContainsCondition("boom"),
+			part:        passed,
+			errContains: "error mismatch\n  expected to contain: boom",
+		},
+		{
+			name:        "contains empty negative",
+			cond:        ErrorContainsCondition(""),
+			part:        passed,
+			errContains: "error mismatch",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(tt *testing.T) {
+			actErr := test.cond.Check(test.part)
+			if test.errContains == "" {
+				assert.NoError(tt, actErr)
+			} else {
+				require.Error(tt, actErr)
+				assert.Contains(tt, actErr.Error(), test.errContains)
+			}
+		})
+	}
+}
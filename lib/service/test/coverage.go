@@ -0,0 +1,559 @@
+package test
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/parser"
+	"github.com/Jeffail/benthos/v3/internal/bundle"
+	ioutput "github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/gabs/v2"
+	yaml "gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+
+type componentKey struct {
+	path  string
+	ctype string
+}
+
+type switchCoverage struct {
+	kind  string
+	cases [][]string
+}
+
+// Coverage records which processors, switch cases and Bloblang mapping
+// branches are exercised by test cases. A single coverage recorder can be
+// shared by any number of test cases, and components with the same path and
+// type are recorded as one.
+type Coverage struct {
+	mut        sync.Mutex
+	components map[componentKey]*int64
+	pathHits   map[string]*int64
+	switches   map[componentKey]switchCoverage
+
+	bloblang *parser.Coverage
+	env      *bundle.Environment
+}
+
+// NewCoverage returns an empty coverage recorder.
+func NewCoverage() *Coverage {
+	c := &Coverage{
+		components: map[componentKey]*int64{},
+		pathHits:   map[string]*int64{},
+		switches:   map[componentKey]switchCoverage{},
+		bloblang:   parser.NewCoverage(),
+	}
+	c.env = bundle.GlobalEnvironment.Clone()
+	c.env.Processors = &bundle.ProcessorSet{}
+	for _, spec := range bundle.AllProcessors.Docs() {
+		_ = c.env.Processors.Add(c.processorCtor, spec)
+	}
+	c.env.Outputs = &bundle.OutputSet{}
+	for _, spec := range bundle.AllOutputs.Docs() {
+		_ = c.env.Outputs.Add(c.outputCtor, spec)
+	}
+	return c
+}
+
+// managerOpts returns options for a manager that result in the processors and
+// outputs it constructs being recorded.
+func (c *Coverage) managerOpts() []manager.OptFunc {
+	if c == nil {
+		return nil
+	}
+	return []manager.OptFunc{
+		manager.OptSetEnvironment(c.env),
+	}
+}
+
+// bloblangEnvironment returns a Bloblang environment where mapping branches
+// are recorded.
+func (c *Coverage) bloblangEnvironment() *bloblang.Environment {
+	if c == nil {
+		return bloblang.GlobalEnvironment()
+	}
+	return bloblang.GlobalEnvironment().WithCoverage(c.bloblang)
+}
+
+func (c *Coverage) componentHits(key componentKey) *int64 {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	hits, exists := c.components[key]
+	if !exists {
+		hits = new(int64)
+		c.components[key] = hits
+	}
+	return hits
+}
+
+// hitsForPath returns a counter of executions of any processor or output at a
+// path, which is used in order to determine which switch cases were executed.
+func (c *Coverage) hitsForPath(path string) *int64 {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	hits, exists := c.pathHits[path]
+	if !exists {
+		hits = new(int64)
+		c.pathHits[path] = hits
+	}
+	return hits
+}
+
+func (c *Coverage) addSwitch(key componentKey, sw switchCoverage) {
+	c.mut.Lock()
+	c.switches[key] = sw
+	c.mut.Unlock()
+}
+
+// childPath returns the path of a child component, which is the label of the
+// child when it has one.
+func childPath(parent, child, label string) string {
+	if label != "" {
+		return label
+	}
+	if parent == "" {
+		return child
+	}
+	return parent + "." + child
+}
+
+func (c *Coverage) processorCtor(conf processor.Config, mgr bundle.NewManagement) (processor.Type, error) {
+	p, err := bundle.AllProcessors.Init(conf, mgr)
+	if err != nil {
+		return nil, err
+	}
+
+	key := componentKey{path: mgr.Label(), ctype: conf.Type}
+	if conf.Type == processor.TypeSwitch {
+		c.addSwitch(key, processorSwitchCoverage(key.path, conf))
+	}
+	return &coveredProcessor{
+		Processor: p,
+		hits:      c.componentHits(key),
+		pathHits:  c.hitsForPath(key.path),
+	}, nil
+}
+
+func (c *Coverage) outputCtor(conf output.Config, mgr bundle.NewManagement, pipelines ...types.PipelineConstructorFunc) (output.Type, error) {
+	o, err := bundle.AllOutputs.Init(conf, mgr, pipelines...)
+	if err != nil {
+		return nil, err
+	}
+
+	path := mgr.Label()
+	if conf.Type == output.TypeSwitch {
+		c.addSwitch(componentKey{path: path, ctype: conf.Type}, outputSwitchCoverage(path, conf))
+	}
+	return &coveredOutput{
+		Output: o,
+		hits:   c.hitsForPath(path),
+	}, nil
+}
+
+func processorSwitchCoverage(path string, conf processor.Config) switchCoverage {
+	sw := switchCoverage{kind: "processor"}
+	for i, caseConf := range conf.Switch {
+		var paths []string
+		for j, procConf := range caseConf.Processors {
+			paths = append(paths, childPath(path, fmt.Sprintf("%v.%v", i, j), procConf.Label))
+		}
+		sw.cases = append(sw.cases, paths)
+	}
+	return sw
+}
+
+func outputSwitchCoverage(path string, conf output.Config) switchCoverage {
+	sw := switchCoverage{kind: "output"}
+	for i, caseConf := range conf.Switch.Cases {
+		sw.cases = append(sw.cases, []string{
+			childPath(path, fmt.Sprintf("switch.%v.output", i), caseConf.Output.Label),
+		})
+	}
+	for i, outConf := range conf.Switch.Outputs {
+		sw.cases = append(sw.cases, []string{
+			childPath(path, fmt.Sprintf("switch.%v.output", i), outConf.Output.Label),
+		})
+	}
+	return sw
+}
+
+//------------------------------------------------------------------------------
+
+type coveredProcessor struct {
+	types.Processor
+	hits     *int64
+	pathHits *int64
+}
+
+func (p *coveredProcessor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	atomic.AddInt64(p.hits, 1)
+	atomic.AddInt64(p.pathHits, 1)
+	return p.Processor.ProcessMessage(msg)
+}
+
+type coveredOutput struct {
+	types.Output
+	hits *int64
+}
+
+func (o *coveredOutput) Consume(ts <-chan types.Transaction) error {
+	tChan := make(chan types.Transaction)
+	if err := o.Output.Consume(tChan); err != nil {
+		return err
+	}
+	go func() {
+		defer close(tChan)
+		for t := range ts {
+			atomic.AddInt64(o.hits, 1)
+			tChan <- t
+		}
+	}()
+	return nil
+}
+
+func (o *coveredOutput) MaxInFlight() (int, bool) {
+	return ioutput.GetMaxInFlight(o.Output)
+}
+
+//------------------------------------------------------------------------------
+
+// ComponentCoverage describes how many times a processor was executed.
+type ComponentCoverage struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Hits int64  `json:"hits"`
+}
+
+// SwitchCaseCoverage describes how many times a case of a switch processor or
+// output was executed.
+type SwitchCaseCoverage struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	Case int    `json:"case"`
+	Hits int64  `json:"hits"`
+}
+
+// BranchCoverage describes how many times a branch of an if or match
+// expression within a Bloblang mapping was executed.
+type BranchCoverage struct {
+	Mapping    string `json:"mapping"`
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+	Expression string `json:"expression"`
+	Branch     string `json:"branch"`
+	Hits       int64  `json:"hits"`
+}
+
+// CoverageReport summarises which processors, switch cases and Bloblang
+// mapping branches were exercised by test cases.
+type CoverageReport struct {
+	Processors  []ComponentCoverage  `json:"processors"`
+	SwitchCases []SwitchCaseCoverage `json:"switch_cases"`
+	Branches    []BranchCoverage     `json:"bloblang_branches"`
+}
+
+// Report returns a report of the coverage recorded so far.
+func (c *Coverage) Report() CoverageReport {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	r := CoverageReport{
+		Processors:  []ComponentCoverage{},
+		SwitchCases: []SwitchCaseCoverage{},
+		Branches:    []BranchCoverage{},
+	}
+	for k, hits := range c.components {
+		r.Processors = append(r.Processors, ComponentCoverage{
+			Path: k.path,
+			Type: k.ctype,
+			Hits: atomic.LoadInt64(hits),
+		})
+	}
+	sort.Slice(r.Processors, func(i, j int) bool {
+		if r.Processors[i].Path != r.Processors[j].Path {
+			return lessPath(r.Processors[i].Path, r.Processors[j].Path)
+		}
+		return r.Processors[i].Type < r.Processors[j].Type
+	})
+
+	for k, sw := range c.switches {
+		for i, paths := range sw.cases {
+			var hits int64
+			for _, p := range paths {
+				if h, exists := c.pathHits[p]; exists {
+					hits += atomic.LoadInt64(h)
+				}
+			}
+			r.SwitchCases = append(r.SwitchCases, SwitchCaseCoverage{
+				Path: k.path,
+				Kind: sw.kind,
+				Case: i,
+				Hits: hits,
+			})
+		}
+	}
+	sort.Slice(r.SwitchCases, func(i, j int) bool {
+		if r.SwitchCases[i].Path != r.SwitchCases[j].Path {
+			return lessPath(r.SwitchCases[i].Path, r.SwitchCases[j].Path)
+		}
+		if r.SwitchCases[i].Kind != r.SwitchCases[j].Kind {
+			return r.SwitchCases[i].Kind < r.SwitchCases[j].Kind
+		}
+		return r.SwitchCases[i].Case < r.SwitchCases[j].Case
+	})
+
+	for _, b := range c.bloblang.Branches() {
+		r.Branches = append(r.Branches, BranchCoverage{
+			Mapping:    b.Source,
+			Line:       b.Line,
+			Column:     b.Column,
+			Expression: b.Expression,
+			Branch:     b.Branch,
+			Hits:       b.Hits,
+		})
+	}
+	return r
+}
+
+// lessPath compares component paths where numeric segments are compared by
+// value, e.g. processor.2 comes before processor.10.
+func lessPath(a, b string) bool {
+	aSegs, bSegs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aSegs) && i < len(bSegs); i++ {
+		if aSegs[i] == bSegs[i] {
+			continue
+		}
+		aN, aErr := strconv.Atoi(aSegs[i])
+		bN, bErr := strconv.Atoi(bSegs[i])
+		if aErr == nil && bErr == nil {
+			return aN < bN
+		}
+		return aSegs[i] < bSegs[i]
+	}
+	return len(aSegs) < len(bSegs)
+}
+
+// Covered returns the number of covered items and the total number of items
+// within the report.
+func (r CoverageReport) Covered() (covered, total int) {
+	for _, p := range r.Processors {
+		if p.Hits > 0 {
+			covered++
+		}
+	}
+	for _, s := range r.SwitchCases {
+		if s.Hits > 0 {
+			covered++
+		}
+	}
+	for _, b := range r.Branches {
+		if b.Hits > 0 {
+			covered++
+		}
+	}
+	total = len(r.Processors) + len(r.SwitchCases) + len(r.Branches)
+	return
+}
+
+// Percentage returns the percentage of items within the report that were
+// covered, a report without any items is considered fully covered.
+func (r CoverageReport) Percentage() float64 {
+	covered, total := r.Covered()
+	if total == 0 {
+		return 100
+	}
+	return float64(covered) * 100 / float64(total)
+}
+
+//------------------------------------------------------------------------------
+
+// addTarget enumerates the processors, switch cases and Bloblang mapping
+// branches of a config file and its resources, so that those never
+// constructed by a test case are reported with zero hits.
+func (c *Coverage) addTarget(targetPath string, resourcesPaths []string) error {
+	for _, path := range append([]string{targetPath}, resourcesPaths...) {
+		confBytes, err := config.ReadWithJSONPointers(path, true)
+		if err != nil {
+			return fmt.Errorf("failed to parse config file '%v': %v", path, err)
+		}
+		root := &yaml.Node{}
+		if err = yaml.Unmarshal(confBytes, root); err != nil {
+			return fmt.Errorf("failed to parse config file '%v': %v", path, err)
+		}
+		c.addConfig(root)
+	}
+	return nil
+}
+
+// configComponent is a component found whilst walking a config.
+type configComponent struct {
+	path  string
+	name  string
+	ctype docs.Type
+}
+
+// addConfig walks a config using its field specs and registers the
+// processors, switch cases and Bloblang mapping branches found within it.
+func (c *Coverage) addConfig(root *yaml.Node) {
+	env := c.bloblangEnvironment()
+	components := map[string]configComponent{}
+
+	_ = config.Spec().WalkYAML(nil, root, nil, func(yamlPath []string, spec docs.FieldSpec, node *yaml.Node) error {
+		if node.Kind == yaml.ScalarNode {
+			// Parsing a mapping registers its branches, mappings that fail to
+			// parse here will also fail when the component is constructed.
+			if spec.Bloblang {
+				_, _ = env.NewMapping(node.Value)
+			} else if spec.Interpolated {
+				_, _ = env.NewField(node.Value)
+			}
+			return nil
+		}
+
+		coreType, isCore := spec.Type.IsCoreComponent()
+		if !isCore || spec.Kind != docs.KindScalar || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		name, _, err := docs.GetInferenceCandidateFromYAML(nil, coreType, "", node)
+		if err != nil {
+			return nil
+		}
+
+		comp := configComponent{
+			path:  componentPathFromYAML(components, yamlPath),
+			name:  name,
+			ctype: coreType,
+		}
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			if node.Content[i].Value == "label" && node.Content[i+1].Value != "" {
+				comp.path = node.Content[i+1].Value
+			}
+		}
+		components[strings.Join(yamlPath, ".")] = comp
+
+		switch {
+		case coreType == docs.TypeProcessor:
+			key := componentKey{path: comp.path, ctype: name}
+			c.componentHits(key)
+			if name == processor.TypeSwitch {
+				conf := processor.NewConfig()
+				if err := node.Decode(&conf); err == nil {
+					c.addSwitch(key, processorSwitchCoverage(comp.path, conf))
+				}
+			}
+		case coreType == docs.TypeOutput && name == output.TypeSwitch:
+			conf := output.NewConfig()
+			if err := node.Decode(&conf); err == nil {
+				c.addSwitch(componentKey{path: comp.path, ctype: name}, outputSwitchCoverage(comp.path, conf))
+			}
+		}
+		return nil
+	})
+}
+
+// componentPathFromYAML returns the path that a component found at a path of
+// a config is given when it is constructed, which is derived from the path of
+// the closest parent component.
+func componentPathFromYAML(components map[string]configComponent, yamlPath []string) string {
+	for i := len(yamlPath) - 1; i > 0; i-- {
+		parent, exists := components[strings.Join(yamlPath[:i], ".")]
+		if !exists {
+			continue
+		}
+		if id := childComponentID(parent, yamlPath[i:]); id != "" {
+			return parent.path + "." + id
+		}
+		return parent.path
+	}
+
+	// Components at the root of a config, e.g. pipeline.processors.0 becomes
+	// pipeline.processor.0 and resources.processors.foo becomes
+	// resource.processor.foo.
+	segs := make([]string, len(yamlPath))
+	copy(segs, yamlPath)
+	if len(segs) == 3 && segs[0] == "resources" {
+		return "resource." + strings.TrimSuffix(segs[1], "s") + "." + segs[2]
+	}
+	for i, s := range segs {
+		if s == "processors" {
+			segs[i] = "processor"
+		}
+	}
+	return strings.Join(segs, ".")
+}
+
+// childComponentID returns the identifier that a parent component gives to a
+// child component found at a path relative to the parent.
+func childComponentID(parent configComponent, rel []string) string {
+	if len(rel) == 2 && rel[0] == "processors" {
+		return "processor." + rel[1]
+	}
+	if len(rel) >= 3 && rel[len(rel)-3] == "batching" && rel[len(rel)-2] == "processors" {
+		return "batching." + rel[len(rel)-1]
+	}
+	if len(rel) == 0 || rel[0] != parent.name {
+		return strings.Join(rel, ".")
+	}
+
+	args := rel[1:]
+	if parent.ctype == docs.TypeProcessor {
+		switch {
+		case parent.name == processor.TypeSwitch && len(args) == 3:
+			return args[0] + "." + args[2]
+		case parent.name == processor.TypeBranch && len(args) == 2,
+			parent.name == processor.TypeProcessMap && len(args) == 2:
+			return "processor." + args[1]
+		case parent.name == processor.TypeWorkflow && len(args) == 4,
+			parent.name == processor.TypeProcessDAG && len(args) == 3:
+			return args[len(args)-3] + ".processor." + args[len(args)-1]
+		case parent.name == processor.TypeWhile && len(args) == 2:
+			return "while." + args[1]
+		case parent.name == processor.TypeConditional && len(args) == 2:
+			if args[0] == "else_processors" {
+				return "else." + args[1]
+			}
+			return "if." + args[1]
+		case parent.name == processor.TypeGroupBy && len(args) == 3:
+			return "groups." + args[0] + ".processor." + args[2]
+		}
+		// Processors such as try, catch and parallel identify children by
+		// their index alone.
+		return args[len(args)-1]
+	}
+
+	if parent.name == output.TypeSwitch && len(args) == 3 {
+		return "switch." + args[1] + ".output"
+	}
+	return strings.Join(rel, ".")
+}
+
+//------------------------------------------------------------------------------
+
+// pointerToComponentPath converts the JSON Pointer of target processors into
+// the path they would be given within a stream, e.g. /pipeline/processors
+// becomes pipeline.processor.
+func pointerToComponentPath(jsonPtr string) string {
+	segs, err := gabs.JSONPointerToSlice(jsonPtr)
+	if err != nil {
+		return strings.Trim(jsonPtr, "/")
+	}
+	for i, s := range segs {
+		if s == "processors" {
+			segs[i] = "processor"
+		}
+	}
+	return strings.Join(segs, ".")
+}
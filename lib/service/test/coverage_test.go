@@ -0,0 +1,174 @@
+package test_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/service/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestCoverageReport(t *testing.T) {
+	testDir, err := initTestFiles(map[string]string{
+		"config1.yaml": `
+pipeline:
+  processors:
+    - bloblang: |
+        root = if this.type == "a" {
+          "is a"
+        } else {
+          "not a"
+        }
+    - switch:
+        - check: content() == "is a"
+          processors:
+            - bloblang: 'root = content().uppercase()'
+        - processors:
+            - label: lowered
+              bloblang: 'root = content().lowercase()'
+
+output:
+  switch:
+    cases:
+      - check: content() == "IS A"
+        output:
+          drop: {}
+      - output:
+          drop: {}
+`,
+	})
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	var def test.Definition
+	require.NoError(t, yaml.Unmarshal([]byte(`
+tests:
+  - name: processors
+    input_batch:
+      - content: '{"type":"a"}'
+    output_batches:
+      - - content_equals: IS A
+
+  - name: stream
+    input_batches:
+      - - content: '{"type":"a"}'
+`), &def))
+
+	cov := test.NewCoverage()
+	failures, err := def.ExecuteWithCoverage(filepath.Join(testDir, "config1.yaml"), log.Noop(), cov)
+	require.NoError(t, err)
+	require.Empty(t, failures)
+
+	report := cov.Report()
+
+	assert.Equal(t, []test.ComponentCoverage{
+		{Path: "lowered", Type: "bloblang", Hits: 0},
+		{Path: "pipeline.processor.0", Type: "bloblang", Hits: 2},
+		{Path: "pipeline.processor.1", Type: "switch", Hits: 2},
+		{Path: "pipeline.processor.1.0.0", Type: "bloblang", Hits: 2},
+	}, report.Processors)
+
+	assert.Equal(t, []test.SwitchCaseCoverage{
+		{Path: "output", Kind: "output", Case: 0, Hits: 1},
+		{Path: "output", Kind: "output", Case: 1, Hits: 0},
+		{Path: "pipeline.processor.1", Kind: "processor", Case: 0, Hits: 2},
+		{Path: "pipeline.processor.1", Kind: "processor", Case: 1, Hits: 0},
+	}, report.SwitchCases)
+
+	require.Len(t, report.Branches, 2)
+	assert.Equal(t, "if", report.Branches[0].Branch)
+	assert.Equal(t, int64(2), report.Branches[0].Hits)
+	assert.Equal(t, 1, report.Branches[0].Line)
+	assert.Equal(t, "else", report.Branches[1].Branch)
+	assert.Equal(t, int64(0), report.Branches[1].Hits)
+
+	covered, total := report.Covered()
+	assert.Equal(t, 6, covered)
+	assert.Equal(t, 10, total)
+	assert.Equal(t, 60.0, report.Percentage())
+}
+
+func TestCoverageReportUnconstructed(t *testing.T) {
+	testDir, err := initTestFiles(map[string]string{
+		"config1.yaml": `
+pipeline:
+  processors:
+    - branch:
+        request_map: 'root = this'
+        processors:
+          - try:
+              - bloblang: 'root = content().uppercase()'
+        result_map: 'root = this'
+    - workflow:
+        branches:
+          foo:
+            processors:
+              - bloblang: 'root = content()'
+
+output:
+  broker:
+    outputs:
+      - drop: {}
+        processors:
+          - bloblang: |
+              root = match content() {
+                "a" => "b"
+                _ => content()
+              }
+      - switch:
+          cases:
+            - check: 'content() == "a"'
+              output:
+                drop: {}
+
+processor_resources:
+  - label: unused
+    bloblang: 'root = content().lowercase()'
+`,
+	})
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	var def test.Definition
+	require.NoError(t, yaml.Unmarshal([]byte(`
+tests:
+  - name: processors
+    input_batch:
+      - content: '{"v":"a"}'
+    output_batches:
+      - - json_contains: { V: A }
+`), &def))
+
+	cov := test.NewCoverage()
+	failures, err := def.ExecuteWithCoverage(filepath.Join(testDir, "config1.yaml"), log.Noop(), cov)
+	require.NoError(t, err)
+	require.Empty(t, failures)
+
+	report := cov.Report()
+
+	assert.Equal(t, []test.ComponentCoverage{
+		{Path: "output.broker.outputs.0.processor.0", Type: "bloblang", Hits: 0},
+		{Path: "pipeline.processor.0", Type: "branch", Hits: 1},
+		{Path: "pipeline.processor.0.processor.0", Type: "try", Hits: 1},
+		{Path: "pipeline.processor.0.processor.0.0", Type: "bloblang", Hits: 1},
+		{Path: "pipeline.processor.1", Type: "workflow", Hits: 1},
+		{Path: "pipeline.processor.1.foo.processor.0", Type: "bloblang", Hits: 1},
+		{Path: "unused", Type: "bloblang", Hits: 0},
+	}, report.Processors)
+
+	assert.Equal(t, []test.SwitchCaseCoverage{
+		{Path: "output.broker.outputs.1", Kind: "output", Case: 0, Hits: 0},
+	}, report.SwitchCases)
+
+	require.Len(t, report.Branches, 2)
+	assert.Equal(t, "case 0", report.Branches[0].Branch)
+	assert.Equal(t, int64(0), report.Branches[0].Hits)
+
+	covered, total := report.Covered()
+	assert.Equal(t, 5, covered)
+	assert.Equal(t, 10, total)
+}
@@ -48,25 +48,56 @@ func ExampleDefinition() Definition {
 // ExecuteWithLogger attempts to run a test definition on a target config file,
 // with a logger. Returns an array of test failures or an error.
 func (d Definition) ExecuteWithLogger(filepath string, logger log.Modular) ([]CaseFailure, error) {
-	return d.execute(filepath, nil, logger)
+	return d.execute(filepath, executeConfig{logger: logger})
 }
 
 // Execute attempts to run a test definition on a target config file. Returns
 // an array of test failures or an error.
 func (d Definition) Execute(filepath string) ([]CaseFailure, error) {
-	return d.execute(filepath, nil, log.Noop())
+	return d.execute(filepath, executeConfig{logger: log.Noop()})
 }
 
-func (d Definition) execute(testFilePath string, resourcesPaths []string, logger log.Modular) ([]CaseFailure, error) {
+// ExecuteWithCoverage attempts to run a test definition on a target config
+// file, recording the processors, switch cases and Bloblang branches executed
+// by the test cases. Returns an array of test failures or an error.
+func (d Definition) ExecuteWithCoverage(filepath string, logger log.Modular, cov *Coverage) ([]CaseFailure, error) {
+	return d.execute(filepath, executeConfig{logger: logger, coverage: cov})
+}
+
+// ExecuteUpdateSnapshots attempts to run a test definition on a target config
+// file, where snapshot conditions are created or overwritten with the messages
+// they are checked against rather than compared. Returns an array of test
+// failures or an error.
+func (d Definition) ExecuteUpdateSnapshots(filepath string, logger log.Modular) ([]CaseFailure, error) {
+	return d.execute(filepath, executeConfig{logger: logger, updateSnapshots: true})
+}
+
+type executeConfig struct {
+	resourcesPaths  []string
+	logger          log.Modular
+	coverage        *Coverage
+	updateSnapshots bool
+}
+
+func (d Definition) execute(testFilePath string, conf executeConfig) ([]CaseFailure, error) {
 	procsProvider := NewProcessorsProvider(
 		testFilePath,
-		OptAddResourcesPaths(resourcesPaths),
-		OptProcessorsProviderSetLogger(logger),
+		OptAddResourcesPaths(conf.resourcesPaths),
+		OptProcessorsProviderSetLogger(conf.logger),
+		OptProcessorsProviderSetCoverage(conf.coverage),
 	)
+	if conf.coverage != nil {
+		if err := conf.coverage.addTarget(testFilePath, conf.resourcesPaths); err != nil {
+			return nil, err
+		}
+	}
 	if d.Parallel {
 		// Warm the cache of processor configs.
 		for _, c := range d.Cases {
-			if _, err := procsProvider.getConfs(c.TargetProcessors, c.Environment, c.Mocks); err != nil {
+			if len(c.InputBatches) > 0 || c.hasResourceMocks() {
+				continue
+			}
+			if _, err := procsProvider.getConfs(c.TargetProcessors, c.Environment, c.Mocks, ResourceMocks{}); err != nil {
 				return nil, err
 			}
 		}
@@ -77,6 +108,7 @@ func (d Definition) execute(testFilePath string, resourcesPaths []string, logger
 	var totalFailures []CaseFailure
 	if !d.Parallel {
 		for i, c := range d.Cases {
+			c.updateSnapshots = conf.updateSnapshots
 			cleanupEnv := setEnvironment(c.Environment)
 			failures, err := c.executeFrom(dir, procsProvider)
 			if err != nil {
@@ -93,6 +125,7 @@ func (d Definition) execute(testFilePath string, resourcesPaths []string, logger
 		for i, c := range d.Cases {
 			i := i
 			c := c
+			c.updateSnapshots = conf.updateSnapshots
 			g.Go(func() error {
 				failures, err := c.executeFrom(dir, procsProvider)
 				if err != nil {
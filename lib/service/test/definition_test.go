@@ -5,8 +5,12 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/service/test"
 	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
 )
 
 func TestDefinitionFail(t *testing.T) {
@@ -163,3 +167,50 @@ pipeline:
 		t.Errorf("Mismatched fail message: %v != %v", act, exp)
 	}
 }
+
+func TestDefinitionUpdateSnapshots(t *testing.T) {
+	color.NoColor = true
+
+	testDir, err := initTestFiles(map[string]string{
+		"config1.yaml": `
+pipeline:
+  processors:
+  - bloblang: 'root = content().uppercase()'`,
+	})
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	var def test.Definition
+	require.NoError(t, yaml.Unmarshal([]byte(`
+parallel: true
+tests:
+  - name: foo
+    input_batch:
+      - content: foo
+    output_batches:
+      - - snapshot: ./snapshots/foo.txt
+  - name: bar
+    input_batch:
+      - content: bar
+    output_batches:
+      - - snapshot: ./snapshots/bar.txt
+`), &def))
+
+	configPath := filepath.Join(testDir, "config1.yaml")
+
+	failures, err := def.Execute(configPath)
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+
+	failures, err = def.ExecuteUpdateSnapshots(configPath, log.Noop())
+	require.NoError(t, err)
+	require.Empty(t, failures)
+
+	fooBytes, err := os.ReadFile(filepath.Join(testDir, "snapshots", "foo.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "FOO", string(fooBytes))
+
+	failures, err = def.Execute(configPath)
+	require.NoError(t, err)
+	require.Empty(t, failures)
+}
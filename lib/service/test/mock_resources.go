@@ -0,0 +1,218 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	yaml "gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+
+// HTTPResponder defines a fake HTTP endpoint that responds to requests matching
+// a method and path with a canned response.
+type HTTPResponder struct {
+	Method  string            `yaml:"method"`
+	Path    string            `yaml:"path"`
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+}
+
+func (h HTTPResponder) matches(r *http.Request) bool {
+	if h.Method != "" && !strings.EqualFold(h.Method, r.Method) {
+		return false
+	}
+	return h.Path == "" || h.Path == r.URL.Path
+}
+
+// newMockHTTPServer starts an HTTP server that responds to requests with the
+// first responder that matches them, requests that do not match any responder
+// receive a 404 response.
+func newMockHTTPServer(responders []HTTPResponder) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, res := range responders {
+			if !res.matches(r) {
+				continue
+			}
+			for k, v := range res.Headers {
+				w.Header().Set(k, v)
+			}
+			status := res.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(res.Body))
+			return
+		}
+		http.Error(w, fmt.Sprintf("no mock responder matches %v %v", r.Method, r.URL.Path), http.StatusNotFound)
+	}))
+}
+
+//------------------------------------------------------------------------------
+
+// ResourceMocks contains the contents of in-memory caches and the address of a
+// fake HTTP server that override the real resources of a config, as well as
+// the sources of time and randomness used by Bloblang functions.
+type ResourceMocks struct {
+	// Caches is a map of cache resource labels to the key/value pairs of an
+	// in-memory cache that replaces it.
+	Caches map[string]map[string]string
+
+	// HTTPURL is the address of an HTTP server that the URLs of all HTTP
+	// components are redirected to, the path of each URL is preserved.
+	HTTPURL string
+
+	// Clock, when set, is the time returned by Bloblang functions that read
+	// the current time, such as now and timestamp_unix.
+	Clock *time.Time
+
+	// RandomSeed, when set, seeds the values generated by Bloblang functions
+	// such as uuid_v4, nanoid and random_int.
+	RandomSeed *int64
+}
+
+// rewritesConfig returns true when the mocks modify the config itself.
+func (r ResourceMocks) rewritesConfig() bool {
+	return len(r.Caches) > 0 || r.HTTPURL != ""
+}
+
+// bloblangEnvironment applies the clock and random seed of the mocks to a
+// Bloblang environment.
+func (r ResourceMocks) bloblangEnvironment(env *bloblang.Environment) *bloblang.Environment {
+	if r.Clock != nil {
+		clock := *r.Clock
+		env = env.WithClock(func() time.Time {
+			return clock
+		})
+	}
+	if r.RandomSeed != nil {
+		env = env.WithRandomSeed(*r.RandomSeed)
+	}
+	return env
+}
+
+// applyCaches replaces any cache resources targeted by the mocks with memory
+// caches, cache resources that do not already exist are added.
+func (r ResourceMocks) applyCaches(conf *manager.ResourceConfig) {
+	for label, values := range r.Caches {
+		delete(conf.Manager.Caches, label)
+
+		caches := conf.ResourceCaches[:0]
+		for _, c := range conf.ResourceCaches {
+			if c.Label != label {
+				caches = append(caches, c)
+			}
+		}
+
+		mockConf := cache.NewConfig()
+		mockConf.Label = label
+		mockConf.Type = cache.TypeMemory
+		for k, v := range values {
+			mockConf.Memory.InitValues[k] = v
+		}
+		conf.ResourceCaches = append(caches, mockConf)
+	}
+}
+
+// rewriteURLs walks a config using its field specs and redirects the host of
+// any HTTP URL fields, including the elements of URL lists, to the mocked HTTP
+// server. An error is returned when an HTTP URL cannot be redirected.
+func (r ResourceMocks) rewriteURLs(node *yaml.Node) error {
+	if r.HTTPURL == "" {
+		return nil
+	}
+	return config.Spec().WalkYAML(nil, node, nil, func(path []string, spec docs.FieldSpec, node *yaml.Node) error {
+		if spec.Kind != docs.KindScalar || node.Kind != yaml.ScalarNode {
+			return nil
+		}
+		var redirected string
+		var err error
+		switch spec.Name {
+		case "url":
+			redirected, err = redirectURL(node.Value, r.HTTPURL)
+		case "urls":
+			urls := strings.Split(node.Value, ",")
+			for i, u := range urls {
+				if urls[i], err = redirectURL(u, r.HTTPURL); err != nil {
+					break
+				}
+			}
+			redirected = strings.Join(urls, ",")
+		default:
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to redirect field '%v' to mock HTTP server: %v", strings.Join(path, "."), err)
+		}
+		node.Value = redirected
+		return nil
+	})
+}
+
+// redirectURL replaces the scheme and host of an HTTP URL, where both the host
+// and path may contain interpolation functions, with those of a target. URLs of
+// other schemes are returned unchanged, and an error is returned when the
+// scheme of a URL is interpolated and therefore cannot be determined.
+func redirectURL(u, target string) (string, error) {
+	var rest string
+	switch {
+	case strings.HasPrefix(u, "http://"):
+		rest = strings.TrimPrefix(u, "http://")
+	case strings.HasPrefix(u, "https://"):
+		rest = strings.TrimPrefix(u, "https://")
+	case strings.HasPrefix(strings.TrimSpace(u), "${!"):
+		return "", fmt.Errorf("url '%v' begins with an interpolation function and so its scheme cannot be determined", u)
+	default:
+		return u, nil
+	}
+
+	for i := 0; i < len(rest); i++ {
+		if strings.HasPrefix(rest[i:], "${!") {
+			end := interpolationEnd(rest[i:])
+			if end == -1 {
+				return "", fmt.Errorf("url '%v' contains an unterminated interpolation function", u)
+			}
+			i += end
+			continue
+		}
+		if strings.IndexByte("/?#", rest[i]) >= 0 {
+			return strings.TrimSuffix(target, "/") + rest[i:], nil
+		}
+	}
+	return strings.TrimSuffix(target, "/"), nil
+}
+
+// interpolationEnd returns the index of the closing brace of an interpolation
+// function at the beginning of a string, or -1 if it is not terminated.
+func interpolationEnd(s string) int {
+	depth := 0
+	inQuotes := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case inQuotes && c == '\\':
+			i++
+		case c == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,202 @@
+package test_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/service/test"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestMockedResources(t *testing.T) {
+	color.NoColor = true
+
+	testDir, err := initTestFiles(map[string]string{
+		"config1.yaml": `
+pipeline:
+  processors:
+    - branch:
+        request_map: 'root = this'
+        processors:
+          - cache:
+              resource: foocache
+              operator: get
+              key: ${! json("id") }
+        result_map: 'root.cached = content().string()'
+    - branch:
+        request_map: 'root = this'
+        processors:
+          - http:
+              url: http://example.com/users/${! json("id") }
+              verb: GET
+              retries: 0
+        result_map: 'root.user = this'
+    - branch:
+        request_map: 'root = this'
+        processors:
+          - resource: post_stuff
+        result_map: 'root.posted = content().string()'
+
+output:
+  label: foo_out
+  http_client:
+    url: https://example.com/output
+
+cache_resources:
+  - label: foocache
+    redis:
+      url: tcp://localhost:1
+
+processor_resources:
+  - label: post_stuff
+    http:
+      url: https://example.com/stuff
+      verb: POST
+`,
+	})
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	var def test.Definition
+	require.NoError(t, yaml.Unmarshal([]byte(`
+tests:
+  - name: processors
+    mock_caches:
+      foocache:
+        a: cached a
+    mock_http:
+      - method: GET
+        path: /users/a
+        body: '{"name":"alice"}'
+      - method: POST
+        path: /stuff
+        status: 201
+        body: posted
+    input_batch:
+      - content: '{"id":"a"}'
+    output_batches:
+      - - json_equals:
+            id: a
+            cached: cached a
+            user: { name: alice }
+            posted: posted
+
+  - name: stream
+    mock_caches:
+      foocache:
+        b: cached b
+    mock_http:
+      - path: /users/b
+        body: '{"name":"bob"}'
+      - path: /stuff
+        body: posted
+    input_batches:
+      - - content: '{"id":"b"}'
+    outputs:
+      foo_out:
+        - - json_equals:
+              id: b
+              cached: cached b
+              user: { name: bob }
+              posted: posted
+
+  - name: unmatched
+    mock_http:
+      - path: /stuff
+        body: posted
+    input_batch:
+      - content: '{"id":"c"}'
+    output_batches:
+      - - json_contains: { user: { name: carol } }
+`), &def))
+
+	failures, err := def.Execute(filepath.Join(testDir, "config1.yaml"))
+	require.NoError(t, err)
+
+	require.Len(t, failures, 2)
+	for _, f := range failures {
+		assert.Equal(t, "unmatched", f.Name)
+	}
+	assert.Contains(t, failures[0].Reason, "json_contains: JSON superset mismatch")
+	assert.Contains(t, failures[1].Reason, "no mock responder matches GET /users/c")
+}
+
+func TestMockedClockAndRandomSeed(t *testing.T) {
+	color.NoColor = true
+
+	exec, err := bloblang.GlobalEnvironment().WithRandomSeed(10).NewMapping(`root = uuid_v4()`)
+	require.NoError(t, err)
+	expUUID, err := exec.MapPart(0, message.New([][]byte{[]byte(`{}`)}))
+	require.NoError(t, err)
+
+	testDir, err := initTestFiles(map[string]string{
+		"config1.yaml": `
+pipeline:
+  processors:
+    - bloblang: |
+        root.id = uuid_v4()
+        root.at = now()
+        root.unix = timestamp_unix()
+
+output:
+  label: foo_out
+  drop: {}
+`,
+		"mapping.blobl": `root.id = uuid_v4()
+root.unix = timestamp_unix()`,
+	})
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	var def test.Definition
+	require.NoError(t, yaml.Unmarshal([]byte(fmt.Sprintf(`
+parallel: true
+tests:
+  - name: processors
+    clock: 2021-10-20T15:04:05Z
+    random_seed: 10
+    input_batch:
+      - content: '{}'
+    output_batches:
+      - - json_equals: { id: %[1]s, at: 2021-10-20T15:04:05Z, unix: 1634742245 }
+
+  - name: mapping
+    target_mapping: ./mapping.blobl
+    clock: 2021-10-20T15:04:05Z
+    random_seed: 10
+    input_batch:
+      - content: '{}'
+    output_batches:
+      - - json_equals: { id: %[1]s, unix: 1634742245 }
+
+  - name: stream
+    clock: 2021-10-20T15:04:05Z
+    random_seed: 10
+    input_batches:
+      - - content: '{}'
+    outputs:
+      foo_out:
+        - - json_equals: { id: %[1]s, at: 2021-10-20T15:04:05Z, unix: 1634742245 }
+
+  - name: bad clock
+    clock: not a time
+    input_batch:
+      - content: '{}'
+`, expUUID.Get())), &def))
+
+	_, err = def.Execute(filepath.Join(testDir, "config1.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse clock")
+
+	def.Cases = def.Cases[:3]
+	failures, err := def.Execute(filepath.Join(testDir, "config1.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+}
@@ -7,8 +7,9 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/Jeffail/benthos/v3/internal/bloblang/parser"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/interop"
 	"github.com/Jeffail/benthos/v3/lib/config"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/manager"
@@ -24,16 +25,23 @@ import (
 type cachedConfig struct {
 	mgr   manager.ResourceConfig
 	procs []processor.Config
+
+	// path is the component path of the target processors, and is indexed by
+	// each processor when the target is an array.
+	path    string
+	indexed bool
 }
 
 // ProcessorsProvider consumes a Benthos config and, given a JSON Pointer,
 // extracts and constructs the target processors from the config file.
 type ProcessorsProvider struct {
 	targetPath     string
+	targetConfig   []byte
 	resourcesPaths []string
 	cachedConfigs  map[string]cachedConfig
 
-	logger log.Modular
+	logger   log.Modular
+	coverage *Coverage
 }
 
 // NewProcessorsProvider returns a new processors provider aimed at a filepath.
@@ -58,6 +66,15 @@ func OptAddResourcesPaths(paths []string) func(*ProcessorsProvider) {
 	}
 }
 
+// OptProcessorsProviderSetConfig sets the YAML of the target config, which is
+// used in place of reading the config from the target path. The target path is
+// still used in order to resolve relative paths.
+func OptProcessorsProviderSetConfig(configYAML []byte) func(*ProcessorsProvider) {
+	return func(p *ProcessorsProvider) {
+		p.targetConfig = configYAML
+	}
+}
+
 // OptProcessorsProviderSetLogger sets the logger used by tested components.
 func OptProcessorsProviderSetLogger(logger log.Modular) func(*ProcessorsProvider) {
 	return func(p *ProcessorsProvider) {
@@ -65,6 +82,14 @@ func OptProcessorsProviderSetLogger(logger log.Modular) func(*ProcessorsProvider
 	}
 }
 
+// OptProcessorsProviderSetCoverage sets a coverage recorder that records the
+// processors, switch cases and Bloblang branches executed by tested components.
+func OptProcessorsProviderSetCoverage(c *Coverage) func(*ProcessorsProvider) {
+	return func(p *ProcessorsProvider) {
+		p.coverage = c
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // Provide attempts to extract an array of processors from a Benthos config. If
@@ -79,16 +104,32 @@ func (p *ProcessorsProvider) Provide(jsonPtr string, environment map[string]stri
 // Pointer targets a single processor config it will be constructed and returned
 // as an array of one element.
 func (p *ProcessorsProvider) ProvideMocked(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node) ([]types.Processor, error) {
-	confs, err := p.getConfs(jsonPtr, environment, mocks)
+	return p.ProvideMockedResources(jsonPtr, environment, mocks, ResourceMocks{})
+}
+
+// ProvideMockedResources attempts to extract an array of processors from a
+// Benthos config. Supports injected mocked components in the parsed config, as
+// well as mocked cache resources and HTTP endpoints. If the JSON Pointer
+// targets a single processor config it will be constructed and returned as an
+// array of one element.
+func (p *ProcessorsProvider) ProvideMockedResources(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node, resources ResourceMocks) ([]types.Processor, error) {
+	confs, err := p.getConfs(jsonPtr, environment, mocks, resources)
 	if err != nil {
 		return nil, err
 	}
-	return p.initProcs(confs)
+	return p.initProcs(confs, resources)
 }
 
 // ProvideBloblang attempts to parse a Bloblang mapping and returns a processor
 // slice that executes it.
 func (p *ProcessorsProvider) ProvideBloblang(pathStr string) ([]types.Processor, error) {
+	return p.ProvideBloblangMockedResources(pathStr, ResourceMocks{})
+}
+
+// ProvideBloblangMockedResources attempts to parse a Bloblang mapping and
+// returns a processor slice that executes it, where the mapping honours the
+// clock and random seed of the resource mocks.
+func (p *ProcessorsProvider) ProvideBloblangMockedResources(pathStr string, resources ResourceMocks) ([]types.Processor, error) {
 	if !filepath.IsAbs(pathStr) {
 		pathStr = filepath.Join(filepath.Dir(p.targetPath), pathStr)
 	}
@@ -98,8 +139,8 @@ func (p *ProcessorsProvider) ProvideBloblang(pathStr string) ([]types.Processor,
 		return nil, err
 	}
 
-	pCtx := parser.GlobalContext().WithImporterRelativeToFile(pathStr)
-	exec, mapErr := parser.ParseMapping(pCtx, string(mappingBytes))
+	env := p.bloblangEnvironment(resources).WithImporterRelativeToFile(pathStr)
+	exec, mapErr := env.NewMapping(string(mappingBytes))
 	if mapErr != nil {
 		return nil, mapErr
 	}
@@ -111,15 +152,34 @@ func (p *ProcessorsProvider) ProvideBloblang(pathStr string) ([]types.Processor,
 
 //------------------------------------------------------------------------------
 
-func (p *ProcessorsProvider) initProcs(confs cachedConfig) ([]types.Processor, error) {
-	mgr, err := manager.NewV2(confs.mgr, types.NoopMgr(), p.logger, metrics.Noop())
+// bloblangEnvironment returns the environment that mappings are parsed from.
+func (p *ProcessorsProvider) bloblangEnvironment(resources ResourceMocks) *bloblang.Environment {
+	return resources.bloblangEnvironment(p.coverage.bloblangEnvironment())
+}
+
+// managerOpts returns the options of managers that tested components are
+// constructed from.
+func (p *ProcessorsProvider) managerOpts(resources ResourceMocks) []manager.OptFunc {
+	return append(
+		p.coverage.managerOpts(),
+		manager.OptSetBloblangEnvironment(p.bloblangEnvironment(resources)),
+	)
+}
+
+func (p *ProcessorsProvider) initProcs(confs cachedConfig, resources ResourceMocks) ([]types.Processor, error) {
+	mgr, err := manager.NewV2(confs.mgr, types.NoopMgr(), p.logger, metrics.Noop(), p.managerOpts(resources)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialise resources: %v", err)
 	}
 
 	procs := make([]types.Processor, len(confs.procs))
 	for i, conf := range confs.procs {
-		if procs[i], err = processor.New(conf, mgr, p.logger, metrics.Noop()); err != nil {
+		path := confs.path
+		if confs.indexed {
+			path = fmt.Sprintf("%v.%v", path, i)
+		}
+		pMgr, pLog, pStats := interop.LabelChild(path, mgr, p.logger, metrics.Noop())
+		if procs[i], err = processor.New(conf, pMgr, pLog, pStats); err != nil {
 			return nil, fmt.Errorf("failed to initialise processor index '%v': %v", i, err)
 		}
 	}
@@ -180,11 +240,14 @@ func resolveProcessorsPointer(targetFile, jsonPtr string) (filePath, procPath st
 	return
 }
 
-func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node) (cachedConfig, error) {
+func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node, resources ResourceMocks) (cachedConfig, error) {
+	// Configs with mocked resources are specific to a single test case and
+	// therefore are not cached.
+	cacheable := !resources.rewritesConfig()
 	cacheKey := confTargetID(jsonPtr, environment, mocks)
 
 	confs, exists := p.cachedConfigs[cacheKey]
-	if exists {
+	if exists && cacheable {
 		return confs, nil
 	}
 
@@ -206,96 +269,134 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 	cleanupEnv := setEnvironment(environment)
 	defer cleanupEnv()
 
-	remainingMocks := map[string]yaml.Node{}
-	for k, v := range mocks {
-		remainingMocks[k] = v
+	root, mgrWrapper, err := p.readMockedConfig(targetPath, mocks, resources)
+	if err != nil {
+		return confs, err
 	}
+	confs.mgr = mgrWrapper
+	confs.path = pointerToComponentPath(procPath)
 
-	configBytes, err := config.ReadWithJSONPointers(targetPath, true)
+	pathSlice, err := gabs.JSONPointerToSlice(procPath)
 	if err != nil {
-		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+		return confs, fmt.Errorf("failed to parse case processors path '%v': %w", procPath, err)
+	}
+	if root, err = docs.GetYAMLPath(root, pathSlice...); err != nil {
+		return confs, fmt.Errorf("failed to resolve case processors from '%v': %v", targetPath, err)
 	}
 
+	if root.Kind == yaml.SequenceNode {
+		confs.indexed = true
+		if err = root.Decode(&confs.procs); err != nil {
+			return confs, fmt.Errorf("failed to resolve case processors from '%v': %v", targetPath, err)
+		}
+	} else {
+		var procConf processor.Config
+		if err = root.Decode(&procConf); err != nil {
+			return confs, fmt.Errorf("failed to resolve case processors from '%v': %v", targetPath, err)
+		}
+		confs.procs = append(confs.procs, procConf)
+	}
+
+	if cacheable {
+		p.cachedConfigs[cacheKey] = confs
+	}
+	return confs, nil
+}
+
+//------------------------------------------------------------------------------
+
+// readMockedConfig parses a config file along with any resource files into a
+// YAML node with mocks applied, and a resources config.
+func (p *ProcessorsProvider) readMockedConfig(targetPath string, mocks map[string]yaml.Node, resources ResourceMocks) (*yaml.Node, manager.ResourceConfig, error) {
 	mgrWrapper := manager.NewResourceConfig()
-	if err = yaml.Unmarshal(configBytes, &mgrWrapper); err != nil {
-		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+
+	var err error
+	configBytes := p.targetConfig
+	if configBytes == nil || targetPath != p.targetPath {
+		if configBytes, err = config.ReadWithJSONPointers(targetPath, true); err != nil {
+			return nil, mgrWrapper, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+		}
+	}
+
+	root := &yaml.Node{}
+	if err = yaml.Unmarshal(configBytes, root); err != nil {
+		return nil, mgrWrapper, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+	}
+	if err = setYAMLTargets(root, mocks, "mock"); err != nil {
+		return nil, mgrWrapper, err
+	}
+	if err = resources.rewriteURLs(root); err != nil {
+		return nil, mgrWrapper, fmt.Errorf("failed to mock config file '%v': %v", targetPath, err)
+	}
+
+	if err = root.Decode(&mgrWrapper); err != nil {
+		return nil, mgrWrapper, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
 	}
 
 	for _, path := range p.resourcesPaths {
 		resourceBytes, err := config.ReadWithJSONPointers(path, true)
 		if err != nil {
-			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
+			return nil, mgrWrapper, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
+		}
+		resourceRoot := &yaml.Node{}
+		if err = yaml.Unmarshal(resourceBytes, resourceRoot); err != nil {
+			return nil, mgrWrapper, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
+		}
+		if err = resources.rewriteURLs(resourceRoot); err != nil {
+			return nil, mgrWrapper, fmt.Errorf("failed to mock resources config file '%v': %v", path, err)
 		}
+
 		extraMgrWrapper := manager.NewResourceConfig()
-		if err = yaml.Unmarshal(resourceBytes, &extraMgrWrapper); err != nil {
-			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
+		if err = resourceRoot.Decode(&extraMgrWrapper); err != nil {
+			return nil, mgrWrapper, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
 		}
 		if err = mgrWrapper.AddFrom(&extraMgrWrapper); err != nil {
-			return confs, fmt.Errorf("failed to merge resources from '%v': %v", path, err)
+			return nil, mgrWrapper, fmt.Errorf("failed to merge resources from '%v': %v", path, err)
 		}
 	}
 
-	confs.mgr = mgrWrapper
+	resources.applyCaches(&mgrWrapper)
+	return root, mgrWrapper, nil
+}
 
-	root := &yaml.Node{}
-	if err = yaml.Unmarshal(configBytes, root); err != nil {
-		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+// setYAMLTargets replaces components of a config with the provided values,
+// starting with all absolute paths in JSON pointer form, then parsing remaining
+// targets as label names.
+func setYAMLTargets(root *yaml.Node, targets map[string]yaml.Node, kind string) error {
+	remaining := map[string]yaml.Node{}
+	for k, v := range targets {
+		remaining[k] = v
 	}
 
-	// Replace mock components, starting with all absolute paths in JSON pointer
-	// form, then parsing remaining mock targets as label names.
 	confSpec := config.Spec()
-	for k, v := range remainingMocks {
+	for k, v := range remaining {
 		if !strings.HasPrefix(k, "/") {
 			continue
 		}
-		mockPathSlice, err := gabs.JSONPointerToSlice(k)
+		pathSlice, err := gabs.JSONPointerToSlice(k)
 		if err != nil {
-			return confs, fmt.Errorf("failed to parse mock path '%v': %w", k, err)
+			return fmt.Errorf("failed to parse %v path '%v': %w", kind, k, err)
 		}
-		if err = confSpec.SetYAMLPath(nil, root, &v, mockPathSlice...); err != nil {
-			return confs, fmt.Errorf("failed to set mock '%v': %w", k, err)
+		if err = confSpec.SetYAMLPath(nil, root, &v, pathSlice...); err != nil {
+			return fmt.Errorf("failed to set %v '%v': %w", kind, k, err)
 		}
-		delete(remainingMocks, k)
+		delete(remaining, k)
 	}
 
-	if len(remainingMocks) > 0 {
+	if len(remaining) > 0 {
 		labelsToPaths := map[string][]string{}
 		confSpec.YAMLLabelsToPaths(nil, root, labelsToPaths, nil)
-		for k, v := range remainingMocks {
-			mockPathSlice, exists := labelsToPaths[k]
+		for k, v := range remaining {
+			pathSlice, exists := labelsToPaths[k]
 			if !exists {
-				return confs, fmt.Errorf("mock for label '%v' could not be applied as the label was not found in the test target file, it is not currently possible to mock resources imported separate to the test file", k)
+				return fmt.Errorf("%v for label '%v' could not be applied as the label was not found in the test target file, it is not currently possible to %v resources imported separate to the test file", kind, k, kind)
 			}
-			if err = confSpec.SetYAMLPath(nil, root, &v, mockPathSlice...); err != nil {
-				return confs, fmt.Errorf("failed to set mock '%v': %w", k, err)
+			if err := confSpec.SetYAMLPath(nil, root, &v, pathSlice...); err != nil {
+				return fmt.Errorf("failed to set %v '%v': %w", kind, k, err)
 			}
-			delete(remainingMocks, k)
 		}
 	}
-
-	pathSlice, err := gabs.JSONPointerToSlice(procPath)
-	if err != nil {
-		return confs, fmt.Errorf("failed to parse case processors path '%v': %w", procPath, err)
-	}
-	if root, err = docs.GetYAMLPath(root, pathSlice...); err != nil {
-		return confs, fmt.Errorf("failed to resolve case processors from '%v': %v", targetPath, err)
-	}
-
-	if root.Kind == yaml.SequenceNode {
-		if err = root.Decode(&confs.procs); err != nil {
-			return confs, fmt.Errorf("failed to resolve case processors from '%v': %v", targetPath, err)
-		}
-	} else {
-		var procConf processor.Config
-		if err = root.Decode(&procConf); err != nil {
-			return confs, fmt.Errorf("failed to resolve case processors from '%v': %v", targetPath, err)
-		}
-		confs.procs = append(confs.procs, procConf)
-	}
-
-	p.cachedConfigs[cacheKey] = confs
-	return confs, nil
+	return nil
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,316 @@
+package test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+
+// Formats supported by the test command for reporting results.
+const (
+	FormatDefault = "default"
+	FormatJUnit   = "junit"
+	FormatTAP     = "tap"
+	FormatJSON    = "json"
+)
+
+// caseResult is the result of executing a single test case.
+type caseResult struct {
+	Name     string   `json:"name"`
+	Line     int      `json:"line"`
+	Passed   bool     `json:"passed"`
+	Failures []string `json:"failures,omitempty"`
+}
+
+// targetResult is the result of linting and executing the test cases of a
+// config file.
+type targetResult struct {
+	Target   string        `json:"target"`
+	Passed   bool          `json:"passed"`
+	Lints    []string      `json:"lints,omitempty"`
+	Cases    []caseResult  `json:"cases"`
+	Duration time.Duration `json:"-"`
+}
+
+func newTargetResult(target string, def Definition, lints []string, failures []CaseFailure, duration time.Duration) targetResult {
+	res := targetResult{
+		Target:   target,
+		Passed:   len(lints) == 0 && len(failures) == 0,
+		Lints:    lints,
+		Duration: duration,
+	}
+	for _, c := range def.Cases {
+		cRes := caseResult{
+			Name: c.Name,
+			Line: c.line,
+		}
+		for _, f := range failures {
+			if f.Name == c.Name && f.TestLine == c.line {
+				cRes.Failures = append(cRes.Failures, f.Reason)
+			}
+		}
+		cRes.Passed = len(cRes.Failures) == 0
+		res.Cases = append(res.Cases, cRes)
+	}
+	return res
+}
+
+//------------------------------------------------------------------------------
+
+func validFormat(format string) bool {
+	switch format {
+	case FormatDefault, FormatJUnit, FormatTAP, FormatJSON:
+		return true
+	}
+	return false
+}
+
+// writeReport writes the results of a test run in a given format. The default
+// format prints results as they are executed and is therefore not written
+// here.
+func writeReport(w io.Writer, format string, results []targetResult, cov *CoverageReport) error {
+	switch format {
+	case FormatJUnit:
+		return writeJUnit(w, results)
+	case FormatTAP:
+		return writeTAP(w, results)
+	case FormatJSON:
+		return writeJSON(w, results, cov)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+func writeJUnit(w io.Writer, results []targetResult) error {
+	suites := junitTestSuites{}
+	for _, res := range results {
+		suite := junitTestSuite{
+			Name: res.Target,
+			Time: fmt.Sprintf("%.3f", res.Duration.Seconds()),
+		}
+		if len(res.Lints) > 0 {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      "lint",
+				Classname: res.Target,
+				Failure: &junitFailure{
+					Message: fmt.Sprintf("%v lint errors", len(res.Lints)),
+					Body:    strings.Join(res.Lints, "\n"),
+				},
+			})
+			suite.Failures++
+		}
+		for _, c := range res.Cases {
+			tc := junitTestCase{
+				Name:      fmt.Sprintf("%v [line %v]", c.Name, c.Line),
+				Classname: res.Target,
+			}
+			if !c.Passed {
+				tc.Failure = &junitFailure{
+					Message: fmt.Sprintf("%v failures", len(c.Failures)),
+					Body:    strings.Join(c.Failures, "\n"),
+				}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Tests = len(suite.Cases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+func writeTAP(w io.Writer, results []targetResult) error {
+	type tapPoint struct {
+		ok          bool
+		description string
+		diagnostics map[string]interface{}
+	}
+
+	var points []tapPoint
+	for _, res := range results {
+		if len(res.Lints) > 0 {
+			points = append(points, tapPoint{
+				description: fmt.Sprintf("%v: lint", res.Target),
+				diagnostics: map[string]interface{}{
+					"lints": res.Lints,
+				},
+			})
+		}
+		for _, c := range res.Cases {
+			p := tapPoint{
+				ok:          c.Passed,
+				description: fmt.Sprintf("%v: %v", res.Target, c.Name),
+			}
+			if !c.Passed {
+				p.diagnostics = map[string]interface{}{
+					"line":     c.Line,
+					"failures": c.Failures,
+				}
+			}
+			points = append(points, p)
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "TAP version 13\n1..%v\n", len(points)); err != nil {
+		return err
+	}
+	for i, p := range points {
+		status := "ok"
+		if !p.ok {
+			status = "not ok"
+		}
+		if _, err := fmt.Fprintf(w, "%v %v - %v\n", status, i+1, p.description); err != nil {
+			return err
+		}
+		if p.diagnostics == nil {
+			continue
+		}
+		diagBytes, err := yaml.Marshal(p.diagnostics)
+		if err != nil {
+			return err
+		}
+		lines := strings.Split(strings.TrimSuffix(string(diagBytes), "\n"), "\n")
+		if _, err := fmt.Fprintf(w, "  ---\n  %v\n  ...\n", strings.Join(lines, "\n  ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func writeJSON(w io.Writer, results []targetResult, cov *CoverageReport) error {
+	passed := true
+	for _, res := range results {
+		passed = passed && res.Passed
+	}
+	if results == nil {
+		results = []targetResult{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Passed   bool            `json:"passed"`
+		Targets  []targetResult  `json:"targets"`
+		Coverage *CoverageReport `json:"coverage,omitempty"`
+	}{
+		Passed:   passed,
+		Targets:  results,
+		Coverage: cov,
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// writeCoverage writes a summary of a coverage report followed by a list of
+// the items that were not covered.
+func writeCoverage(w io.Writer, r CoverageReport) {
+	covered, total := r.Covered()
+	fmt.Fprintf(w, "Coverage: %v/%v (%.1f%%)\n", covered, total, r.Percentage())
+
+	var uncovered []string
+
+	pCovered := 0
+	for _, p := range r.Processors {
+		if p.Hits > 0 {
+			pCovered++
+			continue
+		}
+		uncovered = append(uncovered, fmt.Sprintf("processor %v (%v)", p.Path, p.Type))
+	}
+	fmt.Fprintf(w, "  Processors: %v/%v\n", pCovered, len(r.Processors))
+
+	sCovered := 0
+	for _, s := range r.SwitchCases {
+		if s.Hits > 0 {
+			sCovered++
+			continue
+		}
+		uncovered = append(uncovered, fmt.Sprintf("switch %v %v case %v", s.Kind, s.Path, s.Case))
+	}
+	fmt.Fprintf(w, "  Switch cases: %v/%v\n", sCovered, len(r.SwitchCases))
+
+	bCovered := 0
+	for _, b := range r.Branches {
+		if b.Hits > 0 {
+			bCovered++
+			continue
+		}
+		uncovered = append(uncovered, fmt.Sprintf(
+			"bloblang %v branch '%v' at line %v column %v of mapping: %v",
+			b.Expression, b.Branch, b.Line, b.Column, mappingSummary(b.Mapping),
+		))
+	}
+	fmt.Fprintf(w, "  Bloblang branches: %v/%v\n", bCovered, len(r.Branches))
+
+	if len(uncovered) > 0 {
+		fmt.Fprintf(w, "\nNot covered:\n\n")
+		for _, u := range uncovered {
+			fmt.Fprintf(w, "  %v\n", u)
+		}
+	}
+}
+
+// mappingSummary returns the first line of a mapping, truncated in order to
+// identify it within a report.
+func mappingSummary(mapping string) string {
+	const maxLen = 40
+	summary := strings.TrimSpace(mapping)
+	if i := strings.Index(summary, "\n"); i >= 0 {
+		summary = strings.TrimSpace(summary[:i]) + " ..."
+	}
+	if runes := []rune(summary); len(runes) > maxLen {
+		summary = string(runes[:maxLen]) + "..."
+	}
+	return summary
+}
+
+//------------------------------------------------------------------------------
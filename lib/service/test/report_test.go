@@ -0,0 +1,170 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initReportTestFiles(t *testing.T) string {
+	t.Helper()
+
+	testDir, err := os.MkdirTemp("", "benthos_report_test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(testDir)
+	})
+
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "foo.yaml"), []byte(`
+pipeline:
+  processors:
+    - bloblang: |
+        root = if content() == "a" { "is a" } else { "not a" }
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "foo_benthos_test.yaml"), []byte(`
+tests:
+  - name: passes
+    input_batch:
+      - content: a
+    output_batches:
+      - - content_equals: is a
+  - name: fails
+    input_batch:
+      - content: a
+    output_batches:
+      - - content_equals: not a
+`), 0644))
+	return testDir
+}
+
+func runReport(t *testing.T, dir string, conf runConfig) (passed bool, out, errOut string) {
+	t.Helper()
+
+	var outBuf, errBuf bytes.Buffer
+	conf.logger = log.Noop()
+	conf.out, conf.errOut = &outBuf, &errBuf
+	passed = runAll([]string{dir}, "_benthos_test", conf)
+	return passed, outBuf.String(), errBuf.String()
+}
+
+func TestReportDefault(t *testing.T) {
+	color.NoColor = true
+	dir := initReportTestFiles(t)
+	target := filepath.Join(dir, "foo.yaml")
+
+	passed, out, _ := runReport(t, dir, runConfig{format: FormatDefault})
+	assert.False(t, passed)
+	assert.Equal(t, "Test '"+target+"' failed\n\nFailures:\n\n--- "+target+" ---\n\n"+
+		"fails [line 8]:\nbatch 0 message 0: content_equals: content mismatch\n  expected: not a\n  received: is a\n", out)
+}
+
+func TestReportExtraTargets(t *testing.T) {
+	color.NoColor = true
+	dir := initReportTestFiles(t)
+	target := filepath.Join(dir, "foo.yaml")
+
+	extraCase := NewCase()
+	extraCase.Name = "extra"
+	passed, out, _ := runReport(t, dir, runConfig{
+		format: FormatDefault,
+		extraTargets: map[string]TargetFunc{
+			"template.yaml": func() (Definition, []CaseFailure, error) {
+				return Definition{Cases: []Case{extraCase.AtLine(4)}}, []CaseFailure{
+					{Name: "extra", TestLine: 4, Reason: "nope"},
+				}, nil
+			},
+		},
+	})
+	assert.False(t, passed)
+	assert.Contains(t, out, "Test '"+target+"' failed\nTest 'template.yaml' failed\n")
+	assert.Contains(t, out, "--- template.yaml ---\n\nextra [line 4]:\nnope\n")
+}
+
+func TestReportJUnit(t *testing.T) {
+	dir := initReportTestFiles(t)
+
+	passed, out, _ := runReport(t, dir, runConfig{format: FormatJUnit})
+	assert.False(t, passed)
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal([]byte(out), &suites))
+	assert.Equal(t, 2, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	require.Len(t, suites.Suites, 1)
+	require.Len(t, suites.Suites[0].Cases, 2)
+
+	assert.Equal(t, "passes [line 3]", suites.Suites[0].Cases[0].Name)
+	assert.Nil(t, suites.Suites[0].Cases[0].Failure)
+
+	assert.Equal(t, "fails [line 8]", suites.Suites[0].Cases[1].Name)
+	require.NotNil(t, suites.Suites[0].Cases[1].Failure)
+	assert.Contains(t, suites.Suites[0].Cases[1].Failure.Body, "content_equals: content mismatch")
+}
+
+func TestReportTAP(t *testing.T) {
+	dir := initReportTestFiles(t)
+	target := filepath.Join(dir, "foo.yaml")
+
+	passed, out, _ := runReport(t, dir, runConfig{format: FormatTAP})
+	assert.False(t, passed)
+	assert.Equal(t, `TAP version 13
+1..2
+ok 1 - `+target+`: passes
+not ok 2 - `+target+`: fails
+  ---
+  failures:
+      - |-
+        batch 0 message 0: content_equals: content mismatch
+          expected: not a
+          received: is a
+  line: 8
+  ...
+`, out)
+}
+
+func TestReportJSONCoverage(t *testing.T) {
+	dir := initReportTestFiles(t)
+
+	passed, out, errOut := runReport(t, dir, runConfig{
+		format:      FormatJSON,
+		coverage:    true,
+		coverageMin: 90,
+	})
+	assert.False(t, passed)
+	assert.Contains(t, errOut, "Coverage of 66.7% is below the minimum of 90.0%")
+
+	var report struct {
+		Passed   bool            `json:"passed"`
+		Targets  []targetResult  `json:"targets"`
+		Coverage *CoverageReport `json:"coverage"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+
+	assert.False(t, report.Passed)
+	require.Len(t, report.Targets, 1)
+	require.Len(t, report.Targets[0].Cases, 2)
+	assert.True(t, report.Targets[0].Cases[0].Passed)
+	assert.False(t, report.Targets[0].Cases[1].Passed)
+
+	require.NotNil(t, report.Coverage)
+	covered, total := report.Coverage.Covered()
+	assert.Equal(t, 2, covered)
+	assert.Equal(t, 3, total)
+}
+
+func TestReportUnknownFormat(t *testing.T) {
+	dir := initReportTestFiles(t)
+
+	passed, out, errOut := runReport(t, dir, runConfig{format: "nope"})
+	assert.False(t, passed)
+	assert.Empty(t, out)
+	assert.Equal(t, "Unrecognised test output format: nope\n", errOut)
+}
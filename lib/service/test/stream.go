@@ -0,0 +1,237 @@
+package test
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message/roundtrip"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	yaml "gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	mockedInputPipe    = "benthos_test_input"
+	capturedOutputPipe = "benthos_test_output_"
+)
+
+// StreamProvider constructs a full Benthos stream from a config where the
+// input is optionally replaced with a mock and targeted outputs are replaced
+// with captures.
+type StreamProvider interface {
+	ProvideStream(environment map[string]string, mocks map[string]yaml.Node, resources ResourceMocks, captures []string, mockInput bool) (*MockedStream, error)
+}
+
+// MockedStream is a running Benthos stream where the input has been replaced
+// with a mock that batches can be sent to, and targeted outputs have been
+// replaced with captures that record the batches they receive.
+type MockedStream struct {
+	strm *stream.Type
+	mgr  *manager.Type
+
+	inputChan chan types.Transaction
+
+	capWG     sync.WaitGroup
+	capMut    sync.Mutex
+	captured  map[string][]types.Message
+	capNotify chan struct{}
+
+	closeChan chan struct{}
+}
+
+// Send a batch through the stream and wait for it to be acknowledged. Returns
+// any responses that were set by sync_response outputs, and the error the
+// batch was rejected with, if any.
+func (m *MockedStream) Send(msg types.Message, timeout time.Duration) ([]types.Message, error) {
+	store := roundtrip.NewResultStore()
+	roundtrip.AddResultStore(msg, store)
+
+	resChan := make(chan types.Response)
+	select {
+	case m.inputChan <- types.NewTransaction(msg, resChan):
+	case <-time.After(timeout):
+		return nil, types.ErrTimeout
+	}
+
+	select {
+	case res := <-resChan:
+		return store.Get(), res.Error()
+	case <-time.After(timeout):
+		return nil, types.ErrTimeout
+	}
+}
+
+// Captured returns the batches received by a captured output.
+func (m *MockedStream) Captured(target string) []types.Message {
+	m.capMut.Lock()
+	defer m.capMut.Unlock()
+	return m.captured[target]
+}
+
+// WaitForCaptured blocks until a captured output has received a number of
+// batches, returns false if the timeout elapses first.
+func (m *MockedStream) WaitForCaptured(target string, count int, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		m.capMut.Lock()
+		received, notify := len(m.captured[target]), m.capNotify
+		m.capMut.Unlock()
+		if received >= count {
+			return true
+		}
+		select {
+		case <-notify:
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+// Stop the stream and release its resources.
+func (m *MockedStream) Stop(timeout time.Duration) error {
+	stopBy := time.Now().Add(timeout)
+
+	err := m.strm.Stop(timeout)
+	close(m.closeChan)
+	m.capWG.Wait()
+
+	m.mgr.CloseAsync()
+	if mErr := m.mgr.WaitForClose(time.Until(stopBy)); err == nil {
+		err = mErr
+	}
+	return err
+}
+
+func (m *MockedStream) capture(target string, tChan <-chan types.Transaction) {
+	defer m.capWG.Done()
+	for {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-tChan:
+			if !open {
+				return
+			}
+		case <-m.closeChan:
+			return
+		}
+
+		m.capMut.Lock()
+		m.captured[target] = append(m.captured[target], tran.Payload.DeepCopy())
+		close(m.capNotify)
+		m.capNotify = make(chan struct{})
+		m.capMut.Unlock()
+
+		select {
+		case tran.ResponseChan <- response.NewAck():
+		case <-m.closeChan:
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ProvideStream parses the target config and constructs a stream where the
+// outputs targeted by captures, either by label or JSON Pointer, are replaced
+// with in-memory captures. When mockInput is true the input is replaced with a
+// mock that batches are sent to, otherwise the input of the config is kept.
+// Mocks are applied before captures, and mocked resources override those of
+// the config.
+func (p *ProcessorsProvider) ProvideStream(environment map[string]string, mocks map[string]yaml.Node, resources ResourceMocks, captures []string, mockInput bool) (*MockedStream, error) {
+	cleanupEnv := setEnvironment(environment)
+	defer cleanupEnv()
+
+	root, mgrConf, err := p.readMockedConfig(p.targetPath, mocks, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	sortedCaptures := append([]string{}, captures...)
+	sort.Strings(sortedCaptures)
+
+	captureNodes := map[string]yaml.Node{}
+	for i, c := range sortedCaptures {
+		var node yaml.Node
+		if err = node.Encode(map[string]string{
+			"inproc": fmt.Sprintf("%v%v", capturedOutputPipe, i),
+		}); err != nil {
+			return nil, err
+		}
+		captureNodes[c] = node
+	}
+	if err = setYAMLTargets(root, captureNodes, "capture"); err != nil {
+		return nil, err
+	}
+
+	// A mocked input is replaced with an inproc input whilst keeping any
+	// processors.
+	if mockInput {
+		var inputNode yaml.Node
+		if err = inputNode.Encode(map[string]string{
+			"inproc": mockedInputPipe,
+		}); err != nil {
+			return nil, err
+		}
+		if procsNode, err := docs.GetYAMLPath(root, "input", "processors"); err == nil {
+			inputNode.Content = append(inputNode.Content, &yaml.Node{
+				Kind:  yaml.ScalarNode,
+				Value: "processors",
+			}, procsNode)
+		}
+		if err = config.Spec().SetYAMLPath(nil, root, &inputNode, "input"); err != nil {
+			return nil, fmt.Errorf("failed to mock input: %w", err)
+		}
+	}
+
+	conf := config.New()
+	if err = root.Decode(&conf); err != nil {
+		return nil, fmt.Errorf("failed to parse config file '%v': %v", p.targetPath, err)
+	}
+
+	mgr, err := manager.NewV2(mgrConf, types.NoopMgr(), p.logger, metrics.Noop(), p.managerOpts(resources)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise resources: %v", err)
+	}
+
+	m := &MockedStream{
+		mgr:       mgr,
+		inputChan: make(chan types.Transaction),
+		captured:  map[string][]types.Message{},
+		capNotify: make(chan struct{}),
+		closeChan: make(chan struct{}),
+	}
+	mgr.SetPipe(mockedInputPipe, m.inputChan)
+
+	if m.strm, err = stream.New(
+		conf.Config,
+		stream.OptSetLogger(p.logger),
+		stream.OptSetStats(metrics.Noop()),
+		stream.OptSetManager(mgr),
+	); err != nil {
+		mgr.CloseAsync()
+		return nil, fmt.Errorf("failed to initialise stream: %v", err)
+	}
+
+	for i, c := range sortedCaptures {
+		tChan, err := mgr.GetPipe(fmt.Sprintf("%v%v", capturedOutputPipe, i))
+		if err != nil {
+			_ = m.Stop(time.Second)
+			return nil, fmt.Errorf("failed to capture '%v': target is not an output", c)
+		}
+		m.capWG.Add(1)
+		go m.capture(c, tChan)
+	}
+	return m, nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,161 @@
+package test_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/service/test"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+const streamTestConfig = `
+input:
+  http_server:
+    path: /post
+  processors:
+    - bloblang: 'root = this.merge({"seen_input": true})'
+
+pipeline:
+  processors:
+    - bloblang: 'root = this.merge({"seen_pipeline": true})'
+
+output:
+  broker:
+    pattern: fan_out
+    outputs:
+      - sync_response: {}
+        processors:
+          - bloblang: 'root = this.type.uppercase()'
+      - switch:
+          cases:
+            - check: this.type == "foo"
+              output:
+                label: foo_out
+                http_client:
+                  url: http://localhost:1/foo
+            - output:
+                label: bar_out
+                http_client:
+                  url: http://localhost:1/bar
+`
+
+func TestStreamCase(t *testing.T) {
+	color.NoColor = true
+
+	testDir, err := initTestFiles(map[string]string{
+		"config1.yaml": streamTestConfig,
+	})
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	var def test.Definition
+	require.NoError(t, yaml.Unmarshal([]byte(`
+parallel: true
+tests:
+  - name: routes foo
+    input_batches:
+      - - json_content: { type: foo }
+      - - content: '{"type":"bar"}'
+        - content: '{"type":"foo"}'
+    outputs:
+      foo_out:
+        - - json_equals: { type: foo, seen_input: true, seen_pipeline: true }
+        - - json_equals: { type: foo, seen_input: true, seen_pipeline: true }
+      bar_out:
+        - - json_equals: { type: bar, seen_input: true, seen_pipeline: true }
+    sync_responses:
+      - - content_equals: FOO
+      - - content_equals: BAR
+        - content_equals: FOO
+
+  - name: routes bar
+    input_batches:
+      - - content: '{"type":"bar"}'
+    outputs:
+      foo_out:
+        - - json_contains: { type: bar }
+      bar_out: []
+`), &def))
+
+	failures, err := def.Execute(filepath.Join(testDir, "config1.yaml"))
+	require.NoError(t, err)
+
+	var failStrs []string
+	for _, f := range failures {
+		failStrs = append(failStrs, f.String())
+	}
+	assert.Equal(t, []string{
+		"routes bar [line 20]: output 'bar_out' unexpected batch: [{\"seen_input\":true,\"seen_pipeline\":true,\"type\":\"bar\"}]",
+		"routes bar [line 20]: output 'foo_out' wrong batch count, expected 1, got 0",
+	}, failStrs)
+}
+
+func TestStreamCaseBadCapture(t *testing.T) {
+	testDir, err := initTestFiles(map[string]string{
+		"config1.yaml": streamTestConfig,
+	})
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	def := test.Definition{
+		Cases: []test.Case{
+			{
+				Name: "bad capture",
+				InputBatches: [][]test.InputPart{
+					{{Content: "hello world"}},
+				},
+				Outputs: map[string][][]test.ConditionsMap{
+					"baz_out": {},
+				},
+			},
+		},
+	}
+
+	_, err = def.Execute(filepath.Join(testDir, "config1.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "capture for label 'baz_out' could not be applied")
+}
+
+func TestStreamCaseKeepsInput(t *testing.T) {
+	color.NoColor = true
+
+	testDir, err := initTestFiles(map[string]string{
+		"config1.yaml": `
+input:
+  generate:
+    mapping: 'root.type = "foo"'
+    interval: 1ms
+  processors:
+    - bloblang: 'root = this.merge({"seen_input": true})'
+
+pipeline:
+  processors:
+    - bloblang: 'root = this.merge({"seen_pipeline": true})'
+
+output:
+  label: foo_out
+  http_client:
+    url: http://localhost:1/foo
+`,
+	})
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	var def test.Definition
+	require.NoError(t, yaml.Unmarshal([]byte(`
+tests:
+  - name: generated
+    outputs:
+      foo_out:
+        - - json_equals: { type: foo, seen_input: true, seen_pipeline: true }
+        - - json_equals: { type: foo, seen_input: true, seen_pipeline: true }
+`), &def))
+
+	failures, err := def.Execute(filepath.Join(testDir, "config1.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+}
@@ -0,0 +1,288 @@
+package stream
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// transactionGate forwards transactions from the input layer of a stream to the
+// next layer, and can be paused in order to stop consuming from the input
+// without closing it. Transactions already forwarded are unaffected and are
+// therefore able to finish whilst the gate is paused.
+//
+// The gate also tracks the messages that are in flight, meaning they have been
+// consumed from the input but not yet acknowledged, and can block consumption
+// whilst they exceed a limit or until they have all been acknowledged. Tracking
+// requires an extra goroutine per transaction, and therefore messages are only
+// tracked when a limit is set or once trackAll has been called.
+type transactionGate struct {
+	mut        sync.Mutex
+	resumeChan chan struct{}
+
+	maxInFlight      int
+	maxInFlightBytes int64
+	trackingAll      int32
+
+	inFlightMut   sync.Mutex
+	inFlight      int
+	inFlightBytes int64
+	releasedChan  chan struct{}
+
+	tranChan     chan types.Transaction
+	redirectChan chan chan types.Transaction
+
+	shutChan chan struct{}
+	shutOnce sync.Once
+}
+
+func newTransactionGate(maxInFlight int, maxInFlightBytes int64) *transactionGate {
+	return &transactionGate{
+		maxInFlight:      maxInFlight,
+		maxInFlightBytes: maxInFlightBytes,
+		releasedChan:     make(chan struct{}),
+		tranChan:         make(chan types.Transaction),
+		redirectChan:     make(chan chan types.Transaction),
+		shutChan:         make(chan struct{}),
+	}
+}
+
+func messageBytes(msg types.Message) (size int64) {
+	_ = msg.Iter(func(i int, p types.Part) error {
+		size += int64(len(p.Get()))
+		return nil
+	})
+	return
+}
+
+// acquire blocks until a transaction of a given size fits within the in flight
+// limits, or returns false if the gate is shut down first. A transaction is
+// always permitted when nothing else is in flight, otherwise a single batch
+// larger than a limit would block forever.
+func (g *transactionGate) acquire(count int, size int64) bool {
+	for {
+		g.inFlightMut.Lock()
+		fits := g.inFlight == 0 ||
+			((g.maxInFlight <= 0 || g.inFlight+count <= g.maxInFlight) &&
+				(g.maxInFlightBytes <= 0 || g.inFlightBytes+size <= g.maxInFlightBytes))
+		if fits {
+			g.inFlight += count
+			g.inFlightBytes += size
+			g.inFlightMut.Unlock()
+			return true
+		}
+		releasedChan := g.releasedChan
+		g.inFlightMut.Unlock()
+
+		select {
+		case <-releasedChan:
+		case <-g.shutChan:
+			return false
+		}
+	}
+}
+
+func (g *transactionGate) release(count int, size int64) {
+	g.inFlightMut.Lock()
+	g.inFlight -= count
+	g.inFlightBytes -= size
+	close(g.releasedChan)
+	g.releasedChan = make(chan struct{})
+	g.inFlightMut.Unlock()
+}
+
+// trackAll begins tracking all transactions consumed from now on regardless of
+// the limits of the gate, which allows waitForAcks to account for them.
+func (g *transactionGate) trackAll() {
+	atomic.StoreInt32(&g.trackingAll, 1)
+}
+
+func (g *transactionGate) tracking() bool {
+	return g.maxInFlight > 0 || g.maxInFlightBytes > 0 || atomic.LoadInt32(&g.trackingAll) == 1
+}
+
+// track wraps a transaction in order to account for it being in flight until
+// it receives a response.
+func (g *transactionGate) track(tran types.Transaction) (types.Transaction, bool) {
+	if !g.tracking() {
+		return tran, true
+	}
+
+	count, size := tran.Payload.Len(), messageBytes(tran.Payload)
+	if !g.acquire(count, size) {
+		return tran, false
+	}
+
+	resChan := make(chan types.Response)
+	go func() {
+		var res types.Response
+		select {
+		case res = <-resChan:
+			g.release(count, size)
+		case <-g.shutChan:
+			// Nothing waits on the in flight messages of a shut gate, but the
+			// response must still be forwarded to the input once it arrives.
+			g.release(count, size)
+			res = <-resChan
+		}
+		tran.ResponseChan <- res
+	}()
+	return types.NewTransaction(tran.Payload, resChan), true
+}
+
+// inFlightStats returns the number of messages and bytes currently in flight.
+func (g *transactionGate) inFlightStats() (int, int64) {
+	g.inFlightMut.Lock()
+	defer g.inFlightMut.Unlock()
+	return g.inFlight, g.inFlightBytes
+}
+
+// waitForAcks blocks until all tracked transactions in flight have received a
+// response, or until the timeout is exceeded.
+func (g *transactionGate) waitForAcks(timeout time.Duration) error {
+	timeoutChan := time.After(timeout)
+	for {
+		g.inFlightMut.Lock()
+		inFlight := g.inFlight
+		releasedChan := g.releasedChan
+		g.inFlightMut.Unlock()
+		if inFlight == 0 {
+			return nil
+		}
+
+		select {
+		case <-releasedChan:
+		case <-timeoutChan:
+			return types.ErrTimeout
+		case <-g.shutChan:
+			return types.ErrTypeClosed
+		}
+	}
+}
+
+// consume begins forwarding transactions from a channel, the output channel is
+// closed once the input channel is closed.
+func (g *transactionGate) consume(in <-chan types.Transaction) {
+	go func() {
+		out := g.tranChan
+		defer func() {
+			close(out)
+		}()
+
+		redirect := func(newOut chan types.Transaction) {
+			close(out)
+			out = newOut
+		}
+
+		for {
+			g.mut.Lock()
+			resumeChan := g.resumeChan
+			g.mut.Unlock()
+			if resumeChan != nil {
+				select {
+				case <-resumeChan:
+				case newOut := <-g.redirectChan:
+					redirect(newOut)
+					continue
+				case <-g.shutChan:
+					return
+				}
+			}
+
+			var tran types.Transaction
+			var open bool
+			select {
+			case tran, open = <-in:
+				if !open {
+					return
+				}
+			case newOut := <-g.redirectChan:
+				redirect(newOut)
+				continue
+			case <-g.shutChan:
+				return
+			}
+
+			if tran, open = g.track(tran); !open {
+				rejectTransaction(tran)
+				return
+			}
+
+			for sent := false; !sent; {
+				select {
+				case out <- tran:
+					sent = true
+				case newOut := <-g.redirectChan:
+					redirect(newOut)
+				case <-g.shutChan:
+					rejectTransaction(tran)
+					return
+				}
+			}
+		}
+	}()
+}
+
+// rejectTransaction responds to a transaction that was consumed but never
+// forwarded due to the gate shutting down, as otherwise the input would block
+// waiting for a response. The response is sent asynchronously as the input
+// might be closing as well.
+func rejectTransaction(tran types.Transaction) {
+	go func() {
+		tran.ResponseChan <- response.NewError(types.ErrTypeClosed)
+	}()
+}
+
+// redirect closes the current output channel of the gate, which allows the
+// layers consuming it to drain and close, and forwards all further
+// transactions to a new channel instead.
+func (g *transactionGate) redirect(out chan types.Transaction, timeout time.Duration) error {
+	select {
+	case g.redirectChan <- out:
+		return nil
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	case <-g.shutChan:
+		return types.ErrTypeClosed
+	}
+}
+
+// shutdown stops the gate from forwarding transactions, which is only
+// necessary when the layers of a stream are being closed out of order.
+func (g *transactionGate) shutdown() {
+	g.shutOnce.Do(func() {
+		close(g.shutChan)
+	})
+}
+
+// pause stops the gate from consuming further transactions. A transaction that
+// was already consumed before the pause is still forwarded.
+func (g *transactionGate) pause() {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	if g.resumeChan == nil {
+		g.resumeChan = make(chan struct{})
+	}
+}
+
+// resume allows the gate to consume transactions again.
+func (g *transactionGate) resume() {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	if g.resumeChan != nil {
+		close(g.resumeChan)
+		g.resumeChan = nil
+	}
+}
+
+// paused returns whether the gate is currently paused.
+func (g *transactionGate) paused() bool {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	return g.resumeChan != nil
+}
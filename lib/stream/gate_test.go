@@ -0,0 +1,243 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionGateInFlightLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxMsgs  int
+		maxBytes int64
+	}{
+		{name: "messages", maxMsgs: 2},
+		{name: "bytes", maxBytes: 6},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			inChan := make(chan types.Transaction)
+			g := newTransactionGate(test.maxMsgs, test.maxBytes)
+			g.consume(inChan)
+			defer g.shutdown()
+
+			resChans := make([]chan types.Response, 3)
+			for i := range resChans {
+				resChans[i] = make(chan types.Response)
+			}
+			go func() {
+				for _, resChan := range resChans {
+					inChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan)
+				}
+			}()
+
+			var trans []types.Transaction
+			for i := 0; i < 2; i++ {
+				select {
+				case tran := <-g.tranChan:
+					trans = append(trans, tran)
+				case <-time.After(time.Second):
+					t.Fatal("timed out")
+				}
+			}
+
+			msgs, size := g.inFlightStats()
+			assert.Equal(t, 2, msgs)
+			assert.Equal(t, int64(6), size)
+
+			select {
+			case <-g.tranChan:
+				t.Fatal("received transaction beyond limit")
+			case <-time.After(time.Millisecond * 50):
+			}
+
+			go func() {
+				trans[0].ResponseChan <- response.NewAck()
+			}()
+
+			var acked bool
+			for !acked || len(trans) < 3 {
+				select {
+				case tran := <-g.tranChan:
+					trans = append(trans, tran)
+				case res := <-resChans[0]:
+					assert.NoError(t, res.Error())
+					acked = true
+				case <-time.After(time.Second):
+					t.Fatal("timed out")
+				}
+			}
+			require.Len(t, trans, 3)
+
+			msgs, size = g.inFlightStats()
+			assert.Equal(t, 2, msgs)
+			assert.Equal(t, int64(6), size)
+		})
+	}
+}
+
+func TestTransactionGateOversizedBatch(t *testing.T) {
+	inChan := make(chan types.Transaction)
+	g := newTransactionGate(1, 0)
+	g.consume(inChan)
+	defer g.shutdown()
+
+	go func() {
+		inChan <- types.NewTransaction(message.New([][]byte{[]byte("foo"), []byte("bar")}), make(chan types.Response))
+	}()
+
+	select {
+	case tran := <-g.tranChan:
+		assert.Equal(t, 2, tran.Payload.Len())
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestTransactionGateUntracked(t *testing.T) {
+	inChan := make(chan types.Transaction)
+	g := newTransactionGate(0, 0)
+	g.consume(inChan)
+	defer g.shutdown()
+
+	resChan := make(chan types.Response)
+	go func() {
+		inChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan)
+	}()
+
+	select {
+	case tran := <-g.tranChan:
+		assert.Equal(t, (chan<- types.Response)(resChan), tran.ResponseChan)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	msgs, _ := g.inFlightStats()
+	assert.Equal(t, 0, msgs)
+
+	g.trackAll()
+	go func() {
+		inChan <- types.NewTransaction(message.New([][]byte{[]byte("bar")}), resChan)
+	}()
+
+	select {
+	case tran := <-g.tranChan:
+		assert.NotEqual(t, (chan<- types.Response)(resChan), tran.ResponseChan)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	msgs, _ = g.inFlightStats()
+	assert.Equal(t, 1, msgs)
+}
+
+func TestTransactionGateResponseAfterShutdown(t *testing.T) {
+	inChan := make(chan types.Transaction)
+	g := newTransactionGate(1, 0)
+	g.consume(inChan)
+
+	resChan := make(chan types.Response)
+	go func() {
+		inChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan)
+	}()
+
+	var tran types.Transaction
+	select {
+	case tran = <-g.tranChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	g.shutdown()
+	go func() {
+		tran.ResponseChan <- response.NewAck()
+	}()
+
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestTransactionGateShutdownWhileSending(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxInFlight int
+	}{
+		{name: "untracked"},
+		{name: "tracked", maxInFlight: 1},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			inChan := make(chan types.Transaction)
+			g := newTransactionGate(test.maxInFlight, 0)
+			g.consume(inChan)
+
+			resChan := make(chan types.Response)
+			select {
+			case inChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan):
+			case <-time.After(time.Second):
+				t.Fatal("timed out")
+			}
+
+			// Nothing consumes from the gate and therefore it's blocked
+			// sending the transaction.
+			g.shutdown()
+
+			select {
+			case res := <-resChan:
+				assert.Equal(t, types.ErrTypeClosed, res.Error())
+			case <-time.After(time.Second):
+				t.Fatal("timed out")
+			}
+
+			msgs, _ := g.inFlightStats()
+			assert.Equal(t, 0, msgs)
+		})
+	}
+}
+
+func TestTransactionGateShutdownWhileAcquiring(t *testing.T) {
+	inChan := make(chan types.Transaction)
+	g := newTransactionGate(1, 0)
+	g.consume(inChan)
+
+	go func() {
+		inChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), make(chan types.Response))
+	}()
+
+	select {
+	case <-g.tranChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	// The first transaction is never acknowledged and therefore the gate is
+	// blocked acquiring capacity for the second.
+	resChan := make(chan types.Response)
+	select {
+	case inChan <- types.NewTransaction(message.New([][]byte{[]byte("bar")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	g.shutdown()
+
+	select {
+	case res := <-resChan:
+		assert.Equal(t, types.ErrTypeClosed, res.Error())
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
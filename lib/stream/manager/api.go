@@ -14,6 +14,7 @@ import (
 
 	"github.com/Jeffail/benthos/v3/internal/bundle"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/interop"
 	"github.com/Jeffail/benthos/v3/lib/buffer"
 	"github.com/Jeffail/benthos/v3/lib/cache"
 	"github.com/Jeffail/benthos/v3/lib/input"
@@ -22,6 +23,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/ratelimit"
 	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/benthos/v3/lib/util/text"
 	"github.com/Jeffail/gabs/v2"
 	"github.com/gorilla/mux"
@@ -50,6 +52,21 @@ func (m *Type) registerEndpoints() {
 		"GET a structured JSON object containing metrics for the stream.",
 		m.HandleStreamStats,
 	)
+	m.manager.RegisterEndpoint(
+		"/streams/{id}/pause",
+		"POST: Stop consuming messages from the input of a stream, messages already consumed are processed as normal.",
+		m.HandleStreamPause,
+	)
+	m.manager.RegisterEndpoint(
+		"/streams/{id}/resume",
+		"POST: Resume consuming messages from the input of a paused stream.",
+		m.HandleStreamResume,
+	)
+	m.manager.RegisterEndpoint(
+		"/streams/{id}/limits",
+		"GET: Read the limits of a stream. PUT: Set the limits of a stream, restarting it if they have changed.",
+		m.HandleStreamLimits,
+	)
 	m.manager.RegisterEndpoint(
 		"/resources/{type}/{id}",
 		"POST: Create or replace a given resource configuration of a specified type. Types supported are `cache`, `input`, `output`, `processor` and `rate_limit`.",
@@ -111,6 +128,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 
 	type confInfo struct {
 		Active    bool    `json:"active"`
+		Paused    bool    `json:"paused"`
 		Uptime    float64 `json:"uptime"`
 		UptimeStr string  `json:"uptime_str"`
 	}
@@ -120,6 +138,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	for id, strInfo := range m.streams {
 		infos[id] = confInfo{
 			Active:    strInfo.IsRunning(),
+			Paused:    strInfo.IsPaused(),
 			Uptime:    strInfo.Uptime().Seconds(),
 			UptimeStr: strInfo.Uptime().String(),
 		}
@@ -235,24 +254,32 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	wg.Wait()
 
 	errs := []string{}
-	for _, err := range errDelete {
-		if err != nil {
-			errs = append(errs, fmt.Sprintf("failed to delete stream: %v", err))
+	persistFailed := false
+	addErr := func(verb string, err error) {
+		if err == nil {
+			return
+		}
+		if errors.Is(err, ErrStateNotPersisted) {
+			persistFailed = true
 		}
+		errs = append(errs, fmt.Sprintf("failed to %v stream: %v", verb, err))
+	}
+	for _, err := range errDelete {
+		addErr("delete", err)
 	}
 	for _, err := range errUpdate {
-		if err != nil {
-			errs = append(errs, fmt.Sprintf("failed to update stream: %v", err))
-		}
+		addErr("update", err)
 	}
 	for _, err := range errCreate {
-		if err != nil {
-			errs = append(errs, fmt.Sprintf("failed to create stream: %v", err))
-		}
+		addErr("create", err)
 	}
 
 	if len(errs) > 0 {
-		requestErr = errors.New(strings.Join(errs, "\n"))
+		if persistFailed {
+			serverErr = errors.New(strings.Join(errs, "\n"))
+		} else {
+			requestErr = errors.New(strings.Join(errs, "\n"))
+		}
 	}
 }
 
@@ -287,7 +314,9 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		}
 		confBytes = text.ReplaceEnvVariables(confBytes)
 
-		if r.URL.Query().Get("chilled") != "true" {
+		// Dry runs are always linted so that lints can be reported even when
+		// they are ignored.
+		if r.URL.Query().Get("chilled") != "true" || r.URL.Query().Get("dry_run") == "true" {
 			var node yaml.Node
 			if err = yaml.Unmarshal(confBytes, &node); err != nil {
 				return
@@ -341,6 +370,14 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		deadline = time.Now().Add(m.apiTimeout)
 	}
 
+	update := func(conf stream.Config) error {
+		if r.URL.Query().Get("partial") == "true" {
+			_, err := m.UpdatePartial(id, conf, time.Until(deadline))
+			return err
+		}
+		return m.Update(id, conf, time.Until(deadline))
+	}
+
 	var conf stream.Config
 	var lints []string
 	switch r.Method {
@@ -367,11 +404,13 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 			var bodyBytes []byte
 			if bodyBytes, serverErr = json.Marshal(struct {
 				Active    bool        `json:"active"`
+				Paused    bool        `json:"paused"`
 				Uptime    float64     `json:"uptime"`
 				UptimeStr string      `json:"uptime_str"`
 				Config    interface{} `json:"config"`
 			}{
 				Active:    info.IsRunning(),
+				Paused:    info.IsPaused(),
 				Uptime:    info.Uptime().Seconds(),
 				UptimeStr: info.Uptime().String(),
 				Config:    sanit,
@@ -386,7 +425,7 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		if conf, lints, requestErr = readConfig(); requestErr != nil {
 			return
 		}
-		if len(lints) > 0 {
+		if len(lints) > 0 && r.URL.Query().Get("chilled") != "true" {
 			errBytes, _ := json.Marshal(struct {
 				LintErrs []string `json:"lint_errors"`
 			}{
@@ -396,7 +435,16 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 			w.Write(errBytes)
 			return
 		}
-		serverErr = m.Update(id, conf, time.Until(deadline))
+		if r.URL.Query().Get("dry_run") != "true" {
+			serverErr = update(conf)
+			break
+		}
+		var bodyBytes []byte
+		if bodyBytes, requestErr, serverErr = m.dryRunUpdate(id, conf, lints); requestErr == nil && serverErr == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(bodyBytes)
+			return
+		}
 	case "DELETE":
 		serverErr = m.Delete(id, time.Until(deadline))
 	case "PATCH":
@@ -405,7 +453,7 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 			if conf, requestErr = patchConfig(info.Config()); requestErr != nil {
 				return
 			}
-			serverErr = m.Update(id, conf, time.Until(deadline))
+			serverErr = update(conf)
 		}
 	default:
 		requestErr = fmt.Errorf("verb not supported: %v", r.Method)
@@ -421,6 +469,94 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// dryRunUpdate validates a stream config without running it, and returns a
+// JSON report of the changes that updating a stream to the config would make
+// along with any lint errors of the config.
+//
+// The processors, buffer and output of the config are constructed with a
+// manager that keeps them apart from running components, and are then closed
+// without being started. The input is not constructed as inputs attempt to
+// connect immediately, and therefore it is only linted. A dry run does not
+// check whether any component is able to connect, and when the manager does
+// not support dry runs only the processors are constructed.
+func (m *Type) dryRunUpdate(id string, conf stream.Config, lints []string) (report []byte, requestErr, serverErr error) {
+	var info *StreamStatus
+	if info, serverErr = m.Read(id); serverErr != nil {
+		return
+	}
+
+	dMgr, supportsDryRun := m.manager, false
+	if dm, ok := m.manager.(interface {
+		ForDryRun() types.Manager
+	}); ok {
+		dMgr, supportsDryRun = dm.ForDryRun(), true
+	}
+
+	sMgr, sLog, sStats := interop.LabelStream(id, dMgr, m.logger, m.stats)
+	pMgr, pLog, pStats := interop.LabelChild("pipeline", sMgr, sLog, sStats)
+	for i, pConf := range conf.Pipeline.Processors {
+		iMgr, iLog, iStats := interop.LabelChild(fmt.Sprintf("processor_%v", i), pMgr, pLog, pStats)
+		proc, err := processor.New(pConf, iMgr, iLog, iStats)
+		if err != nil {
+			requestErr = fmt.Errorf("failed to create processor %v: %w", i, err)
+			return
+		}
+		proc.CloseAsync()
+	}
+
+	if supportsDryRun {
+		if conf.Buffer.Type != buffer.TypeNone {
+			bMgr, bLog, bStats := interop.LabelChild("buffer", sMgr, sLog, sStats)
+			buf, err := buffer.New(conf.Buffer, bMgr, bLog, bStats)
+			if err != nil {
+				requestErr = fmt.Errorf("failed to create buffer: %w", err)
+				return
+			}
+			buf.CloseAsync()
+		}
+
+		oMgr, oLog, oStats := interop.LabelChild("output", sMgr, sLog, sStats)
+		out, err := output.New(conf.Output, oMgr, oLog, oStats)
+		if err != nil {
+			requestErr = fmt.Errorf("failed to create output: %w", err)
+			return
+		}
+		out.CloseAsync()
+	}
+
+	var diff []ConfigDiff
+	if diff, serverErr = DiffConfigs(info.Config(), conf); serverErr != nil {
+		return
+	}
+
+	changed := []string{}
+	for _, d := range diff {
+		component := strings.SplitN(d.Path, ".", 2)[0]
+		if len(changed) == 0 || changed[len(changed)-1] != component {
+			changed = append(changed, component)
+		}
+	}
+
+	if lints == nil {
+		lints = []string{}
+	}
+
+	report, serverErr = json.Marshal(struct {
+		DryRun   bool         `json:"dry_run"`
+		Changed  []string     `json:"changed"`
+		Partial  bool         `json:"partial_update"`
+		Diff     []ConfigDiff `json:"diff"`
+		LintErrs []string     `json:"lint_errors"`
+	}{
+		DryRun:   true,
+		Changed:  changed,
+		Partial:  info.strm.CanUpdatePartial(conf),
+		Diff:     diff,
+		LintErrs: lints,
+	})
+	return
+}
+
 // HandleResourceCRUD is an http.HandleFunc for performing CRUD operations on
 // resource components.
 func (m *Type) HandleResourceCRUD(w http.ResponseWriter, r *http.Request) {
@@ -450,60 +586,11 @@ func (m *Type) HandleResourceCRUD(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newMgr, ok := m.manager.(bundle.NewManagement)
-	if !ok {
-		serverErr = errors.New("server does not support resource CRUD operations")
-		return
-	}
-
 	ctx, done := context.WithDeadline(r.Context(), time.Now().Add(m.apiTimeout))
 	defer done()
 
-	var storeFn func(*yaml.Node)
-
 	docType := docs.Type(mux.Vars(r)["type"])
-	switch docType {
-	case docs.TypeCache:
-		storeFn = func(n *yaml.Node) {
-			cacheConf := cache.NewConfig()
-			if requestErr = n.Decode(&cacheConf); requestErr != nil {
-				return
-			}
-			serverErr = newMgr.StoreCache(ctx, id, cacheConf)
-		}
-	case docs.TypeInput:
-		storeFn = func(n *yaml.Node) {
-			inputConf := input.NewConfig()
-			if requestErr = n.Decode(&inputConf); requestErr != nil {
-				return
-			}
-			serverErr = newMgr.StoreInput(ctx, id, inputConf)
-		}
-	case docs.TypeOutput:
-		storeFn = func(n *yaml.Node) {
-			outputConf := output.NewConfig()
-			if requestErr = n.Decode(&outputConf); requestErr != nil {
-				return
-			}
-			serverErr = newMgr.StoreOutput(ctx, id, outputConf)
-		}
-	case docs.TypeProcessor:
-		storeFn = func(n *yaml.Node) {
-			procConf := processor.NewConfig()
-			if requestErr = n.Decode(&procConf); requestErr != nil {
-				return
-			}
-			serverErr = newMgr.StoreProcessor(ctx, id, procConf)
-		}
-	case docs.TypeRateLimit:
-		storeFn = func(n *yaml.Node) {
-			rlConf := ratelimit.NewConfig()
-			if requestErr = n.Decode(&rlConf); requestErr != nil {
-				return
-			}
-			serverErr = newMgr.StoreRateLimit(ctx, id, rlConf)
-		}
-	default:
+	if !isResourceType(docType) {
 		http.Error(w, "Var `type` must be set to one of `cache`, `input`, `output`, `processor` or `rate_limit`", http.StatusBadRequest)
 		return
 	}
@@ -541,7 +628,79 @@ func (m *Type) HandleResourceCRUD(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	storeFn(confNode)
+	if requestErr, serverErr = m.storeResource(ctx, string(docType), id, confNode); requestErr != nil || serverErr != nil {
+		return
+	}
+	serverErr = m.persistState(ctx)
+}
+
+func isResourceType(t docs.Type) bool {
+	switch t {
+	case docs.TypeCache, docs.TypeInput, docs.TypeOutput, docs.TypeProcessor, docs.TypeRateLimit:
+		return true
+	}
+	return false
+}
+
+// storeResource parses a resource config of a given type and stores it within
+// the resource manager, replacing any existing resource of the same name. When
+// successful the config is recorded so that it can be persisted as state.
+func (m *Type) storeResource(ctx context.Context, typeStr, name string, n *yaml.Node) (decodeErr, storeErr error) {
+	newMgr, ok := m.manager.(bundle.NewManagement)
+	if !ok {
+		storeErr = errors.New("server does not support resource CRUD operations")
+		return
+	}
+
+	switch docs.Type(typeStr) {
+	case docs.TypeCache:
+		cacheConf := cache.NewConfig()
+		if decodeErr = n.Decode(&cacheConf); decodeErr != nil {
+			return
+		}
+		storeErr = newMgr.StoreCache(ctx, name, cacheConf)
+	case docs.TypeInput:
+		inputConf := input.NewConfig()
+		if decodeErr = n.Decode(&inputConf); decodeErr != nil {
+			return
+		}
+		storeErr = newMgr.StoreInput(ctx, name, inputConf)
+	case docs.TypeOutput:
+		outputConf := output.NewConfig()
+		if decodeErr = n.Decode(&outputConf); decodeErr != nil {
+			return
+		}
+		storeErr = newMgr.StoreOutput(ctx, name, outputConf)
+	case docs.TypeProcessor:
+		procConf := processor.NewConfig()
+		if decodeErr = n.Decode(&procConf); decodeErr != nil {
+			return
+		}
+		storeErr = newMgr.StoreProcessor(ctx, name, procConf)
+	case docs.TypeRateLimit:
+		rlConf := ratelimit.NewConfig()
+		if decodeErr = n.Decode(&rlConf); decodeErr != nil {
+			return
+		}
+		storeErr = newMgr.StoreRateLimit(ctx, name, rlConf)
+	default:
+		decodeErr = fmt.Errorf("resource type not recognised: %v", typeStr)
+	}
+	if decodeErr != nil || storeErr != nil {
+		return
+	}
+
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+
+	m.stateLock.Lock()
+	if m.resourceNodes[typeStr] == nil {
+		m.resourceNodes[typeStr] = map[string]yaml.Node{}
+	}
+	m.resourceNodes[typeStr][name] = *n
+	m.stateLock.Unlock()
+	return
 }
 
 // HandleStreamStats is an http.HandleFunc for obtaining metrics for a stream.
@@ -586,6 +745,14 @@ func (m *Type) HandleStreamStats(w http.ResponseWriter, r *http.Request) {
 				obj.SetP(time.Duration(v).String(), k+"_readable")
 			}
 			obj.SetP(fmt.Sprintf("%v", uptime), "uptime")
+
+			limits := info.Limits()
+			obj.SetP(limits.MaxInFlight, "limits.max_in_flight")
+			obj.SetP(limits.MaxInFlightBytes, "limits.max_in_flight_bytes")
+			obj.SetP(limits.MaxProcessorThreads, "limits.max_processor_threads")
+			inFlight, inFlightBytes := info.InFlight()
+			obj.SetP(inFlight, "limits.in_flight")
+			obj.SetP(inFlightBytes, "limits.in_flight_bytes")
 			w.Header().Set("Content-Type", "application/json")
 			w.Write(obj.Bytes())
 		}
@@ -598,6 +765,117 @@ func (m *Type) HandleStreamStats(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// HandleStreamPause is an http.HandleFunc for pausing the consumption of
+// messages of a stream.
+func (m *Type) HandleStreamPause(w http.ResponseWriter, r *http.Request) {
+	m.handleStreamPauseState(w, r, m.Pause)
+}
+
+// HandleStreamResume is an http.HandleFunc for resuming the consumption of
+// messages of a paused stream.
+func (m *Type) HandleStreamResume(w http.ResponseWriter, r *http.Request) {
+	m.handleStreamPauseState(w, r, m.Resume)
+}
+
+func (m *Type) handleStreamPauseState(w http.ResponseWriter, r *http.Request, fn func(id string) error) {
+	var serverErr, requestErr error
+	defer func() {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		if serverErr != nil {
+			m.logger.Errorf("Stream pause Error: %v\n", serverErr)
+			http.Error(w, fmt.Sprintf("Error: %v", serverErr), http.StatusBadGateway)
+		}
+		if requestErr != nil {
+			m.logger.Debugf("Stream request pause Error: %v\n", requestErr)
+			http.Error(w, fmt.Sprintf("Error: %v", requestErr), http.StatusBadRequest)
+		}
+	}()
+
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		http.Error(w, "Var `id` must be set", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case "POST":
+		serverErr = fn(id)
+	default:
+		requestErr = fmt.Errorf("verb not supported: %v", r.Method)
+	}
+	if serverErr == ErrStreamDoesNotExist {
+		serverErr = nil
+		http.Error(w, "Stream not found", http.StatusNotFound)
+	}
+}
+
+// HandleStreamLimits is an http.HandleFunc for reading and setting the limits
+// of a stream.
+func (m *Type) HandleStreamLimits(w http.ResponseWriter, r *http.Request) {
+	var serverErr, requestErr error
+	defer func() {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		if serverErr != nil {
+			m.logger.Errorf("Stream limits Error: %v\n", serverErr)
+			http.Error(w, fmt.Sprintf("Error: %v", serverErr), http.StatusBadGateway)
+		}
+		if requestErr != nil {
+			m.logger.Debugf("Stream request limits Error: %v\n", requestErr)
+			http.Error(w, fmt.Sprintf("Error: %v", requestErr), http.StatusBadRequest)
+		}
+	}()
+
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		http.Error(w, "Var `id` must be set", http.StatusBadRequest)
+		return
+	}
+
+	var info *StreamStatus
+	if info, serverErr = m.Read(id); serverErr != nil {
+		if serverErr == ErrStreamDoesNotExist {
+			serverErr = nil
+			http.Error(w, "Stream not found", http.StatusNotFound)
+		}
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		var resBytes []byte
+		if resBytes, serverErr = json.Marshal(info.Limits()); serverErr != nil {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(resBytes)
+	case "PUT":
+		var limits StreamLimits
+		var limitsBytes []byte
+		if limitsBytes, requestErr = io.ReadAll(r.Body); requestErr != nil {
+			return
+		}
+		if requestErr = json.Unmarshal(limitsBytes, &limits); requestErr != nil {
+			return
+		}
+
+		deadline, hasDeadline := r.Context().Deadline()
+		if !hasDeadline {
+			deadline = time.Now().Add(m.apiTimeout)
+		}
+		serverErr = m.SetStreamLimits(id, limits, time.Until(deadline))
+	default:
+		requestErr = fmt.Errorf("verb not supported: %v", r.Method)
+	}
+	if serverErr == ErrStreamDoesNotExist {
+		serverErr = nil
+		http.Error(w, "Stream not found", http.StatusNotFound)
+	}
+}
+
 // HandleStreamReady is an http.HandleFunc for providing a ready check across
 // all streams.
 func (m *Type) HandleStreamReady(w http.ResponseWriter, r *http.Request) {
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -20,6 +21,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/stream"
 	"github.com/Jeffail/benthos/v3/lib/stream/manager"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -37,6 +40,9 @@ func router(m *manager.Type) *mux.Router {
 	router.HandleFunc("/streams", m.HandleStreamsCRUD)
 	router.HandleFunc("/streams/{id}", m.HandleStreamCRUD)
 	router.HandleFunc("/streams/{id}/stats", m.HandleStreamStats)
+	router.HandleFunc("/streams/{id}/pause", m.HandleStreamPause)
+	router.HandleFunc("/streams/{id}/resume", m.HandleStreamResume)
+	router.HandleFunc("/streams/{id}/limits", m.HandleStreamLimits)
 	router.HandleFunc("/resources/{type}/{id}", m.HandleResourceCRUD)
 	return router
 }
@@ -81,6 +87,7 @@ func genYAMLRequest(verb, url string, payload interface{}) *http.Request {
 
 type listItemBody struct {
 	Active    bool    `json:"active"`
+	Paused    bool    `json:"paused"`
 	Uptime    float64 `json:"uptime"`
 	UptimeStr string  `json:"uptime_str"`
 }
@@ -97,6 +104,7 @@ func parseListBody(data *bytes.Buffer) listBody {
 
 type getBody struct {
 	Active    bool          `json:"active"`
+	Paused    bool          `json:"paused"`
 	Uptime    float64       `json:"uptime"`
 	UptimeStr string        `json:"uptime_str"`
 	Config    stream.Config `json:"config"`
@@ -801,6 +809,100 @@ func TestTypeAPIGetStats(t *testing.T) {
 	assert.Equal(t, 1.0, stats.S("input", "running").Data(), response.Body.String())
 }
 
+func TestTypeAPIPauseResume(t *testing.T) {
+	mgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), types.DudMgr{}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	smgr := manager.New(
+		manager.OptSetLogger(log.Noop()),
+		manager.OptSetStats(metrics.Noop()),
+		manager.OptSetManager(mgr),
+		manager.OptSetAPITimeout(time.Second*5),
+	)
+	t.Cleanup(func() {
+		assert.NoError(t, smgr.Stop(time.Second*5))
+	})
+
+	r := router(smgr)
+
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Input.Generate.Interval = "10ms"
+	conf.Output.Type = "drop"
+	require.NoError(t, smgr.Create("foo", conf))
+
+	received := func() int64 {
+		info, err := smgr.Read("foo")
+		require.NoError(t, err)
+		return info.Metrics().GetCounters()["foo.input.received"]
+	}
+	assert.Eventually(t, func() bool {
+		return received() > 0
+	}, time.Second*5, time.Millisecond*10)
+
+	request := genRequest("POST", "/streams/not_exist/pause", nil)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	request = genRequest("GET", "/streams/foo/pause", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	request = genRequest("POST", "/streams/foo/pause", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	request = genRequest("GET", "/streams/foo", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	info := parseGetBody(t, response.Body)
+	assert.True(t, info.Active)
+	assert.True(t, info.Paused)
+
+	// Allow for a message consumed prior to pausing.
+	<-time.After(time.Millisecond * 100)
+	pausedCount := received()
+	<-time.After(time.Millisecond * 200)
+	assert.Equal(t, pausedCount, received())
+
+	// Updates retain the paused state. The metrics of the updated stream begin
+	// from zero and the input is only able to read the single message that it
+	// blocks on.
+	conf.Input.Generate.Mapping = `root = "hello updated world"`
+	require.NoError(t, smgr.Update("foo", conf, time.Second*5))
+
+	request = genRequest("GET", "/streams", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.True(t, parseListBody(response.Body)["foo"].Paused)
+
+	<-time.After(time.Millisecond * 100)
+	updatedCount := received()
+	assert.LessOrEqual(t, updatedCount, int64(1))
+	<-time.After(time.Millisecond * 200)
+	assert.Equal(t, updatedCount, received())
+
+	request = genRequest("POST", "/streams/foo/resume", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	assert.Eventually(t, func() bool {
+		return received() > pausedCount
+	}, time.Second*5, time.Millisecond*10)
+
+	request = genRequest("GET", "/streams/foo", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.False(t, parseGetBody(t, response.Body).Paused)
+}
+
 func TestTypeAPISetResources(t *testing.T) {
 	bmgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), types.DudMgr{}, log.Noop(), metrics.Noop())
 	require.NoError(t, err)
@@ -897,3 +999,170 @@ func TestTypeAPISetResources(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, `{"id":"second","content":"hello world 2"}`, string(file2Bytes))
 }
+
+func TestTypeAPIUpdateDryRunAndPartial(t *testing.T) {
+	mgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), types.DudMgr{}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	smgr := manager.New(
+		manager.OptSetLogger(log.Noop()),
+		manager.OptSetStats(metrics.Noop()),
+		manager.OptSetManager(mgr),
+		manager.OptSetAPITimeout(time.Second*5),
+	)
+	t.Cleanup(func() {
+		assert.NoError(t, smgr.Stop(time.Second*5))
+	})
+
+	r := router(smgr)
+
+	procConf := func(mapping string) []processor.Config {
+		pConf := processor.NewConfig()
+		pConf.Type = processor.TypeBloblang
+		pConf.Bloblang = processor.BloblangConfig(mapping)
+		return []processor.Config{pConf}
+	}
+
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Input.Generate.Interval = "10ms"
+	conf.Pipeline.Processors = procConf(`root = "first"`)
+	conf.Output.Type = output.TypeInproc
+	conf.Output.Inproc = "foo_out"
+	require.NoError(t, smgr.Create("foo", conf))
+
+	// Continuously drain the output, which is recreated by partial updates,
+	// and track the most recent message.
+	var latest atomic.Value
+	latest.Store("")
+	doneChan := make(chan struct{})
+	t.Cleanup(func() {
+		close(doneChan)
+	})
+	go func() {
+		for {
+			outChan, err := mgr.GetPipe("foo_out")
+			if err != nil {
+				select {
+				case <-doneChan:
+					return
+				case <-time.After(time.Millisecond * 10):
+				}
+				continue
+			}
+			for tran := range outChan {
+				latest.Store(string(tran.Payload.Get(0).Get()))
+				tran.ResponseChan <- response.NewAck()
+			}
+		}
+	}()
+	assert.Eventually(t, func() bool {
+		return latest.Load() == "first"
+	}, time.Second*5, time.Millisecond*10)
+
+	infoBefore, err := smgr.Read("foo")
+	require.NoError(t, err)
+
+	newConf := conf
+	newConf.Pipeline.Processors = procConf(`root = "second"`)
+
+	request := genRequest("PUT", "/streams/not_exist?dry_run=true&chilled=true", newConf)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, request)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	request = genRequest("PUT", "/streams/foo?dry_run=true&chilled=true", newConf)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, request)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var report struct {
+		DryRun   bool                 `json:"dry_run"`
+		Changed  []string             `json:"changed"`
+		Partial  bool                 `json:"partial_update"`
+		Diff     []manager.ConfigDiff `json:"diff"`
+		LintErrs []string             `json:"lint_errors"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+	assert.True(t, report.DryRun)
+	assert.True(t, report.Partial)
+	assert.Equal(t, []string{"pipeline"}, report.Changed)
+	assert.Equal(t, []manager.ConfigDiff{
+		{
+			Path:      "pipeline.processors.0.bloblang",
+			Operation: manager.DiffModified,
+			Old:       `root = "first"`,
+			New:       `root = "second"`,
+		},
+	}, report.Diff)
+
+	sanitised := func(conf stream.Config) interface{} {
+		t.Helper()
+		sanit, err := conf.Sanitised()
+		require.NoError(t, err)
+		return sanit
+	}
+
+	// A dry run does not modify the stream.
+	info, err := smgr.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, sanitised(conf), sanitised(info.Config()))
+
+	badConf := newConf
+	badConf.Pipeline.Processors = procConf(`root = this.nope(`)
+	request = genRequest("PUT", "/streams/foo?dry_run=true&chilled=true", badConf)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, request)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	badOutConf := newConf
+	badOutConf.Output = output.NewConfig()
+	badOutConf.Output.Type = output.TypeResource
+	badOutConf.Output.Resource = "does_not_exist"
+	request = genRequest("PUT", "/streams/foo?dry_run=true&chilled=true", badOutConf)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, request)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "failed to create output")
+
+	// Lint errors are reported by chilled dry runs rather than rejected.
+	lintConf := sanitised(newConf).(map[string]interface{})
+	lintConf["nope"] = "not a field"
+	request = genRequest("PUT", "/streams/foo?dry_run=true&chilled=true", lintConf)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, request)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+	assert.Len(t, report.LintErrs, 1)
+
+	request = genRequest("PUT", "/streams/foo?dry_run=true", lintConf)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, request)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	inputConf := newConf
+	inputConf.Input.Generate.Mapping = `root = "hello other world"`
+	request = genRequest("PUT", "/streams/foo?dry_run=true&chilled=true", inputConf)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, request)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+	assert.False(t, report.Partial)
+	assert.Equal(t, []string{"input", "pipeline"}, report.Changed)
+
+	request = genRequest("PUT", "/streams/foo?partial=true&chilled=true", newConf)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, request)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	// The stream was updated in place rather than being recreated.
+	info, err = smgr.Read("foo")
+	require.NoError(t, err)
+	assert.True(t, info == infoBefore)
+	assert.Equal(t, sanitised(newConf), sanitised(info.Config()))
+
+	assert.Eventually(t, func() bool {
+		return latest.Load() == "second"
+	}, time.Second*5, time.Millisecond*10)
+}
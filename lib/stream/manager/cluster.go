@@ -0,0 +1,513 @@
+package manager
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// ClusterState is the record shared by all nodes of a cluster, describing the
+// nodes that are alive and which node owns each stream.
+type ClusterState struct {
+	// Nodes is a map of live node ids to the time (in unix nanoseconds) at
+	// which they are considered dead unless they heartbeat again.
+	Nodes map[string]int64 `json:"nodes"`
+
+	// Owners is a map of stream ids to the id of the node that owns them. A
+	// stream is only ever run by its owner.
+	Owners map[string]string `json:"owners"`
+}
+
+// ClusterDiscovery is a pluggable backend through which the nodes of a cluster
+// discover each other and coordinate the ownership of streams.
+type ClusterDiscovery interface {
+	// Update atomically reads the current cluster state, calls fn with it, and
+	// writes the modified state back unless fn returns an error. No other node
+	// may modify the state between the read and the write.
+	Update(ctx context.Context, fn func(state *ClusterState) error) error
+}
+
+//------------------------------------------------------------------------------
+
+// The period after which a lock held on the cluster state is assumed to have
+// been abandoned by a node that crashed whilst holding it.
+const clusterLockTTL = 10 * time.Second
+
+type cacheClusterDiscovery struct {
+	mgr      types.Manager
+	cache    string
+	lockKey  string
+	stateKey string
+	token    string
+}
+
+// NewCacheClusterDiscovery returns a ClusterDiscovery that stores the cluster
+// state as a key of a cache resource. Updates are guarded by a lock key that is
+// obtained with the atomic add operation of the cache and released with an
+// atomic compare-and-delete, and therefore the cache must be shared by all
+// nodes and implement types.CacheWithCompareAndDelete (e.g. redis). An error is
+// returned if the cache does not exist or lacks these operations.
+func NewCacheClusterDiscovery(mgr types.Manager, cache, keyPrefix string) (ClusterDiscovery, error) {
+	var supported bool
+	if err := interop.AccessCache(context.Background(), mgr, cache, func(c types.Cache) {
+		_, supported = c.(types.CacheWithCompareAndDelete)
+	}); err != nil {
+		return nil, err
+	}
+	if !supported {
+		return nil, fmt.Errorf("cache '%v' does not support the atomic compare-and-delete operation required for clustering", cache)
+	}
+
+	tokenBytes := make([]byte, 8)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, err
+	}
+	return &cacheClusterDiscovery{
+		mgr:      mgr,
+		cache:    cache,
+		lockKey:  keyPrefix + "_lock",
+		stateKey: keyPrefix + "_state",
+		token:    hex.EncodeToString(tokenBytes),
+	}, nil
+}
+
+// lock attempts to obtain the lock key, where the value of the key is the time
+// at which the lock expires, so that abandoned locks can be broken, followed by
+// a token unique to this discovery.
+func (c *cacheClusterDiscovery) lock(ctx context.Context, cache types.CacheWithCompareAndDelete) ([]byte, error) {
+	for {
+		value := []byte(strconv.FormatInt(time.Now().Add(clusterLockTTL).UnixNano(), 10) + ":" + c.token)
+		err := cache.Add(c.lockKey, value)
+		if err == nil {
+			return value, nil
+		}
+		if err != types.ErrKeyAlreadyExists {
+			return nil, err
+		}
+
+		// Breaking an expired lock is only successful when it still holds the
+		// value we read, otherwise another node has already broken it.
+		if held, gerr := cache.Get(c.lockKey); gerr == nil && lockExpired(held) {
+			if derr := cache.CompareAndDelete(c.lockKey, held); derr == nil {
+				continue
+			}
+		}
+
+		select {
+		case <-time.After(time.Millisecond * 50):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func lockExpired(value []byte) bool {
+	expiryStr := string(value)
+	if i := strings.IndexByte(expiryStr, ':'); i >= 0 {
+		expiryStr = expiryStr[:i]
+	}
+	expiry, err := strconv.ParseInt(expiryStr, 10, 64)
+	return err == nil && time.Now().UnixNano() > expiry
+}
+
+func (c *cacheClusterDiscovery) unlock(cache types.CacheWithCompareAndDelete, value []byte) {
+	// The lock is only removed if it still holds our value, as it may have
+	// expired and since been obtained by another node.
+	_ = cache.CompareAndDelete(c.lockKey, value)
+}
+
+func (c *cacheClusterDiscovery) Update(ctx context.Context, fn func(state *ClusterState) error) (err error) {
+	if cerr := interop.AccessCache(ctx, c.mgr, c.cache, func(ccache types.Cache) {
+		cache, ok := ccache.(types.CacheWithCompareAndDelete)
+		if !ok {
+			err = fmt.Errorf("cache '%v' does not support the atomic compare-and-delete operation required for clustering", c.cache)
+			return
+		}
+
+		var lockValue []byte
+		if lockValue, err = c.lock(ctx, cache); err != nil {
+			err = fmt.Errorf("failed to obtain cluster lock: %w", err)
+			return
+		}
+		defer c.unlock(cache, lockValue)
+
+		state := ClusterState{}
+		var stateBytes []byte
+		if stateBytes, err = cache.Get(c.stateKey); err == nil {
+			if err = json.Unmarshal(stateBytes, &state); err != nil {
+				err = fmt.Errorf("failed to parse cluster state: %w", err)
+				return
+			}
+		} else if err != types.ErrKeyNotFound {
+			return
+		}
+		if state.Nodes == nil {
+			state.Nodes = map[string]int64{}
+		}
+		if state.Owners == nil {
+			state.Owners = map[string]string{}
+		}
+
+		if err = fn(&state); err != nil {
+			return
+		}
+		if stateBytes, err = json.Marshal(state); err != nil {
+			return
+		}
+		err = cache.Set(c.stateKey, stateBytes)
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// assignedNode returns the node that a stream should be assigned to from a set
+// of live nodes using rendezvous hashing, which means that when a node leaves
+// the cluster only the streams assigned to it are reassigned.
+func assignedNode(streamID string, nodes []string) string {
+	var chosen string
+	var chosenWeight uint64
+	for _, node := range nodes {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(node))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(streamID))
+		if weight := mixHash(h.Sum64()); chosen == "" || weight > chosenWeight || (weight == chosenWeight && node < chosen) {
+			chosen, chosenWeight = node, weight
+		}
+	}
+	return chosen
+}
+
+// mixHash is the finaliser of MurmurHash3, which is required as FNV hashes of
+// node ids that only differ slightly are otherwise ordered the same way for
+// most stream ids.
+func mixHash(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+type clusterNode struct {
+	m         *Type
+	discovery ClusterDiscovery
+	nodeID    string
+	interval  time.Duration
+	ttl       time.Duration
+
+	// The configs of all streams of the cluster, streams that are not present
+	// here (created via the API, etc) are never modified.
+	confs map[string]stream.Config
+
+	mut      sync.Mutex
+	nodes    []string
+	running  map[string]struct{}
+	lastSync time.Time
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// StartCluster joins a cluster of stream managers that coordinate through a
+// discovery backend, where each stream of the provided configs is assigned to
+// and run by exactly one live node of the cluster. All nodes are expected to
+// be provided the same set of stream configs.
+//
+// Every interval the node heartbeats to the backend and reconciles the streams
+// that it runs with the live nodes of the cluster. A node is considered dead
+// when it fails to heartbeat for three intervals, at which point its streams
+// are reassigned to the remaining nodes. A node that is unable to reach the
+// backend for that period stops its streams as they may have been reassigned.
+//
+// Streams that are not within the provided configs, such as those created via
+// the HTTP API, are run by the node they were created on and left untouched.
+func (m *Type) StartCluster(discovery ClusterDiscovery, nodeID string, interval time.Duration, confs map[string]stream.Config) error {
+	if nodeID == "" {
+		return errors.New("a cluster node id must be provided")
+	}
+	if interval <= 0 {
+		return errors.New("the cluster heartbeat interval must be greater than zero")
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.closed {
+		return types.ErrTypeClosed
+	}
+	if m.cluster != nil {
+		return errors.New("stream manager has already joined a cluster")
+	}
+
+	c := &clusterNode{
+		m:          m,
+		discovery:  discovery,
+		nodeID:     nodeID,
+		interval:   interval,
+		ttl:        interval * 3,
+		confs:      confs,
+		running:    map[string]struct{}{},
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+	m.cluster = c
+
+	m.manager.RegisterEndpoint(
+		"/cluster",
+		"GET: Show the live nodes of the cluster along with the streams run by this node.",
+		c.handleClusterInfo,
+	)
+
+	go c.loop()
+	return nil
+}
+
+func (c *clusterNode) loop() {
+	defer close(c.closedChan)
+	for {
+		c.sync()
+		select {
+		case <-time.After(c.interval):
+		case <-c.closeChan:
+			return
+		}
+	}
+}
+
+func (c *clusterNode) ctx() (context.Context, func()) {
+	ctx, done := context.WithTimeout(context.Background(), c.interval)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-c.closeChan:
+			done()
+		}
+	}()
+	return ctx, done
+}
+
+// sync heartbeats to the backend, claims the unowned streams assigned to this
+// node, and then starts the streams this node owns and stops the rest.
+func (c *clusterNode) sync() {
+	ctx, done := c.ctx()
+	defer done()
+
+	var nodes []string
+	owned := map[string]struct{}{}
+	err := c.discovery.Update(ctx, func(state *ClusterState) error {
+		now := time.Now()
+		state.Nodes[c.nodeID] = now.Add(c.ttl).UnixNano()
+
+		nodes = nodes[:0]
+		for id, expiry := range state.Nodes {
+			if now.UnixNano() > expiry {
+				delete(state.Nodes, id)
+				continue
+			}
+			nodes = append(nodes, id)
+		}
+		sort.Strings(nodes)
+
+		for streamID, owner := range state.Owners {
+			if _, alive := state.Nodes[owner]; !alive {
+				delete(state.Owners, streamID)
+			}
+		}
+		for streamID := range c.confs {
+			if assignedNode(streamID, nodes) != c.nodeID {
+				continue
+			}
+			if _, exists := state.Owners[streamID]; !exists {
+				state.Owners[streamID] = c.nodeID
+			}
+		}
+		for streamID, owner := range state.Owners {
+			if owner == c.nodeID {
+				owned[streamID] = struct{}{}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.m.logger.Errorf("Failed to sync with cluster: %v\n", err)
+
+		c.mut.Lock()
+		expired := time.Since(c.lastSync) > c.ttl
+		c.mut.Unlock()
+		if expired {
+			c.m.logger.Warnln("Stopping cluster streams as this node may no longer be considered alive")
+			c.stopStreams(c.runningStreams())
+		}
+		return
+	}
+
+	c.mut.Lock()
+	c.nodes = nodes
+	c.lastSync = time.Now()
+	c.mut.Unlock()
+
+	var toRelease []string
+	for _, id := range c.runningStreams() {
+		if _, isOwned := owned[id]; !isOwned {
+			c.stopStreams([]string{id})
+		}
+	}
+	for id := range owned {
+		conf, exists := c.confs[id]
+		if !exists || assignedNode(id, nodes) != c.nodeID {
+			// The stream has been reassigned to another node, which is only
+			// able to claim it once we've stopped running it.
+			if c.stopStreams([]string{id}) {
+				toRelease = append(toRelease, id)
+			}
+			continue
+		}
+		c.startStream(id, conf)
+	}
+
+	if len(toRelease) == 0 {
+		return
+	}
+	if err := c.discovery.Update(ctx, func(state *ClusterState) error {
+		for _, id := range toRelease {
+			if state.Owners[id] == c.nodeID {
+				delete(state.Owners, id)
+			}
+		}
+		return nil
+	}); err != nil {
+		c.m.logger.Errorf("Failed to release reassigned streams: %v\n", err)
+	}
+}
+
+func (c *clusterNode) runningStreams() []string {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	ids := make([]string, 0, len(c.running))
+	for id := range c.running {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (c *clusterNode) startStream(id string, conf stream.Config) {
+	c.mut.Lock()
+	_, isRunning := c.running[id]
+	c.mut.Unlock()
+	if isRunning {
+		return
+	}
+
+	err := c.m.Create(id, conf)
+	if err == ErrStreamExists {
+		// Reuse the existing stream as long as it has the expected config.
+		var info *StreamStatus
+		if info, err = c.m.Read(id); err == nil && !reflect.DeepEqual(info.Config(), conf) {
+			err = c.m.Update(id, conf, c.ttl)
+		}
+	}
+	if err != nil {
+		c.m.logger.Errorf("Failed to start cluster stream '%v': %v\n", id, err)
+		return
+	}
+
+	c.m.logger.Infof("Started cluster stream '%v'\n", id)
+	c.mut.Lock()
+	c.running[id] = struct{}{}
+	c.mut.Unlock()
+}
+
+// stopStreams deletes streams that are running on this node, and returns true
+// if all of them are no longer running.
+func (c *clusterNode) stopStreams(ids []string) bool {
+	stopped := true
+	for _, id := range ids {
+		if err := c.m.Delete(id, c.ttl); err != nil && err != ErrStreamDoesNotExist {
+			c.m.logger.Errorf("Failed to stop cluster stream '%v': %v\n", id, err)
+			stopped = false
+			continue
+		}
+		c.mut.Lock()
+		if _, isRunning := c.running[id]; isRunning {
+			c.m.logger.Infof("Stopped cluster stream '%v'\n", id)
+		}
+		delete(c.running, id)
+		c.mut.Unlock()
+	}
+	return stopped
+}
+
+// stop halts the sync loop, which must be called before the streams of the
+// manager are stopped.
+func (c *clusterNode) stop() {
+	close(c.closeChan)
+	<-c.closedChan
+}
+
+// leave removes the node from the cluster along with its stream ownership,
+// which must only be called once the streams of the node have stopped.
+func (c *clusterNode) leave(timeout time.Duration) error {
+	ctx, done := context.WithTimeout(context.Background(), timeout)
+	defer done()
+	return c.discovery.Update(ctx, func(state *ClusterState) error {
+		delete(state.Nodes, c.nodeID)
+		for id, owner := range state.Owners {
+			if owner == c.nodeID {
+				delete(state.Owners, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (c *clusterNode) handleClusterInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, fmt.Sprintf("Error: verb not supported: %v", r.Method), http.StatusBadRequest)
+		return
+	}
+
+	c.mut.Lock()
+	nodes := append([]string{}, c.nodes...)
+	c.mut.Unlock()
+
+	resBytes, err := json.Marshal(struct {
+		NodeID  string   `json:"node_id"`
+		Nodes   []string `json:"nodes"`
+		Streams []string `json:"streams"`
+	}{
+		NodeID:  c.nodeID,
+		Nodes:   nodes,
+		Streams: c.runningStreams(),
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resBytes)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,182 @@
+package manager_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	bmanager "github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/Jeffail/benthos/v3/lib/stream/manager"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func clusterStreamConfs(n int) map[string]stream.Config {
+	confs := map[string]stream.Config{}
+	for i := 0; i < n; i++ {
+		conf := stream.NewConfig()
+		conf.Input.Type = input.TypeGenerate
+		conf.Input.Generate.Mapping = "root = this"
+		conf.Input.Generate.Interval = "1s"
+		conf.Output.Type = output.TypeDrop
+		confs[fmt.Sprintf("stream%v", i)] = conf
+	}
+	return confs
+}
+
+func runningClusterStreams(mgr *manager.Type, confs map[string]stream.Config) map[string]struct{} {
+	running := map[string]struct{}{}
+	for id := range confs {
+		if _, err := mgr.Read(id); err == nil {
+			running[id] = struct{}{}
+		}
+	}
+	return running
+}
+
+func TestClusterStreamAssignment(t *testing.T) {
+	conf := bmanager.NewResourceConfig()
+	memConf := cache.NewConfig()
+	memConf.Type = cache.TypeMemory
+	memConf.Label = "clustercache"
+	conf.ResourceCaches = append(conf.ResourceCaches, memConf)
+
+	bmgr, err := bmanager.NewV2(conf, types.DudMgr{}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	discovery, err := manager.NewCacheClusterDiscovery(bmgr, "clustercache", "benthos_cluster")
+	require.NoError(t, err)
+	confs := clusterStreamConfs(20)
+
+	newNode := func(id string) *manager.Type {
+		mgr := manager.New(manager.OptSetManager(bmgr))
+		require.NoError(t, mgr.StartCluster(discovery, id, time.Millisecond*50, confs))
+		return mgr
+	}
+
+	nodeA, nodeB := newNode("a"), newNode("b")
+	defer nodeA.Stop(time.Second)
+
+	assert.Eventually(t, func() bool {
+		runningA := runningClusterStreams(nodeA, confs)
+		runningB := runningClusterStreams(nodeB, confs)
+		if len(runningA) == 0 || len(runningB) == 0 {
+			return false
+		}
+		if len(runningA)+len(runningB) != len(confs) {
+			return false
+		}
+		for id := range runningA {
+			if _, exists := runningB[id]; exists {
+				return false
+			}
+		}
+		return true
+	}, time.Second*5, time.Millisecond*50)
+
+	var state manager.ClusterState
+	require.NoError(t, discovery.Update(context.Background(), func(s *manager.ClusterState) error {
+		state = *s
+		return nil
+	}))
+	assert.Len(t, state.Nodes, 2)
+	assert.Len(t, state.Owners, len(confs))
+
+	require.NoError(t, nodeB.Stop(time.Second))
+
+	assert.Eventually(t, func() bool {
+		return len(runningClusterStreams(nodeA, confs)) == len(confs)
+	}, time.Second*5, time.Millisecond*50)
+}
+
+func TestClusterStreamsUntouched(t *testing.T) {
+	conf := bmanager.NewResourceConfig()
+	memConf := cache.NewConfig()
+	memConf.Type = cache.TypeMemory
+	memConf.Label = "clustercache"
+	conf.ResourceCaches = append(conf.ResourceCaches, memConf)
+
+	bmgr, err := bmanager.NewV2(conf, types.DudMgr{}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	discovery, err := manager.NewCacheClusterDiscovery(bmgr, "clustercache", "benthos_cluster")
+	require.NoError(t, err)
+	confs := clusterStreamConfs(3)
+
+	mgr := manager.New(manager.OptSetManager(bmgr))
+	defer mgr.Stop(time.Second)
+
+	localConf := clusterStreamConfs(1)["stream0"]
+	require.NoError(t, mgr.Create("local", localConf))
+
+	require.NoError(t, mgr.StartCluster(discovery, "a", time.Millisecond*50, confs))
+	assert.Error(t, mgr.StartCluster(discovery, "a", time.Millisecond*50, confs))
+
+	assert.Eventually(t, func() bool {
+		return len(runningClusterStreams(mgr, confs)) == len(confs)
+	}, time.Second*5, time.Millisecond*50)
+
+	_, err = mgr.Read("local")
+	assert.NoError(t, err)
+}
+
+func TestClusterDiscoveryLock(t *testing.T) {
+	conf := bmanager.NewResourceConfig()
+	memConf := cache.NewConfig()
+	memConf.Type = cache.TypeMemory
+	memConf.Label = "clustercache"
+	conf.ResourceCaches = append(conf.ResourceCaches, memConf)
+
+	ristConf := cache.NewConfig()
+	ristConf.Type = cache.TypeRistretto
+	ristConf.Label = "ristrettocache"
+	conf.ResourceCaches = append(conf.ResourceCaches, ristConf)
+
+	bmgr, err := bmanager.NewV2(conf, types.DudMgr{}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	_, err = manager.NewCacheClusterDiscovery(bmgr, "ristrettocache", "benthos_cluster")
+	assert.Error(t, err)
+
+	_, err = manager.NewCacheClusterDiscovery(bmgr, "doesnotexist", "benthos_cluster")
+	assert.Error(t, err)
+
+	discovery, err := manager.NewCacheClusterDiscovery(bmgr, "clustercache", "benthos_cluster")
+	require.NoError(t, err)
+
+	c, err := bmgr.GetCache("clustercache")
+	require.NoError(t, err)
+
+	noop := func(*manager.ClusterState) error { return nil }
+
+	// A lock held by another node blocks updates until it expires.
+	heldLock := []byte(fmt.Sprintf("%v:foo", time.Now().Add(time.Hour).UnixNano()))
+	require.NoError(t, c.Add("benthos_cluster_lock", heldLock))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*200)
+	assert.Error(t, discovery.Update(ctx, noop))
+	done()
+
+	held, err := c.Get("benthos_cluster_lock")
+	require.NoError(t, err)
+	assert.Equal(t, heldLock, held)
+
+	// An expired lock is broken.
+	require.NoError(t, c.Delete("benthos_cluster_lock"))
+	require.NoError(t, c.Add("benthos_cluster_lock", []byte(fmt.Sprintf("%v:foo", time.Now().Add(-time.Second).UnixNano()))))
+
+	ctx, done = context.WithTimeout(context.Background(), time.Second)
+	require.NoError(t, discovery.Update(ctx, noop))
+	done()
+
+	_, err = c.Get("benthos_cluster_lock")
+	assert.Equal(t, types.ErrKeyNotFound, err)
+}
@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/Jeffail/benthos/v3/lib/stream"
+)
+
+//------------------------------------------------------------------------------
+
+// Operations of a ConfigDiff.
+const (
+	DiffAdded    = "added"
+	DiffRemoved  = "removed"
+	DiffModified = "modified"
+)
+
+// ConfigDiff describes a single difference between two stream configs.
+type ConfigDiff struct {
+	// Path is a dot separated path to the field that differs, where array
+	// elements are referenced by their index.
+	Path string `json:"path"`
+
+	// Operation is either added, removed or modified.
+	Operation string `json:"operation"`
+
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// DiffConfigs returns the differences between the sanitised forms of two stream
+// configs, sorted by path.
+func DiffConfigs(from, to stream.Config) ([]ConfigDiff, error) {
+	fromSanit, err := from.Sanitised()
+	if err != nil {
+		return nil, err
+	}
+	toSanit, err := to.Sanitised()
+	if err != nil {
+		return nil, err
+	}
+	diffs := []ConfigDiff{}
+	diffValues("", fromSanit, toSanit, &diffs)
+	return diffs, nil
+}
+
+func diffPath(base, key string) string {
+	if base == "" {
+		return key
+	}
+	return base + "." + key
+}
+
+func diffValues(path string, from, to interface{}, diffs *[]ConfigDiff) {
+	switch f := from.(type) {
+	case map[string]interface{}:
+		t, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(f)+len(t))
+		for k := range f {
+			keys = append(keys, k)
+		}
+		for k := range t {
+			if _, exists := f[k]; !exists {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fv, fExists := f[k]
+			tv, tExists := t[k]
+			switch {
+			case !fExists:
+				*diffs = append(*diffs, ConfigDiff{Path: diffPath(path, k), Operation: DiffAdded, New: tv})
+			case !tExists:
+				*diffs = append(*diffs, ConfigDiff{Path: diffPath(path, k), Operation: DiffRemoved, Old: fv})
+			default:
+				diffValues(diffPath(path, k), fv, tv, diffs)
+			}
+		}
+		return
+	case []interface{}:
+		t, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(f) || i < len(t); i++ {
+			iPath := diffPath(path, strconv.Itoa(i))
+			switch {
+			case i >= len(f):
+				*diffs = append(*diffs, ConfigDiff{Path: iPath, Operation: DiffAdded, New: t[i]})
+			case i >= len(t):
+				*diffs = append(*diffs, ConfigDiff{Path: iPath, Operation: DiffRemoved, Old: f[i]})
+			default:
+				diffValues(iPath, f[i], t[i], diffs)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(from, to) {
+		*diffs = append(*diffs, ConfigDiff{Path: path, Operation: DiffModified, Old: from, New: to})
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,44 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	procConf := func(mapping string) processor.Config {
+		pConf := processor.NewConfig()
+		pConf.Type = processor.TypeBloblang
+		pConf.Bloblang = processor.BloblangConfig(mapping)
+		return pConf
+	}
+
+	from := stream.NewConfig()
+	from.Input.Type = "generate"
+	from.Input.Generate.Mapping = `root = "foo"`
+	from.Pipeline.Processors = []processor.Config{procConf(`root = "a"`), procConf(`root = "b"`)}
+	from.Output.Type = "drop"
+
+	diff, err := DiffConfigs(from, from)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	to := from
+	to.Input.Generate.Interval = "5s"
+	to.Pipeline.Processors = []processor.Config{procConf(`root = "c"`)}
+	to.Output.Type = "stdout"
+
+	diff, err = DiffConfigs(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, []ConfigDiff{
+		{Path: "input.generate.interval", Operation: DiffModified, Old: "1s", New: "5s"},
+		{Path: "output.drop", Operation: DiffRemoved, Old: map[string]interface{}{}},
+		{Path: "output.stdout", Operation: DiffAdded, New: map[string]interface{}{"codec": "lines", "delimiter": ""}},
+		{Path: "pipeline.processors.0.bloblang", Operation: DiffModified, Old: `root = "a"`, New: `root = "c"`},
+		{Path: "pipeline.processors.1", Operation: DiffRemoved, Old: map[string]interface{}{"bloblang": `root = "b"`, "label": ""}},
+	}, diff)
+}
@@ -12,12 +12,15 @@ import (
 
 //------------------------------------------------------------------------------
 
-func loadFile(dir, path, testSuffix string, confs map[string]stream.Config) ([]string, error) {
+// streamIDFromPath derives a stream id from the path of a config file relative
+// to the directory it was found in. Returns an empty id if the file is a unit
+// test definition that should be ignored.
+func streamIDFromPath(dir, path, testSuffix string) (string, error) {
 	var id string
 	if len(dir) > 0 {
 		var err error
 		if id, err = filepath.Rel(dir, path); err != nil {
-			return nil, err
+			return "", err
 		}
 	} else {
 		id = filepath.Base(path)
@@ -28,10 +31,17 @@ func loadFile(dir, path, testSuffix string, confs map[string]stream.Config) ([]s
 
 	// Do not run unit test files
 	if len(testSuffix) > 0 && strings.HasSuffix(id, testSuffix) {
-		return nil, nil
+		return "", nil
 	}
 
-	id = strings.ReplaceAll(id, string(filepath.Separator), "_")
+	return strings.ReplaceAll(id, string(filepath.Separator), "_"), nil
+}
+
+func loadFile(dir, path, testSuffix string, confs map[string]stream.Config) ([]string, error) {
+	id, err := streamIDFromPath(dir, path, testSuffix)
+	if err != nil || id == "" {
+		return nil, err
+	}
 
 	if _, exists := confs[id]; exists {
 		return nil, fmt.Errorf("stream id (%v) collision from file: %v", id, path)
@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/stream"
+)
+
+//------------------------------------------------------------------------------
+
+// StreamLimits describes limits placed on the resources consumed by a stream,
+// where a limit of zero or less is unlimited.
+type StreamLimits struct {
+	// MaxInFlight is the maximum number of messages that have been consumed
+	// from the input of the stream but not yet acknowledged. Whilst the limit
+	// is reached the stream stops consuming from its input.
+	MaxInFlight int `json:"max_in_flight" yaml:"max_in_flight"`
+
+	// MaxInFlightBytes is the maximum total size in bytes of messages that
+	// have been consumed from the input of the stream but not yet acknowledged.
+	// Whilst the limit is reached the stream stops consuming from its input.
+	// Messages written to a buffer are acknowledged, and therefore messages
+	// within a buffer do not count towards the limit.
+	MaxInFlightBytes int64 `json:"max_in_flight_bytes" yaml:"max_in_flight_bytes"`
+
+	// MaxProcessorThreads is the maximum number of processing threads of the
+	// stream pipeline, streams configured with more threads are capped.
+	MaxProcessorThreads int `json:"max_processor_threads" yaml:"max_processor_threads"`
+}
+
+// OptSetStreamLimits sets the default limits of all streams created by the
+// manager, which can be overridden for individual streams with
+// SetStreamLimits.
+func OptSetStreamLimits(limits StreamLimits) func(*Type) {
+	return func(t *Type) {
+		t.defaultLimits = limits
+	}
+}
+
+// streamLimits returns the limits of a stream, the caller must hold the lock.
+func (m *Type) streamLimits(id string) StreamLimits {
+	if limits, exists := m.limits[id]; exists {
+		return limits
+	}
+	return m.defaultLimits
+}
+
+// applyLimits returns stream options and a modified config that enforce a set
+// of limits.
+func (m *Type) applyLimits(id string, conf stream.Config, limits StreamLimits) (stream.Config, []func(*stream.Type)) {
+	if max := limits.MaxProcessorThreads; max > 0 {
+		if threads := conf.Pipeline.Threads; threads <= 0 || threads > max {
+			m.logger.Warnf("Capping processor threads of stream '%v' from %v to %v\n", id, threads, max)
+			conf.Pipeline.Threads = max
+		}
+	}
+	return conf, []func(*stream.Type){
+		stream.OptSetInFlightLimits(limits.MaxInFlight, limits.MaxInFlightBytes),
+	}
+}
+
+// SetStreamLimits sets the limits of a stream by its ID, overriding the default
+// limits of the manager. If the stream is already running with different
+// limits then it is restarted with the new limits, within the provided
+// timeout. Limits set for a stream are removed when the stream is deleted.
+func (m *Type) SetStreamLimits(id string, limits StreamLimits, timeout time.Duration) error {
+	m.lock.Lock()
+	m.limits[id] = limits
+	wrapper, exists := m.streams[id]
+	m.lock.Unlock()
+
+	if !exists {
+		return nil
+	}
+	if wrapper.limits != limits {
+		if err := m.recreate(id, wrapper, wrapper.Config(), timeout); err != nil {
+			return err
+		}
+	}
+	return m.persistStreams(wrapper.static)
+}
+
+// Limits returns the limits applied to the stream.
+func (s *StreamStatus) Limits() StreamLimits {
+	return s.limits
+}
+
+// InFlight returns the number of messages, and the total size of those messages
+// in bytes, that have been consumed from the input of the stream and not yet
+// acknowledged. Messages are only counted when the stream has in flight limits
+// or the manager was created with OptTrackInFlight.
+func (s *StreamStatus) InFlight() (messages int, bytes int64) {
+	return s.strm.InFlight()
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,203 @@
+package manager_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	bmanager "github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/Jeffail/benthos/v3/lib/stream/manager"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/gabs/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamLimitsProcessorThreads(t *testing.T) {
+	mgr := manager.New(
+		manager.OptSetManager(types.NoopMgr()),
+		manager.OptSetStreamLimits(manager.StreamLimits{
+			MaxProcessorThreads: 2,
+		}),
+	)
+	defer mgr.Stop(time.Second)
+
+	conf := harmlessConf()
+	conf.Pipeline.Threads = 10
+	require.NoError(t, mgr.Create("foo", conf))
+
+	info, err := mgr.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, 2, info.Limits().MaxProcessorThreads)
+	assert.Equal(t, 10, info.Config().Pipeline.Threads)
+
+	require.NoError(t, mgr.SetStreamLimits("foo", manager.StreamLimits{
+		MaxInFlight: 5,
+	}, time.Second))
+
+	info, err = mgr.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, manager.StreamLimits{MaxInFlight: 5}, info.Limits())
+}
+
+func TestStreamLimitsInFlight(t *testing.T) {
+	bmgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), types.DudMgr{}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	bmgr.SetPipe("feed_in", tChan)
+
+	smgr := manager.New(
+		manager.OptSetManager(bmgr),
+		manager.OptSetStats(metrics.Noop()),
+		manager.OptSetStreamLimits(manager.StreamLimits{
+			MaxInFlight: 2,
+		}),
+	)
+	defer smgr.Stop(time.Second)
+
+	conf := stream.NewConfig()
+	conf.Input.Type = input.TypeInproc
+	conf.Input.Inproc = "feed_in"
+	conf.Output.Type = output.TypeInproc
+	conf.Output.Inproc = "feed_out"
+	require.NoError(t, smgr.Create("foo", conf))
+
+	var outChan <-chan types.Transaction
+	require.Eventually(t, func() bool {
+		outChan, err = bmgr.GetPipe("feed_out")
+		return err == nil
+	}, time.Second*5, time.Millisecond*10)
+
+	resChan := make(chan types.Response)
+	go func() {
+		for i := 0; i < 3; i++ {
+			tChan <- types.NewTransaction(message.New([][]byte{[]byte("hello")}), resChan)
+		}
+	}()
+
+	var outTrans []types.Transaction
+	for i := 0; i < 2; i++ {
+		select {
+		case tran := <-outChan:
+			outTrans = append(outTrans, tran)
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+	}
+
+	select {
+	case <-outChan:
+		t.Fatal("received message beyond in flight limit")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	r := router(smgr)
+	request := genRequest("GET", "/streams/foo/stats", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, request)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	stats, err := gabs.ParseJSON(resp.Body.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, stats.S("limits", "max_in_flight").Data(), resp.Body.String())
+	assert.Equal(t, 2.0, stats.S("limits", "in_flight").Data(), resp.Body.String())
+	assert.Equal(t, 10.0, stats.S("limits", "in_flight_bytes").Data(), resp.Body.String())
+
+	go func() {
+		outTrans[0].ResponseChan <- response.NewAck()
+	}()
+	<-resChan
+
+	select {
+	case tran := <-outChan:
+		outTrans = append(outTrans, tran)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	for _, tran := range outTrans[1:] {
+		go func(tran types.Transaction) {
+			tran.ResponseChan <- response.NewAck()
+		}(tran)
+		<-resChan
+	}
+}
+
+func TestStreamLimitsAPI(t *testing.T) {
+	mgr := manager.New(
+		manager.OptSetManager(types.NoopMgr()),
+		manager.OptSetStreamLimits(manager.StreamLimits{
+			MaxInFlight: 10,
+		}),
+	)
+	defer mgr.Stop(time.Second)
+
+	r := router(mgr)
+
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, genRequest("GET", "/streams/foo/limits", nil))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, genRequest("PUT", "/streams/foo/limits", manager.StreamLimits{MaxInFlight: 5}))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	require.NoError(t, mgr.Create("foo", harmlessConf()))
+
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, genRequest("GET", "/streams/foo/limits", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"max_in_flight":10,"max_in_flight_bytes":0,"max_processor_threads":0}`, resp.Body.String())
+
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, genRequest("PUT", "/streams/foo/limits", manager.StreamLimits{
+		MaxInFlight:      5,
+		MaxInFlightBytes: 1024,
+	}))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	info, err := mgr.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, manager.StreamLimits{MaxInFlight: 5, MaxInFlightBytes: 1024}, info.Limits())
+
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, genRequest("GET", "/streams/foo/limits", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"max_in_flight":5,"max_in_flight_bytes":1024,"max_processor_threads":0}`, resp.Body.String())
+
+	request := genRequest("PUT", "/streams/foo/limits", nil)
+	request.Body = io.NopCloser(strings.NewReader("not json"))
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, request)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestStreamLimitsRemovedOnDelete(t *testing.T) {
+	mgr := manager.New(
+		manager.OptSetManager(types.NoopMgr()),
+		manager.OptSetStreamLimits(manager.StreamLimits{
+			MaxInFlight: 10,
+		}),
+	)
+	defer mgr.Stop(time.Second)
+
+	require.NoError(t, mgr.Create("foo", harmlessConf()))
+	require.NoError(t, mgr.SetStreamLimits("foo", manager.StreamLimits{MaxInFlight: 5}, time.Second))
+	require.NoError(t, mgr.Delete("foo", time.Second))
+
+	require.NoError(t, mgr.Create("foo", harmlessConf()))
+	info, err := mgr.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, manager.StreamLimits{MaxInFlight: 10}, info.Limits())
+}
@@ -0,0 +1,317 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+
+// State is a snapshot of the stream and resource configs of a stream manager,
+// which can be persisted in order to restore the manager after a restart.
+type State struct {
+	// Streams is a map of stream ids to their configs, and does not contain
+	// streams that were loaded from static config files.
+	Streams map[string]yaml.Node `yaml:"streams"`
+
+	// Paused is a list of the stream ids that are paused.
+	Paused []string `yaml:"paused,omitempty"`
+
+	// Limits is a map of stream ids to the limits set for them, which override
+	// the default limits of the manager.
+	Limits map[string]StreamLimits `yaml:"limits,omitempty"`
+
+	// Resources is a map of resource types (cache, input, etc) to maps of
+	// resource names to their configs, and only contains resources that were
+	// created via the HTTP API.
+	Resources map[string]map[string]yaml.Node `yaml:"resources"`
+}
+
+// StateStore describes a persistent store for the state of a stream manager.
+type StateStore interface {
+	// Read the most recently written state, if no state has been written yet
+	// then an empty state is returned.
+	Read(ctx context.Context) (State, error)
+
+	// Write a state, replacing any previously written state.
+	Write(ctx context.Context, state State) error
+}
+
+func parseState(b []byte) (State, error) {
+	var state State
+	if err := yaml.Unmarshal(b, &state); err != nil {
+		return state, err
+	}
+	return state, nil
+}
+
+//------------------------------------------------------------------------------
+
+// DirStateFile is the name of the file within a state directory that the state
+// of a stream manager is written to.
+const DirStateFile = "streams_state.yaml"
+
+type dirStateStore struct {
+	path string
+}
+
+// NewDirStateStore returns a StateStore that persists state to a file within a
+// directory on the local filesystem. The directory is created if it does not
+// already exist.
+func NewDirStateStore(dir string) (StateStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &dirStateStore{
+		path: filepath.Join(dir, DirStateFile),
+	}, nil
+}
+
+func (d *dirStateStore) Read(ctx context.Context) (State, error) {
+	b, err := os.ReadFile(d.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return State{}, nil
+		}
+		return State{}, err
+	}
+	return parseState(b)
+}
+
+func (d *dirStateStore) Write(ctx context.Context, state State) error {
+	b, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that the state file is never left
+	// partially written.
+	tmp, err := os.CreateTemp(filepath.Dir(d.path), "."+DirStateFile+"-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = os.Rename(tmp.Name(), d.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type cacheStateStore struct {
+	mgr   types.Manager
+	cache string
+	key   string
+}
+
+// NewCacheStateStore returns a StateStore that persists state as a single key
+// of a cache resource.
+func NewCacheStateStore(mgr types.Manager, cache, key string) StateStore {
+	return &cacheStateStore{
+		mgr:   mgr,
+		cache: cache,
+		key:   key,
+	}
+}
+
+func (c *cacheStateStore) Read(ctx context.Context) (state State, err error) {
+	if cerr := interop.AccessCache(ctx, c.mgr, c.cache, func(cache types.Cache) {
+		var b []byte
+		if b, err = cache.Get(c.key); err != nil {
+			if err == types.ErrKeyNotFound {
+				err = nil
+			}
+			return
+		}
+		state, err = parseState(b)
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+func (c *cacheStateStore) Write(ctx context.Context, state State) (err error) {
+	var b []byte
+	if b, err = yaml.Marshal(state); err != nil {
+		return
+	}
+	if cerr := interop.AccessCache(ctx, c.mgr, c.cache, func(cache types.Cache) {
+		err = cache.Set(c.key, b)
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// OptSetStateStore sets a store that the stream and resource configs of the
+// manager are persisted to every time they are modified, the state can then be
+// restored with RestoreState. Streams created with CreateStatic are not
+// persisted.
+func OptSetStateStore(store StateStore) func(*Type) {
+	return func(t *Type) {
+		t.stateStore = store
+	}
+}
+
+// ErrStateNotPersisted is wrapped by errors returned when a change to the
+// streams or resources of a manager was applied successfully but the resulting
+// state could not be written to the state store.
+var ErrStateNotPersisted = errors.New("failed to persist state")
+
+// persistStreams writes the current state to the state store following a
+// change to a stream, unless the stream is static.
+func (m *Type) persistStreams(static bool) error {
+	if static || m.stateStore == nil {
+		return nil
+	}
+	ctx, done := context.WithTimeout(context.Background(), m.apiTimeout)
+	defer done()
+	return m.persistState(ctx)
+}
+
+// persistState writes the current stream configs, excluding static streams, and
+// resource configs created via the HTTP API to the state store, if one is
+// configured.
+func (m *Type) persistState(ctx context.Context) error {
+	if m.stateStore == nil {
+		return nil
+	}
+	if err := m.writeState(ctx); err != nil {
+		return fmt.Errorf("%w: %v", ErrStateNotPersisted, err)
+	}
+	return nil
+}
+
+func (m *Type) writeState(ctx context.Context) error {
+	m.stateLock.Lock()
+	defer m.stateLock.Unlock()
+
+	state := State{
+		Streams:   map[string]yaml.Node{},
+		Resources: map[string]map[string]yaml.Node{},
+	}
+
+	m.lock.Lock()
+	for id, info := range m.streams {
+		if info.static {
+			continue
+		}
+		if info.IsPaused() {
+			state.Paused = append(state.Paused, id)
+		}
+		if limits, exists := m.limits[id]; exists {
+			if state.Limits == nil {
+				state.Limits = map[string]StreamLimits{}
+			}
+			state.Limits[id] = limits
+		}
+		sanit, err := info.Config().Sanitised()
+		if err != nil {
+			m.lock.Unlock()
+			return fmt.Errorf("failed to sanitise stream '%v' config: %w", id, err)
+		}
+		var node yaml.Node
+		if err := node.Encode(sanit); err != nil {
+			m.lock.Unlock()
+			return fmt.Errorf("failed to encode stream '%v' config: %w", id, err)
+		}
+		state.Streams[id] = node
+	}
+	m.lock.Unlock()
+	sort.Strings(state.Paused)
+
+	for t, nodes := range m.resourceNodes {
+		tNodes := make(map[string]yaml.Node, len(nodes))
+		for k, v := range nodes {
+			tNodes[k] = v
+		}
+		state.Resources[t] = tNodes
+	}
+	return m.stateStore.Write(ctx, state)
+}
+
+// RestoreState reads the state from the configured state store and recreates
+// the resources and streams that it contains. Resources are stored before any
+// streams are created. Streams that already exist, such as those loaded from
+// static config files, take precedence over the persisted state and are left
+// untouched. Restoring the state does not write to the state store.
+func (m *Type) RestoreState(ctx context.Context) error {
+	if m.stateStore == nil {
+		return errors.New("a state store has not been configured")
+	}
+
+	state, err := m.stateStore.Read(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read state: %w", err)
+	}
+
+	resTypes := make([]string, 0, len(state.Resources))
+	for t := range state.Resources {
+		resTypes = append(resTypes, t)
+	}
+	sort.Strings(resTypes)
+
+	for _, t := range resTypes {
+		for name, node := range state.Resources[t] {
+			node := node
+			decodeErr, storeErr := m.storeResource(ctx, t, name, &node)
+			if decodeErr != nil {
+				return fmt.Errorf("failed to parse %v resource '%v': %w", t, name, decodeErr)
+			}
+			if storeErr != nil {
+				return fmt.Errorf("failed to create %v resource '%v': %w", t, name, storeErr)
+			}
+		}
+	}
+
+	paused := make(map[string]struct{}, len(state.Paused))
+	for _, id := range state.Paused {
+		paused[id] = struct{}{}
+	}
+
+	for id, node := range state.Streams {
+		conf := stream.NewConfig()
+		if err := node.Decode(&conf); err != nil {
+			return fmt.Errorf("failed to parse stream '%v': %w", id, err)
+		}
+		_, isPaused := paused[id]
+		if limits, exists := state.Limits[id]; exists {
+			m.lock.Lock()
+			if _, streamExists := m.streams[id]; !streamExists {
+				m.limits[id] = limits
+			}
+			m.lock.Unlock()
+		}
+		if err := m.create(id, conf, false, isPaused); err != nil {
+			if err == ErrStreamExists {
+				m.logger.Infof("Stream '%v' already exists and will not be restored from state\n", id)
+				continue
+			}
+			return fmt.Errorf("failed to create stream '%v': %w", id, err)
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,305 @@
+package manager_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	bmanager "github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/Jeffail/benthos/v3/lib/stream/manager"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestDirStateStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state")
+
+	store, err := manager.NewDirStateStore(dir)
+	require.NoError(t, err)
+
+	state, err := store.Read(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, state.Streams)
+	assert.Empty(t, state.Resources)
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`input: { inproc: foo }`), &node))
+
+	require.NoError(t, store.Write(context.Background(), manager.State{
+		Streams: map[string]yaml.Node{"foo": *node.Content[0]},
+	}))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, manager.DirStateFile, files[0].Name())
+
+	state, err = store.Read(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, state.Streams, "foo")
+
+	var res map[string]interface{}
+	sNode := state.Streams["foo"]
+	require.NoError(t, sNode.Decode(&res))
+	assert.Equal(t, map[string]interface{}{
+		"input": map[string]interface{}{"inproc": "foo"},
+	}, res)
+}
+
+func testStateConfs(t *testing.T) (cache.Config, stream.Config) {
+	t.Helper()
+
+	cacheConf := cache.NewConfig()
+	cacheConf.Type = cache.TypeFile
+	cacheConf.File.Directory = t.TempDir()
+
+	streamConf := stream.NewConfig()
+	streamConf.Input.Type = input.TypeInproc
+	streamConf.Input.Inproc = "feed_in"
+	streamConf.Output.Type = output.TypeCache
+	streamConf.Output.Cache.Key = `${! json("id") }`
+	streamConf.Output.Cache.Target = "foocache"
+	return cacheConf, streamConf
+}
+
+func testStateRoundTrip(t *testing.T, newStore func(mgr types.Manager) manager.StateStore) {
+	t.Helper()
+
+	cacheConf, streamConf := testStateConfs(t)
+
+	conf := bmanager.NewResourceConfig()
+	memConf := cache.NewConfig()
+	memConf.Type = cache.TypeMemory
+	memConf.Label = "statecache"
+	conf.ResourceCaches = append(conf.ResourceCaches, memConf)
+
+	bmgr, err := bmanager.NewV2(conf, types.DudMgr{}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	newStreamManager := func() *manager.Type {
+		return manager.New(
+			manager.OptSetLogger(log.Noop()),
+			manager.OptSetStats(metrics.Noop()),
+			manager.OptSetManager(bmgr),
+			manager.OptSetAPITimeout(time.Second),
+			manager.OptSetStateStore(newStore(bmgr)),
+		)
+	}
+
+	mgr := newStreamManager()
+	r := router(mgr)
+
+	request := genYAMLRequest("POST", "/resources/cache/foocache?chilled=true", cacheConf)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	for _, id := range []string{"foo", "bar", "baz"} {
+		request = genYAMLRequest("POST", "/streams/"+id+"?chilled=true", streamConf)
+		response = httptest.NewRecorder()
+		r.ServeHTTP(response, request)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	}
+
+	request = genRequest("DELETE", "/streams/bar", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	patchedConf := streamConf
+	patchedConf.Input.Inproc = "feed_in_baz"
+	request = genYAMLRequest("PUT", "/streams/baz?chilled=true", patchedConf)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	request = genRequest("PUT", "/streams/foo/limits", manager.StreamLimits{MaxInFlight: 5})
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	state, err := newStore(bmgr).Read(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, state.Streams, 2)
+	assert.Contains(t, state.Resources["cache"], "foocache")
+	assert.Equal(t, map[string]manager.StreamLimits{"foo": {MaxInFlight: 5}}, state.Limits)
+
+	// The state cache must outlive the original stream manager, so restore
+	// into a fresh stream manager that shares the same resource manager.
+	require.NoError(t, mgr.Stop(time.Second))
+	restoredMgr := newStreamManager()
+	require.NoError(t, restoredMgr.RestoreState(context.Background()))
+
+	info, err := restoredMgr.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, manager.StreamLimits{MaxInFlight: 5}, info.Limits())
+
+	_, err = restoredMgr.Read("bar")
+	assert.Equal(t, manager.ErrStreamDoesNotExist, err)
+
+	info, err = restoredMgr.Read("baz")
+	require.NoError(t, err)
+	assert.Equal(t, input.InprocConfig("feed_in_baz"), info.Config().Input.Inproc)
+	assert.Equal(t, "foocache", info.Config().Output.Cache.Target)
+
+	require.NoError(t, restoredMgr.Stop(time.Second))
+}
+
+func TestStatePersistedToDir(t *testing.T) {
+	dir := t.TempDir()
+	testStateRoundTrip(t, func(types.Manager) manager.StateStore {
+		store, err := manager.NewDirStateStore(dir)
+		require.NoError(t, err)
+		return store
+	})
+}
+
+func TestStatePersistedToCache(t *testing.T) {
+	testStateRoundTrip(t, func(mgr types.Manager) manager.StateStore {
+		return manager.NewCacheStateStore(mgr, "statecache", "streams_state")
+	})
+}
+
+func TestStateRestoreKeepsExistingStreams(t *testing.T) {
+	store, err := manager.NewDirStateStore(t.TempDir())
+	require.NoError(t, err)
+
+	var node yaml.Node
+	require.NoError(t, node.Encode(map[string]interface{}{
+		"input":  map[string]interface{}{"inproc": "from_state"},
+		"output": map[string]interface{}{"drop": map[string]interface{}{}},
+	}))
+	require.NoError(t, store.Write(context.Background(), manager.State{
+		Streams: map[string]yaml.Node{"foo": node},
+	}))
+
+	bmgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), types.DudMgr{}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr := manager.New(
+		manager.OptSetManager(bmgr),
+		manager.OptSetStateStore(store),
+	)
+
+	conf := stream.NewConfig()
+	conf.Input.Type = input.TypeInproc
+	conf.Input.Inproc = "from_file"
+	conf.Output.Type = output.TypeDrop
+	require.NoError(t, mgr.CreateStatic("foo", conf))
+
+	require.NoError(t, mgr.RestoreState(context.Background()))
+
+	info, err := mgr.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, input.InprocConfig("from_file"), info.Config().Input.Inproc)
+
+	require.NoError(t, mgr.Stop(time.Second))
+}
+
+func TestStatePersistedFromManagerMethods(t *testing.T) {
+	store, err := manager.NewDirStateStore(t.TempDir())
+	require.NoError(t, err)
+
+	bmgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), types.DudMgr{}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	newStreamManager := func() *manager.Type {
+		return manager.New(
+			manager.OptSetManager(bmgr),
+			manager.OptSetStateStore(store),
+		)
+	}
+	mgr := newStreamManager()
+
+	conf := stream.NewConfig()
+	conf.Input.Type = input.TypeInproc
+	conf.Input.Inproc = "feed_in"
+	conf.Output.Type = output.TypeDrop
+
+	require.NoError(t, mgr.CreateStatic("static", conf))
+	require.NoError(t, mgr.Create("foo", conf))
+	require.NoError(t, mgr.Create("bar", conf))
+	require.NoError(t, mgr.Pause("foo"))
+
+	updatedConf := conf
+	updatedConf.Input.Inproc = "feed_in_updated"
+	require.NoError(t, mgr.Update("static", updatedConf, time.Second))
+	require.NoError(t, mgr.Update("foo", updatedConf, time.Second))
+	require.NoError(t, mgr.Delete("bar", time.Second))
+
+	state, err := store.Read(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, state.Streams, 1)
+	assert.Contains(t, state.Streams, "foo")
+	assert.Equal(t, []string{"foo"}, state.Paused)
+
+	require.NoError(t, mgr.Stop(time.Second))
+
+	restoredMgr := newStreamManager()
+	require.NoError(t, restoredMgr.RestoreState(context.Background()))
+
+	info, err := restoredMgr.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, input.InprocConfig("feed_in_updated"), info.Config().Input.Inproc)
+	assert.True(t, info.IsPaused())
+
+	_, err = restoredMgr.Read("static")
+	assert.Equal(t, manager.ErrStreamDoesNotExist, err)
+
+	require.NoError(t, restoredMgr.Stop(time.Second))
+}
+
+type failingStateStore struct{}
+
+func (f failingStateStore) Read(ctx context.Context) (manager.State, error) {
+	return manager.State{}, nil
+}
+
+func (f failingStateStore) Write(ctx context.Context, state manager.State) error {
+	return errors.New("nope")
+}
+
+func TestStatePersistFailure(t *testing.T) {
+	cacheConf, streamConf := testStateConfs(t)
+	streamConf.Output.Type = output.TypeDrop
+
+	bmgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), types.DudMgr{}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr := manager.New(
+		manager.OptSetManager(bmgr),
+		manager.OptSetAPITimeout(time.Second),
+		manager.OptSetStateStore(failingStateStore{}),
+	)
+	t.Cleanup(func() {
+		assert.NoError(t, mgr.Stop(time.Second))
+	})
+	r := router(mgr)
+
+	request := genYAMLRequest("POST", "/resources/cache/foocache?chilled=true", cacheConf)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadGateway, response.Code, response.Body.String())
+
+	request = genYAMLRequest("POST", "/streams/foo?chilled=true", streamConf)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadGateway, response.Code, response.Body.String())
+	assert.Contains(t, response.Body.String(), "failed to persist state: nope")
+
+	err = mgr.Pause("foo")
+	assert.True(t, errors.Is(err, manager.ErrStateNotPersisted), err)
+}
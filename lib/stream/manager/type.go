@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,6 +15,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/stream"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
 )
 
 //------------------------------------------------------------------------------
@@ -21,10 +24,13 @@ import (
 // StreamStatus tracks a stream along with information regarding its internals.
 type StreamStatus struct {
 	stoppedAfter int64
+	configMut    sync.RWMutex
 	config       stream.Config
 	strm         *stream.Type
 	logger       log.Modular
 	metrics      *metrics.Local
+	limits       StreamLimits
+	static       bool
 	createdAt    time.Time
 }
 
@@ -56,6 +62,11 @@ func (s *StreamStatus) IsReady() bool {
 	return s.strm.IsReady()
 }
 
+// IsPaused returns a boolean indicating whether the stream is paused.
+func (s *StreamStatus) IsPaused() bool {
+	return s.strm.IsPaused()
+}
+
 // Uptime returns a time.Duration indicating the current uptime of the stream.
 func (s *StreamStatus) Uptime() time.Duration {
 	if stoppedAfter := atomic.LoadInt64(&s.stoppedAfter); stoppedAfter > 0 {
@@ -66,6 +77,8 @@ func (s *StreamStatus) Uptime() time.Duration {
 
 // Config returns the configuration of the stream.
 func (s *StreamStatus) Config() stream.Config {
+	s.configMut.RLock()
+	defer s.configMut.RUnlock()
 	return s.config
 }
 
@@ -104,6 +117,16 @@ type Type struct {
 	apiTimeout time.Duration
 
 	pipelineProcCtors []StreamProcConstructorFunc
+	watcher           *fsnotify.Watcher
+	cluster           *clusterNode
+
+	defaultLimits StreamLimits
+	limits        map[string]StreamLimits
+	trackInFlight bool
+
+	stateStore    StateStore
+	resourceNodes map[string]map[string]yaml.Node
+	stateLock     sync.Mutex
 
 	lock sync.Mutex
 }
@@ -111,11 +134,13 @@ type Type struct {
 // New creates a new stream manager.Type.
 func New(opts ...func(*Type)) *Type {
 	t := &Type{
-		streams:    map[string]*StreamStatus{},
-		manager:    types.DudMgr{},
-		stats:      metrics.Noop(),
-		apiTimeout: time.Second * 5,
-		logger:     log.Noop(),
+		streams:       map[string]*StreamStatus{},
+		resourceNodes: map[string]map[string]yaml.Node{},
+		limits:        map[string]StreamLimits{},
+		manager:       types.DudMgr{},
+		stats:         metrics.Noop(),
+		apiTimeout:    time.Second * 5,
+		logger:        log.Noop(),
 	}
 	for _, opt := range opts {
 		opt(t)
@@ -158,6 +183,15 @@ func OptSetAPITimeout(tout time.Duration) func(*Type) {
 	}
 }
 
+// OptTrackInFlight tracks the messages in flight of all streams regardless of
+// their limits, which allows Drain to wait for the acknowledgement of messages
+// consumed before the drain began.
+func OptTrackInFlight() func(*Type) {
+	return func(t *Type) {
+		t.trackInFlight = true
+	}
+}
+
 // OptAddProcessors adds processor constructors that will be called for every
 // new stream and attached to the processor pipelines. The constructor is given
 // the name of the stream as an argument.
@@ -178,8 +212,25 @@ var (
 //------------------------------------------------------------------------------
 
 // Create attempts to construct and run a new stream under a unique ID. If the
-// ID already exists an error is returned.
+// ID already exists an error is returned. When a state store is configured the
+// stream is persisted to it.
 func (m *Type) Create(id string, conf stream.Config) error {
+	if err := m.create(id, conf, false, false); err != nil {
+		return err
+	}
+	return m.persistStreams(false)
+}
+
+// CreateStatic attempts to construct and run a new stream under a unique ID in
+// the same way as Create. However, the stream is considered to be owned by a
+// static config file and is therefore never persisted to a state store, which
+// also applies to any subsequent updates of the stream. If the ID already
+// exists an error is returned.
+func (m *Type) CreateStatic(id string, conf stream.Config) error {
+	return m.create(id, conf, true, false)
+}
+
+func (m *Type) create(id string, conf stream.Config, static, paused bool) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
@@ -211,22 +262,35 @@ func (m *Type) Create(id string, conf stream.Config) error {
 	sStats = metrics.Combine(sStats, strmFlatMetrics)
 	sMgr = manager.SwapMetrics(sMgr, sStats)
 
+	limits := m.streamLimits(id)
+	limitedConf, limitOpts := m.applyLimits(id, conf, limits)
+	if paused {
+		limitOpts = append(limitOpts, stream.OptStartPaused())
+	}
+	if m.trackInFlight {
+		limitOpts = append(limitOpts, stream.OptTrackInFlight())
+	}
+
 	var wrapper *StreamStatus
 	strm, err := stream.New(
-		conf,
-		stream.OptAddProcessors(procCtors...),
-		stream.OptSetLogger(sLog),
-		stream.OptSetStats(sStats),
-		stream.OptSetManager(sMgr),
-		stream.OptOnClose(func() {
-			wrapper.setClosed()
-		}),
+		limitedConf,
+		append([]func(*stream.Type){
+			stream.OptAddProcessors(procCtors...),
+			stream.OptSetLogger(sLog),
+			stream.OptSetStats(sStats),
+			stream.OptSetManager(sMgr),
+			stream.OptOnClose(func() {
+				wrapper.setClosed()
+			}),
+		}, limitOpts...)...,
 	)
 	if err != nil {
 		return err
 	}
 
 	wrapper = NewStreamStatus(conf, strm, sLog, strmFlatMetrics)
+	wrapper.limits = limits
+	wrapper.static = static
 	m.streams[id] = wrapper
 	return nil
 }
@@ -264,20 +328,120 @@ func (m *Type) Update(id string, conf stream.Config, timeout time.Duration) erro
 		return ErrStreamDoesNotExist
 	}
 
-	if reflect.DeepEqual(wrapper.config, conf) {
+	if reflect.DeepEqual(wrapper.Config(), conf) {
 		return nil
 	}
 
-	if err := m.Delete(id, timeout); err != nil {
+	if err := m.recreate(id, wrapper, conf, timeout); err != nil {
 		return err
 	}
-	return m.Create(id, conf)
+	return m.persistStreams(wrapper.static)
+}
+
+// recreate stops a stream and creates it again from a config. The stream must
+// be stopped first as both versions might otherwise contend for resources such
+// as ports. If the new stream cannot be created then the stream is restored
+// from its previous config, which keeps the running streams consistent with
+// the persisted state, and the error is returned.
+func (m *Type) recreate(id string, wrapper *StreamStatus, conf stream.Config, timeout time.Duration) error {
+	paused := wrapper.IsPaused()
+	if err := m.delete(id, timeout); err != nil {
+		return err
+	}
+
+	err := m.create(id, conf, wrapper.static, paused)
+	if err == nil {
+		return nil
+	}
+	if restoreErr := m.create(id, wrapper.Config(), wrapper.static, paused); restoreErr != nil {
+		// The stream no longer exists and therefore neither should its state.
+		_ = m.persistStreams(wrapper.static)
+		return fmt.Errorf("%w, and failed to restore previous config: %v", err, restoreErr)
+	}
+	return err
+}
+
+// UpdatePartial attempts to replace an existing stream with a new version of
+// the same stream by only rebuilding the components after the input, which
+// remains connected throughout. If the input or buffer of the stream have
+// changed then the stream is fully rebuilt as with Update instead. Returns a
+// boolean indicating whether the update was partial.
+func (m *Type) UpdatePartial(id string, conf stream.Config, timeout time.Duration) (bool, error) {
+	m.lock.Lock()
+	wrapper, exists := m.streams[id]
+	closed := m.closed
+	m.lock.Unlock()
+
+	if closed {
+		return false, types.ErrTypeClosed
+	}
+	if !exists {
+		return false, ErrStreamDoesNotExist
+	}
+
+	if reflect.DeepEqual(wrapper.Config(), conf) {
+		return true, nil
+	}
+
+	if !wrapper.strm.CanUpdatePartial(conf) {
+		return false, m.Update(id, conf, timeout)
+	}
+
+	m.lock.Lock()
+	limitedConf, _ := m.applyLimits(id, conf, wrapper.limits)
+	m.lock.Unlock()
+	if err := wrapper.strm.UpdatePartial(limitedConf, timeout); err != nil {
+		return true, err
+	}
+
+	wrapper.configMut.Lock()
+	wrapper.config = conf
+	wrapper.configMut.Unlock()
+	return true, m.persistStreams(wrapper.static)
+}
+
+// Pause stops a stream from consuming messages from its input, without closing
+// any of its components. Messages that were already consumed continue to be
+// processed and delivered. Returns an error if the stream does not exist.
+func (m *Type) Pause(id string) error {
+	wrapper, err := m.Read(id)
+	if err != nil {
+		return err
+	}
+	wrapper.strm.Pause()
+	return m.persistStreams(wrapper.static)
+}
+
+// Resume continues consuming messages from the input of a paused stream.
+// Returns an error if the stream does not exist.
+func (m *Type) Resume(id string) error {
+	wrapper, err := m.Read(id)
+	if err != nil {
+		return err
+	}
+	wrapper.strm.Resume()
+	return m.persistStreams(wrapper.static)
 }
 
 // Delete attempts to stop and remove a stream by its ID. Returns an error if
 // the stream was not found, or if clean shutdown fails in the specified period
 // of time.
 func (m *Type) Delete(id string, timeout time.Duration) error {
+	m.lock.Lock()
+	wrapper, exists := m.streams[id]
+	m.lock.Unlock()
+
+	if err := m.delete(id, timeout); err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	delete(m.limits, id)
+	m.lock.Unlock()
+	return m.persistStreams(exists && wrapper.static)
+}
+
+func (m *Type) delete(id string, timeout time.Duration) error {
 	m.lock.Lock()
 	if m.closed {
 		m.lock.Unlock()
@@ -303,12 +467,81 @@ func (m *Type) Delete(id string, timeout time.Duration) error {
 
 //------------------------------------------------------------------------------
 
+// Drain stops all active streams from consuming new messages and waits until
+// the messages already consumed have been flushed and acknowledged, or until
+// the timeout is exceeded. Streams are not removed, and Stop should be called
+// afterwards in order to close any that failed to drain.
+func (m *Type) Drain(timeout time.Duration) error {
+	m.lock.Lock()
+	streams := make(map[string]*StreamStatus, len(m.streams))
+	for k, v := range m.streams {
+		streams[k] = v
+	}
+	m.lock.Unlock()
+
+	resultChan := make(chan string)
+	for k, v := range streams {
+		go func(id string, strm *StreamStatus) {
+			if err := strm.strm.Drain(timeout); err != nil {
+				resultChan <- id
+			} else {
+				resultChan <- ""
+			}
+		}(k, v)
+	}
+
+	failedStreams := []string{}
+	for i := 0; i < len(streams); i++ {
+		if failedStrm := <-resultChan; len(failedStrm) > 0 {
+			failedStreams = append(failedStreams, failedStrm)
+		}
+	}
+	if len(failedStreams) > 0 {
+		sort.Strings(failedStreams)
+		return fmt.Errorf("failed to drain the following streams: %v", failedStreams)
+	}
+	return nil
+}
+
+// DrainStages returns the drain stage of each active stream.
+func (m *Type) DrainStages() map[string]stream.DrainStage {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	stages := make(map[string]stream.DrainStage, len(m.streams))
+	for k, v := range m.streams {
+		stages[k] = v.strm.DrainStage()
+	}
+	return stages
+}
+
 // Stop attempts to gracefully shut down all active streams and close the
 // stream manager.
 func (m *Type) Stop(timeout time.Duration) error {
+	m.lock.Lock()
+	cluster := m.cluster
+	m.cluster = nil
+	m.lock.Unlock()
+
+	// The cluster must stop reconciling streams before they're stopped, and
+	// may only leave the cluster once they have.
+	if cluster != nil {
+		cluster.stop()
+		defer func() {
+			if err := cluster.leave(timeout); err != nil {
+				m.logger.Errorf("Failed to leave cluster: %v\n", err)
+			}
+		}()
+	}
+
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
+	if m.watcher != nil {
+		m.watcher.Close()
+		m.watcher = nil
+	}
+
 	resultChan := make(chan string)
 
 	for k, v := range m.streams {
@@ -168,6 +168,37 @@ func TestTypeBasicOperations(t *testing.T) {
 	}
 }
 
+func TestTypeUpdateFailure(t *testing.T) {
+	mgr := New(
+		OptSetLogger(log.Noop()),
+		OptSetStats(metrics.Noop()),
+		OptSetManager(types.DudMgr{}),
+	)
+
+	if err := mgr.Create("foo", harmlessConf()); err != nil {
+		t.Fatal(err)
+	}
+
+	badConf := harmlessConf()
+	badConf.Buffer.Type = "does not exist"
+
+	if err := mgr.Update("foo", badConf, time.Second); err == nil {
+		t.Error("Expected error on bad update")
+	}
+
+	if info, err := mgr.Read("foo"); err != nil {
+		t.Error(err)
+	} else if !info.IsRunning() {
+		t.Error("Stream not active")
+	} else if act, exp := info.Config(), harmlessConf(); !reflect.DeepEqual(act, exp) {
+		t.Errorf("Unexpected config: %v != %v", act, exp)
+	}
+
+	if err := mgr.Stop(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestTypeBasicClose(t *testing.T) {
 	mgr := New(
 		OptSetLogger(log.Noop()),
@@ -190,3 +221,42 @@ func TestTypeBasicClose(t *testing.T) {
 		t.Errorf("Unexpected error: %v != %v", act, exp)
 	}
 }
+
+func TestTypeDrain(t *testing.T) {
+	mgr := New(
+		OptSetLogger(log.Noop()),
+		OptSetStats(metrics.Noop()),
+		OptSetManager(types.DudMgr{}),
+	)
+
+	if err := mgr.Create("foo", harmlessConf()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.Create("bar", harmlessConf()); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]stream.DrainStage{
+		"foo": stream.DrainStageNone,
+		"bar": stream.DrainStageNone,
+	}
+	if act := mgr.DrainStages(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Unexpected drain stages: %v != %v", act, exp)
+	}
+
+	if err := mgr.Drain(time.Second * 5); err != nil {
+		t.Fatal(err)
+	}
+
+	exp = map[string]stream.DrainStage{
+		"foo": stream.DrainStageDrained,
+		"bar": stream.DrainStageDrained,
+	}
+	if act := mgr.DrainStages(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Unexpected drain stages: %v != %v", act, exp)
+	}
+
+	if err := mgr.Stop(time.Second); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,311 @@
+package manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/config"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/fsnotify/fsnotify"
+)
+
+//------------------------------------------------------------------------------
+
+// How long the watched paths must go without further events before they are
+// read, which prevents reading files that are only partially written.
+const watchDebounce = 200 * time.Millisecond
+
+type pathWatcher struct {
+	m          *Type
+	watcher    *fsnotify.Watcher
+	targets    []string
+	testSuffix string
+	strict     bool
+
+	// The stream configs most recently applied from the watched paths, streams
+	// not present here (created via the API, etc) are never modified.
+	confs map[string]stream.Config
+}
+
+// WatchStreamConfigPaths begins watching a list of files and directories of
+// stream configs, as accepted by LoadStreamConfigsFromPath, and reconciles the
+// streams of the manager whenever a config file is added, modified or removed
+// by calling CreateStatic, Update and Delete respectively.
+//
+// The provided map should contain the stream configs that were loaded from the
+// paths and have already been created. Streams that did not originate from the
+// paths, such as those created via the HTTP API, are left untouched.
+//
+// When strict is true configs containing linting errors are rejected, and the
+// stream retains its previous config. When a stream fails to update it is
+// restored to its previous config.
+func (m *Type) WatchStreamConfigPaths(confs map[string]stream.Config, testSuffix string, strict bool, paths ...string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.watcher != nil {
+		return errors.New("stream config paths are already being watched")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	w := &pathWatcher{
+		m:          m,
+		watcher:    watcher,
+		testSuffix: testSuffix,
+		strict:     strict,
+		confs:      map[string]stream.Config{},
+	}
+	for k, v := range confs {
+		w.confs[k] = v
+	}
+
+	for _, path := range paths {
+		if err = w.addTarget(path); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	m.watcher = watcher
+	go w.loop()
+	return nil
+}
+
+func (w *pathWatcher) addTarget(target string) error {
+	target, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	w.targets = append(w.targets, target)
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		// Watching the parent directory means we continue to receive events
+		// for files that are replaced rather than written to.
+		return w.watcher.Add(filepath.Dir(target))
+	}
+	return w.addDirs(target)
+}
+
+// addDirs watches a directory and all of its subdirectories, as watches are
+// not recursive.
+func (w *pathWatcher) addDirs(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return w.watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+// isRelevant returns whether an event path is a target file or a stream config
+// file or directory within a target directory.
+func (w *pathWatcher) isRelevant(path string) bool {
+	path = filepath.Clean(path)
+	for _, target := range w.targets {
+		if path == target {
+			return true
+		}
+		if !strings.HasPrefix(path, target+string(filepath.Separator)) {
+			continue
+		}
+		if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
+			return true
+		}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return true
+		}
+		// Removed directories can no longer be checked, and so any path
+		// without an extension is assumed to be one.
+		if filepath.Ext(path) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *pathWatcher) loop() {
+	ticker := time.NewTicker(watchDebounce / 2)
+	defer ticker.Stop()
+
+	var lastEvent time.Time
+	for {
+		select {
+		case event, open := <-w.watcher.Events:
+			if !open {
+				return
+			}
+			if event.Op == fsnotify.Chmod || !w.isRelevant(event.Name) {
+				continue
+			}
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err = w.addDirs(event.Name); err != nil {
+						w.m.logger.Errorf("Failed to watch stream config directory: %v\n", err)
+					}
+				}
+			}
+			lastEvent = time.Now()
+		case err, open := <-w.watcher.Errors:
+			if !open {
+				return
+			}
+			w.m.logger.Errorf("Stream config watcher error: %v\n", err)
+		case <-ticker.C:
+			if !lastEvent.IsZero() && time.Since(lastEvent) >= watchDebounce {
+				lastEvent = time.Time{}
+				w.reconcile()
+			}
+		}
+	}
+}
+
+// streamFiles returns the stream config files of a target along with the
+// directory that their ids are relative to.
+func (w *pathWatcher) streamFiles(target string) (dir string, files []string, err error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return "", nil, err
+	}
+	if !info.IsDir() {
+		return "", []string{target}, nil
+	}
+	err = filepath.Walk(target, func(path string, info os.FileInfo, werr error) error {
+		if werr != nil {
+			return werr
+		}
+		if !info.IsDir() &&
+			(strings.HasSuffix(info.Name(), ".yaml") ||
+				strings.HasSuffix(info.Name(), ".yml")) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return target, files, err
+}
+
+// reconcile reads all stream configs from the watched paths and applies any
+// changes to the streams of the manager. Streams with configs that fail to read
+// or are rejected due to linting errors retain their previous config.
+func (w *pathWatcher) reconcile() {
+	newConfs := map[string]stream.Config{}
+	retained := map[string]struct{}{}
+
+	lintlog := w.m.logger.NewModule(".linter")
+	for _, target := range w.targets {
+		dir, files, err := w.streamFiles(target)
+		if err != nil {
+			w.m.logger.Errorf("Failed to walk stream config path '%v': %v\n", target, err)
+			return
+		}
+
+		testSuffix := w.testSuffix
+		if dir == "" {
+			testSuffix = ""
+		}
+		for _, path := range files {
+			id, err := streamIDFromPath(dir, path, testSuffix)
+			if err != nil {
+				w.m.logger.Errorf("Failed to load stream config '%v': %v\n", path, err)
+				continue
+			}
+			if id == "" {
+				continue
+			}
+
+			conf := config.New()
+			lints, err := config.Read(path, true, &conf)
+			if err != nil {
+				w.m.logger.Errorf("Failed to load stream config '%v': %v\n", path, err)
+				retained[id] = struct{}{}
+				continue
+			}
+			for _, lint := range lints {
+				if w.strict {
+					lintlog.Errorf("%v: %v\n", path, lint)
+				} else {
+					lintlog.Infof("%v: %v\n", path, lint)
+				}
+			}
+			if w.strict && len(lints) > 0 {
+				w.m.logger.Errorf("Rejecting updated stream config '%v' due to linter errors, to allow these changes run Benthos with --chilled\n", path)
+				retained[id] = struct{}{}
+				continue
+			}
+
+			if _, exists := newConfs[id]; exists {
+				w.m.logger.Errorf("Stream id (%v) collision from file: %v\n", id, path)
+				continue
+			}
+			newConfs[id] = conf.Config
+		}
+	}
+
+	for id := range w.confs {
+		if _, exists := newConfs[id]; exists {
+			continue
+		}
+		if _, exists := retained[id]; exists {
+			continue
+		}
+		if err := w.m.Delete(id, w.m.apiTimeout); err != nil && err != ErrStreamDoesNotExist {
+			w.m.logger.Errorf("Failed to delete stream '%v': %v\n", id, err)
+			continue
+		}
+		delete(w.confs, id)
+		w.m.logger.Infof("Deleted stream '%v' as its config was removed.\n", id)
+	}
+
+	for id, conf := range newConfs {
+		prevConf, exists := w.confs[id]
+		if !exists {
+			if err := w.m.CreateStatic(id, conf); err != nil {
+				w.m.logger.Errorf("Failed to create stream '%v': %v\n", id, err)
+				continue
+			}
+			w.confs[id] = conf
+			w.m.logger.Infof("Created stream '%v' from a new config.\n", id)
+			continue
+		}
+		if reflect.DeepEqual(prevConf, conf) {
+			continue
+		}
+		err := w.m.Update(id, conf, w.m.apiTimeout)
+		if err == ErrStreamDoesNotExist {
+			// The stream was removed by other means, e.g. the HTTP API.
+			err = w.m.CreateStatic(id, conf)
+		}
+		if err != nil {
+			w.m.logger.Errorf("Failed to update stream '%v': %v\n", id, err)
+			// A failed update may have already removed the previous stream, in
+			// which case we restore it.
+			if _, rErr := w.m.Read(id); rErr == ErrStreamDoesNotExist {
+				if rErr = w.m.CreateStatic(id, prevConf); rErr != nil {
+					w.m.logger.Errorf("Failed to restore the previous config of stream '%v': %v\n", id, rErr)
+				} else {
+					w.m.logger.Infof("Restored the previous config of stream '%v'.\n", id)
+				}
+			}
+			continue
+		}
+		w.confs[id] = conf
+		w.m.logger.Infof("Updated stream '%v' from a modified config.\n", id)
+	}
+}
@@ -0,0 +1,90 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/stream"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func watcherTestConf(mapping string) string {
+	return `
+input:
+  generate:
+    interval: 1s
+    mapping: '` + mapping + `'
+output:
+  drop: {}
+`
+}
+
+func TestWatchStreamConfigPaths(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo.yaml"), []byte(watcherTestConf(`root = "foo"`)), 0644))
+
+	mgr := New(
+		OptSetLogger(log.Noop()),
+		OptSetStats(metrics.Noop()),
+		OptSetManager(types.DudMgr{}),
+	)
+	t.Cleanup(func() {
+		assert.NoError(t, mgr.Stop(time.Second*5))
+	})
+
+	confs := map[string]stream.Config{}
+	_, err := LoadStreamConfigsFromPath(dir, "_benthos_test", confs)
+	require.NoError(t, err)
+	for id, conf := range confs {
+		require.NoError(t, mgr.Create(id, conf))
+	}
+
+	// Streams created by other means are never modified by the watcher.
+	apiConf := stream.NewConfig()
+	apiConf.Input.Type = "generate"
+	apiConf.Input.Generate.Mapping = `root = "api"`
+	apiConf.Output.Type = "drop"
+	require.NoError(t, mgr.Create("api", apiConf))
+
+	require.NoError(t, mgr.WatchStreamConfigPaths(confs, "_benthos_test", true, dir))
+
+	mappingOf := func(id string) string {
+		info, err := mgr.Read(id)
+		if err != nil {
+			return ""
+		}
+		return info.Config().Input.Generate.Mapping
+	}
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nested", "bar.yaml"), []byte(watcherTestConf(`root = "bar"`)), 0644))
+	assert.Eventually(t, func() bool {
+		return mappingOf("nested_bar") == `root = "bar"`
+	}, time.Second*5, time.Millisecond*50)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo.yaml"), []byte(watcherTestConf(`root = "foo updated"`)), 0644))
+	assert.Eventually(t, func() bool {
+		return mappingOf("foo") == `root = "foo updated"`
+	}, time.Second*5, time.Millisecond*50)
+
+	// Configs that fail to initialise are reverted.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo.yaml"), []byte(watcherTestConf(`root = this.`)), 0644))
+	<-time.After(time.Millisecond * 500)
+	assert.Equal(t, `root = "foo updated"`, mappingOf("foo"))
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "nested", "bar.yaml")))
+	assert.Eventually(t, func() bool {
+		_, err := mgr.Read("nested_bar")
+		return err == ErrStreamDoesNotExist
+	}, time.Second*5, time.Millisecond*50)
+
+	assert.Equal(t, `root = "foo updated"`, mappingOf("foo"))
+	assert.Equal(t, `root = "api"`, mappingOf("api"))
+}
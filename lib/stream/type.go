@@ -2,8 +2,11 @@ package stream
 
 import (
 	"bytes"
+	"errors"
 	"net/http"
+	"reflect"
 	"runtime/pprof"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/interop"
@@ -27,12 +30,30 @@ type Type struct {
 	conf Config
 
 	inputLayer    input.Type
+	inputGate     *transactionGate
 	bufferLayer   buffer.Type
 	pipelineLayer pipeline.Type
 	outputLayer   output.Type
 
+	// Serialises UpdatePartial and Stop, which replace and close the layers
+	// after the input respectively.
+	layersMut sync.Mutex
+
+	// Protects reads of the layers after the input, which are replaced by
+	// UpdatePartial.
+	layerPtrMut sync.RWMutex
+
+	drainMut   sync.Mutex
+	drainStage DrainStage
+	drained    bool
+
 	complementaryProcs []types.ProcessorConstructorFunc
 
+	maxInFlight      int
+	maxInFlightBytes int64
+	trackInFlight    bool
+	startPaused      bool
+
 	manager types.Manager
 	stats   metrics.Type
 	logger  log.Modular
@@ -63,7 +84,7 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 			w.WriteHeader(http.StatusServiceUnavailable)
 			w.Write([]byte("input not connected\n"))
 		}
-		if !t.outputLayer.Connected() {
+		if _, out := t.currentLayers(); !out.Connected() {
 			connected = false
 			w.WriteHeader(http.StatusServiceUnavailable)
 			w.Write([]byte("output not connected\n"))
@@ -123,6 +144,36 @@ func OptSetManager(mgr types.Manager) func(*Type) {
 	}
 }
 
+// OptSetInFlightLimits limits the number of messages, and the total size of
+// those messages in bytes, that are consumed from the input of the stream but
+// not yet acknowledged. Whilst a limit is reached the stream stops consuming
+// from its input. A limit of zero or less is unlimited.
+func OptSetInFlightLimits(maxMessages int, maxBytes int64) func(*Type) {
+	return func(t *Type) {
+		t.maxInFlight = maxMessages
+		t.maxInFlightBytes = maxBytes
+	}
+}
+
+// OptTrackInFlight tracks all messages that are in flight regardless of the
+// in flight limits of the stream, which allows Drain to wait for the
+// acknowledgement of messages consumed before the drain began. Without this
+// option, and without in flight limits, messages are only tracked once Drain is
+// called in order to avoid the overhead of tracking.
+func OptTrackInFlight() func(*Type) {
+	return func(t *Type) {
+		t.trackInFlight = true
+	}
+}
+
+// OptStartPaused creates the stream in a paused state, meaning it does not
+// consume messages from its input until Resume is called.
+func OptStartPaused() func(*Type) {
+	return func(t *Type) {
+		t.startPaused = true
+	}
+}
+
 // OptOnClose sets a closure to be called when the stream closes.
 func OptOnClose(onClose func()) func(*Type) {
 	return func(t *Type) {
@@ -135,36 +186,94 @@ func OptOnClose(onClose func()) func(*Type) {
 // IsReady returns a boolean indicating whether both the input and output layers
 // of the stream are connected.
 func (t *Type) IsReady() bool {
-	return t.inputLayer.Connected() && t.outputLayer.Connected()
+	_, out := t.currentLayers()
+	return t.inputLayer.Connected() && out.Connected()
+}
+
+// Pause stops the stream from consuming messages from its input whilst keeping
+// all components connected. Messages already consumed continue through the
+// pipeline and are delivered as normal.
+func (t *Type) Pause() {
+	t.inputGate.pause()
+}
+
+// Resume continues consuming messages from the input of a paused stream.
+func (t *Type) Resume() {
+	t.inputGate.resume()
+}
+
+// IsPaused returns a boolean indicating whether the stream is paused.
+func (t *Type) IsPaused() bool {
+	return t.inputGate.paused()
+}
+
+// InFlight returns the number of messages, and the total size of those
+// messages in bytes, that have been consumed from the input of the stream and
+// not yet acknowledged. Messages are only counted when the stream has in flight
+// limits, was created with OptTrackInFlight, or is draining.
+func (t *Type) InFlight() (messages int, bytes int64) {
+	return t.inputGate.inFlightStats()
+}
+
+// managerFor returns the manager for the components of a config, where
+// processors record the sources of their failures when the config has a
+// dead_letter output.
+func (t *Type) managerFor(conf Config) types.Manager {
+	if output.ContainsDeadLetter(conf.Output) {
+		return interop.WithFailSources(t.manager)
+	}
+	return t.manager
+}
+
+// newPipelineAndOutput constructs the pipeline and output layers of a config,
+// where the pipeline layer is nil if there are no processors.
+func (t *Type) newPipelineAndOutput(conf Config) (pipelineLayer pipeline.Type, outputLayer output.Type, err error) {
+	mgr := t.managerFor(conf)
+	if tLen := len(t.complementaryProcs) + len(conf.Pipeline.Processors); tLen > 0 {
+		pMgr, pLog, pStats := interop.LabelChild("pipeline", mgr, t.logger, t.stats)
+		if pipelineLayer, err = pipeline.New(conf.Pipeline, pMgr, pLog, pStats, t.complementaryProcs...); err != nil {
+			return
+		}
+	}
+	oMgr, oLog, oStats := interop.LabelChild("output", mgr, t.logger, t.stats)
+	if outputLayer, err = output.New(conf.Output, oMgr, oLog, oStats); err != nil {
+		if pipelineLayer != nil {
+			pipelineLayer.CloseAsync()
+		}
+		return
+	}
+	return
 }
 
 func (t *Type) start() (err error) {
 	// Constructors
-	iMgr, iLog, iStats := interop.LabelChild("input", t.manager, t.logger, t.stats)
+	mgr := t.managerFor(t.conf)
+	iMgr, iLog, iStats := interop.LabelChild("input", mgr, t.logger, t.stats)
 	if t.inputLayer, err = input.New(t.conf.Input, iMgr, iLog, iStats); err != nil {
 		return
 	}
 	if t.conf.Buffer.Type != buffer.TypeNone {
-		bMgr, bLog, bStats := interop.LabelChild("buffer", t.manager, t.logger, t.stats)
+		bMgr, bLog, bStats := interop.LabelChild("buffer", mgr, t.logger, t.stats)
 		if t.bufferLayer, err = buffer.New(t.conf.Buffer, bMgr, bLog, bStats); err != nil {
 			return
 		}
 	}
-	if tLen := len(t.complementaryProcs) + len(t.conf.Pipeline.Processors); tLen > 0 {
-		pMgr, pLog, pStats := interop.LabelChild("pipeline", t.manager, t.logger, t.stats)
-		if t.pipelineLayer, err = pipeline.New(t.conf.Pipeline, pMgr, pLog, pStats, t.complementaryProcs...); err != nil {
-			return
-		}
-	}
-	oMgr, oLog, oStats := interop.LabelChild("output", t.manager, t.logger, t.stats)
-	if t.outputLayer, err = output.New(t.conf.Output, oMgr, oLog, oStats); err != nil {
+	if t.pipelineLayer, t.outputLayer, err = t.newPipelineAndOutput(t.conf); err != nil {
 		return
 	}
 
 	// Start chaining components
 	var nextTranChan <-chan types.Transaction
 
-	nextTranChan = t.inputLayer.TransactionChan()
+	t.inputGate = newTransactionGate(t.maxInFlight, t.maxInFlightBytes)
+	if t.trackInFlight {
+		t.inputGate.trackAll()
+	}
+	if t.startPaused {
+		t.inputGate.pause()
+	}
+	t.inputGate.consume(t.inputLayer.TransactionChan())
+	nextTranChan = t.inputGate.tranChan
 	if t.bufferLayer != nil {
 		if err = t.bufferLayer.Consume(nextTranChan); err != nil {
 			return
@@ -181,23 +290,210 @@ func (t *Type) start() (err error) {
 		return
 	}
 
-	go func(out output.Type) {
+	go func() {
 		for {
-			if err := out.WaitForClose(time.Second); err == nil {
+			_, out := t.currentLayers()
+			if err := out.WaitForClose(time.Second); err != nil {
+				continue
+			}
+
+			// The output may have closed due to being replaced, in which case
+			// wait for any ongoing replacement to finish before checking.
+			t.layersMut.Lock()
+			_, currentOut := t.currentLayers()
+			t.layersMut.Unlock()
+			if currentOut == out {
 				t.onClose()
 				return
 			}
 		}
-	}(t.outputLayer)
+	}()
 
 	return nil
 }
 
+// ErrPartialUpdateUnsupported is returned by UpdatePartial when the changes to
+// a config cannot be applied without rebuilding the entire stream.
+var ErrPartialUpdateUnsupported = errors.New("config changes require a full rebuild of the stream")
+
+// CanUpdatePartial returns whether a config can be applied to the stream with
+// UpdatePartial, which requires that both the input and buffer are unchanged
+// and that the stream has no buffer.
+func (t *Type) CanUpdatePartial(conf Config) bool {
+	t.layersMut.Lock()
+	defer t.layersMut.Unlock()
+	return t.canUpdatePartial(conf)
+}
+
+func (t *Type) canUpdatePartial(conf Config) bool {
+	if t.bufferLayer != nil || conf.Buffer.Type != buffer.TypeNone {
+		return false
+	}
+
+	// Compare the sanitised forms of the inputs, as the same input may be
+	// represented by structurally different configs depending on whether they
+	// were parsed or built programmatically.
+	current, err := t.conf.Sanitised()
+	if err != nil {
+		return false
+	}
+	next, err := conf.Sanitised()
+	if err != nil {
+		return false
+	}
+	currentObj, _ := current.(map[string]interface{})
+	nextObj, _ := next.(map[string]interface{})
+	return reflect.DeepEqual(currentObj["input"], nextObj["input"])
+}
+
+// UpdatePartial replaces the pipeline and output layers of the stream with
+// those of a new config whilst keeping the input running and connected. The
+// new layers are constructed first, then consumption from the input is
+// redirected to them once the previous layers have finished processing their
+// in-flight messages. Messages of the previous layers that are not delivered
+// within the timeout are abandoned and will be reattempted by the input.
+//
+// Returns ErrPartialUpdateUnsupported if the input or buffer of the config
+// differ from the running stream, or if the stream has a buffer.
+func (t *Type) UpdatePartial(conf Config, timeout time.Duration) error {
+	t.layersMut.Lock()
+	defer t.layersMut.Unlock()
+
+	if !t.canUpdatePartial(conf) {
+		return ErrPartialUpdateUnsupported
+	}
+
+	pipelineLayer, outputLayer, err := t.newPipelineAndOutput(conf)
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	nextTranChan := make(chan types.Transaction)
+	if err = t.inputGate.redirect(nextTranChan, timeout); err != nil {
+		if pipelineLayer != nil {
+			pipelineLayer.CloseAsync()
+		}
+		outputLayer.CloseAsync()
+		return err
+	}
+
+	// The previous layers now drain by proxy of their input channel closing.
+	if err = t.waitForLayersClose(timeout - time.Since(started)); err != nil {
+		t.logger.Warnf("Unable to drain previous pipeline and output within target time: %v\n", err)
+		if t.pipelineLayer != nil {
+			t.pipelineLayer.CloseAsync()
+		}
+		t.outputLayer.CloseAsync()
+	}
+
+	var tranChan <-chan types.Transaction = nextTranChan
+	if pipelineLayer != nil {
+		if err = pipelineLayer.Consume(tranChan); err != nil {
+			return err
+		}
+		tranChan = pipelineLayer.TransactionChan()
+	}
+	if err = outputLayer.Consume(tranChan); err != nil {
+		return err
+	}
+
+	t.layerPtrMut.Lock()
+	t.pipelineLayer, t.outputLayer = pipelineLayer, outputLayer
+	t.conf = conf
+	t.layerPtrMut.Unlock()
+	return nil
+}
+
+// DrainStage describes the layer of a stream that is currently being drained.
+type DrainStage string
+
+// Stages of a stream being drained, in the order that they occur.
+const (
+	DrainStageNone     DrainStage = ""
+	DrainStageInput    DrainStage = "input"
+	DrainStageBuffer   DrainStage = "buffer"
+	DrainStagePipeline DrainStage = "pipeline"
+	DrainStageOutput   DrainStage = "output"
+	DrainStageAcks     DrainStage = "acks"
+	DrainStageDrained  DrainStage = "drained"
+)
+
+func (t *Type) setDrainStage(stage DrainStage) {
+	t.drainMut.Lock()
+	t.drainStage = stage
+	t.drainMut.Unlock()
+}
+
+// DrainStage returns the layer of the stream that is currently being drained,
+// or DrainStageNone if the stream is not being stopped.
+func (t *Type) DrainStage() DrainStage {
+	t.drainMut.Lock()
+	defer t.drainMut.Unlock()
+	return t.drainStage
+}
+
+// Drain stops the stream from consuming new messages and waits until all
+// messages already consumed have been flushed through the buffer, pipeline and
+// output of the stream and acknowledged, or until the timeout is exceeded.
+//
+// Unlike Stop the stream is not forcefully closed when the timeout is exceeded,
+// instead a subsequent call to Stop closes any remaining layers without
+// attempting to drain them again.
+//
+// Acknowledgements of messages consumed before the drain began are only waited
+// for when the stream was created with OptTrackInFlight or in flight limits.
+func (t *Type) Drain(timeout time.Duration) error {
+	t.layersMut.Lock()
+	defer t.layersMut.Unlock()
+
+	t.inputGate.trackAll()
+	t.inputGate.resume()
+
+	started := time.Now()
+	err := t.stopGracefully(timeout)
+	if err == nil {
+		// Some outputs hand off messages before they are acknowledged, and
+		// therefore may close before all responses are received.
+		t.setDrainStage(DrainStageAcks)
+		err = t.inputGate.waitForAcks(timeout - time.Since(started))
+	}
+
+	t.drainMut.Lock()
+	t.drained = true
+	if err == nil {
+		t.drainStage = DrainStageDrained
+	}
+	t.drainMut.Unlock()
+	return err
+}
+
+func (t *Type) currentLayers() (pipeline.Type, output.Type) {
+	t.layerPtrMut.RLock()
+	defer t.layerPtrMut.RUnlock()
+	return t.pipelineLayer, t.outputLayer
+}
+
+func (t *Type) waitForLayersClose(timeout time.Duration) error {
+	started := time.Now()
+	if t.pipelineLayer != nil {
+		if err := t.pipelineLayer.WaitForClose(timeout); err != nil {
+			return err
+		}
+	}
+	remaining := timeout - time.Since(started)
+	if remaining < 0 {
+		return types.ErrTimeout
+	}
+	return t.outputLayer.WaitForClose(remaining)
+}
+
 // stopGracefully attempts to close the stream in the most graceful way by only
 // closing the input layer and waiting for all other layers to terminate by
 // proxy. This should guarantee that all in-flight and buffered data is resolved
 // before shutting down.
 func (t *Type) stopGracefully(timeout time.Duration) (err error) {
+	t.setDrainStage(DrainStageInput)
 	t.inputLayer.CloseAsync()
 	started := time.Now()
 	if err = t.inputLayer.WaitForClose(timeout); err != nil {
@@ -209,6 +505,7 @@ func (t *Type) stopGracefully(timeout time.Duration) (err error) {
 	// If we have a buffer then wait right here. We want to try and allow the
 	// buffer to empty out before prompting the other layers to shut down.
 	if t.bufferLayer != nil {
+		t.setDrainStage(DrainStageBuffer)
 		t.bufferLayer.StopConsuming()
 		remaining = timeout - time.Since(started)
 		if remaining < 0 {
@@ -221,6 +518,7 @@ func (t *Type) stopGracefully(timeout time.Duration) (err error) {
 
 	// After this point we can start closing the remaining components.
 	if t.pipelineLayer != nil {
+		t.setDrainStage(DrainStagePipeline)
 		t.pipelineLayer.CloseAsync()
 		remaining = timeout - time.Since(started)
 		if remaining < 0 {
@@ -231,6 +529,7 @@ func (t *Type) stopGracefully(timeout time.Duration) (err error) {
 		}
 	}
 
+	t.setDrainStage(DrainStageOutput)
 	t.outputLayer.CloseAsync()
 	remaining = timeout - time.Since(started)
 	if remaining < 0 {
@@ -345,20 +644,42 @@ func (t *Type) stopUnordered(timeout time.Duration) (err error) {
 // Initially the attempt is graceful, but as the timeout draws close the attempt
 // becomes progressively less graceful.
 func (t *Type) Stop(timeout time.Duration) error {
+	t.layersMut.Lock()
+	defer t.layersMut.Unlock()
+
 	tOutUnordered := timeout / 4
 	tOutGraceful := timeout - tOutUnordered
 
-	err := t.stopGracefully(tOutGraceful)
-	if err == nil {
-		return nil
-	}
-	if err == types.ErrTimeout {
-		t.logger.Infoln("Unable to fully drain buffered messages within target time.")
+	t.drainMut.Lock()
+	drained := t.drained
+	t.drainMut.Unlock()
+
+	// A stream that has already been drained only needs to close the layers
+	// that failed to do so, which are given the entire timeout.
+	if drained {
+		if t.DrainStage() == DrainStageDrained {
+			t.inputGate.shutdown()
+			return nil
+		}
+		tOutUnordered = timeout
 	} else {
-		t.logger.Errorf("Encountered error whilst shutting down: %v\n", err)
+		// A paused stream must consume from its input in order to observe it
+		// closing.
+		t.inputGate.resume()
+
+		err := t.stopGracefully(tOutGraceful)
+		if err == nil {
+			return nil
+		}
+		if err == types.ErrTimeout {
+			t.logger.Infoln("Unable to fully drain buffered messages within target time.")
+		} else {
+			t.logger.Errorf("Encountered error whilst shutting down: %v\n", err)
+		}
 	}
 
-	err = t.stopUnordered(tOutUnordered)
+	t.inputGate.shutdown()
+	err := t.stopUnordered(tOutUnordered)
 	if err == nil {
 		return nil
 	}
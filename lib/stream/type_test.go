@@ -1,14 +1,18 @@
 package stream
 
 import (
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/input"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output"
 	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -116,3 +120,258 @@ func TestTypeCloseUnordered(t *testing.T) {
 	require.NoError(t, err)
 	assert.NoError(t, strm.stopUnordered(time.Minute))
 }
+
+func TestTypeUpdatePartial(t *testing.T) {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	mgr.SetPipe("feed_in", tChan)
+
+	conf := NewConfig()
+	conf.Input.Type = input.TypeInproc
+	conf.Input.Inproc = "feed_in"
+	conf.Output.Type = output.TypeInproc
+	conf.Output.Inproc = "feed_out"
+
+	bloblConf := func(mapping string) []processor.Config {
+		pConf := processor.NewConfig()
+		pConf.Type = processor.TypeBloblang
+		pConf.Bloblang = processor.BloblangConfig(mapping)
+		return []processor.Config{pConf}
+	}
+	conf.Pipeline.Processors = bloblConf(`root = "first"`)
+
+	var closed int32
+	strm, err := New(conf, OptSetManager(mgr), OptOnClose(func() {
+		atomic.StoreInt32(&closed, 1)
+	}))
+	require.NoError(t, err)
+
+	sendAndReceive := func(exp string) {
+		t.Helper()
+
+		var outChan <-chan types.Transaction
+		require.Eventually(t, func() bool {
+			outChan, err = mgr.GetPipe("feed_out")
+			return err == nil
+		}, time.Second*5, time.Millisecond*10)
+
+		resChan := make(chan types.Response)
+		select {
+		case tChan <- types.NewTransaction(message.New([][]byte{[]byte("hello")}), resChan):
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+
+		select {
+		case tran := <-outChan:
+			assert.Equal(t, exp, string(tran.Payload.Get(0).Get()))
+			go func() {
+				tran.ResponseChan <- response.NewAck()
+			}()
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+
+		select {
+		case res := <-resChan:
+			assert.NoError(t, res.Error())
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+	}
+
+	sendAndReceive("first")
+
+	inputLayer := strm.inputLayer
+
+	newConf := conf
+	newConf.Pipeline.Processors = bloblConf(`root = "second"`)
+	require.True(t, strm.CanUpdatePartial(newConf))
+	require.NoError(t, strm.UpdatePartial(newConf, time.Second*5))
+
+	assert.Equal(t, inputLayer, strm.inputLayer)
+	sendAndReceive("second")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&closed))
+
+	inputConf := newConf
+	inputConf.Input.Inproc = "feed_other"
+	assert.False(t, strm.CanUpdatePartial(inputConf))
+	assert.Equal(t, ErrPartialUpdateUnsupported, strm.UpdatePartial(inputConf, time.Second))
+
+	require.NoError(t, strm.Stop(time.Second*5))
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&closed) == 1
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestTypeDrain(t *testing.T) {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	mgr.SetPipe("feed_in", tChan)
+
+	conf := NewConfig()
+	conf.Input.Type = input.TypeInproc
+	conf.Input.Inproc = "feed_in"
+	conf.Output.Type = output.TypeInproc
+	conf.Output.Inproc = "feed_out"
+
+	strm, err := New(conf, OptSetManager(mgr), OptTrackInFlight())
+	require.NoError(t, err)
+	assert.Equal(t, DrainStageNone, strm.DrainStage())
+
+	var outChan <-chan types.Transaction
+	require.Eventually(t, func() bool {
+		outChan, err = mgr.GetPipe("feed_out")
+		return err == nil
+	}, time.Second*5, time.Millisecond*10)
+
+	resChan := make(chan types.Response)
+	select {
+	case tChan <- types.NewTransaction(message.New([][]byte{[]byte("hello")}), resChan):
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	var tran types.Transaction
+	select {
+	case tran = <-outChan:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	drainErrChan := make(chan error)
+	go func() {
+		drainErrChan <- strm.Drain(time.Second * 5)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return strm.DrainStage() != DrainStageNone
+	}, time.Second*5, time.Millisecond*10)
+
+	select {
+	case err := <-drainErrChan:
+		t.Fatalf("drain finished before the message was acknowledged: %v", err)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	go func() {
+		tran.ResponseChan <- response.NewAck()
+	}()
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	select {
+	case err := <-drainErrChan:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	assert.Equal(t, DrainStageDrained, strm.DrainStage())
+
+	require.NoError(t, strm.Stop(time.Second))
+}
+
+func TestTypeDrainTimeout(t *testing.T) {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	mgr.SetPipe("feed_in", tChan)
+
+	conf := NewConfig()
+	conf.Input.Type = input.TypeInproc
+	conf.Input.Inproc = "feed_in"
+	conf.Output.Type = output.TypeInproc
+	conf.Output.Inproc = "feed_out"
+
+	strm, err := New(conf, OptSetManager(mgr), OptTrackInFlight())
+	require.NoError(t, err)
+
+	var outChan <-chan types.Transaction
+	require.Eventually(t, func() bool {
+		outChan, err = mgr.GetPipe("feed_out")
+		return err == nil
+	}, time.Second*5, time.Millisecond*10)
+
+	select {
+	case tChan <- types.NewTransaction(message.New([][]byte{[]byte("hello")}), make(chan types.Response, 1)):
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	select {
+	case <-outChan:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	assert.Error(t, strm.Drain(time.Millisecond*100))
+	assert.NotEqual(t, DrainStageDrained, strm.DrainStage())
+
+	require.NoError(t, strm.Stop(time.Second))
+}
+
+func TestTypeStartPaused(t *testing.T) {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	mgr.SetPipe("feed_in", tChan)
+
+	conf := NewConfig()
+	conf.Input.Type = input.TypeInproc
+	conf.Input.Inproc = "feed_in"
+	conf.Output.Type = output.TypeInproc
+	conf.Output.Inproc = "feed_out"
+
+	strm, err := New(conf, OptSetManager(mgr), OptStartPaused())
+	require.NoError(t, err)
+	assert.True(t, strm.IsPaused())
+
+	var outChan <-chan types.Transaction
+	require.Eventually(t, func() bool {
+		outChan, err = mgr.GetPipe("feed_out")
+		return err == nil
+	}, time.Second*5, time.Millisecond*10)
+
+	resChan := make(chan types.Response)
+	go func() {
+		tChan <- types.NewTransaction(message.New([][]byte{[]byte("hello")}), resChan)
+	}()
+
+	select {
+	case <-outChan:
+		t.Fatal("message consumed whilst paused")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	strm.Resume()
+	assert.False(t, strm.IsPaused())
+
+	var tran types.Transaction
+	select {
+	case tran = <-outChan:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	assert.Equal(t, "hello", string(tran.Payload.Get(0).Get()))
+
+	go func() {
+		tran.ResponseChan <- response.NewAck()
+	}()
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	require.NoError(t, strm.Stop(time.Second))
+}
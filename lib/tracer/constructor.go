@@ -87,6 +87,7 @@ type Config struct {
 	Type   string       `json:"type" yaml:"type"`
 	Jaeger JaegerConfig `json:"jaeger" yaml:"jaeger"`
 	None   struct{}     `json:"none" yaml:"none"`
+	Plugin interface{}  `json:"plugin,omitempty" yaml:"plugin,omitempty"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
@@ -95,6 +96,7 @@ func NewConfig() Config {
 		Type:   TypeNone,
 		Jaeger: NewJaegerConfig(),
 		None:   struct{}{},
+		Plugin: nil,
 	}
 }
 
@@ -135,10 +137,21 @@ func (conf *Config) UnmarshalYAML(value *yaml.Node) error {
 		return fmt.Errorf("line %v: %v", value.Line, err)
 	}
 
-	if aliased.Type, _, err = docs.GetInferenceCandidateFromYAML(nil, docs.TypeTracer, aliased.Type, value); err != nil {
+	var spec docs.ComponentSpec
+	if aliased.Type, spec, err = docs.GetInferenceCandidateFromYAML(nil, docs.TypeTracer, aliased.Type, value); err != nil {
 		return fmt.Errorf("line %v: %w", value.Line, err)
 	}
 
+	if spec.Plugin {
+		pluginNode, err := docs.GetPluginConfigYAML(aliased.Type, value)
+		if err != nil {
+			return fmt.Errorf("line %v: %v", value.Line, err)
+		}
+		aliased.Plugin = &pluginNode
+	} else {
+		aliased.Plugin = nil
+	}
+
 	*conf = Config(aliased)
 	return nil
 }
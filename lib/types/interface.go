@@ -59,6 +59,18 @@ type CacheWithTTL interface {
 	Cache
 }
 
+// CacheWithCompareAndDelete is a key/value store that is able to atomically
+// remove a key only when it holds an expected value, which is required in order
+// to implement locks that are shared across Benthos instances.
+type CacheWithCompareAndDelete interface {
+	// CompareAndDelete attempts to remove a key only if its current value
+	// matches the provided value, returns ErrKeyNotFound if the key does not
+	// exist or holds a different value, or an error if the command fails.
+	CompareAndDelete(key string, value []byte) error
+
+	Cache
+}
+
 //------------------------------------------------------------------------------
 
 // RateLimit is a strategy for limiting access to a shared resource, this
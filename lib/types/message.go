@@ -11,6 +11,11 @@ import (
 // be interpretted as having failed a processor step somewhere in the pipeline.
 var FailFlagKey = "benthos_processing_failed"
 
+// FailFlagSourceKey is a metadata key used for recording the label of the
+// processor that flagged a message part as failed, or its path when it has no
+// label.
+var FailFlagSourceKey = "benthos_processing_failed_source"
+
 //------------------------------------------------------------------------------
 
 // Metadata is an interface representing the metadata of a message part within
@@ -74,6 +74,34 @@ func (s *Stream) Run(ctx context.Context) (err error) {
 	return ctx.Err()
 }
 
+// Pause stops the stream from consuming messages from its input whilst keeping
+// all components connected. Messages that were already consumed continue to be
+// processed and delivered as normal. Returns an error if the stream has not
+// been run yet.
+func (s *Stream) Pause() error {
+	s.strmMut.Lock()
+	strm := s.strm
+	s.strmMut.Unlock()
+	if strm == nil {
+		return errors.New("stream has not been run yet")
+	}
+	strm.Pause()
+	return nil
+}
+
+// Resume continues consuming messages from the input of a paused stream.
+// Returns an error if the stream has not been run yet.
+func (s *Stream) Resume() error {
+	s.strmMut.Lock()
+	strm := s.strm
+	s.strmMut.Unlock()
+	if strm == nil {
+		return errors.New("stream has not been run yet")
+	}
+	strm.Resume()
+	return nil
+}
+
 // StopWithin attempts to close the stream within the specified timeout period.
 // Initially the attempt is graceful, but as the timeout draws close the attempt
 // becomes progressively less graceful.
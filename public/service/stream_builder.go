@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/Jeffail/benthos/v3/internal/bundle"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/api"
 	"github.com/Jeffail/benthos/v3/lib/buffer"
@@ -679,7 +680,7 @@ func (s *StreamBuilder) Build() (*Stream, error) {
 		}
 	}
 
-	stats, err := metrics.New(s.metrics, metrics.OptSetLogger(logger))
+	stats, err := bundle.AllMetrics.Init(s.metrics, metrics.OptSetLogger(logger))
 	if err != nil {
 		return nil, err
 	}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -700,3 +701,48 @@ output:
 		assert.Contains(t, act, str)
 	}
 }
+
+func TestStreamBuilderPauseResume(t *testing.T) {
+	b := service.NewStreamBuilder()
+	require.NoError(t, b.SetLoggerYAML("level: NONE"))
+	require.NoError(t, b.AddInputYAML(`
+generate:
+  interval: 10ms
+  mapping: 'root = "hello world"'
+`))
+
+	var count int64
+	require.NoError(t, b.AddConsumerFunc(func(_ context.Context, m *service.Message) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	}))
+
+	strm, err := b.Build()
+	require.NoError(t, err)
+
+	require.Error(t, strm.Pause())
+	require.Error(t, strm.Resume())
+
+	go func() {
+		assert.NoError(t, strm.Run(context.Background()))
+	}()
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt64(&count) > 0
+	}, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, strm.Pause())
+
+	// Allow for a message consumed prior to pausing.
+	<-time.After(time.Millisecond * 100)
+	pausedCount := atomic.LoadInt64(&count)
+	<-time.After(time.Millisecond * 200)
+	assert.Equal(t, pausedCount, atomic.LoadInt64(&count))
+
+	require.NoError(t, strm.Resume())
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt64(&count) > pausedCount
+	}, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, strm.StopWithin(time.Second*5))
+}